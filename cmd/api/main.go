@@ -2,19 +2,33 @@ package main
 
 import (
 	"context"
+	"errors"
 	"log"
 	"net/http"
 	"os"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/franco/payment-api/internal/application/command"
 	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/domain/payment"
 	"github.com/franco/payment-api/internal/domain/shared"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 	"github.com/franco/payment-api/internal/infrastructure"
+	"github.com/franco/payment-api/internal/infrastructure/fees"
+	"github.com/franco/payment-api/internal/infrastructure/fx"
+	"github.com/franco/payment-api/internal/infrastructure/gateway"
 	httpHandler "github.com/franco/payment-api/internal/infrastructure/http"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/inbox"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/outbox"
 	"github.com/franco/payment-api/internal/infrastructure/messaging/sns"
 	"github.com/franco/payment-api/internal/infrastructure/messaging/sqs"
 	dynamodbRepo "github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/eventsourcing"
+	"github.com/shopspring/decimal"
 )
 
 func main() {
@@ -22,6 +36,9 @@ func main() {
 
 	// Load configuration
 	config := loadConfig()
+	if err := config.requireSecrets(); err != nil {
+		log.Fatalf("Invalid configuration: %v", err)
+	}
 
 	// Initialize AWS clients
 	awsClients, err := infrastructure.NewAWSClients(ctx)
@@ -39,46 +56,138 @@ func main() {
 
 	// Initialize repositories
 	paymentRepo := dynamodbRepo.NewDynamoDBPaymentRepository(awsClients.DynamoDB, "Payments")
-	walletRepo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets")
+	ledgerRepo := dynamodbRepo.NewDynamoDBLedgerRepository(awsClients.DynamoDB, "JournalEntries", "LedgerPostings")
+	walletRepo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets", ledgerRepo)
 	idempotencyStore := dynamodbRepo.NewDynamoDBIdempotencyStore(awsClients.DynamoDB, "Idempotency")
 	eventStore := dynamodbRepo.NewDynamoDBEventStore(awsClients.DynamoDB, "EventStore")
+	outboxStore := dynamodbRepo.NewDynamoDBOutboxStore(awsClients.DynamoDB, "Outbox")
+	inboxStore := dynamodbRepo.NewDynamoDBInboxStore(awsClients.DynamoDB, "Inbox")
+	timeoutStore := dynamodbRepo.NewDynamoDBTimeoutStore(awsClients.DynamoDB, "PaymentTimeouts")
+	sagaRepo := dynamodbRepo.NewDynamoDBSagaRepository(awsClients.DynamoDB, "PaymentSagas")
+	fxQuoteStore := dynamodbRepo.NewDynamoDBFXQuoteStore(awsClients.DynamoDB, "FXQuotes")
+	paymentUnitOfWork := dynamodbRepo.NewDynamoDBPaymentUnitOfWork(
+		awsClients.DynamoDB, "Payments", "Idempotency", "EventStore", "Outbox", "FXQuotes",
+	)
+	unitOfWork := dynamodbRepo.NewDynamoDBUnitOfWork(
+		awsClients.DynamoDB, paymentRepo, walletRepo, sagaRepo, eventStore, outboxStore, inboxStore, timeoutStore,
+	)
 
 	// Initialize event bus
-	eventPublisher := sns.NewSNSPublisher(awsClients.SNS)
+	// Services write to the outbox; the relay is the only component that talks to SNS
+	snsPublisher := sns.NewSNSPublisher(awsClients.SNS)
 	eventConsumer := sqs.NewSQSConsumer(awsClients.SQS)
 
+	outboxRelay := outbox.NewRelay(outboxStore, snsPublisher, outbox.DefaultRelayConfig())
+	outboxRelay.Start(ctx)
+
+	gateways, gatewayRouter := newPaymentGateways(config)
+
 	// Initialize services
 	createPaymentService := command.NewCreatePaymentService(
-		paymentRepo,
+		paymentUnitOfWork,
 		walletRepo,
 		idempotencyStore,
-		eventStore,
-		eventPublisher,
+		fxQuoteStore,
+		newFeeSchedule(config),
+		config.PaymentsTopicArn,
+	)
+
+	refundPaymentService := command.NewRefundPaymentService(
+		paymentRepo,
+		paymentUnitOfWork,
+		idempotencyStore,
 		config.PaymentsTopicArn,
 	)
 
 	paymentOrchestrator := orchestrator.NewPaymentOrchestrator(
 		paymentRepo,
 		walletRepo,
+		sagaRepo,
+		gatewayRouter,
 		eventStore,
-		eventPublisher,
+		unitOfWork,
+		config.PaymentsTopicArn,
+		config.SagaStepTimeout,
+	)
+
+	cancelPaymentService := command.NewCancelPaymentService(paymentOrchestrator)
+
+	timeoutScheduler := orchestrator.NewTimeoutScheduler(
+		paymentRepo,
+		timeoutStore,
+		unitOfWork,
 		config.PaymentsTopicArn,
+		config.ExternalPaymentTimeout,
+	)
+	timeoutScheduler.Start(ctx, 5*time.Second)
+
+	getPaymentService := query.NewGetPaymentService(paymentRepo, eventStore)
+	auditPaymentService := query.NewAuditPaymentService(
+		paymentRepo,
+		eventsourcing.NewEventSourcedPaymentRepository(eventStore),
 	)
 
-	externalGatewayMock := orchestrator.NewExternalGatewayMock(
+	createWalletService := command.NewCreateWalletService(walletRepo)
+	topUpWalletService := command.NewTopUpWalletService(
+		walletRepo,
+		ledgerRepo,
 		eventStore,
-		eventPublisher,
+		unitOfWork,
+		config.PaymentsTopicArn,
+	)
+	getWalletService := query.NewGetWalletService(walletRepo, eventStore)
+	getSagaService := query.NewGetSagaService(sagaRepo)
+	quoteFXService := command.NewQuoteFXService(
+		walletRepo,
+		newFXRateProvider(config),
+		fxQuoteStore,
+		config.FXSpread,
+		config.FXQuoteTTL,
+	)
+	processWebhookService := command.NewProcessGatewayWebhookService(
+		paymentRepo,
+		eventStore,
+		inboxStore,
+		unitOfWork,
+		config.PaymentsTopicArn,
+	)
+
+	externalPaymentHandler := orchestrator.NewExternalPaymentHandler(
+		gateways,
+		gatewayRouter,
+		unitOfWork,
 		config.PaymentsTopicArn,
-		true, // always success for demo
 	)
 
 	// Start event consumers
-	startEventConsumers(eventConsumer, paymentOrchestrator, externalGatewayMock, config)
+	startEventConsumers(eventConsumer, inboxStore, paymentOrchestrator, timeoutScheduler, externalPaymentHandler, config)
 
 	// Initialize HTTP server
-	handler := httpHandler.NewPaymentHandler(createPaymentService)
+	handler := httpHandler.NewPaymentHandler(createPaymentService, refundPaymentService, cancelPaymentService, getPaymentService, auditPaymentService)
 
 	http.HandleFunc("/payments", handler.HandleCreatePayment)
+	http.HandleFunc("/payments/", handler.HandlePaymentResource)
+
+	walletHandler := httpHandler.NewWalletHandler(createWalletService, topUpWalletService, getWalletService)
+
+	http.HandleFunc("/wallets", walletHandler.HandleCreateWallet)
+	http.HandleFunc("/wallets/", walletHandler.HandleWalletResource)
+
+	sagaHandler := httpHandler.NewSagaHandler(getSagaService)
+
+	http.HandleFunc("/sagas/", sagaHandler.HandleSagaResource)
+
+	fxHandler := httpHandler.NewFXHandler(quoteFXService)
+
+	http.HandleFunc("/fx/quotes", fxHandler.HandleCreateQuote)
+
+	webhookHandler := httpHandler.NewWebhookHandler(
+		processWebhookService,
+		config.GatewayWebhookSecrets,
+		config.GatewayWebhookTolerance,
+	)
+
+	http.HandleFunc("/webhooks/gateway/", webhookHandler.HandleGatewayWebhook)
 	http.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		w.Write([]byte("OK"))
@@ -97,6 +206,21 @@ type Config struct {
 	PaymentQueueURL         string
 	ExternalGatewayQueueURL string
 	Port                    string
+	ExternalPaymentTimeout  time.Duration
+	SagaStepTimeout         time.Duration
+	GatewayURL              string // empty uses the in-process mock gateway
+	GatewayAPIKey           string
+	GatewayTimeout          time.Duration
+	GatewayRoutesFile       string // empty routes every payment to the single gateway above
+	GatewayMockScenario     string // preset name or JSON file, used when GatewayURL is empty
+	GatewayWebhookSecrets   map[string]string
+	GatewayWebhookTolerance time.Duration
+	GatewayResilience       gateway.ResilienceConfig
+	FXRatesFile             string // empty disables conversions, every quote is FX_RATE_UNAVAILABLE
+	FXSpread                decimal.Decimal
+	FXQuoteTTL              time.Duration
+	FeeScheduleFile         string // empty charges no fees
+	FeeRefundPolicy         string // NEVER, PROPORTIONAL or ON_FULL_REFUND, for rules without their own
 }
 
 func loadConfig() Config {
@@ -106,7 +230,130 @@ func loadConfig() Config {
 		PaymentQueueURL:         getEnv("PAYMENT_QUEUE_URL", "http://localhost:4566/000000000000/payment-service-queue"),
 		ExternalGatewayQueueURL: getEnv("EXTERNAL_GATEWAY_QUEUE_URL", "http://localhost:4566/000000000000/external-gateway-queue"),
 		Port:                    getEnv("PORT", "8080"),
+		ExternalPaymentTimeout:  getDurationEnv("EXTERNAL_PAYMENT_TIMEOUT", 60*time.Second),
+		SagaStepTimeout:         getDurationEnv("SAGA_STEP_TIMEOUT", 5*time.Minute),
+		GatewayURL:              getEnv("GATEWAY_URL", ""),
+		GatewayAPIKey:           getEnv("GATEWAY_API_KEY", ""),
+		GatewayTimeout:          getDurationEnv("GATEWAY_TIMEOUT", 10*time.Second),
+		GatewayRoutesFile:       getEnv("GATEWAY_ROUTES_FILE", ""),
+		GatewayMockScenario:     getEnv("GATEWAY_MOCK_SCENARIO", gateway.DefaultMockScenario),
+		GatewayWebhookSecrets:   getMapEnv("GATEWAY_WEBHOOK_SECRETS", ""),
+		GatewayWebhookTolerance: getDurationEnv("GATEWAY_WEBHOOK_TOLERANCE", 5*time.Minute),
+		GatewayResilience:       loadGatewayResilience(),
+		FXRatesFile:             getEnv("FX_RATES_FILE", ""),
+		FXSpread:                getDecimalEnv("FX_SPREAD", decimal.RequireFromString("0.01")),
+		FXQuoteTTL:              getDurationEnv("FX_QUOTE_TTL", 60*time.Second),
+		FeeScheduleFile:         getEnv("FEE_SCHEDULE_FILE", ""),
+		FeeRefundPolicy:         getEnv("FEE_REFUND_POLICY", vo.FeeRefundProportional.String()),
+	}
+}
+
+// requireSecrets refuses to start without the credentials the gateways need
+// There are no defaults: a well-known secret would let anyone sign webhooks
+func (c Config) requireSecrets() error {
+	if len(c.GatewayWebhookSecrets) == 0 {
+		return errors.New("GATEWAY_WEBHOOK_SECRETS is not set, webhooks could not be verified")
+	}
+	if c.GatewayURL != "" && c.GatewayAPIKey == "" {
+		return errors.New("GATEWAY_API_KEY is not set, required by the gateway at GATEWAY_URL")
+	}
+	return nil
+}
+
+// loadGatewayResilience overrides the default breaker and retry settings from the environment
+func loadGatewayResilience() gateway.ResilienceConfig {
+	breaker := gateway.DefaultCircuitBreakerConfig()
+	breaker.FailureThreshold = getIntEnv("GATEWAY_BREAKER_FAILURE_THRESHOLD", breaker.FailureThreshold)
+	breaker.OpenTimeout = getDurationEnv("GATEWAY_BREAKER_OPEN_TIMEOUT", breaker.OpenTimeout)
+	breaker.HalfOpenProbes = getIntEnv("GATEWAY_BREAKER_HALF_OPEN_PROBES", breaker.HalfOpenProbes)
+
+	retry := gateway.DefaultRetryConfig()
+	retry.MaxAttempts = getIntEnv("GATEWAY_RETRY_MAX_ATTEMPTS", retry.MaxAttempts)
+	retry.BaseDelay = getDurationEnv("GATEWAY_RETRY_BASE_DELAY", retry.BaseDelay)
+	retry.MaxDelay = getDurationEnv("GATEWAY_RETRY_MAX_DELAY", retry.MaxDelay)
+
+	return gateway.ResilienceConfig{Breaker: breaker, Retry: retry}
+}
+
+// newPaymentGateways builds the gateways and routing table from GATEWAY_ROUTES_FILE
+// Without it every payment goes to one gateway named "default"
+func newPaymentGateways(config Config) (map[string]port.PaymentGateway, *orchestrator.GatewayRouter) {
+	if config.GatewayRoutesFile != "" {
+		routing, err := gateway.LoadRoutingConfig(config.GatewayRoutesFile)
+		if err != nil {
+			log.Fatalf("Failed to load gateway routes: %v", err)
+		}
+		gateways, router, err := routing.Build(config.GatewayTimeout, config.GatewayResilience)
+		if err != nil {
+			log.Fatalf("Invalid gateway routes in %s: %v", config.GatewayRoutesFile, err)
+		}
+
+		log.Printf("Using %d payment gateways routed by %s", len(gateways), config.GatewayRoutesFile)
+		return gateways, router
+	}
+
+	const defaultGateway = "default"
+	gw, breaker := gateway.WrapResilient(defaultGateway, newPaymentGateway(config), config.GatewayResilience)
+	gateways := map[string]port.PaymentGateway{defaultGateway: gw}
+	router := orchestrator.NewSingleGatewayRouter(defaultGateway).
+		WithHealth(gateway.CircuitBreakers{defaultGateway: breaker})
+	return gateways, router
+}
+
+// newFXRateProvider loads the market rates from FX_RATES_FILE
+func newFXRateProvider(config Config) port.FXRateProvider {
+	if config.FXRatesFile == "" {
+		log.Printf("FX_RATES_FILE not set, cross-currency payments are disabled")
+		return fx.NewStaticRateProvider(nil)
+	}
+
+	rates, err := fx.LoadRatesFile(config.FXRatesFile)
+	if err != nil {
+		log.Fatalf("Failed to load fx rates: %v", err)
+	}
+
+	log.Printf("Using fx rates from %s with a %s spread", config.FXRatesFile, config.FXSpread)
+	return rates
+}
+
+// newFeeSchedule loads the fee schedule from FEE_SCHEDULE_FILE
+func newFeeSchedule(config Config) *payment.FeeSchedule {
+	refundPolicy, err := vo.ParseFeeRefundPolicy(config.FeeRefundPolicy)
+	if err != nil {
+		log.Fatalf("Invalid FEE_REFUND_POLICY: %v", err)
+	}
+
+	if config.FeeScheduleFile == "" {
+		log.Printf("FEE_SCHEDULE_FILE not set, payments are charged no fees")
+		return payment.NewEmptyFeeSchedule()
 	}
+
+	schedule, err := fees.LoadScheduleFile(config.FeeScheduleFile, refundPolicy)
+	if err != nil {
+		log.Fatalf("Failed to load fee schedule: %v", err)
+	}
+
+	log.Printf("Using fee schedule from %s, fees refunded %s by default", config.FeeScheduleFile, refundPolicy)
+	return schedule
+}
+
+// newPaymentGateway uses the HTTP gateway when GATEWAY_URL is set, otherwise the simulated mock
+func newPaymentGateway(config Config) port.PaymentGateway {
+	if config.GatewayURL == "" {
+		log.Printf("GATEWAY_URL not set, using mock payment gateway with scenario %s", config.GatewayMockScenario)
+		scenario, err := gateway.LoadMockScenario(config.GatewayMockScenario)
+		if err != nil {
+			log.Fatalf("Failed to load mock gateway scenario: %v", err)
+		}
+		mock, err := gateway.NewMockGateway(scenario)
+		if err != nil {
+			log.Fatalf("Invalid mock gateway scenario %s: %v", config.GatewayMockScenario, err)
+		}
+		return mock
+	}
+
+	log.Printf("Using HTTP payment gateway at %s", config.GatewayURL)
+	return gateway.NewHTTPGateway(config.GatewayURL, config.GatewayAPIKey, config.GatewayTimeout)
 }
 
 func getEnv(key, defaultValue string) string {
@@ -116,6 +363,35 @@ func getEnv(key, defaultValue string) string {
 	return defaultValue
 }
 
+func getDurationEnv(key string, defaultValue time.Duration) time.Duration {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	duration, err := time.ParseDuration(value)
+	if err != nil {
+		log.Printf("Invalid %s=%q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return duration
+}
+
+// getDecimalEnv parses a decimal such as "0.01"; negative values fall back to the default
+func getDecimalEnv(key string, defaultValue decimal.Decimal) decimal.Decimal {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := decimal.NewFromString(value)
+	if err != nil || parsed.IsNegative() {
+		log.Printf("Invalid %s=%q, using %s", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
 // EventConsumer defines the interface for consuming events
 type EventConsumer interface {
 	StartConsuming(queueURL string, handler func(ctx context.Context, event shared.Event) error)
@@ -123,15 +399,20 @@ type EventConsumer interface {
 
 func startEventConsumers(
 	consumer EventConsumer,
+	inboxStore port.InboxStore,
 	orch *orchestrator.PaymentOrchestrator,
-	gateway *orchestrator.ExternalGatewayMock,
+	timeouts *orchestrator.TimeoutScheduler,
+	gatewayHandler *orchestrator.ExternalPaymentHandler,
 	config Config,
 ) {
+	// Every handler is wrapped with the inbox so SQS redeliveries are skipped
 	// Payment service queue - handles PaymentRequested events
-	consumer.StartConsuming(config.PaymentQueueURL, func(ctx context.Context, event shared.Event) error {
+	consumer.StartConsuming(config.PaymentQueueURL, inbox.Deduplicate(inboxStore, "payment-service", func(ctx context.Context, event shared.Event) error {
 		switch event.EventType() {
 		case "PaymentRequested":
 			return orch.HandlePaymentRequested(ctx, event)
+		case "ExternalPaymentRequested":
+			return timeouts.HandleExternalPaymentRequested(ctx, event)
 		case "ExternalPaymentSucceeded":
 			return orch.HandleExternalPaymentSucceeded(ctx, event)
 		case "ExternalPaymentFailed":
@@ -142,29 +423,64 @@ func startEventConsumers(
 			log.Printf("Unhandled event type in payment queue: %s", event.EventType())
 			return nil
 		}
-	})
+	}))
 
-	// Wallet service queue - handles refund requests
-	consumer.StartConsuming(config.WalletQueueURL, func(ctx context.Context, event shared.Event) error {
+	// Wallet service queue - releases the holds of failed payments and credits merchant refunds
+	consumer.StartConsuming(config.WalletQueueURL, inbox.Deduplicate(inboxStore, "wallet-service", func(ctx context.Context, event shared.Event) error {
 		switch event.EventType() {
 		case "PaymentRefundRequested":
 			return orch.HandlePaymentRefundRequested(ctx, event)
+		case "PaymentRefunded":
+			return orch.HandlePaymentRefunded(ctx, event)
 		default:
 			log.Printf("Unhandled event type in wallet queue: %s", event.EventType())
 			return nil
 		}
-	})
+	}))
 
-	// External gateway queue - simulates external payment processing
-	consumer.StartConsuming(config.ExternalGatewayQueueURL, func(ctx context.Context, event shared.Event) error {
+	// External gateway queue - charges the payment on the configured gateway
+	consumer.StartConsuming(config.ExternalGatewayQueueURL, inbox.Deduplicate(inboxStore, "external-gateway", func(ctx context.Context, event shared.Event) error {
 		switch event.EventType() {
 		case "ExternalPaymentRequested":
-			return gateway.HandleExternalPaymentRequested(ctx, event)
+			return gatewayHandler.HandleExternalPaymentRequested(ctx, event)
 		default:
 			log.Printf("Unhandled event type in gateway queue: %s", event.EventType())
 			return nil
 		}
-	})
+	}))
 
 	log.Println("Event consumers started")
 }
+
+func getIntEnv(key string, defaultValue int) int {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+
+	parsed, err := strconv.Atoi(value)
+	if err != nil || parsed <= 0 {
+		log.Printf("Invalid %s=%q, using %d", key, value, defaultValue)
+		return defaultValue
+	}
+	return parsed
+}
+
+// getMapEnv parses "key=value,key=value"; malformed pairs are skipped
+// Values may be secrets, so they are never logged
+func getMapEnv(key, defaultValue string) map[string]string {
+	result := make(map[string]string)
+	value := getEnv(key, defaultValue)
+	if value == "" {
+		return result
+	}
+	for i, pair := range strings.Split(value, ",") {
+		k, v, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok || k == "" || v == "" {
+			log.Printf("Ignoring malformed %s entry #%d", key, i+1)
+			continue
+		}
+		result[k] = v
+	}
+	return result
+}
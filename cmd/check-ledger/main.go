@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/infrastructure"
+	dynamodbRepo "github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb"
+)
+
+// Recomputes every wallet balance from the ledger and reports the ones that drifted
+// Exits with status 1 when any did
+func main() {
+	open := flag.Bool("open", false, "post opening entries for balances the ledger does not explain yet, before checking")
+	flag.Parse()
+
+	ctx := context.Background()
+
+	awsClients, err := infrastructure.NewAWSClients(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create AWS clients: %v", err)
+	}
+
+	ledgerRepo := dynamodbRepo.NewDynamoDBLedgerRepository(awsClients.DynamoDB, "JournalEntries", "LedgerPostings")
+	walletRepo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets", ledgerRepo)
+
+	if *open {
+		opened, excess, err := walletRepo.OpenLedgerBalances(ctx)
+		if err != nil {
+			log.Fatalf("Opening balances stopped after %d wallets: %v", opened, err)
+		}
+		log.Printf("Posted opening balances for %d wallets", opened)
+
+		// The ledger already has more than these wallets hold, an opening entry cannot fix that
+		for _, balance := range excess {
+			log.Printf("EXCESS wallet=%s currency=%s balance=%s ledger=%s difference=%s",
+				balance.UserID, balance.Currency, balance.Balance.String(), balance.Ledger.String(),
+				balance.Balance.Sub(balance.Ledger).String())
+		}
+	}
+
+	report, err := query.NewCheckLedgerService(walletRepo, ledgerRepo).Execute(ctx)
+	if err != nil {
+		log.Fatalf("Ledger check failed: %v", err)
+	}
+
+	for _, drift := range report.Drifts {
+		log.Printf("DRIFT wallet=%s currency=%s balance=%s ledger=%s difference=%s",
+			drift.UserID, drift.Currency, drift.Balance.String(), drift.Ledger.String(), drift.Difference.String())
+	}
+
+	if !report.Consistent {
+		log.Printf("%d of %d wallets checked have drifted from the ledger (%d balances)",
+			countWallets(report.Drifts), report.WalletsChecked, len(report.Drifts))
+		os.Exit(1)
+	}
+
+	log.Printf("Checked %d wallets, every balance matches the ledger", report.WalletsChecked)
+}
+
+// countWallets counts the distinct wallets among the drifts
+func countWallets(drifts []query.LedgerDrift) int {
+	wallets := make(map[string]struct{}, len(drifts))
+	for _, drift := range drifts {
+		wallets[drift.UserID] = struct{}{}
+	}
+	return len(wallets)
+}
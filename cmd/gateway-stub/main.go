@@ -0,0 +1,27 @@
+package main
+
+import (
+	"log"
+	"net/http"
+	"os"
+
+	"github.com/franco/payment-api/internal/infrastructure/gateway"
+)
+
+// Local payment gateway speaking the v1 protocol, for running the API with GATEWAY_URL
+func main() {
+	port := getEnv("GATEWAY_STUB_PORT", "8090")
+	apiKey := getEnv("GATEWAY_API_KEY", "local-gateway-key")
+
+	log.Printf("Starting gateway stub on port %s...", port)
+	if err := http.ListenAndServe(":"+port, gateway.NewStubServer(apiKey)); err != nil {
+		log.Fatalf("Gateway stub failed: %v", err)
+	}
+}
+
+func getEnv(key, defaultValue string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/franco/payment-api/internal/infrastructure"
+	dynamodbRepo "github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb"
+)
+
+// Rewrites wallets stored with a single balance into the per-currency format
+func main() {
+	ctx := context.Background()
+
+	awsClients, err := infrastructure.NewAWSClients(ctx)
+	if err != nil {
+		log.Fatalf("Failed to create AWS clients: %v", err)
+	}
+
+	ledgerRepo := dynamodbRepo.NewDynamoDBLedgerRepository(awsClients.DynamoDB, "JournalEntries", "LedgerPostings")
+	walletRepo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets", ledgerRepo)
+
+	migrated, err := walletRepo.MigrateLegacyBalances(ctx)
+	if err != nil {
+		log.Fatalf("Wallet migration stopped after %d wallets: %v", migrated, err)
+	}
+
+	log.Printf("Migrated %d wallets to per-currency balances", migrated)
+}
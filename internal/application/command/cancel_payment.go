@@ -0,0 +1,80 @@
+package command
+
+import (
+	"context"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+)
+
+// defaultCancelReason is recorded when the client does not give one
+const defaultCancelReason = "CANCELLED_BY_CLIENT"
+
+// CancelPaymentRequest represents a client cancellation of a payment not settled yet
+type CancelPaymentRequest struct {
+	PaymentID string
+	Reason    string
+	ClientID  string
+}
+
+// CancelPaymentResponse represents the response from a cancellation
+type CancelPaymentResponse struct {
+	PaymentID string
+	Status    string
+}
+
+// PaymentCanceller cancels a payment through its saga, so the cancellation cannot race the
+// orchestrator into holding funds for a cancelled payment; PaymentOrchestrator implements it
+type PaymentCanceller interface {
+	CancelPayment(ctx context.Context, paymentID, reason string, metadata shared.Metadata) (*payment.Payment, error)
+}
+
+// CancelPaymentService handles client cancellations
+// Cancelling an already cancelled payment succeeds without doing anything
+type CancelPaymentService struct {
+	canceller PaymentCanceller
+}
+
+// NewCancelPaymentService creates a new CancelPaymentService
+func NewCancelPaymentService(canceller PaymentCanceller) *CancelPaymentService {
+	return &CancelPaymentService{
+		canceller: canceller,
+	}
+}
+
+// Execute cancels the payment, or fails with PAYMENT_NOT_CANCELLABLE once the gateway settled it
+// CONCURRENT_MODIFICATION means the payment is being processed right now and the call may be retried
+func (s *CancelPaymentService) Execute(ctx context.Context, req CancelPaymentRequest) (*CancelPaymentResponse, error) {
+	if req.PaymentID == "" {
+		return nil, requiredFieldError("paymentId")
+	}
+
+	paymentID, err := vo.NewPaymentID(req.PaymentID)
+	if err != nil {
+		return nil, domerrors.ValidationError("paymentId", err.Error())
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = defaultCancelReason
+	}
+
+	metadata := shared.Metadata{
+		ClientID:  req.ClientID,
+		RequestID: vo.GeneratePaymentID().String(),
+		Source:    "payment-api",
+		Extra:     make(map[string]string),
+	}
+
+	pmt, err := s.canceller.CancelPayment(ctx, paymentID.String(), reason, metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return &CancelPaymentResponse{
+		PaymentID: pmt.ID().String(),
+		Status:    pmt.Status().String(),
+	}, nil
+}
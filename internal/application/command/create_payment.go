@@ -2,9 +2,10 @@ package command
 
 import (
 	"context"
-	"errors"
 	"fmt"
+	"time"
 
+	"github.com/franco/payment-api/internal/application/port"
 	"github.com/franco/payment-api/internal/domain/payment"
 	"github.com/franco/payment-api/internal/domain/shared"
 	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
@@ -16,11 +17,12 @@ import (
 // CreatePaymentRequest represents a payment creation request
 type CreatePaymentRequest struct {
 	UserID         string
-	Amount         float64
+	Amount         decimal.Decimal
 	Currency       string
 	ServiceID      string
 	IdempotencyKey string
 	ClientID       string
+	FXQuoteID      string // optional, funds the payment from another wallet currency at the quoted rate
 }
 
 // CreatePaymentResponse represents the response from payment creation
@@ -31,11 +33,11 @@ type CreatePaymentResponse struct {
 
 // CreatePaymentService handles payment creation use case
 type CreatePaymentService struct {
-	paymentRepo      PaymentRepository
+	unitOfWork       PaymentUnitOfWork
 	walletRepo       WalletRepository
 	idempotencyStore shared.IdempotencyStore
-	eventStore       shared.EventStore
-	eventPublisher   EventPublisher
+	quotes           port.FXQuoteStore
+	fees             *payment.FeeSchedule
 	topicArn         string
 }
 
@@ -46,31 +48,38 @@ type PaymentRepository interface {
 	Update(ctx context.Context, pmt *payment.Payment) error
 }
 
+// PaymentUnitOfWork persists a new payment together with its idempotency key and PaymentRequested event,
+// and queues the event in the outbox for delivery to topicArn
+// A converted payment also marks its FX quote as used by it
+// Implementations must write everything atomically and return a DUPLICATE_REQUEST error
+// when the idempotency key has already been claimed, or an FX_QUOTE_ALREADY_USED error when
+// another payment consumed the quote first
+type PaymentUnitOfWork interface {
+	CreatePayment(ctx context.Context, pmt *payment.Payment, event shared.Event, topicArn string) error
+}
+
 // WalletRepository defines wallet operations
 type WalletRepository interface {
 	GetByUserID(ctx context.Context, userID string) (*wallet.Wallet, error)
-}
-
-// EventPublisher publishes events
-type EventPublisher interface {
-	Publish(ctx context.Context, event shared.Event, topicArn string) error
+	Save(ctx context.Context, wlt *wallet.Wallet) error
+	Update(ctx context.Context, wlt *wallet.Wallet) error
 }
 
 // NewCreatePaymentService creates a new CreatePaymentService
 func NewCreatePaymentService(
-	paymentRepo PaymentRepository,
+	unitOfWork PaymentUnitOfWork,
 	walletRepo WalletRepository,
 	idempotencyStore shared.IdempotencyStore,
-	eventStore shared.EventStore,
-	eventPublisher EventPublisher,
+	quotes port.FXQuoteStore,
+	fees *payment.FeeSchedule,
 	topicArn string,
 ) *CreatePaymentService {
 	return &CreatePaymentService{
-		paymentRepo:      paymentRepo,
+		unitOfWork:       unitOfWork,
 		walletRepo:       walletRepo,
 		idempotencyStore: idempotencyStore,
-		eventStore:       eventStore,
-		eventPublisher:   eventPublisher,
+		quotes:           quotes,
+		fees:             fees,
 		topicArn:         topicArn,
 	}
 }
@@ -82,7 +91,7 @@ func (s *CreatePaymentService) Execute(ctx context.Context, req CreatePaymentReq
 		return nil, err
 	}
 
-	// Check idempotency
+	// Check idempotency (fast path; the unit of work enforces it atomically)
 	existingPaymentID, err := s.idempotencyStore.GetPaymentIDByKey(ctx, req.IdempotencyKey)
 	if err == nil && existingPaymentID != "" {
 		return &CreatePaymentResponse{
@@ -91,8 +100,9 @@ func (s *CreatePaymentService) Execute(ctx context.Context, req CreatePaymentReq
 		}, nil
 	}
 
-	// Validate wallet exists and has sufficient balance (SYNC)
-	if err := s.validateWalletBalance(ctx, req); err != nil {
+	// A payment funded from another currency uses the rate its quote locked
+	conversion, err := s.lockedConversion(ctx, req)
+	if err != nil {
 		return nil, err
 	}
 
@@ -101,28 +111,22 @@ func (s *CreatePaymentService) Execute(ctx context.Context, req CreatePaymentReq
 
 	userID, err := vo.NewUserID(req.UserID)
 	if err != nil {
-		return nil, err
+		return nil, domerrors.ValidationError("userID", err.Error())
 	}
 
 	serviceID, err := vo.NewServiceID(req.ServiceID)
 	if err != nil {
-		return nil, err
-	}
-
-	currency, err := vo.NewCurrency(req.Currency)
-	if err != nil {
-		return nil, err
+		return nil, domerrors.ValidationError("serviceID", err.Error())
 	}
 
-	amount := decimal.NewFromFloat(req.Amount)
-	money, err := vo.NewMoney(amount, currency)
+	money, err := newMoney(req.Amount, req.Currency)
 	if err != nil {
 		return nil, err
 	}
 
 	idempKey, err := vo.NewIdempotencyKey(req.IdempotencyKey)
 	if err != nil {
-		return nil, err
+		return nil, domerrors.ValidationError("idempotencyKey", err.Error())
 	}
 
 	// Create new payment aggregate
@@ -137,17 +141,26 @@ func (s *CreatePaymentService) Execute(ctx context.Context, req CreatePaymentReq
 		return nil, err
 	}
 
-	// Save payment
-	if err := s.paymentRepo.Save(ctx, pmt); err != nil {
+	// Fees go on top of the amount: the gateway charges the amount, the wallet pays both
+	fees, err := s.fees.Evaluate(serviceID.String(), req.ClientID, money)
+	if err != nil {
 		return nil, err
 	}
+	if err := pmt.ApplyFees(fees); err != nil {
+		return nil, err
+	}
+	if conversion != nil {
+		if err := pmt.ApplyConversion(*conversion); err != nil {
+			return nil, err
+		}
+	}
 
-	// Save idempotency key
-	if err := s.idempotencyStore.SaveKey(ctx, req.IdempotencyKey, paymentID.String()); err != nil {
+	// Validate wallet exists and has sufficient balance (SYNC)
+	if err := s.validateWalletBalance(ctx, pmt); err != nil {
 		return nil, err
 	}
 
-	// Create and publish PaymentRequested event
+	// Create PaymentRequested event
 	metadata := shared.Metadata{
 		ClientID:  req.ClientID,
 		RequestID: vo.GeneratePaymentID().String(),
@@ -158,20 +171,20 @@ func (s *CreatePaymentService) Execute(ctx context.Context, req CreatePaymentReq
 	event := payment.NewPaymentRequestedEvent(
 		paymentID.String(),
 		req.UserID,
-		req.Amount,
-		req.Currency,
+		money.Amount(),
+		money.Currency().Code(),
 		req.ServiceID,
 		req.IdempotencyKey,
 		metadata,
-	)
-
-	// Store event
-	if err := s.eventStore.Append(ctx, event, paymentID.String()); err != nil {
-		return nil, err
-	}
-
-	// Publish event
-	if err := s.eventPublisher.Publish(ctx, event, s.topicArn); err != nil {
+	).WithFX(pmt.FXDetails()).WithFees(pmt.FeeDetails())
+
+	// Save payment, idempotency key, event and outbox entry in one atomic write
+	// The outbox relay publishes the event, so an SNS outage no longer fails the request
+	if err := s.unitOfWork.CreatePayment(ctx, pmt, event, s.topicArn); err != nil {
+		// A concurrent request with the same key won the race; answer like the idempotent path
+		if domerrors.IsErrorCode(err, domerrors.ErrCodeDuplicateRequest) {
+			return s.alreadyProcessed(ctx, req.IdempotencyKey, err)
+		}
 		return nil, err
 	}
 
@@ -181,61 +194,122 @@ func (s *CreatePaymentService) Execute(ctx context.Context, req CreatePaymentReq
 	}, nil
 }
 
+// alreadyProcessed resolves the payment that owns an idempotency key after losing the creation race
+func (s *CreatePaymentService) alreadyProcessed(ctx context.Context, idempotencyKey string, duplicateErr error) (*CreatePaymentResponse, error) {
+	existingPaymentID, err := s.idempotencyStore.GetPaymentIDByKey(ctx, idempotencyKey)
+	if err != nil || existingPaymentID == "" {
+		return nil, duplicateErr
+	}
+
+	return &CreatePaymentResponse{
+		PaymentID: existingPaymentID,
+		Status:    "ALREADY_PROCESSED",
+	}, nil
+}
+
 func (s *CreatePaymentService) validateRequest(req CreatePaymentRequest) error {
 	if req.UserID == "" {
-		return errors.New("userID is required")
+		return requiredFieldError("userID")
 	}
-	if req.Amount <= 0 {
-		return errors.New("amount must be greater than zero")
+	if !req.Amount.IsPositive() {
+		return domerrors.NewDomainError(
+			domerrors.ErrCodeInvalidAmount,
+			"amount must be greater than zero",
+		).WithDetail("field", "amount")
 	}
 	if req.Currency == "" {
-		return errors.New("currency is required")
+		return requiredFieldError("currency")
 	}
 	if req.ServiceID == "" {
-		return errors.New("serviceID is required")
+		return requiredFieldError("serviceID")
 	}
 	if req.IdempotencyKey == "" {
-		return errors.New("idempotencyKey is required")
+		return requiredFieldError("idempotencyKey")
 	}
 	return nil
 }
 
-// validateWalletBalance checks wallet exists and has sufficient funds
-// This is a SYNC validation before creating the payment
-func (s *CreatePaymentService) validateWalletBalance(ctx context.Context, req CreatePaymentRequest) error {
-	// Get wallet
-	wlt, err := s.walletRepo.GetByUserID(ctx, req.UserID)
-	if err != nil {
-		return domerrors.WalletNotFoundError(req.UserID)
+func requiredFieldError(field string) *domerrors.DomainError {
+	return domerrors.NewDomainError(
+		domerrors.ErrCodeValidationFailed,
+		fmt.Sprintf("%s is required", field),
+	).WithDetail("field", field).WithDetail("reason", "required")
+}
+
+// lockedConversion loads the quote named by the request, nil when the payment is not converted
+// Someone else's quote is reported as not found
+// A quote already used is rejected here as a fast path; the unit of work consumes it atomically
+func (s *CreatePaymentService) lockedConversion(ctx context.Context, req CreatePaymentRequest) (*vo.Conversion, error) {
+	if req.FXQuoteID == "" {
+		return nil, nil
 	}
 
-	// Create money value object for amount
-	currency, err := vo.NewCurrency(req.Currency)
+	quote, err := s.quotes.Get(ctx, req.FXQuoteID)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if quote.UserID != req.UserID {
+		return nil, domerrors.FXQuoteNotFoundError(req.FXQuoteID)
+	}
+	if quote.IsExpired(time.Now()) {
+		return nil, domerrors.FXQuoteExpiredError(req.FXQuoteID, quote.ExpiresAt.UTC().Format(time.RFC3339))
+	}
+	if quote.UsedBy != "" {
+		return nil, domerrors.FXQuoteUsedError(req.FXQuoteID)
 	}
 
-	amount := decimal.NewFromFloat(req.Amount)
-	money, err := vo.NewMoney(amount, currency)
+	money, err := newMoney(req.Amount, req.Currency)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if !quote.Conversion.Target().Equals(money) {
+		return nil, domerrors.ValidationError("fxQuoteId",
+			fmt.Sprintf("quote is for %s, payment is %s", quote.Conversion.Target().String(), money.String()))
 	}
 
-	// Check currency match
-	if !wlt.Balance().Currency().Equals(money.Currency()) {
-		return domerrors.CurrencyMismatchError(
-			wlt.Balance().Currency().Code(),
-			money.Currency().Code(),
-		)
+	return &quote.Conversion, nil
+}
+
+// validateWalletBalance checks wallet exists and has sufficient funds
+// The wallet must cover the funding amount: fees included, converted when the payment is
+// funded from another currency
+// This is a SYNC validation before creating the payment
+func (s *CreatePaymentService) validateWalletBalance(ctx context.Context, pmt *payment.Payment) error {
+	// Get wallet
+	wlt, err := s.walletRepo.GetByUserID(ctx, pmt.UserID().String())
+	if err != nil {
+		if domerrors.IsErrorCode(err, domerrors.ErrCodeWalletNotFound) {
+			return domerrors.WalletNotFoundError(pmt.UserID().String())
+		}
+		return err
 	}
 
-	// Check sufficient balance
-	if !wlt.CanDebit(money) {
+	// Check sufficient balance in the funding currency outside the funds already held
+	funding := pmt.FundingMoney()
+	if !wlt.CanDebit(funding) {
 		return domerrors.InsufficientFundsError(
-			fmt.Sprintf("%.2f %s", money.AmountFloat(), money.Currency().Code()),
-			fmt.Sprintf("%.2f %s", wlt.Balance().AmountFloat(), wlt.Balance().Currency().Code()),
+			funding.String(),
+			wlt.AvailableBalance(funding.Currency()).String(),
 		)
 	}
 
 	return nil
 }
+
+// newMoney builds the Money value object for a request, reporting bad input as typed errors
+func newMoney(amount decimal.Decimal, currencyCode string) (vo.Money, error) {
+	currency, err := vo.NewCurrency(currencyCode)
+	if err != nil {
+		return vo.Money{}, domerrors.NewDomainError(
+			domerrors.ErrCodeInvalidCurrency,
+			err.Error(),
+		).WithDetail("currency", currencyCode)
+	}
+
+	money, err := vo.NewMoney(amount, currency)
+	if err != nil {
+		return vo.Money{}, domerrors.NewDomainError(domerrors.ErrCodeInvalidAmount, err.Error())
+	}
+
+	return money, nil
+}
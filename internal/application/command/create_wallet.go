@@ -0,0 +1,70 @@
+package command
+
+import (
+	"context"
+
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/shopspring/decimal"
+)
+
+// CreateWalletRequest represents a wallet opening request
+type CreateWalletRequest struct {
+	UserID   string
+	Currency string
+}
+
+// CreateWalletResponse represents the newly opened wallet
+type CreateWalletResponse struct {
+	UserID   string
+	Balance  decimal.Decimal
+	Currency string
+}
+
+// CreateWalletService handles the wallet opening use case
+type CreateWalletService struct {
+	walletRepo WalletRepository
+}
+
+// NewCreateWalletService creates a new CreateWalletService
+func NewCreateWalletService(walletRepo WalletRepository) *CreateWalletService {
+	return &CreateWalletService{
+		walletRepo: walletRepo,
+	}
+}
+
+// Execute opens a new empty wallet for a user
+func (s *CreateWalletService) Execute(ctx context.Context, req CreateWalletRequest) (*CreateWalletResponse, error) {
+	userID, err := vo.NewUserID(req.UserID)
+	if err != nil {
+		return nil, domerrors.ValidationError("userId", err.Error())
+	}
+
+	currency, err := vo.NewCurrency(req.Currency)
+	if err != nil {
+		return nil, domerrors.ValidationError("currency", err.Error())
+	}
+
+	// A user owns a single wallet
+	if _, err := s.walletRepo.GetByUserID(ctx, userID.String()); err == nil {
+		return nil, domerrors.WalletAlreadyExistsError(userID.String())
+	} else if !domerrors.IsErrorCode(err, domerrors.ErrCodeWalletNotFound) {
+		return nil, err
+	}
+
+	wlt, err := wallet.NewWallet(userID, vo.Zero(currency))
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.walletRepo.Save(ctx, wlt); err != nil {
+		return nil, err
+	}
+
+	return &CreateWalletResponse{
+		UserID:   wlt.UserID().String(),
+		Balance:  wlt.Balance(currency).Amount(),
+		Currency: currency.Code(),
+	}, nil
+}
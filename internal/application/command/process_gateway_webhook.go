@@ -0,0 +1,191 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/google/uuid"
+)
+
+// Webhook processing statuses
+const (
+	WebhookAccepted         = "ACCEPTED"
+	WebhookAlreadyProcessed = "ALREADY_PROCESSED"
+)
+
+// declineReasonNotGiven is used when a failed charge callback carries no reason
+const declineReasonNotGiven = "GATEWAY_DECLINED"
+
+// GatewayWebhookRequest is a verified charge result reported by a gateway callback
+type GatewayWebhookRequest struct {
+	Provider      string
+	EventID       string // provider event ID, callbacks are deduplicated on it
+	Status        port.ChargeStatus
+	PaymentID     string
+	TransactionID string // set when approved
+	DeclineReason string // set when declined
+	DeclineCode   string
+}
+
+// GatewayWebhookResponse represents the result of a callback
+type GatewayWebhookResponse struct {
+	EventID   string
+	PaymentID string
+	Status    string
+}
+
+// ProcessGatewayWebhookService turns gateway callbacks into the external payment result events
+// PaymentOrchestrator consumes them exactly as if the charge had answered synchronously
+type ProcessGatewayWebhookService struct {
+	paymentRepo PaymentRepository
+	eventStore  shared.EventStore
+	inboxStore  port.InboxStore
+	unitOfWork  port.UnitOfWork
+	topicArn    string
+}
+
+// NewProcessGatewayWebhookService creates a new ProcessGatewayWebhookService
+func NewProcessGatewayWebhookService(
+	paymentRepo PaymentRepository,
+	eventStore shared.EventStore,
+	inboxStore port.InboxStore,
+	unitOfWork port.UnitOfWork,
+	topicArn string,
+) *ProcessGatewayWebhookService {
+	return &ProcessGatewayWebhookService{
+		paymentRepo: paymentRepo,
+		eventStore:  eventStore,
+		inboxStore:  inboxStore,
+		unitOfWork:  unitOfWork,
+		topicArn:    topicArn,
+	}
+}
+
+// Execute publishes the result of a callback once per provider event ID
+// Gateways redeliver callbacks until they get a 2xx, so a repeated event ID is acknowledged
+// without publishing again. The inbox claim is written in the same transaction as the event, so
+// of two copies racing past the inbox check only one is recorded. Only the gateways the payment
+// was routed to may report its result: a valid signature proves who the provider is, not that
+// it charged this payment
+func (s *ProcessGatewayWebhookService) Execute(ctx context.Context, req GatewayWebhookRequest) (*GatewayWebhookResponse, error) {
+	if req.EventID == "" {
+		return nil, domerrors.ValidationError("id", "is required")
+	}
+	if req.PaymentID == "" {
+		return nil, domerrors.ValidationError("reference", "is required")
+	}
+
+	consumer := webhookInboxConsumer(req.Provider)
+	processed, err := s.inboxStore.IsProcessed(ctx, consumer, req.EventID)
+	if err != nil {
+		return nil, domerrors.DatabaseError("check webhook inbox", err)
+	}
+	if processed {
+		return &GatewayWebhookResponse{
+			EventID:   req.EventID,
+			PaymentID: req.PaymentID,
+			Status:    WebhookAlreadyProcessed,
+		}, nil
+	}
+
+	if _, err := s.paymentRepo.FindByID(ctx, req.PaymentID); err != nil {
+		return nil, err
+	}
+	if err := s.checkRouted(ctx, req.PaymentID, req.Provider); err != nil {
+		return nil, err
+	}
+
+	metadata := shared.Metadata{
+		RequestID: uuid.New().String(),
+		Source:    "gateway-webhook",
+		Extra: map[string]string{
+			orchestrator.MetadataGateway: req.Provider, // same key the external payment handler writes
+			"providerEventId":            req.EventID,
+		},
+	}
+
+	var event shared.Event
+	switch req.Status {
+	case port.ChargeApproved:
+		if req.TransactionID == "" {
+			return nil, domerrors.ValidationError("chargeId", "is required for a succeeded charge")
+		}
+		event = payment.NewExternalPaymentSucceededEvent(req.PaymentID, req.TransactionID, metadata)
+	case port.ChargeDeclined:
+		reason := req.DeclineReason
+		if reason == "" {
+			reason = declineReasonNotGiven
+		}
+		event = payment.NewExternalPaymentFailedEvent(req.PaymentID, reason, req.DeclineCode, metadata)
+	default:
+		return nil, domerrors.ValidationError("type", fmt.Sprintf("unsupported charge status %s", req.Status))
+	}
+
+	err = s.unitOfWork.Commit(ctx, port.Changes{
+		Inbox: &port.InboxClaim{Consumer: consumer, EventID: req.EventID, EventType: event.EventType()},
+		Events: []port.RecordedEvent{{
+			Event:   event,
+			Streams: []string{req.PaymentID},
+		}},
+		TopicArn: s.topicArn,
+	})
+	if domerrors.IsErrorCode(err, domerrors.ErrCodeDuplicateRequest) {
+		return &GatewayWebhookResponse{
+			EventID:   req.EventID,
+			PaymentID: req.PaymentID,
+			Status:    WebhookAlreadyProcessed,
+		}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return &GatewayWebhookResponse{
+		EventID:   req.EventID,
+		PaymentID: req.PaymentID,
+		Status:    WebhookAccepted,
+	}, nil
+}
+
+// checkRouted fails unless provider is on the route recorded by the payment's ExternalPaymentRequested
+// Requests published before routing existed only name the gateway asked
+func (s *ProcessGatewayWebhookService) checkRouted(ctx context.Context, paymentID, provider string) error {
+	events, err := s.eventStore.ListByPaymentID(ctx, paymentID)
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to list payment events", err)
+	}
+
+	for _, stored := range events {
+		if stored.EventType != "ExternalPaymentRequested" {
+			continue
+		}
+		var metadata shared.Metadata
+		if err := json.Unmarshal([]byte(stored.Metadata), &metadata); err != nil {
+			return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to parse ExternalPaymentRequested metadata", err)
+		}
+
+		route := orchestrator.RouteFromMetadata(metadata.Extra)
+		if len(route) == 0 && metadata.Extra[orchestrator.MetadataGateway] != "" {
+			route = []string{metadata.Extra[orchestrator.MetadataGateway]}
+		}
+		for _, gateway := range route {
+			if gateway == provider {
+				return nil
+			}
+		}
+	}
+
+	// No request yet means no gateway was asked to charge the payment
+	return domerrors.GatewayMismatchError(paymentID, provider)
+}
+
+// webhookInboxConsumer namespaces callback IDs per provider, two gateways may reuse an ID
+func webhookInboxConsumer(provider string) string {
+	return "gateway-webhook#" + provider
+}
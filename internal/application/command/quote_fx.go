@@ -0,0 +1,132 @@
+package command
+
+import (
+	"context"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// QuoteFXRequest asks what a payment would cost in another currency of the wallet
+type QuoteFXRequest struct {
+	UserID          string
+	Amount          decimal.Decimal // in the payment currency
+	Currency        string          // the payment currency
+	FundingCurrency string          // the wallet currency that pays, empty uses the wallet default
+}
+
+// QuoteFXResponse is a locked conversion, valid until ExpiresAt
+type QuoteFXResponse struct {
+	QuoteID        string
+	Rate           decimal.Decimal
+	Spread         decimal.Decimal
+	SourceAmount   decimal.Decimal
+	SourceCurrency string
+	TargetAmount   decimal.Decimal
+	TargetCurrency string
+	ExpiresAt      time.Time
+}
+
+// QuoteFXService locks an exchange rate so a payment can be funded from another currency
+type QuoteFXService struct {
+	walletRepo WalletRepository
+	rates      port.FXRateProvider
+	quotes     port.FXQuoteStore
+	spread     decimal.Decimal
+	ttl        time.Duration
+}
+
+// NewQuoteFXService creates a new QuoteFXService
+// spread is the fraction added to the market rate; ttl is how long a quote can be used
+func NewQuoteFXService(
+	walletRepo WalletRepository,
+	rates port.FXRateProvider,
+	quotes port.FXQuoteStore,
+	spread decimal.Decimal,
+	ttl time.Duration,
+) *QuoteFXService {
+	return &QuoteFXService{
+		walletRepo: walletRepo,
+		rates:      rates,
+		quotes:     quotes,
+		spread:     spread,
+		ttl:        ttl,
+	}
+}
+
+// Execute prices the payment amount in the funding currency and stores the quote
+func (s *QuoteFXService) Execute(ctx context.Context, req QuoteFXRequest) (*QuoteFXResponse, error) {
+	if req.UserID == "" {
+		return nil, requiredFieldError("userId")
+	}
+	if req.Currency == "" {
+		return nil, requiredFieldError("currency")
+	}
+	if !req.Amount.IsPositive() {
+		return nil, domerrors.NewDomainError(
+			domerrors.ErrCodeInvalidAmount,
+			"amount must be greater than zero",
+		).WithDetail("field", "amount")
+	}
+
+	target, err := newMoney(req.Amount, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	wlt, err := s.walletRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	funding := wlt.DefaultCurrency()
+	if req.FundingCurrency != "" {
+		if funding, err = vo.NewCurrency(req.FundingCurrency); err != nil {
+			return nil, domerrors.NewDomainError(
+				domerrors.ErrCodeInvalidCurrency,
+				err.Error(),
+			).WithDetail("currency", req.FundingCurrency)
+		}
+	}
+	if funding.Equals(target.Currency()) {
+		return nil, domerrors.ValidationError("fundingCurrency", "must differ from the payment currency")
+	}
+
+	marketRate, err := s.rates.Rate(ctx, target.Currency().Code(), funding.Code())
+	if err != nil {
+		return nil, err
+	}
+
+	quoteID := uuid.New().String()
+	conversion, err := vo.NewConversion(quoteID, target, funding, marketRate, s.spread)
+	if err != nil {
+		return nil, domerrors.ValidationError("amount", err.Error())
+	}
+
+	now := time.Now().UTC()
+	quote := port.FXQuote{
+		ID:         quoteID,
+		UserID:     req.UserID,
+		Conversion: conversion,
+		CreatedAt:  now,
+		ExpiresAt:  now.Add(s.ttl),
+	}
+	if err := s.quotes.Save(ctx, quote); err != nil {
+		return nil, domerrors.DatabaseError("save fx quote", err)
+	}
+
+	return &QuoteFXResponse{
+		QuoteID:        quoteID,
+		Rate:           conversion.Rate(),
+		Spread:         conversion.Spread(),
+		SourceAmount:   conversion.Source().Amount(),
+		SourceCurrency: conversion.Source().Currency().Code(),
+		TargetAmount:   conversion.Target().Amount(),
+		TargetCurrency: conversion.Target().Currency().Code(),
+		ExpiresAt:      quote.ExpiresAt,
+	}, nil
+}
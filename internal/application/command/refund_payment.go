@@ -0,0 +1,175 @@
+package command
+
+import (
+	"context"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// defaultRefundReason is recorded when the merchant does not give one
+const defaultRefundReason = "REQUESTED_BY_MERCHANT"
+
+// RefundPaymentRequest represents a merchant refund of a completed payment
+type RefundPaymentRequest struct {
+	PaymentID      string
+	Amount         decimal.Decimal // zero refunds whatever is left
+	Reason         string
+	IdempotencyKey string
+	ClientID       string
+}
+
+// RefundPaymentResponse represents the response from a refund
+type RefundPaymentResponse struct {
+	RefundID         string
+	PaymentID        string
+	Amount           decimal.Decimal
+	RefundedAmount   decimal.Decimal
+	RefundableAmount decimal.Decimal
+	Currency         string
+	Status           string
+	Fees             []shared.FeeDetail // fees given back with this refund
+}
+
+// RefundUnitOfWork persists a refunded payment together with the refund idempotency key and the
+// PaymentRefunded event, and queues the event in the outbox for delivery to topicArn
+// Implementations must write everything atomically, return a DUPLICATE_REQUEST error when the key
+// has already been claimed, and a CONCURRENT_MODIFICATION error when the stored refunded total is
+// no longer previousRefunded
+type RefundUnitOfWork interface {
+	RecordRefund(ctx context.Context, pmt *payment.Payment, previousRefunded vo.Money, idempotencyKey string, event shared.Event, topicArn string) error
+}
+
+// RefundPaymentService handles merchant refunds of completed payments
+// The wallet is credited asynchronously by the consumer of PaymentRefunded, together with the
+// fees the refund gives back under each fee's refund policy
+type RefundPaymentService struct {
+	paymentRepo      PaymentRepository
+	unitOfWork       RefundUnitOfWork
+	idempotencyStore shared.IdempotencyStore
+	topicArn         string
+}
+
+// NewRefundPaymentService creates a new RefundPaymentService
+func NewRefundPaymentService(
+	paymentRepo PaymentRepository,
+	unitOfWork RefundUnitOfWork,
+	idempotencyStore shared.IdempotencyStore,
+	topicArn string,
+) *RefundPaymentService {
+	return &RefundPaymentService{
+		paymentRepo:      paymentRepo,
+		unitOfWork:       unitOfWork,
+		idempotencyStore: idempotencyStore,
+		topicArn:         topicArn,
+	}
+}
+
+// Execute refunds part or all of a payment, or reports a refund already made with the same key
+func (s *RefundPaymentService) Execute(ctx context.Context, req RefundPaymentRequest) (*RefundPaymentResponse, error) {
+	if err := s.validateRequest(req); err != nil {
+		return nil, err
+	}
+
+	idempotencyKey := refundIdempotencyKey(req.IdempotencyKey)
+
+	// Check idempotency (fast path; the unit of work enforces it atomically)
+	if existingPaymentID, err := s.idempotencyStore.GetPaymentIDByKey(ctx, idempotencyKey); err == nil && existingPaymentID != "" {
+		return &RefundPaymentResponse{PaymentID: existingPaymentID, Status: "ALREADY_PROCESSED"}, nil
+	}
+
+	paymentID, err := vo.NewPaymentID(req.PaymentID)
+	if err != nil {
+		return nil, domerrors.ValidationError("paymentId", err.Error())
+	}
+
+	pmt, err := s.paymentRepo.FindByID(ctx, paymentID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	// Without an amount the whole remainder is refunded
+	amount := pmt.RefundableAmount()
+	if req.Amount.IsPositive() {
+		if amount, err = newMoney(req.Amount, pmt.Money().Currency().Code()); err != nil {
+			return nil, err
+		}
+	}
+
+	previousRefunded := pmt.RefundedAmount()
+	if err := pmt.Refund(amount); err != nil {
+		return nil, err
+	}
+
+	fees, err := pmt.RefundedFees(previousRefunded)
+	if err != nil {
+		return nil, err
+	}
+
+	reason := req.Reason
+	if reason == "" {
+		reason = defaultRefundReason
+	}
+
+	refundID := uuid.New().String()
+	metadata := shared.Metadata{
+		ClientID:  req.ClientID,
+		RequestID: vo.GeneratePaymentID().String(),
+		Source:    "payment-api",
+		Extra:     make(map[string]string),
+	}
+
+	event := payment.NewPaymentRefundedEvent(
+		pmt.ID().String(),
+		pmt.UserID().String(),
+		refundID,
+		amount.Amount(),
+		pmt.RefundedAmount().Amount(),
+		pmt.Money().Currency().Code(),
+		reason,
+		metadata,
+	).WithFees(payment.FeeDetailsOf(fees))
+
+	if err := s.unitOfWork.RecordRefund(ctx, pmt, previousRefunded, idempotencyKey, event, s.topicArn); err != nil {
+		// A concurrent request with the same key won the race; answer like the idempotent path
+		if domerrors.IsErrorCode(err, domerrors.ErrCodeDuplicateRequest) {
+			return &RefundPaymentResponse{PaymentID: pmt.ID().String(), Status: "ALREADY_PROCESSED"}, nil
+		}
+		return nil, err
+	}
+
+	return &RefundPaymentResponse{
+		RefundID:         refundID,
+		PaymentID:        pmt.ID().String(),
+		Amount:           amount.Amount(),
+		RefundedAmount:   pmt.RefundedAmount().Amount(),
+		RefundableAmount: pmt.RefundableAmount().Amount(),
+		Currency:         pmt.Money().Currency().Code(),
+		Status:           pmt.Status().String(),
+		Fees:             payment.FeeDetailsOf(fees),
+	}, nil
+}
+
+func (s *RefundPaymentService) validateRequest(req RefundPaymentRequest) error {
+	if req.PaymentID == "" {
+		return requiredFieldError("paymentId")
+	}
+	if req.Amount.IsNegative() {
+		return domerrors.NewDomainError(
+			domerrors.ErrCodeInvalidAmount,
+			"refund amount must not be negative",
+		).WithDetail("field", "amount")
+	}
+	if req.IdempotencyKey == "" {
+		return requiredFieldError("idempotencyKey")
+	}
+	return nil
+}
+
+func refundIdempotencyKey(key string) string {
+	return "refund#" + key
+}
@@ -0,0 +1,282 @@
+package command
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/ledger"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// TopUpReason is the credit reason recorded for back office top-ups
+const TopUpReason = "TOPUP"
+
+// topUpNamespace derives top-up IDs from the wallet and the client's idempotency key
+var topUpNamespace = uuid.MustParse("6f1d9c1e-3b8a-4f43-9a57-2f7c0e5b8d21")
+
+// TopUpWalletRequest represents a wallet top-up request
+type TopUpWalletRequest struct {
+	UserID         string
+	Amount         decimal.Decimal
+	Currency       string
+	IdempotencyKey string
+	ClientID       string
+}
+
+// TopUpWalletResponse represents the result of a top-up
+type TopUpWalletResponse struct {
+	TopUpID  string
+	UserID   string
+	Balance  decimal.Decimal
+	Currency string
+	Status   string
+}
+
+// maxTopUpAttempts bounds the retries of a top-up whose wallet write lost a race
+const maxTopUpAttempts = 3
+
+// LedgerEntryReader reads back posted journal entries
+type LedgerEntryReader interface {
+	FindEntry(ctx context.Context, entryID string) (*ledger.JournalEntry, error)
+}
+
+// TopUpWalletService handles the wallet top-up use case
+// The idempotency key is claimed by the top-up's journal entry, whose ID is derived from it and is
+// written in the same transaction as the credit and its event: a retry, concurrent or after a crash,
+// cannot credit twice. A retry is checked against the entry, so a key reused for another amount
+// or currency is refused instead of being reported as done
+type TopUpWalletService struct {
+	walletRepo WalletRepository
+	ledger     LedgerEntryReader
+	eventStore shared.EventStore
+	unitOfWork port.UnitOfWork
+	topicArn   string
+}
+
+// NewTopUpWalletService creates a new TopUpWalletService
+func NewTopUpWalletService(
+	walletRepo WalletRepository,
+	ledger LedgerEntryReader,
+	eventStore shared.EventStore,
+	unitOfWork port.UnitOfWork,
+	topicArn string,
+) *TopUpWalletService {
+	return &TopUpWalletService{
+		walletRepo: walletRepo,
+		ledger:     ledger,
+		eventStore: eventStore,
+		unitOfWork: unitOfWork,
+		topicArn:   topicArn,
+	}
+}
+
+// Execute credits a wallet or returns the current state if the key was already used
+// The credit starts over on a fresh copy of the wallet while another write beats it, up to
+// maxTopUpAttempts times
+func (s *TopUpWalletService) Execute(ctx context.Context, req TopUpWalletRequest) (*TopUpWalletResponse, error) {
+	if req.IdempotencyKey == "" {
+		return nil, domerrors.ValidationError("idempotencyKey", "is required")
+	}
+	if !req.Amount.IsPositive() {
+		return nil, domerrors.NewDomainError(domerrors.ErrCodeInvalidAmount, "amount must be greater than zero")
+	}
+
+	money, err := newMoney(req.Amount, req.Currency)
+	if err != nil {
+		return nil, err
+	}
+
+	for attempt := 1; ; attempt++ {
+		result, err := s.topUp(ctx, req, money)
+		if attempt >= maxTopUpAttempts || !domerrors.IsErrorCode(err, domerrors.ErrCodeConcurrentModification) {
+			return result, err
+		}
+		log.Printf("Wallet %s was modified concurrently during top-up (attempt %d/%d), retrying", req.UserID, attempt, maxTopUpAttempts)
+	}
+}
+
+// topUp credits one top-up on a fresh copy of the wallet, unless its key was used before
+func (s *TopUpWalletService) topUp(ctx context.Context, req TopUpWalletRequest, money vo.Money) (*TopUpWalletResponse, error) {
+	wlt, err := s.walletRepo.GetByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keys are scoped to the wallet, so the same key on another wallet is another top-up
+	userID := wlt.UserID().String()
+	topUpID := topUpIDFor(userID, req.IdempotencyKey)
+
+	metadata := shared.Metadata{
+		ClientID:  req.ClientID,
+		RequestID: uuid.New().String(),
+		Source:    "payment-api",
+		Extra:     map[string]string{"topUpId": topUpID},
+	}
+
+	stored, err := s.ledger.FindEntry(ctx, ledger.TopUpEntryID(topUpID))
+	if err == nil {
+		return s.alreadyProcessed(ctx, req.IdempotencyKey, stored, topUpID, userID, money, metadata)
+	}
+	if !domerrors.IsErrorCode(err, domerrors.ErrCodeLedgerEntryNotFound) {
+		return nil, err
+	}
+
+	prevBalance, newBalance, err := wlt.Credit(money)
+	if err != nil {
+		return nil, err
+	}
+
+	// The money comes in from outside, so the ledger takes it from the funding account
+	entry, err := ledger.NewTopUpEntry(topUpID, userID, money)
+	if err != nil {
+		return nil, err
+	}
+	if err := wlt.RecordEntry(entry); err != nil {
+		return nil, err
+	}
+
+	err = s.commitCredited(ctx, topUpID, userID, money, prevBalance, newBalance, metadata, wlt)
+	if err != nil {
+		if !domerrors.IsErrorCode(err, domerrors.ErrCodeLedgerEntryExists) {
+			return nil, err
+		}
+		// A concurrent retry posted the entry first
+		if stored, err = s.ledger.FindEntry(ctx, entry.ID()); err != nil {
+			return nil, err
+		}
+		return s.alreadyProcessed(ctx, req.IdempotencyKey, stored, topUpID, userID, money, metadata)
+	}
+
+	return &TopUpWalletResponse{
+		TopUpID:  topUpID,
+		UserID:   userID,
+		Balance:  newBalance.Amount(),
+		Currency: newBalance.Currency().Code(),
+		Status:   "COMPLETED",
+	}, nil
+}
+
+// commitCredited writes WalletCredited for a top-up, with the credited wallet when there is one
+func (s *TopUpWalletService) commitCredited(
+	ctx context.Context,
+	topUpID, userID string,
+	money, prevBalance, newBalance vo.Money,
+	metadata shared.Metadata,
+	wlt *wallet.Wallet,
+) error {
+	event := wallet.NewWalletCreditedEvent(
+		topUpID,
+		userID,
+		money.Amount(),
+		prevBalance.Amount(),
+		newBalance.Amount(),
+		money.Currency().Code(),
+		TopUpReason,
+		metadata,
+	)
+
+	return s.unitOfWork.Commit(ctx, port.Changes{
+		Wallet: wlt,
+		Events: []port.RecordedEvent{{
+			Event:   event,
+			Streams: []string{wallet.EventStreamID(userID)},
+		}},
+		TopicArn: s.topicArn,
+	})
+}
+
+// alreadyProcessed answers a retry of a top-up that was credited before with the current balance
+// The key must stand for the same amount and currency it was first used with. A credit whose
+// WalletCredited is missing from the wallet stream gets it now
+func (s *TopUpWalletService) alreadyProcessed(
+	ctx context.Context,
+	idempotencyKey string,
+	stored *ledger.JournalEntry,
+	topUpID, userID string,
+	money vo.Money,
+	metadata shared.Metadata,
+) (*TopUpWalletResponse, error) {
+	if !creditsWallet(stored, userID, money) {
+		return nil, domerrors.NewDomainError(
+			domerrors.ErrCodeDuplicateRequest,
+			"Idempotency key was already used for a different top-up",
+		).WithDetail("idempotencyKey", idempotencyKey)
+	}
+
+	wlt, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	credited, err := s.creditedEventRecorded(ctx, topUpID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if !credited {
+		// The balances are read back from the wallet, since they cannot be known exactly anymore
+		log.Printf("Top-up %s of wallet %s was credited without its event, recording it now", topUpID, userID)
+		newBalance := wlt.Balance(money.Currency())
+		prevBalance := vo.Zero(money.Currency())
+		if !newBalance.Amount().LessThan(money.Amount()) {
+			if prevBalance, err = newBalance.Subtract(money); err != nil {
+				return nil, err
+			}
+		}
+		if err := s.commitCredited(ctx, topUpID, userID, money, prevBalance, newBalance, metadata, nil); err != nil {
+			return nil, err
+		}
+	}
+
+	return &TopUpWalletResponse{
+		TopUpID:  topUpID,
+		UserID:   userID,
+		Balance:  wlt.Balance(money.Currency()).Amount(),
+		Currency: money.Currency().Code(),
+		Status:   "ALREADY_PROCESSED",
+	}, nil
+}
+
+// creditedEventRecorded checks the wallet stream for the WalletCredited of a top-up
+func (s *TopUpWalletService) creditedEventRecorded(ctx context.Context, topUpID, userID string) (bool, error) {
+	events, err := s.eventStore.ListByPaymentID(ctx, wallet.EventStreamID(userID))
+	if err != nil {
+		return false, domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to list wallet events", err)
+	}
+
+	for _, stored := range events {
+		if stored.EventType != "WalletCredited" {
+			continue
+		}
+		var metadata shared.Metadata
+		if err := json.Unmarshal([]byte(stored.Metadata), &metadata); err != nil {
+			continue
+		}
+		if metadata.Extra["topUpId"] == topUpID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// creditsWallet checks that a top-up entry credited exactly money to the wallet
+func creditsWallet(entry *ledger.JournalEntry, userID string, money vo.Money) bool {
+	for _, posting := range entry.Postings() {
+		if posting.Account == ledger.WalletAccount(userID) && posting.Side == ledger.Credit {
+			return posting.Amount.Equals(money)
+		}
+	}
+	return false
+}
+
+// topUpIDFor derives the ID of the top-up a client key stands for on a wallet
+func topUpIDFor(userID, idempotencyKey string) string {
+	return uuid.NewSHA1(topUpNamespace, []byte(userID+"\x00"+idempotencyKey)).String()
+}
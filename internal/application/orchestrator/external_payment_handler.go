@@ -0,0 +1,140 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// ExternalPaymentHandler charges ExternalPaymentRequested through the routed PaymentGateways
+// and turns the answer into ExternalPaymentSucceeded or ExternalPaymentFailed
+type ExternalPaymentHandler struct {
+	gateways      map[string]port.PaymentGateway
+	gatewayRouter *GatewayRouter
+	unitOfWork    port.UnitOfWork
+	topicArn      string
+}
+
+// NewExternalPaymentHandler creates a new ExternalPaymentHandler
+// gateways holds every gateway a route may name, by name
+func NewExternalPaymentHandler(
+	gateways map[string]port.PaymentGateway,
+	gatewayRouter *GatewayRouter,
+	unitOfWork port.UnitOfWork,
+	topicArn string,
+) *ExternalPaymentHandler {
+	return &ExternalPaymentHandler{
+		gateways:      gateways,
+		gatewayRouter: gatewayRouter,
+		unitOfWork:    unitOfWork,
+		topicArn:      topicArn,
+	}
+}
+
+// HandleExternalPaymentRequested charges the payment on the gateways of its route, in order
+// Only GATEWAY_UNAVAILABLE moves on to the next gateway: that gateway never took the charge.
+// Any other error means the outcome is unknown, so it is returned and SQS redelivers the request;
+// the charge reuses the payment ID as idempotency key, so the retry cannot double charge
+func (h *ExternalPaymentHandler) HandleExternalPaymentRequested(ctx context.Context, event shared.Event) error {
+	externalEvent, ok := event.(*payment.ExternalPaymentRequestedEvent)
+	if !ok {
+		return fmt.Errorf("unexpected event type: %T", event)
+	}
+
+	route, err := h.route(externalEvent)
+	if err != nil {
+		return err
+	}
+
+	gatewayName, result, err := h.charge(ctx, externalEvent, route)
+	if err != nil {
+		log.Printf("Gateway charge for payment %s failed: %v", externalEvent.PaymentID(), err)
+		return err
+	}
+	metadata := event.Metadata().WithExtra(MetadataGateway, gatewayName)
+
+	if result.Status == port.ChargePending {
+		// The webhook brings the outcome; if it never comes ExternalPaymentTimeout fires
+		log.Printf("Gateway %s charge for payment %s pending, waiting for webhook", gatewayName, externalEvent.PaymentID())
+		return nil
+	}
+
+	var resultEvent shared.Event
+	switch result.Status {
+	case port.ChargeApproved:
+		resultEvent = payment.NewExternalPaymentSucceededEvent(
+			externalEvent.PaymentID(),
+			result.TransactionID,
+			metadata,
+		)
+	case port.ChargeDeclined:
+		resultEvent = payment.NewExternalPaymentFailedEvent(
+			externalEvent.PaymentID(),
+			result.DeclineReason,
+			result.DeclineCode,
+			metadata,
+		)
+	default:
+		return domerrors.NewDomainError(
+			domerrors.ErrCodeExternalGatewayError,
+			fmt.Sprintf("unknown charge status: %s", result.Status),
+		).WithDetail("paymentId", externalEvent.PaymentID())
+	}
+
+	// Store and queue the result event together
+	return h.unitOfWork.Commit(ctx, port.Changes{
+		Events:   []port.RecordedEvent{paymentEvent(resultEvent, externalEvent.PaymentID())},
+		TopicArn: h.topicArn,
+	})
+}
+
+// route returns the gateways recorded on the event, falling back to the routing table
+// for requests published before routing existed
+func (h *ExternalPaymentHandler) route(event *payment.ExternalPaymentRequestedEvent) ([]string, error) {
+	if route := RouteFromMetadata(event.Metadata().Extra); len(route) > 0 {
+		return route, nil
+	}
+	return h.gatewayRouter.Route(event.ServiceID(), event.Currency())
+}
+
+// charge tries each gateway of the route until one answers
+func (h *ExternalPaymentHandler) charge(
+	ctx context.Context,
+	event *payment.ExternalPaymentRequestedEvent,
+	route []string,
+) (string, *port.ChargeResult, error) {
+	req := port.ChargeRequest{
+		PaymentID:      event.PaymentID(),
+		IdempotencyKey: event.PaymentID(),
+		UserID:         event.UserID(),
+		ServiceID:      event.ServiceID(),
+		Amount:         event.Amount(),
+		Currency:       event.Currency(),
+	}
+
+	var lastErr error
+	for _, name := range route {
+		gateway, ok := h.gateways[name]
+		if !ok {
+			return "", nil, fmt.Errorf("gateway %q in route is not configured", name)
+		}
+
+		result, err := gateway.Charge(ctx, req)
+		if err == nil {
+			return name, result, nil
+		}
+		if !domerrors.IsErrorCode(err, domerrors.ErrCodeGatewayUnavailable) {
+			return "", nil, err
+		}
+
+		log.Printf("Gateway %s unavailable for payment %s, trying next: %v", name, event.PaymentID(), err)
+		lastErr = err
+	}
+
+	return "", nil, lastErr
+}
@@ -0,0 +1,139 @@
+package orchestrator
+
+import (
+	"fmt"
+	"strings"
+
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// Metadata keys written to the Extra map of the external payment events
+const (
+	MetadataGateway      = "gateway"      // gateway asked first (request) or that answered (result)
+	MetadataGatewayRoute = "gatewayRoute" // comma separated gateways in failover order
+)
+
+// wildcard matches any service ID; a trailing wildcard makes a prefix ("telecom-*")
+const wildcard = "*"
+
+// GatewayRoute maps service IDs and a currency to the gateways that may charge them
+type GatewayRoute struct {
+	ServiceID string   // exact ID, prefix ending in "*" or "*" for any service
+	Currency  string   // ISO 4217 code, empty for any currency
+	Gateways  []string // gateway names, primary first
+}
+
+// GatewayHealth reports whether a gateway is currently taking charges (e.g. its circuit is not open)
+type GatewayHealth interface {
+	Available(gateway string) bool
+}
+
+// GatewayRouter picks the gateways for a payment from a routing table
+type GatewayRouter struct {
+	routes []GatewayRoute
+	health GatewayHealth // nil treats every gateway as available
+}
+
+// NewGatewayRouter creates a new GatewayRouter
+func NewGatewayRouter(routes []GatewayRoute) (*GatewayRouter, error) {
+	for i, route := range routes {
+		if route.ServiceID == "" {
+			return nil, fmt.Errorf("gateway route %d: serviceId is required", i)
+		}
+		if len(route.Gateways) == 0 {
+			return nil, fmt.Errorf("gateway route %d (%s): at least one gateway is required", i, route.ServiceID)
+		}
+		if strings.Contains(strings.TrimSuffix(route.ServiceID, wildcard), wildcard) {
+			return nil, fmt.Errorf("gateway route %d (%s): '*' is only allowed at the end", i, route.ServiceID)
+		}
+	}
+
+	return &GatewayRouter{routes: routes}, nil
+}
+
+// NewSingleGatewayRouter creates a GatewayRouter that sends every payment to one gateway
+func NewSingleGatewayRouter(gateway string) *GatewayRouter {
+	return &GatewayRouter{
+		routes: []GatewayRoute{{ServiceID: wildcard, Gateways: []string{gateway}}},
+	}
+}
+
+// WithHealth returns a copy of the router that checks gateway health in Available
+func (r *GatewayRouter) WithHealth(health GatewayHealth) *GatewayRouter {
+	return &GatewayRouter{routes: r.routes, health: health}
+}
+
+// Available keeps the gateways of a route that are taking charges, in route order
+func (r *GatewayRouter) Available(route []string) []string {
+	if r.health == nil {
+		return route
+	}
+
+	available := make([]string, 0, len(route))
+	for _, gateway := range route {
+		if r.health.Available(gateway) {
+			available = append(available, gateway)
+		}
+	}
+	return available
+}
+
+// Route returns the gateways for a service and currency, primary first
+// An exact service ID wins over a prefix and a longer prefix over a shorter one;
+// between equally specific routes, one for the currency wins over one for any currency
+func (r *GatewayRouter) Route(serviceID, currency string) ([]string, error) {
+	var best *GatewayRoute
+	bestScore := -1
+
+	for i := range r.routes {
+		route := &r.routes[i]
+		if route.Currency != "" && !strings.EqualFold(route.Currency, currency) {
+			continue
+		}
+
+		score := matchScore(route.ServiceID, serviceID)
+		if score < 0 {
+			continue
+		}
+		score *= 2
+		if route.Currency != "" {
+			score++
+		}
+
+		if score > bestScore {
+			best, bestScore = route, score
+		}
+	}
+
+	if best == nil {
+		return nil, domerrors.NoGatewayRouteError(serviceID, currency)
+	}
+
+	gateways := make([]string, len(best.Gateways))
+	copy(gateways, best.Gateways)
+	return gateways, nil
+}
+
+// matchScore returns how specific pattern is for serviceID, or -1 when it does not match
+func matchScore(pattern, serviceID string) int {
+	if prefix, ok := strings.CutSuffix(pattern, wildcard); ok {
+		if !strings.HasPrefix(serviceID, prefix) {
+			return -1
+		}
+		return len(prefix)
+	}
+
+	if pattern != serviceID {
+		return -1
+	}
+	// Above any prefix, which is at most as long as the ID itself
+	return len(serviceID) + 1
+}
+
+// RouteFromMetadata reads the route recorded on an ExternalPaymentRequested event
+func RouteFromMetadata(extra map[string]string) []string {
+	if extra[MetadataGatewayRoute] == "" {
+		return nil
+	}
+	return strings.Split(extra[MetadataGatewayRoute], ",")
+}
@@ -2,24 +2,42 @@ package orchestrator
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"log"
+	"strings"
+	"time"
 
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/ledger"
 	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/saga"
 	"github.com/franco/payment-api/internal/domain/shared"
 	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
 	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 	"github.com/franco/payment-api/internal/domain/wallet"
 )
 
+// maxStepAttempts bounds the retries of a step whose commit lost a race for the wallet
+const maxStepAttempts = 3
+
+// stepLease is how long an accepted delivery holds its step against a cancellation; it matches
+// the SQS visibility timeout, after which the message goes back to the queue anyway
+const stepLease = 30 * time.Second
+
 // PaymentOrchestrator uses Domain Services and follows SRP
+// Every handler checks the payment saga first, so each step runs once and in order, and commits
+// the state it changed together with its events through the unit of work
 type PaymentOrchestrator struct {
 	paymentRepo      PaymentRepository
 	walletRepo       WalletRepository
+	sagaRepo         SagaRepository
+	gatewayRouter    *GatewayRouter
 	eventStore       shared.EventStore
-	eventPublisher   EventPublisher
+	unitOfWork       port.UnitOfWork
 	paymentProcessor *payment.Processor
 	topicArn         string
+	stepTimeout      time.Duration
 }
 
 // PaymentRepository defines operations for Payment
@@ -36,21 +54,35 @@ type WalletRepository interface {
 	Update(ctx context.Context, wlt *wallet.Wallet) error
 }
 
+// SagaRepository defines operations for the payment saga
+// Save is a versioned write: it fails with CONCURRENT_MODIFICATION on a stale copy
+type SagaRepository interface {
+	FindByPaymentID(ctx context.Context, paymentID string) (*saga.Saga, error)
+	Save(ctx context.Context, sg *saga.Saga) error
+}
+
 // NewPaymentOrchestrator creates a new payment orchestrator
+// stepTimeout is how long a saga may stay in one step before it is reported as stuck
 func NewPaymentOrchestrator(
 	paymentRepo PaymentRepository,
 	walletRepo WalletRepository,
+	sagaRepo SagaRepository,
+	gatewayRouter *GatewayRouter,
 	eventStore shared.EventStore,
-	eventPublisher EventPublisher,
+	unitOfWork port.UnitOfWork,
 	topicArn string,
+	stepTimeout time.Duration,
 ) *PaymentOrchestrator {
 	return &PaymentOrchestrator{
 		paymentRepo:      paymentRepo,
 		walletRepo:       walletRepo,
+		sagaRepo:         sagaRepo,
+		gatewayRouter:    gatewayRouter,
 		eventStore:       eventStore,
-		eventPublisher:   eventPublisher,
+		unitOfWork:       unitOfWork,
 		paymentProcessor: payment.NewProcessor(),
 		topicArn:         topicArn,
+		stepTimeout:      stepTimeout,
 	}
 }
 
@@ -58,196 +90,626 @@ func NewPaymentOrchestrator(
 // Now much simpler - delegates to PaymentProcessor
 func (o *PaymentOrchestrator) HandlePaymentRequested(ctx context.Context, event shared.Event) error {
 	// Parse event
-	paymentEvent, ok := event.(*payment.PaymentRequestedEvent)
+	requestedEvent, ok := event.(*payment.PaymentRequestedEvent)
 	if !ok {
 		return fmt.Errorf("unexpected event type: %T", event)
 	}
 
-	// Get payment
-	pmt, err := o.paymentRepo.FindByID(ctx, paymentEvent.PaymentID())
-	if err != nil {
-		return domerrors.WrapError(domerrors.ErrCodePaymentNotFound, "payment not found", err)
-	}
-
-	// Get wallet
-	wlt, err := o.walletRepo.GetByUserID(ctx, paymentEvent.UserID())
-	if err != nil {
-		// If wallet not found, fail the payment
-		return o.failPayment(ctx, pmt, "WALLET_NOT_FOUND")
-	}
+	// Check the saga before touching the wallet
+	return o.runStep(ctx, requestedEvent.PaymentID(), event, func(sg *saga.Saga) error {
+		// Get payment
+		pmt, err := o.paymentRepo.FindByID(ctx, requestedEvent.PaymentID())
+		if err != nil {
+			return domerrors.WrapError(domerrors.ErrCodePaymentNotFound, "payment not found", err)
+		}
+
+		// Get wallet
+		wlt, err := o.walletRepo.GetByUserID(ctx, requestedEvent.UserID())
+		if err != nil {
+			// If wallet not found, fail the payment
+			return o.rejectPayment(ctx, pmt, sg, event.EventType(), "WALLET_NOT_FOUND")
+		}
+
+		// Pick the gateways before holding funds, a payment nobody can charge must not touch the wallet
+		route, err := o.gatewayRouter.Route(pmt.ServiceID().String(), pmt.Money().Currency().Code())
+		if err != nil {
+			return o.rejectPayment(ctx, pmt, sg, event.EventType(), string(domerrors.ErrCodeNoGatewayRoute))
+		}
+		// Gateways with an open circuit are skipped; with none left the payment fails fast
+		if route = o.gatewayRouter.Available(route); len(route) == 0 {
+			return o.rejectPayment(ctx, pmt, sg, event.EventType(), string(domerrors.ErrCodeGatewayUnavailable))
+		}
+
+		// Authorize: hold the funds until the gateway answers, the balance itself does not move yet
+		// Delegate to Domain Service
+		result, err := o.paymentProcessor.Process(pmt, wlt)
+		if err != nil {
+			return err
+		}
+
+		// Check if processing failed
+		if !result.Success {
+			return o.rejectPayment(ctx, pmt, sg, event.EventType(), result.FailureReason)
+		}
+
+		if err := pmt.MarkProcessing(); err != nil {
+			return err
+		}
+
+		// WalletFundsHeld is recorded in the currency the wallet pays with
+		heldEvent := wallet.NewWalletFundsHeldEvent(
+			pmt.ID().String(),
+			pmt.UserID().String(),
+			result.Held.Amount(),
+			result.NewAvailable.Amount(),
+			result.HeldBalance.Amount(),
+			result.Held.Currency().Code(),
+			event.Metadata(),
+		).WithFX(pmt.FXDetails()).WithFees(pmt.FeeDetails())
+
+		externalEvent := payment.NewExternalPaymentRequestedEvent(
+			pmt.ID().String(),
+			pmt.UserID().String(),
+			pmt.Money().Amount(),
+			pmt.Money().Currency().Code(),
+			pmt.ServiceID().String(),
+			event.Metadata().
+				WithExtra(MetadataGateway, route[0]).
+				WithExtra(MetadataGatewayRoute, strings.Join(route, ",")),
+		)
+
+		if err := o.advanceSaga(sg, event.EventType(), saga.StepAwaitingGateway); err != nil {
+			return err
+		}
+
+		return o.commit(ctx, port.Changes{
+			Payment: pmt,
+			Wallet:  wlt,
+			Saga:    sg,
+			Events: []port.RecordedEvent{
+				walletEvent(heldEvent, pmt.ID().String(), pmt.UserID().String()),
+				paymentEvent(externalEvent, pmt.ID().String()),
+			},
+		})
+	})
+}
 
-	// Delegate to Domain Service
-	result, err := o.paymentProcessor.Process(pmt, wlt)
-	if err != nil {
-		return err
+// HandleExternalPaymentSucceeded processes successful external payments
+func (o *PaymentOrchestrator) HandleExternalPaymentSucceeded(ctx context.Context, event shared.Event) error {
+	successEvent, ok := event.(*payment.ExternalPaymentSucceededEvent)
+	if !ok {
+		return fmt.Errorf("unexpected event type: %T", event)
 	}
 
-	// Check if processing failed
-	if !result.Success {
-		return o.failPayment(ctx, pmt, result.FailureReason)
-	}
+	return o.runStep(ctx, successEvent.PaymentID(), event, func(sg *saga.Saga) error {
+		// Get payment
+		pmt, err := o.paymentRepo.FindByID(ctx, successEvent.PaymentID())
+		if err != nil {
+			return err
+		}
+
+		// Get wallet
+		wlt, err := o.walletRepo.GetByUserID(ctx, pmt.UserID().String())
+		if err != nil {
+			return err
+		}
+
+		// Capture the hold; a payment without one was already captured or debited before holds existed
+		captured, err := o.paymentProcessor.Capture(pmt, wlt)
+		if err != nil {
+			return err
+		}
+
+		// Mark as completed
+		if err := pmt.MarkCompleted(successEvent.ExternalTransactionID()); err != nil {
+			return err
+		}
+
+		changes := port.Changes{Payment: pmt}
+
+		if captured.Captured {
+			capturedEvent := wallet.NewWalletHoldCapturedEvent(
+				pmt.ID().String(),
+				pmt.UserID().String(),
+				captured.Amount.Amount(),
+				captured.PreviousBalance.Amount(),
+				captured.NewBalance.Amount(),
+				captured.Amount.Currency().Code(),
+				event.Metadata(),
+			).WithFX(pmt.FXDetails()).WithFees(pmt.FeeDetails())
+
+			changes.Wallet = wlt
+			changes.Events = append(changes.Events, walletEvent(capturedEvent, pmt.ID().String(), pmt.UserID().String()))
+		}
+
+		completedEvent := payment.NewPaymentCompletedEvent(
+			pmt.ID().String(),
+			pmt.UserID().String(),
+			pmt.Money().Amount(),
+			pmt.Money().Currency().Code(),
+			pmt.ExternalTxID(),
+			event.Metadata(),
+		)
+		changes.Events = append(changes.Events, paymentEvent(completedEvent, pmt.ID().String()))
+
+		if err := o.advanceSaga(sg, event.EventType(), saga.StepCompleted); err != nil {
+			return err
+		}
+		changes.Saga = sg
+
+		return o.commit(ctx, changes)
+	})
+}
 
-	// Save updated wallet
-	if err := o.walletRepo.Update(ctx, wlt); err != nil {
-		return domerrors.DatabaseError("update wallet", err)
+// HandleExternalPaymentFailed processes failed external payments
+func (o *PaymentOrchestrator) HandleExternalPaymentFailed(ctx context.Context, event shared.Event) error {
+	failedEvent, ok := event.(*payment.ExternalPaymentFailedEvent)
+	if !ok {
+		return fmt.Errorf("unexpected event type: %T", event)
 	}
 
-	// Publish WalletDebited event
-	debitedEvent := wallet.NewWalletDebitedEvent(
-		pmt.ID().String(),
-		pmt.UserID().String(),
-		pmt.Money().AmountFloat(),
-		result.PreviousBalance.AmountFloat(),
-		result.NewBalance.AmountFloat(),
-		event.Metadata(),
-	)
+	return o.runStep(ctx, failedEvent.PaymentID(), event, func(sg *saga.Saga) error {
+		return o.initiateRefund(ctx, sg, event, failedEvent.Reason())
+	})
+}
 
-	if err := o.publishEvent(ctx, debitedEvent, pmt.ID().String()); err != nil {
-		return err
+// HandleExternalPaymentTimeout processes payment timeouts
+func (o *PaymentOrchestrator) HandleExternalPaymentTimeout(ctx context.Context, event shared.Event) error {
+	timeoutEvent, ok := event.(*payment.ExternalPaymentTimeoutEvent)
+	if !ok {
+		return fmt.Errorf("unexpected event type: %T", event)
 	}
 
-	// Publish ExternalPaymentRequested event
-	externalEvent := payment.NewExternalPaymentRequestedEvent(
-		pmt.ID().String(),
-		pmt.UserID().String(),
-		pmt.Money().AmountFloat(),
-		pmt.Money().Currency().Code(),
-		pmt.ServiceID().String(),
-		event.Metadata(),
-	)
-
-	return o.publishEvent(ctx, externalEvent, pmt.ID().String())
+	return o.runStep(ctx, timeoutEvent.PaymentID(), event, func(sg *saga.Saga) error {
+		return o.initiateRefund(ctx, sg, event, payment.FailureReasonTimeout)
+	})
 }
 
-// HandleExternalPaymentSucceeded processes successful external payments
-func (o *PaymentOrchestrator) HandleExternalPaymentSucceeded(ctx context.Context, event shared.Event) error {
-	successEvent, ok := event.(*payment.ExternalPaymentSucceededEvent)
+// HandlePaymentRefundRequested undoes the wallet side of a failed payment
+// The hold is released; payments debited before holds existed are refunded instead
+func (o *PaymentOrchestrator) HandlePaymentRefundRequested(ctx context.Context, event shared.Event) error {
+	refundEvent, ok := event.(*payment.PaymentRefundRequestedEvent)
 	if !ok {
 		return fmt.Errorf("unexpected event type: %T", event)
 	}
 
-	// Get payment
-	pmt, err := o.paymentRepo.FindByID(ctx, successEvent.PaymentID())
+	return o.runStep(ctx, refundEvent.PaymentID(), event, func(sg *saga.Saga) error {
+		// Get payment
+		paymentID, _ := vo.NewPaymentID(refundEvent.PaymentID())
+		pmt, err := o.paymentRepo.FindByID(ctx, paymentID.String())
+		if err != nil {
+			return err
+		}
+
+		// Get wallet
+		wlt, err := o.walletRepo.GetByUserID(ctx, refundEvent.UserID())
+		if err != nil {
+			return err
+		}
+
+		// Void the hold
+		released, err := o.paymentProcessor.Release(pmt, wlt)
+		if err != nil {
+			return err
+		}
+
+		if !released.Released {
+			return o.refundDebitedPayment(ctx, sg, pmt, wlt, refundEvent)
+		}
+
+		releasedEvent := wallet.NewWalletHoldReleasedEvent(
+			refundEvent.PaymentID(),
+			refundEvent.UserID(),
+			released.Amount.Amount(),
+			released.AvailableBalance.Amount(),
+			released.Amount.Currency().Code(),
+			refundEvent.Reason(),
+			event.Metadata(),
+		)
+
+		sg.RecordCompensation(saga.CompensationHoldRelease, refundEvent.Reason())
+		if err := o.advanceSaga(sg, event.EventType(), saga.StepCompensated); err != nil {
+			return err
+		}
+
+		return o.commit(ctx, port.Changes{
+			Wallet: wlt,
+			Saga:   sg,
+			Events: []port.RecordedEvent{walletEvent(releasedEvent, refundEvent.PaymentID(), refundEvent.UserID())},
+		})
+	})
+}
+
+// refundDebitedPayment compensates a payment that has no hold to release
+// Payments authorized before holds existed were debited outright and get their money back;
+// for any other the hold is already gone, so there is nothing left to undo
+func (o *PaymentOrchestrator) refundDebitedPayment(
+	ctx context.Context,
+	sg *saga.Saga,
+	pmt *payment.Payment,
+	wlt *wallet.Wallet,
+	refundEvent *payment.PaymentRefundRequestedEvent,
+) error {
+	debited, err := o.debitedWithoutHold(ctx, pmt.ID().String())
 	if err != nil {
 		return err
 	}
-
-	// Mark as completed
-	if err := pmt.MarkCompleted(successEvent.ExternalTransactionID()); err != nil {
-		return err
+	if !debited {
+		log.Printf("Payment %s has no hold to release, nothing to compensate", pmt.ID().String())
+		if err := o.advanceSaga(sg, refundEvent.EventType(), saga.StepCompensated); err != nil {
+			return err
+		}
+		return o.commit(ctx, port.Changes{Saga: sg})
 	}
 
-	// Save updated payment
-	if err := o.paymentRepo.Update(ctx, pmt); err != nil {
+	// Credit the wallet
+	// Delegate to Domain Service
+	result, err := o.paymentProcessor.RefundDebit(pmt, wlt)
+	if err != nil {
 		return err
 	}
 
-	// Publish PaymentCompleted event
-	completedEvent := payment.NewPaymentCompletedEvent(
-		pmt.ID().String(),
-		pmt.UserID().String(),
-		pmt.Money().AmountFloat(),
-		pmt.ExternalTxID(),
-		event.Metadata(),
+	creditedEvent := wallet.NewWalletCreditedEvent(
+		refundEvent.PaymentID(),
+		refundEvent.UserID(),
+		refundEvent.Amount(),
+		result.PreviousBalance.Amount(),
+		result.NewBalance.Amount(),
+		pmt.Money().Currency().Code(),
+		"REFUND",
+		refundEvent.Metadata(),
 	)
 
-	return o.publishEvent(ctx, completedEvent, pmt.ID().String())
+	sg.RecordCompensation(saga.CompensationWalletRefund, refundEvent.Reason())
+	if err := o.advanceSaga(sg, refundEvent.EventType(), saga.StepCompensated); err != nil {
+		return err
+	}
+
+	return o.commit(ctx, port.Changes{
+		Wallet: wlt,
+		Saga:   sg,
+		Events: []port.RecordedEvent{walletEvent(creditedEvent, refundEvent.PaymentID(), refundEvent.UserID())},
+	})
 }
 
-// HandleExternalPaymentFailed processes failed external payments
-func (o *PaymentOrchestrator) HandleExternalPaymentFailed(ctx context.Context, event shared.Event) error {
-	failedEvent, ok := event.(*payment.ExternalPaymentFailedEvent)
+// HandlePaymentRefunded credits a merchant refund back to the user's wallet
+// The payment is already refunded when this runs; the saga is done, so the payment stream is
+// checked instead to credit each refund only once. The fees the refund gives back are credited
+// with it, and a converted payment is credited in the currency the wallet paid with, at the rate
+// it was charged. The credit posts the reverse of the capture on the ledger
+func (o *PaymentOrchestrator) HandlePaymentRefunded(ctx context.Context, event shared.Event) error {
+	refundedEvent, ok := event.(*payment.PaymentRefundedEvent)
 	if !ok {
 		return fmt.Errorf("unexpected event type: %T", event)
 	}
 
-	return o.initiateRefund(ctx, failedEvent.PaymentID(), failedEvent.Reason(), event.Metadata())
+	return o.retryOnConflict(refundedEvent.PaymentID(), event.EventType(), func() error {
+		return o.creditRefund(ctx, refundedEvent)
+	})
 }
 
-// HandleExternalPaymentTimeout processes payment timeouts
-func (o *PaymentOrchestrator) HandleExternalPaymentTimeout(ctx context.Context, event shared.Event) error {
-	timeoutEvent, ok := event.(*payment.ExternalPaymentTimeoutEvent)
-	if !ok {
-		return fmt.Errorf("unexpected event type: %T", event)
+// creditRefund credits one refund on fresh copies of the payment and the wallet
+func (o *PaymentOrchestrator) creditRefund(ctx context.Context, refundedEvent *payment.PaymentRefundedEvent) error {
+	credited, err := o.refundCredited(ctx, refundedEvent.PaymentID(), refundedEvent.RefundID())
+	if err != nil {
+		return err
+	}
+	if credited {
+		log.Printf("Refund %s of payment %s already credited, skipping", refundedEvent.RefundID(), refundedEvent.PaymentID())
+		return nil
 	}
 
-	return o.initiateRefund(ctx, timeoutEvent.PaymentID(), "TIMEOUT", event.Metadata())
-}
+	currency, err := vo.NewCurrency(refundedEvent.Currency())
+	if err != nil {
+		return err
+	}
+	amount, err := vo.NewMoney(refundedEvent.Amount(), currency)
+	if err != nil {
+		return err
+	}
 
-// HandlePaymentRefundRequested processes refund requests
-func (o *PaymentOrchestrator) HandlePaymentRefundRequested(ctx context.Context, event shared.Event) error {
-	refundEvent, ok := event.(*payment.PaymentRefundRequestedEvent)
-	if !ok {
-		return fmt.Errorf("unexpected event type: %T", event)
+	total, err := vo.NewMoney(refundedEvent.RefundedTotal(), currency)
+	if err != nil {
+		return err
+	}
+	previous, err := total.Subtract(amount)
+	if err != nil {
+		return err
 	}
 
-	// Get payment
-	paymentID, _ := vo.NewPaymentID(refundEvent.PaymentID())
-	pmt, err := o.paymentRepo.FindByID(ctx, paymentID.String())
+	// The credit adds the fees the refund gives back and is paid in the funding currency
+	pmt, err := o.paymentRepo.FindByID(ctx, refundedEvent.PaymentID())
+	if err != nil {
+		return err
+	}
+	credit, err := pmt.RefundCredit(previous, total)
 	if err != nil {
 		return err
 	}
 
-	// Get wallet
-	wlt, err := o.walletRepo.GetByUserID(ctx, refundEvent.UserID())
+	// The ledger gives back what the refund took off settlement and each fee account
+	fees, err := payment.FeeLinesOf(refundedEvent.Fees(), currency)
+	if err != nil {
+		return err
+	}
+	entry, err := ledger.NewRefundEntry(
+		refundedEvent.RefundID(),
+		refundedEvent.PaymentID(),
+		refundedEvent.UserID(),
+		credit,
+		amount,
+		fees,
+	)
 	if err != nil {
 		return err
 	}
 
-	// Delegate to Domain Service
-	result, err := o.paymentProcessor.Refund(pmt, wlt)
+	wlt, err := o.walletRepo.GetByUserID(ctx, refundedEvent.UserID())
 	if err != nil {
 		return err
 	}
 
-	// Save updated wallet
-	if err := o.walletRepo.Update(ctx, wlt); err != nil {
+	prevBalance, newBalance, err := wlt.Credit(credit)
+	if err != nil {
+		return err
+	}
+	if err := wlt.RecordEntry(entry); err != nil {
 		return err
 	}
 
-	// Publish WalletCredited event
-	creditedEvent := wallet.NewWalletCreditedEvent(
-		refundEvent.PaymentID(),
-		refundEvent.UserID(),
-		refundEvent.Amount(),
-		result.PreviousBalance.AmountFloat(),
-		result.NewBalance.AmountFloat(),
-		"REFUND",
-		event.Metadata(),
+	// WalletCredited is tagged with the refund it pays out
+	creditedEvent := func(prevBalance, newBalance vo.Money) port.RecordedEvent {
+		return walletEvent(wallet.NewWalletCreditedEvent(
+			refundedEvent.PaymentID(),
+			refundedEvent.UserID(),
+			credit.Amount(),
+			prevBalance.Amount(),
+			newBalance.Amount(),
+			credit.Currency().Code(),
+			"REFUND",
+			refundedEvent.Metadata().WithExtra("refundId", refundedEvent.RefundID()),
+		), refundedEvent.PaymentID(), refundedEvent.UserID())
+	}
+
+	err = o.commit(ctx, port.Changes{
+		Wallet: wlt,
+		Events: []port.RecordedEvent{creditedEvent(prevBalance, newBalance)},
+	})
+	if !domerrors.IsErrorCode(err, domerrors.ErrCodeLedgerEntryExists) {
+		return err
+	}
+
+	// Credits posted before the event was written with them have no WalletCredited yet;
+	// the balances are read back from the wallet, since they cannot be known exactly anymore
+	log.Printf("Refund %s of payment %s was credited without its event, recording it now",
+		refundedEvent.RefundID(), refundedEvent.PaymentID())
+	if prevBalance, newBalance, err = o.creditedBalances(ctx, refundedEvent.UserID(), credit); err != nil {
+		return err
+	}
+
+	return o.commit(ctx, port.Changes{
+		Events: []port.RecordedEvent{creditedEvent(prevBalance, newBalance)},
+	})
+}
+
+// CancelPayment cancels a payment on behalf of the client, which is only possible before its funds
+// are held. The saga is the lock shared with the event handlers: its versioned save is what decides
+// whether the cancellation or HandlePaymentRequested wins, and a PaymentRequested handled after
+// the cancellation is ignored as stale
+func (o *PaymentOrchestrator) CancelPayment(ctx context.Context, paymentID, reason string, metadata shared.Metadata) (*payment.Payment, error) {
+	sg, err := o.sagaRepo.FindByPaymentID(ctx, paymentID)
+	if err != nil {
+		// PaymentRequested was not consumed yet
+		if !domerrors.IsErrorCode(err, domerrors.ErrCodeSagaNotFound) {
+			return nil, err
+		}
+		if sg, err = saga.NewSaga(paymentID, o.stepDeadline()); err != nil {
+			return nil, err
+		}
+	}
+
+	// Read after the saga, so a handler changing the payment since then makes the saga save conflict
+	pmt, err := o.paymentRepo.FindByID(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+	if pmt.Status().IsCancelled() {
+		return pmt, nil
+	}
+
+	// The saga is saved with the payment, so a stale saga makes the whole cancellation conflict
+	if err := sg.Cancel(o.stepDeadline()); err != nil {
+		return nil, err
+	}
+	if err := pmt.Cancel(reason); err != nil {
+		return nil, err
+	}
+
+	cancelledEvent := payment.NewPaymentCancelledEvent(
+		pmt.ID().String(),
+		pmt.UserID().String(),
+		pmt.Money().Amount(),
+		pmt.Money().Currency().Code(),
+		reason,
+		metadata,
 	)
 
-	return o.publishEvent(ctx, creditedEvent, refundEvent.PaymentID())
+	err = o.commit(ctx, port.Changes{
+		Payment: pmt,
+		Saga:    sg,
+		Events:  []port.RecordedEvent{paymentEvent(cancelledEvent, pmt.ID().String())},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return pmt, nil
 }
 
 // Private helper methods
 
-func (o *PaymentOrchestrator) failPayment(ctx context.Context, pmt *payment.Payment, reason string) error {
-	// Mark payment as failed
-	if err := pmt.MarkFailed(reason); err != nil {
-		log.Printf("Warning: failed to mark payment as failed: %v", err)
-		// Continue anyway to publish the event
+// runStep checks the saga and runs step, starting over on fresh copies when its commit loses a
+// race for the wallet or the saga
+func (o *PaymentOrchestrator) runStep(ctx context.Context, paymentID string, event shared.Event, step func(sg *saga.Saga) error) error {
+	return o.retryOnConflict(paymentID, event.EventType(), func() error {
+		sg, proceed, err := o.beginStep(ctx, paymentID, event)
+		if err != nil || !proceed {
+			return err
+		}
+		return step(sg)
+	})
+}
+
+// retryOnConflict runs attempt again while it fails with CONCURRENT_MODIFICATION,
+// up to maxStepAttempts times
+func (o *PaymentOrchestrator) retryOnConflict(paymentID, eventType string, attempt func() error) error {
+	for n := 1; ; n++ {
+		err := attempt()
+		if n >= maxStepAttempts || !domerrors.IsErrorCode(err, domerrors.ErrCodeConcurrentModification) {
+			return err
+		}
+		log.Printf("%s for payment %s was modified concurrently (attempt %d/%d), retrying", eventType, paymentID, n, maxStepAttempts)
+	}
+}
+
+// beginStep loads the payment saga and checks that event may drive its current step
+// Stale events (duplicates, or the loser of a race) return proceed=false and are acknowledged
+// without side effects; out-of-order events return an error, so SQS redelivers them until the
+// saga catches up or they land in the DLQ
+func (o *PaymentOrchestrator) beginStep(ctx context.Context, paymentID string, event shared.Event) (*saga.Saga, bool, error) {
+	sg, err := o.sagaRepo.FindByPaymentID(ctx, paymentID)
+	if err != nil {
+		// Only PaymentRequested may start a saga
+		if !domerrors.IsErrorCode(err, domerrors.ErrCodeSagaNotFound) || event.EventType() != "PaymentRequested" {
+			return nil, false, err
+		}
+		if sg, err = saga.NewSaga(paymentID, o.stepDeadline()); err != nil {
+			return nil, false, err
+		}
 	}
 
-	// Save updated payment
-	if err := o.paymentRepo.Update(ctx, pmt); err != nil {
-		return domerrors.DatabaseError("update payment status", err)
+	if err := sg.Accept(event.EventType(), time.Now().UTC().Add(stepLease)); err != nil {
+		if domerrors.IsErrorCode(err, domerrors.ErrCodeSagaStaleEvent) {
+			log.Printf("Ignoring %s (event %s) for payment %s: %v", event.EventType(), event.EventID(), paymentID, err)
+			return nil, false, nil
+		}
+		return nil, false, err
+	}
+
+	// Persisting the attempt makes concurrent deliveries of the same event conflict here
+	if err := o.sagaRepo.Save(ctx, sg); err != nil {
+		return nil, false, err
+	}
+
+	return sg, true, nil
+}
+
+// advanceSaga moves the saga to the step reached; it is saved in the commit of the step, so a
+// delivery whose changes and events were not written finds the saga where it was and runs again
+func (o *PaymentOrchestrator) advanceSaga(sg *saga.Saga, eventType string, to saga.Step) error {
+	return sg.Advance(eventType, to, o.stepDeadline())
+}
+
+func (o *PaymentOrchestrator) stepDeadline() time.Time {
+	return time.Now().UTC().Add(o.stepTimeout)
+}
+
+// debitedWithoutHold reports whether the payment stream shows an outright debit that was not
+// refunded yet, which is how payments were authorized before holds existed
+func (o *PaymentOrchestrator) debitedWithoutHold(ctx context.Context, paymentID string) (bool, error) {
+	events, err := o.eventStore.ListByPaymentID(ctx, paymentID)
+	if err != nil {
+		return false, domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to list payment events", err)
+	}
+
+	debited := false
+	for _, stored := range events {
+		switch stored.EventType {
+		case "WalletFundsHeld":
+			return false, nil
+		case "WalletDebited":
+			debited = true
+		case "WalletCredited":
+			debited = false
+		}
+	}
+
+	return debited, nil
+}
+
+// refundCredited reports whether the payment stream already has the wallet credit of a refund
+func (o *PaymentOrchestrator) refundCredited(ctx context.Context, paymentID, refundID string) (bool, error) {
+	events, err := o.eventStore.ListByPaymentID(ctx, paymentID)
+	if err != nil {
+		return false, domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to list payment events", err)
+	}
+
+	for _, stored := range events {
+		if stored.EventType != "WalletCredited" {
+			continue
+		}
+		var metadata shared.Metadata
+		if err := json.Unmarshal([]byte(stored.Metadata), &metadata); err != nil {
+			continue
+		}
+		if metadata.Extra["refundId"] == refundID {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// creditedBalances reads the balance a credit that was already applied left, and works out the one before it
+func (o *PaymentOrchestrator) creditedBalances(ctx context.Context, userID string, credit vo.Money) (vo.Money, vo.Money, error) {
+	wlt, err := o.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return vo.Money{}, vo.Money{}, err
+	}
+
+	// The wallet may have spent part of the credit since, in which case the previous balance is unknown
+	newBalance := wlt.Balance(credit.Currency())
+	if newBalance.Amount().LessThan(credit.Amount()) {
+		return vo.Zero(credit.Currency()), newBalance, nil
+	}
+	prevBalance, err := newBalance.Subtract(credit)
+	if err != nil {
+		return vo.Money{}, vo.Money{}, err
+	}
+	return prevBalance, newBalance, nil
+}
+
+// rejectPayment ends a payment refused before its funds were held
+func (o *PaymentOrchestrator) rejectPayment(ctx context.Context, pmt *payment.Payment, sg *saga.Saga, eventType, reason string) error {
+	// Mark payment as rejected
+	if err := pmt.Reject(reason); err != nil {
+		log.Printf("Warning: failed to mark payment as rejected: %v", err)
+		// Continue anyway to record the event
 	}
 
-	// Publish PaymentFailed event
 	failedEvent := payment.NewPaymentFailedEvent(
 		pmt.ID().String(),
 		pmt.UserID().String(),
-		pmt.Money().AmountFloat(),
+		pmt.Money().Amount(),
+		pmt.Money().Currency().Code(),
 		reason,
 		shared.Metadata{},
 	)
 
-	return o.publishEvent(ctx, failedEvent, pmt.ID().String())
+	if err := o.advanceSaga(sg, eventType, saga.StepFailed); err != nil {
+		return err
+	}
+
+	return o.commit(ctx, port.Changes{
+		Payment: pmt,
+		Saga:    sg,
+		Events:  []port.RecordedEvent{paymentEvent(failedEvent, pmt.ID().String())},
+	})
 }
 
-func (o *PaymentOrchestrator) initiateRefund(ctx context.Context, paymentID, reason string, metadata shared.Metadata) error {
+func (o *PaymentOrchestrator) initiateRefund(ctx context.Context, sg *saga.Saga, event shared.Event, reason string) error {
 	// Get payment
-	pmt, err := o.paymentRepo.FindByID(ctx, paymentID)
+	pmt, err := o.paymentRepo.FindByID(ctx, sg.PaymentID())
 	if err != nil {
 		return err
 	}
@@ -257,33 +719,38 @@ func (o *PaymentOrchestrator) initiateRefund(ctx context.Context, paymentID, rea
 		log.Printf("Warning: failed to mark payment as failed: %v", err)
 	}
 
-	// Save updated payment
-	if err := o.paymentRepo.Update(ctx, pmt); err != nil {
-		return err
-	}
-
-	// Publish PaymentRefundRequested event
 	refundEvent := payment.NewPaymentRefundRequestedEvent(
 		pmt.ID().String(),
 		pmt.UserID().String(),
-		pmt.Money().AmountFloat(),
+		pmt.Money().Amount(),
+		pmt.Money().Currency().Code(),
 		reason,
-		metadata,
+		event.Metadata(),
 	)
 
-	return o.publishEvent(ctx, refundEvent, pmt.ID().String())
+	if err := o.advanceSaga(sg, event.EventType(), saga.StepCompensating); err != nil {
+		return err
+	}
+
+	return o.commit(ctx, port.Changes{
+		Payment: pmt,
+		Saga:    sg,
+		Events:  []port.RecordedEvent{paymentEvent(refundEvent, pmt.ID().String())},
+	})
 }
 
-func (o *PaymentOrchestrator) publishEvent(ctx context.Context, event shared.Event, paymentID string) error {
-	// Store event (event sourcing)
-	if err := o.eventStore.Append(ctx, event, paymentID); err != nil {
-		return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to store event", err)
-	}
+// commit writes the changes of a step with its events queued for the payments topic
+func (o *PaymentOrchestrator) commit(ctx context.Context, changes port.Changes) error {
+	changes.TopicArn = o.topicArn
+	return o.unitOfWork.Commit(ctx, changes)
+}
 
-	// Publish event
-	if err := o.eventPublisher.Publish(ctx, event, o.topicArn); err != nil {
-		return domerrors.EventPublishError(event.EventType(), err)
-	}
+// paymentEvent records an event on the payment stream
+func paymentEvent(event shared.Event, paymentID string) port.RecordedEvent {
+	return port.RecordedEvent{Event: event, Streams: []string{paymentID}}
+}
 
-	return nil
+// walletEvent also records the event on the wallet stream so it shows up in the wallet history
+func walletEvent(event shared.Event, paymentID, userID string) port.RecordedEvent {
+	return port.RecordedEvent{Event: event, Streams: []string{paymentID, wallet.EventStreamID(userID)}}
 }
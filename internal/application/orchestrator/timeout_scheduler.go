@@ -0,0 +1,146 @@
+package orchestrator
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// timeoutBatchSize bounds how many due deadlines are handled per pass
+const timeoutBatchSize = 25
+
+// TimeoutScheduler emits ExternalPaymentTimeout for gateway requests that never got an answer
+// Deadlines live in a TimeoutStore rather than in-memory timers, so they survive restarts
+type TimeoutScheduler struct {
+	paymentRepo  PaymentRepository
+	timeoutStore port.TimeoutStore
+	unitOfWork   port.UnitOfWork
+	topicArn     string
+	timeout      time.Duration
+}
+
+// NewTimeoutScheduler creates a new TimeoutScheduler
+// timeout is how long the gateway has to answer after ExternalPaymentRequested
+func NewTimeoutScheduler(
+	paymentRepo PaymentRepository,
+	timeoutStore port.TimeoutStore,
+	unitOfWork port.UnitOfWork,
+	topicArn string,
+	timeout time.Duration,
+) *TimeoutScheduler {
+	return &TimeoutScheduler{
+		paymentRepo:  paymentRepo,
+		timeoutStore: timeoutStore,
+		unitOfWork:   unitOfWork,
+		topicArn:     topicArn,
+		timeout:      timeout,
+	}
+}
+
+// HandleExternalPaymentRequested starts tracking the deadline of a gateway request
+// The deadline is based on when the request happened, so redeliveries schedule the same one
+func (s *TimeoutScheduler) HandleExternalPaymentRequested(ctx context.Context, event shared.Event) error {
+	requestedEvent, ok := event.(*payment.ExternalPaymentRequestedEvent)
+	if !ok {
+		return fmt.Errorf("unexpected event type: %T", event)
+	}
+
+	return s.timeoutStore.Schedule(ctx, port.ScheduledTimeout{
+		PaymentID: requestedEvent.PaymentID(),
+		Deadline:  requestedEvent.OccurredAt().Add(s.timeout),
+		Timeout:   s.timeout,
+	})
+}
+
+// Start polls for expired deadlines in the background until ctx is cancelled
+func (s *TimeoutScheduler) Start(ctx context.Context, pollInterval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(pollInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if _, err := s.FireDue(ctx, time.Now().UTC()); err != nil {
+					log.Printf("Timeout scheduler: %v", err)
+				}
+			}
+		}
+	}()
+}
+
+// FireDue publishes ExternalPaymentTimeout for every deadline passed at now
+// Payments that already reached a terminal status are resolved silently instead
+func (s *TimeoutScheduler) FireDue(ctx context.Context, now time.Time) (int, error) {
+	due, err := s.timeoutStore.ListDue(ctx, now, timeoutBatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list due timeouts: %w", err)
+	}
+
+	// One timeout failing must not hold back the ones behind it; it stays due for the next pass
+	fired, failed := 0, 0
+	for _, timeout := range due {
+		ok, err := s.fire(ctx, timeout)
+		if err != nil {
+			log.Printf("Timeout scheduler: failed to fire %s: %v", timeout.PaymentID, err)
+			failed++
+			continue
+		}
+		if ok {
+			fired++
+		}
+	}
+
+	if failed > 0 {
+		return fired, fmt.Errorf("%d of %d due timeouts failed", failed, len(due))
+	}
+	return fired, nil
+}
+
+func (s *TimeoutScheduler) fire(ctx context.Context, timeout port.ScheduledTimeout) (bool, error) {
+	pmt, err := s.paymentRepo.FindByID(ctx, timeout.PaymentID)
+	if err != nil {
+		if domerrors.IsErrorCode(err, domerrors.ErrCodePaymentNotFound) {
+			_, err := s.timeoutStore.Close(ctx, timeout.PaymentID, port.TimeoutOutcomeResolved)
+			return false, err
+		}
+		return false, err
+	}
+
+	if pmt.Status().IsSettled() {
+		_, err := s.timeoutStore.Close(ctx, timeout.PaymentID, port.TimeoutOutcomeResolved)
+		return false, err
+	}
+
+	timeoutEvent := payment.NewExternalPaymentTimeoutEvent(
+		timeout.PaymentID,
+		timeout.Timeout,
+		shared.Metadata{Source: "timeout-scheduler"},
+	)
+
+	// The deadline is closed in the transaction that queues the event: two instances never both
+	// publish a timeout, and a failed commit leaves it due for the next pass
+	err = s.unitOfWork.Commit(ctx, port.Changes{
+		Timeout:  &port.TimeoutClosing{PaymentID: timeout.PaymentID, Outcome: port.TimeoutOutcomeFired},
+		Events:   []port.RecordedEvent{{Event: timeoutEvent, Streams: []string{timeout.PaymentID}}},
+		TopicArn: s.topicArn,
+	})
+	if domerrors.IsErrorCode(err, domerrors.ErrCodeConcurrentModification) {
+		// Another instance closed it first
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	log.Printf("External payment %s timed out after %s", timeout.PaymentID, timeout.Timeout)
+	return true, nil
+}
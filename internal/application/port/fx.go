@@ -0,0 +1,39 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+)
+
+// FXRateProvider returns market exchange rates
+// A pair without a rate is an FX_RATE_UNAVAILABLE error
+type FXRateProvider interface {
+	// Rate returns how many units of quote buy one unit of base
+	Rate(ctx context.Context, base, quote string) (decimal.Decimal, error)
+}
+
+// FXQuote is a conversion locked for one user until ExpiresAt
+// A quote funds a single payment: UsedBy is set, in the same transaction that creates it, to its ID
+type FXQuote struct {
+	ID         string
+	UserID     string
+	Conversion vo.Conversion
+	CreatedAt  time.Time
+	ExpiresAt  time.Time
+	UsedBy     string // payment ID, empty while the quote is unused
+}
+
+// IsExpired reports whether the locked rate is no longer honoured at the given time
+func (q FXQuote) IsExpired(now time.Time) bool {
+	return !now.Before(q.ExpiresAt)
+}
+
+// FXQuoteStore keeps quotes while their rate is locked
+// Get returns an FX_QUOTE_NOT_FOUND error for unknown quotes; expired ones may still be returned
+type FXQuoteStore interface {
+	Save(ctx context.Context, quote FXQuote) error
+	Get(ctx context.Context, quoteID string) (*FXQuote, error)
+}
@@ -0,0 +1,43 @@
+package port
+
+import (
+	"context"
+
+	"github.com/shopspring/decimal"
+)
+
+// ChargeStatus is the business outcome of a charge
+type ChargeStatus string
+
+const (
+	ChargeApproved ChargeStatus = "APPROVED"
+	ChargeDeclined ChargeStatus = "DECLINED"
+	// ChargePending means the gateway took the charge and will report the outcome by webhook
+	ChargePending ChargeStatus = "PENDING"
+)
+
+// ChargeRequest asks a payment gateway to collect a payment
+type ChargeRequest struct {
+	PaymentID string
+	// IdempotencyKey must be stable per payment so retried charges are never collected twice
+	IdempotencyKey string
+	UserID         string
+	ServiceID      string
+	Amount         decimal.Decimal
+	Currency       string
+}
+
+// ChargeResult is the gateway's answer to a charge
+type ChargeResult struct {
+	Status        ChargeStatus
+	TransactionID string // set when approved or pending
+	DeclineReason string // set when declined
+	DeclineCode   string // gateway specific code, set when declined
+}
+
+// PaymentGateway collects payments from an external provider
+// A declined charge is a result, not an error; errors mean the outcome is unknown
+// (network failure, 5xx, timeout) and the charge can be retried with the same idempotency key
+type PaymentGateway interface {
+	Charge(ctx context.Context, req ChargeRequest) (*ChargeResult, error)
+}
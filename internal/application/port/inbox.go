@@ -0,0 +1,10 @@
+package port
+
+import "context"
+
+// InboxStore remembers which events each consumer has already handled
+// Keys are per consumer because SNS fans the same event out to every queue
+type InboxStore interface {
+	IsProcessed(ctx context.Context, consumer, eventID string) (bool, error)
+	MarkProcessed(ctx context.Context, consumer, eventID, eventType string) error
+}
@@ -0,0 +1,29 @@
+package port
+
+import (
+	"context"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/shared"
+)
+
+// OutboxEntry is a serialized event waiting to be delivered to the message bus
+type OutboxEntry struct {
+	ID        string
+	EventType string
+	TopicArn  string
+	Payload   string
+	Attempts  int
+	LastError string
+	CreatedAt time.Time
+}
+
+// OutboxStore persists events until the relay has delivered them
+// MarkDead takes an entry that can never be delivered out of the pending list, keeping it for inspection
+type OutboxStore interface {
+	Add(ctx context.Context, event shared.Event, topicArn string) error
+	ListPending(ctx context.Context, limit int) ([]OutboxEntry, error)
+	MarkDispatched(ctx context.Context, entryID string) error
+	RecordFailure(ctx context.Context, entryID string, reason string) error
+	MarkDead(ctx context.Context, entryID string, reason string) error
+}
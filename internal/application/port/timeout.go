@@ -0,0 +1,29 @@
+package port
+
+import (
+	"context"
+	"time"
+)
+
+// Outcomes recorded when a scheduled timeout stops being pending
+const (
+	TimeoutOutcomeFired    = "FIRED"    // ExternalPaymentTimeout was published
+	TimeoutOutcomeResolved = "RESOLVED" // the payment finished before the deadline
+)
+
+// ScheduledTimeout is the deadline of an in-flight external payment request
+type ScheduledTimeout struct {
+	PaymentID string
+	Deadline  time.Time
+	Timeout   time.Duration
+}
+
+// TimeoutStore persists external payment deadlines so they survive restarts
+// Schedule leaves a timeout that was already closed as it is, so a redelivered request cannot reopen it
+type TimeoutStore interface {
+	Schedule(ctx context.Context, timeout ScheduledTimeout) error
+	ListDue(ctx context.Context, now time.Time, limit int) ([]ScheduledTimeout, error)
+	// Close moves a pending timeout to the given outcome; it returns false if another
+	// instance already closed it, so a deadline is acted on at most once
+	Close(ctx context.Context, paymentID, outcome string) (bool, error)
+}
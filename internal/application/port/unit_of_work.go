@@ -0,0 +1,50 @@
+package port
+
+import (
+	"context"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/saga"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/domain/wallet"
+)
+
+// RecordedEvent is an event written by a unit of work: appended to each of its EventStore
+// streams and queued once in the outbox
+type RecordedEvent struct {
+	Event   shared.Event
+	Streams []string // the payment ID, plus the wallet stream for wallet events
+}
+
+// TimeoutClosing moves a pending timeout to an outcome
+type TimeoutClosing struct {
+	PaymentID string
+	Outcome   string
+}
+
+// InboxClaim records that a consumer handled a message
+type InboxClaim struct {
+	Consumer  string
+	EventID   string
+	EventType string
+}
+
+// Changes is everything one step writes: the state it changed, the events that record the change
+// and their outbox entries for delivery to TopicArn. Nil fields are left as they are
+type Changes struct {
+	Payment  *payment.Payment
+	Wallet   *wallet.Wallet  // versioned, posted with the journal entries it recorded
+	Saga     *saga.Saga      // versioned, saved at the step the changes move the payment to
+	Timeout  *TimeoutClosing // only while the timeout is still pending
+	Inbox    *InboxClaim     // only if the consumer has not handled the message yet
+	Events   []RecordedEvent
+	TopicArn string
+}
+
+// UnitOfWork commits Changes in a single transaction, so the state, its events and their outbox
+// entries are either all written or none is
+// A stale wallet or saga, or a timeout closed by someone else, fails with CONCURRENT_MODIFICATION, a journal
+// entry already posted with LEDGER_ENTRY_EXISTS and an inbox claim already taken with DUPLICATE_REQUEST
+type UnitOfWork interface {
+	Commit(ctx context.Context, changes Changes) error
+}
@@ -0,0 +1,96 @@
+package query
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+)
+
+// AuditPaymentResponse compares the stored snapshot of a payment with its replayed event stream
+type AuditPaymentResponse struct {
+	PaymentID      string
+	Consistent     bool
+	SnapshotStatus string
+	ReplayedStatus string
+	Mismatches     []string
+}
+
+// AuditPaymentService cross-checks the Payments table against the EventStore
+type AuditPaymentService struct {
+	snapshotRepo PaymentRepository
+	replayRepo   PaymentRepository
+}
+
+// NewAuditPaymentService creates a new AuditPaymentService
+// snapshotRepo reads the current row, replayRepo rebuilds the payment from its events
+func NewAuditPaymentService(snapshotRepo, replayRepo PaymentRepository) *AuditPaymentService {
+	return &AuditPaymentService{
+		snapshotRepo: snapshotRepo,
+		replayRepo:   replayRepo,
+	}
+}
+
+// Execute loads both views of a payment and reports every field where they disagree
+func (s *AuditPaymentService) Execute(ctx context.Context, paymentID string) (*AuditPaymentResponse, error) {
+	id, err := vo.NewPaymentID(paymentID)
+	if err != nil {
+		return nil, domerrors.ValidationError("paymentId", err.Error())
+	}
+
+	snapshot, err := s.snapshotRepo.FindByID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	replayed, err := s.replayRepo.FindByID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	mismatches := comparePayments(snapshot, replayed)
+
+	return &AuditPaymentResponse{
+		PaymentID:      id.String(),
+		Consistent:     len(mismatches) == 0,
+		SnapshotStatus: snapshot.Status().String(),
+		ReplayedStatus: replayed.Status().String(),
+		Mismatches:     mismatches,
+	}, nil
+}
+
+// comparePayments lists the business fields that differ between two payments
+// Timestamps are not compared: the snapshot keeps second precision and is stamped before the event
+func comparePayments(snapshot, replayed *payment.Payment) []string {
+	mismatches := make([]string, 0)
+
+	check := func(field, snapshotValue, replayedValue string) {
+		if snapshotValue != replayedValue {
+			mismatches = append(mismatches, fmt.Sprintf("%s: snapshot=%q replayed=%q", field, snapshotValue, replayedValue))
+		}
+	}
+
+	check("userId", snapshot.UserID().String(), replayed.UserID().String())
+	check("serviceId", snapshot.ServiceID().String(), replayed.ServiceID().String())
+	check("idempotencyKey", snapshot.IdempotencyKey().String(), replayed.IdempotencyKey().String())
+	check("status", snapshot.Status().String(), replayed.Status().String())
+	check("failureReason", snapshot.FailureReason(), replayed.FailureReason())
+	check("externalTxId", snapshot.ExternalTxID(), replayed.ExternalTxID())
+
+	if !snapshot.Money().Equals(replayed.Money()) {
+		check("money", snapshot.Money().String(), replayed.Money().String())
+	}
+	if !snapshot.RefundedAmount().Equals(replayed.RefundedAmount()) {
+		check("refundedAmount", snapshot.RefundedAmount().String(), replayed.RefundedAmount().String())
+	}
+	if !snapshot.FeeTotal().Equals(replayed.FeeTotal()) {
+		check("feeTotal", snapshot.FeeTotal().String(), replayed.FeeTotal().String())
+	}
+	if !snapshot.FundingMoney().Equals(replayed.FundingMoney()) {
+		check("fundingAmount", snapshot.FundingMoney().String(), replayed.FundingMoney().String())
+	}
+
+	return mismatches
+}
@@ -0,0 +1,99 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+)
+
+// GetPaymentRequest represents a payment lookup request
+type GetPaymentRequest struct {
+	PaymentID     string
+	IncludeEvents bool
+}
+
+// GetPaymentResponse represents the current state of a payment
+type GetPaymentResponse struct {
+	PaymentID      string
+	UserID         string
+	ServiceID      string
+	Amount         decimal.Decimal
+	Currency       string
+	Status         string
+	RefundedAmount decimal.Decimal
+	FailureReason  string
+	ExternalTxID   string
+	Fees           []shared.FeeDetail // charged on top of Amount
+	TotalAmount    decimal.Decimal    // Amount plus fees
+	FX             *shared.FXDetails  // set when the wallet paid in another currency
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+	Events         []shared.StoredEvent
+}
+
+// GetPaymentService handles the payment lookup use case
+type GetPaymentService struct {
+	paymentRepo PaymentRepository
+	eventStore  shared.EventStore
+}
+
+// PaymentRepository defines payment read operations
+type PaymentRepository interface {
+	FindByID(ctx context.Context, paymentID string) (*payment.Payment, error)
+}
+
+// NewGetPaymentService creates a new GetPaymentService
+func NewGetPaymentService(
+	paymentRepo PaymentRepository,
+	eventStore shared.EventStore,
+) *GetPaymentService {
+	return &GetPaymentService{
+		paymentRepo: paymentRepo,
+		eventStore:  eventStore,
+	}
+}
+
+// Execute loads a payment and, optionally, its event timeline
+func (s *GetPaymentService) Execute(ctx context.Context, req GetPaymentRequest) (*GetPaymentResponse, error) {
+	paymentID, err := vo.NewPaymentID(req.PaymentID)
+	if err != nil {
+		return nil, domerrors.ValidationError("paymentId", err.Error())
+	}
+
+	pmt, err := s.paymentRepo.FindByID(ctx, paymentID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	response := &GetPaymentResponse{
+		PaymentID:      pmt.ID().String(),
+		UserID:         pmt.UserID().String(),
+		ServiceID:      pmt.ServiceID().String(),
+		Amount:         pmt.Money().Amount(),
+		Currency:       pmt.Money().Currency().Code(),
+		Status:         pmt.Status().String(),
+		RefundedAmount: pmt.RefundedAmount().Amount(),
+		FailureReason:  pmt.FailureReason(),
+		ExternalTxID:   pmt.ExternalTxID(),
+		Fees:           pmt.FeeDetails(),
+		TotalAmount:    pmt.TotalMoney().Amount(),
+		FX:             pmt.FXDetails(),
+		CreatedAt:      pmt.CreatedAt(),
+		UpdatedAt:      pmt.UpdatedAt(),
+	}
+
+	if req.IncludeEvents {
+		events, err := s.eventStore.ListByPaymentID(ctx, paymentID.String())
+		if err != nil {
+			return nil, domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to list payment events", err)
+		}
+		response.Events = events
+	}
+
+	return response, nil
+}
@@ -0,0 +1,110 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/saga"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+)
+
+const (
+	defaultStuckSagasLimit = 50
+	maxStuckSagasLimit     = 200
+)
+
+// SagaResponse represents the state of a payment saga
+type SagaResponse struct {
+	PaymentID     string
+	Step          string
+	Attempts      int
+	Deadline      time.Time // zero once the saga is terminal
+	Stuck         bool
+	Compensations []saga.Compensation
+	History       []saga.Transition
+	StartedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+// ListStuckSagasRequest represents a lookup of sagas past their step deadline
+type ListStuckSagasRequest struct {
+	Step  string // optional, only sagas in this step
+	Limit int
+}
+
+// SagaRepository defines saga read operations
+type SagaRepository interface {
+	FindByPaymentID(ctx context.Context, paymentID string) (*saga.Saga, error)
+	ListStuck(ctx context.Context, now time.Time, limit int) ([]*saga.Saga, error)
+}
+
+// GetSagaService lets operators inspect payment sagas
+type GetSagaService struct {
+	sagaRepo SagaRepository
+}
+
+// NewGetSagaService creates a new GetSagaService
+func NewGetSagaService(sagaRepo SagaRepository) *GetSagaService {
+	return &GetSagaService{
+		sagaRepo: sagaRepo,
+	}
+}
+
+// Execute returns the saga of a payment
+func (s *GetSagaService) Execute(ctx context.Context, paymentID string) (*SagaResponse, error) {
+	id, err := vo.NewPaymentID(paymentID)
+	if err != nil {
+		return nil, domerrors.ValidationError("paymentId", err.Error())
+	}
+
+	sg, err := s.sagaRepo.FindByPaymentID(ctx, id.String())
+	if err != nil {
+		return nil, err
+	}
+
+	response := toSagaResponse(sg, time.Now().UTC())
+	return &response, nil
+}
+
+// ListStuck returns running sagas whose current step is past its deadline, oldest first
+func (s *GetSagaService) ListStuck(ctx context.Context, req ListStuckSagasRequest) ([]SagaResponse, error) {
+	limit := req.Limit
+	if limit <= 0 {
+		limit = defaultStuckSagasLimit
+	}
+	if limit > maxStuckSagasLimit {
+		return nil, domerrors.ValidationError("limit", "must be at most 200")
+	}
+
+	now := time.Now().UTC()
+
+	sagas, err := s.sagaRepo.ListStuck(ctx, now, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	responses := make([]SagaResponse, 0, len(sagas))
+	for _, sg := range sagas {
+		if req.Step != "" && sg.Step().String() != req.Step {
+			continue
+		}
+		responses = append(responses, toSagaResponse(sg, now))
+	}
+
+	return responses, nil
+}
+
+func toSagaResponse(sg *saga.Saga, now time.Time) SagaResponse {
+	return SagaResponse{
+		PaymentID:     sg.PaymentID(),
+		Step:          sg.Step().String(),
+		Attempts:      sg.Attempts(),
+		Deadline:      sg.Deadline(),
+		Stuck:         sg.IsStuck(now),
+		Compensations: sg.Compensations(),
+		History:       sg.History(),
+		StartedAt:     sg.StartedAt(),
+		UpdatedAt:     sg.UpdatedAt(),
+	}
+}
@@ -0,0 +1,94 @@
+package query
+
+import (
+	"context"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/shopspring/decimal"
+)
+
+// GetWalletResponse represents the current balances of a wallet
+// The top-level fields are the default currency; Balances lists every currency, default first
+type GetWalletResponse struct {
+	UserID           string
+	Balance          decimal.Decimal
+	AvailableBalance decimal.Decimal // balance minus held funds
+	HeldBalance      decimal.Decimal // reserved for payments awaiting the gateway
+	Currency         string
+	Balances         []CurrencyBalance
+	UpdatedAt        time.Time
+}
+
+// CurrencyBalance is the balance a wallet holds in one currency
+type CurrencyBalance struct {
+	Currency         string
+	Balance          decimal.Decimal
+	AvailableBalance decimal.Decimal
+	HeldBalance      decimal.Decimal
+}
+
+// WalletRepository defines wallet read operations
+type WalletRepository interface {
+	GetByUserID(ctx context.Context, userID string) (*wallet.Wallet, error)
+}
+
+// GetWalletService handles wallet balance and history lookups
+type GetWalletService struct {
+	walletRepo WalletRepository
+	eventStore shared.EventStore
+}
+
+// NewGetWalletService creates a new GetWalletService
+func NewGetWalletService(walletRepo WalletRepository, eventStore shared.EventStore) *GetWalletService {
+	return &GetWalletService{
+		walletRepo: walletRepo,
+		eventStore: eventStore,
+	}
+}
+
+// Execute returns the current balance of a user's wallet
+func (s *GetWalletService) Execute(ctx context.Context, userID string) (*GetWalletResponse, error) {
+	wlt, err := s.walletRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	balances := make([]CurrencyBalance, 0, len(wlt.Balances()))
+	for _, balance := range wlt.Balances() {
+		balances = append(balances, CurrencyBalance{
+			Currency:         balance.Currency().Code(),
+			Balance:          balance.Amount(),
+			AvailableBalance: wlt.AvailableBalance(balance.Currency()).Amount(),
+			HeldBalance:      wlt.HeldBalance(balance.Currency()).Amount(),
+		})
+	}
+	primary := balances[0]
+
+	return &GetWalletResponse{
+		UserID:           wlt.UserID().String(),
+		Balance:          primary.Balance,
+		AvailableBalance: primary.AvailableBalance,
+		HeldBalance:      primary.HeldBalance,
+		Currency:         primary.Currency,
+		Balances:         balances,
+		UpdatedAt:        wlt.UpdatedAt(),
+	}, nil
+}
+
+// History returns the ordered movements applied to a user's wallet, holds included
+func (s *GetWalletService) History(ctx context.Context, userID string) ([]shared.StoredEvent, error) {
+	// Ensure the wallet exists so unknown users get a not found instead of an empty history
+	if _, err := s.walletRepo.GetByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	events, err := s.eventStore.ListByPaymentID(ctx, wallet.EventStreamID(userID))
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to list wallet events", err)
+	}
+
+	return events, nil
+}
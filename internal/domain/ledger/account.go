@@ -0,0 +1,42 @@
+package ledger
+
+import "strings"
+
+// AccountID names a ledger account
+// Wallets and fees get one account each; the rest are shared by every payment
+type AccountID string
+
+const (
+	// SettlementAccount holds what captured payments owe the merchants
+	SettlementAccount AccountID = "settlement"
+	// FXAccount clears cross-currency payments, it keeps one balance per currency
+	FXAccount AccountID = "fx"
+	// FundingAccount is the money that came in through top-ups
+	FundingAccount AccountID = "funding"
+	// OpeningAccount is the counterpart of wallet balances that predate the ledger
+	OpeningAccount AccountID = "equity:opening"
+)
+
+const (
+	walletAccountPrefix = "wallet:"
+	feeAccountPrefix    = "fees:"
+)
+
+// WalletAccount returns the account behind a user's wallet
+func WalletAccount(userID string) AccountID {
+	return AccountID(walletAccountPrefix + userID)
+}
+
+// FeeAccount returns the account that collects a fee code
+func FeeAccount(code string) AccountID {
+	return AccountID(feeAccountPrefix + code)
+}
+
+func (a AccountID) String() string {
+	return string(a)
+}
+
+// IsWallet reports whether the account backs a user's wallet
+func (a AccountID) IsWallet() bool {
+	return strings.HasPrefix(string(a), walletAccountPrefix)
+}
@@ -0,0 +1,106 @@
+package ledger
+
+import (
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+)
+
+// Entry IDs, one per movement they record
+
+func CaptureEntryID(paymentID string) string {
+	return "capture#" + paymentID
+}
+
+func RefundEntryID(refundID string) string {
+	return "refund#" + refundID
+}
+
+func CompensationEntryID(paymentID string) string {
+	return "compensation#" + paymentID
+}
+
+func TopUpEntryID(topUpID string) string {
+	return "topup#" + topUpID
+}
+
+func OpeningEntryID(userID string, currency vo.Currency) string {
+	return "opening#" + userID + "#" + currency.Code()
+}
+
+// NewCaptureEntry records a captured payment: the wallet pays funding, the merchant is owed the
+// amount and each fee goes to its own account
+func NewCaptureEntry(paymentID, userID string, funding, amount vo.Money, fees []vo.FeeLine) (*JournalEntry, error) {
+	postings, err := chargePostings(userID, Debit, funding, amount, fees)
+	if err != nil {
+		return nil, err
+	}
+	return NewJournalEntry(CaptureEntryID(paymentID), EntryCapture, paymentID, postings)
+}
+
+// NewRefundEntry records a merchant refund credited back to the wallet, the reverse of a capture
+// for the refunded amount and the fees it gives back
+func NewRefundEntry(refundID, paymentID, userID string, credit, amount vo.Money, fees []vo.FeeLine) (*JournalEntry, error) {
+	postings, err := chargePostings(userID, Credit, credit, amount, fees)
+	if err != nil {
+		return nil, err
+	}
+	return NewJournalEntry(RefundEntryID(refundID), EntryRefund, paymentID, postings)
+}
+
+// NewCompensationEntry records the credit back of a payment debited before holds existed
+// The debit itself predates the ledger and is part of the opening balance, so the credit is
+// taken from settlement, where the payment would have gone
+func NewCompensationEntry(paymentID, userID string, credit vo.Money) (*JournalEntry, error) {
+	return NewJournalEntry(CompensationEntryID(paymentID), EntryCompensation, paymentID, []Posting{
+		{Account: SettlementAccount, Side: Debit, Amount: credit},
+		{Account: WalletAccount(userID), Side: Credit, Amount: credit},
+	})
+}
+
+// NewTopUpEntry records money loaded into a wallet
+func NewTopUpEntry(topUpID, userID string, amount vo.Money) (*JournalEntry, error) {
+	return NewJournalEntry(TopUpEntryID(topUpID), EntryTopUp, topUpID, []Posting{
+		{Account: FundingAccount, Side: Debit, Amount: amount},
+		{Account: WalletAccount(userID), Side: Credit, Amount: amount},
+	})
+}
+
+// NewOpeningEntry records a wallet balance that existed before the ledger did
+func NewOpeningEntry(userID string, balance vo.Money) (*JournalEntry, error) {
+	return NewJournalEntry(OpeningEntryID(userID, balance.Currency()), EntryOpening, userID, []Posting{
+		{Account: OpeningAccount, Side: Debit, Amount: balance},
+		{Account: WalletAccount(userID), Side: Credit, Amount: balance},
+	})
+}
+
+// chargePostings books money moving between a wallet and the merchant side of a payment: the
+// amount on settlement and each fee on its fee account. A wallet paying in another currency goes
+// through the FX account, so each currency balances on its own
+func chargePostings(userID string, walletSide Side, walletMoney, amount vo.Money, fees []vo.FeeLine) ([]Posting, error) {
+	merchantSide := walletSide.Opposite()
+
+	merchant := make([]Posting, 0, len(fees)+1)
+	gross := amount
+	if amount.IsPositive() {
+		merchant = append(merchant, Posting{Account: SettlementAccount, Side: merchantSide, Amount: amount})
+	}
+	for _, fee := range fees {
+		if !fee.Amount().IsPositive() {
+			continue
+		}
+		merchant = append(merchant, Posting{Account: FeeAccount(fee.Code()), Side: merchantSide, Amount: fee.Amount()})
+
+		var err error
+		if gross, err = gross.Add(fee.Amount()); err != nil {
+			return nil, err
+		}
+	}
+
+	postings := []Posting{{Account: WalletAccount(userID), Side: walletSide, Amount: walletMoney}}
+	if !walletMoney.Currency().Equals(amount.Currency()) {
+		postings = append(postings,
+			Posting{Account: FXAccount, Side: merchantSide, Amount: walletMoney},
+			Posting{Account: FXAccount, Side: walletSide, Amount: gross},
+		)
+	}
+	return append(postings, merchant...), nil
+}
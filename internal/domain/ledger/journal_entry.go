@@ -0,0 +1,182 @@
+package ledger
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+)
+
+// Side is the side of an account a posting goes to
+type Side string
+
+const (
+	Debit  Side = "DEBIT"
+	Credit Side = "CREDIT"
+)
+
+// Opposite returns the other side
+func (s Side) Opposite() Side {
+	if s == Debit {
+		return Credit
+	}
+	return Debit
+}
+
+func (s Side) String() string {
+	return string(s)
+}
+
+// EntryType tells which movement a journal entry records
+type EntryType string
+
+const (
+	EntryCapture      EntryType = "CAPTURE"      // a payment leaves the wallet
+	EntryRefund       EntryType = "REFUND"       // a merchant refund comes back to the wallet
+	EntryCompensation EntryType = "COMPENSATION" // a payment debited before holds existed is given back
+	EntryTopUp        EntryType = "TOP_UP"
+	EntryOpening      EntryType = "OPENING" // a balance that predates the ledger
+)
+
+func (t EntryType) String() string {
+	return string(t)
+}
+
+// Posting is one line of a journal entry: an amount on one side of an account
+type Posting struct {
+	Account AccountID
+	Side    Side
+	Amount  vo.Money
+}
+
+// JournalEntry records a movement as postings whose debits and credits are equal in every currency
+// Entry IDs are derived from what they record (the payment, the refund, the top-up), so the same
+// movement can never be posted twice
+type JournalEntry struct {
+	id        string
+	entryType EntryType
+	reference string // payment, refund or top-up the entry records
+	postings  []Posting
+	postedAt  time.Time
+}
+
+// NewJournalEntry creates a JournalEntry, checking that it balances
+func NewJournalEntry(id string, entryType EntryType, reference string, postings []Posting) (*JournalEntry, error) {
+	if id == "" {
+		return nil, errors.New("entry ID is required")
+	}
+	if entryType == "" {
+		return nil, errors.New("entry type is required")
+	}
+	if err := validatePostings(postings); err != nil {
+		return nil, fmt.Errorf("entry %s: %w", id, err)
+	}
+
+	return &JournalEntry{
+		id:        id,
+		entryType: entryType,
+		reference: reference,
+		postings:  copyPostings(postings),
+		postedAt:  time.Now().UTC(),
+	}, nil
+}
+
+// Getters
+
+func (e *JournalEntry) ID() string {
+	return e.id
+}
+
+func (e *JournalEntry) Type() EntryType {
+	return e.entryType
+}
+
+func (e *JournalEntry) Reference() string {
+	return e.reference
+}
+
+func (e *JournalEntry) Postings() []Posting {
+	return copyPostings(e.postings)
+}
+
+func (e *JournalEntry) PostedAt() time.Time {
+	return e.postedAt
+}
+
+// Touches reports whether the entry posts to an account
+func (e *JournalEntry) Touches(account AccountID) bool {
+	for _, posting := range e.postings {
+		if posting.Account == account {
+			return true
+		}
+	}
+	return false
+}
+
+// ReconstructJournalEntry reconstructs a JournalEntry from persistence
+func ReconstructJournalEntry(id string, entryType EntryType, reference string, postings []Posting, postedAt time.Time) *JournalEntry {
+	return &JournalEntry{
+		id:        id,
+		entryType: entryType,
+		reference: reference,
+		postings:  copyPostings(postings),
+		postedAt:  postedAt,
+	}
+}
+
+// Balances sums postings per currency code as credits minus debits, which is how wallet
+// accounts are read: credits are what the platform owes the user
+func Balances(postings []Posting) map[string]decimal.Decimal {
+	balances := make(map[string]decimal.Decimal)
+	for _, posting := range postings {
+		code := posting.Amount.Currency().Code()
+		amount := posting.Amount.Amount()
+		if posting.Side == Debit {
+			amount = amount.Neg()
+		}
+		balances[code] = balances[code].Add(amount)
+	}
+	return balances
+}
+
+// validatePostings checks every posting and that each currency balances on its own
+func validatePostings(postings []Posting) error {
+	if len(postings) < 2 {
+		return errors.New("an entry needs at least two postings")
+	}
+
+	for _, posting := range postings {
+		if posting.Account == "" {
+			return errors.New("posting account is required")
+		}
+		if posting.Side != Debit && posting.Side != Credit {
+			return fmt.Errorf("invalid posting side %q", posting.Side)
+		}
+		if !posting.Amount.IsPositive() {
+			return fmt.Errorf("posting to %s must be positive", posting.Account)
+		}
+	}
+
+	balances := Balances(postings)
+	codes := make([]string, 0, len(balances))
+	for code := range balances {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+
+	for _, code := range codes {
+		if !balances[code].IsZero() {
+			return fmt.Errorf("debits and credits differ by %s %s", balances[code].Abs().String(), code)
+		}
+	}
+	return nil
+}
+
+func copyPostings(postings []Posting) []Posting {
+	copied := make([]Posting, len(postings))
+	copy(copied, postings)
+	return copied
+}
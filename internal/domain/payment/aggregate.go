@@ -2,8 +2,11 @@ package payment
 
 import (
 	"errors"
+	"fmt"
 	"time"
 
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
 	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 )
 
@@ -17,8 +20,11 @@ type Payment struct {
 	idempotencyKey vo.IdempotencyKey
 
 	// Value Objects
-	money  vo.Money
-	status vo.PaymentStatus
+	money          vo.Money
+	status         vo.PaymentStatus
+	refundedAmount vo.Money       // cumulative merchant refunds, zero until the first one
+	fees           []vo.FeeLine   // charged on top of money, in its currency
+	conversion     *vo.Conversion // set when the wallet pays in another currency
 
 	// Optional fields
 	failureReason string
@@ -63,6 +69,7 @@ func NewPayment(
 		money:          money,
 		idempotencyKey: idempotencyKey,
 		status:         vo.PaymentStatusPending,
+		refundedAmount: vo.Zero(money.Currency()),
 		createdAt:      now,
 		updatedAt:      now,
 	}, nil
@@ -86,10 +93,93 @@ func (p *Payment) Money() vo.Money {
 	return p.money
 }
 
+// Conversion returns the exchange the wallet pays with, nil for same-currency payments
+func (p *Payment) Conversion() *vo.Conversion {
+	return p.conversion
+}
+
+// Fees returns the fee lines charged on top of the payment amount
+func (p *Payment) Fees() []vo.FeeLine {
+	fees := make([]vo.FeeLine, len(p.fees))
+	copy(fees, p.fees)
+	return fees
+}
+
+// FeeTotal returns the sum of the fees, zero when the payment has none
+func (p *Payment) FeeTotal() vo.Money {
+	total, err := vo.SumFees(p.fees, p.money.Currency())
+	if err != nil {
+		return vo.Zero(p.money.Currency())
+	}
+	return total
+}
+
+// TotalMoney returns what the user pays in the payment currency: the amount plus its fees
+func (p *Payment) TotalMoney() vo.Money {
+	total, err := p.money.Add(p.FeeTotal())
+	if err != nil {
+		return p.money
+	}
+	return total
+}
+
+// FundingMoney returns what the wallet is debited: the total with fees, converted when the
+// payment is funded from another currency
+func (p *Payment) FundingMoney() vo.Money {
+	total := p.TotalMoney()
+	if p.conversion == nil {
+		return total
+	}
+
+	source, err := p.conversion.SourceFor(total)
+	if err != nil {
+		return p.conversion.Source()
+	}
+	return source
+}
+
+// FeeDetails returns the fee lines in event form, nil when the payment has none
+func (p *Payment) FeeDetails() []shared.FeeDetail {
+	return FeeDetailsOf(p.fees)
+}
+
+// FXDetails returns the conversion in event form, nil for same-currency payments
+func (p *Payment) FXDetails() *shared.FXDetails {
+	if p.conversion == nil {
+		return nil
+	}
+	return &shared.FXDetails{
+		QuoteID:        p.conversion.QuoteID(),
+		Rate:           p.conversion.Rate(),
+		Spread:         p.conversion.Spread(),
+		SourceAmount:   p.conversion.Source().Amount(),
+		SourceCurrency: p.conversion.Source().Currency().Code(),
+		TargetAmount:   p.conversion.Target().Amount(),
+		TargetCurrency: p.conversion.Target().Currency().Code(),
+	}
+}
+
 func (p *Payment) Status() vo.PaymentStatus {
 	return p.status
 }
 
+// RefundedAmount returns how much of the payment has been refunded so far
+func (p *Payment) RefundedAmount() vo.Money {
+	return p.refundedAmount
+}
+
+// RefundableAmount returns how much can still be refunded, zero unless the payment completed
+func (p *Payment) RefundableAmount() vo.Money {
+	if !p.status.IsRefundable() {
+		return vo.Zero(p.money.Currency())
+	}
+	remaining, err := p.money.Subtract(p.refundedAmount)
+	if err != nil {
+		return vo.Zero(p.money.Currency())
+	}
+	return remaining
+}
+
 func (p *Payment) IdempotencyKey() vo.IdempotencyKey {
 	return p.idempotencyKey
 }
@@ -112,6 +202,56 @@ func (p *Payment) UpdatedAt() time.Time {
 
 // Domain Behaviors (protected state transitions)
 
+// ApplyFees charges fee lines on top of a pending payment
+func (p *Payment) ApplyFees(fees []vo.FeeLine) error {
+	if !p.status.IsPending() {
+		return errors.New("fees can only be applied to pending payments")
+	}
+
+	codes := make(map[string]bool, len(fees))
+	for _, fee := range fees {
+		if !fee.Amount().Currency().Equals(p.money.Currency()) {
+			return domerrors.CurrencyMismatchError(p.money.Currency().String(), fee.Amount().Currency().String())
+		}
+		if codes[fee.Code()] {
+			return fmt.Errorf("fee %s charged twice", fee.Code())
+		}
+		codes[fee.Code()] = true
+	}
+
+	p.fees = make([]vo.FeeLine, len(fees))
+	copy(p.fees, fees)
+	return nil
+}
+
+// ApplyConversion funds a pending payment from another currency at a locked rate
+func (p *Payment) ApplyConversion(conversion vo.Conversion) error {
+	if !p.status.IsPending() {
+		return errors.New("only pending payments can be converted")
+	}
+	if !conversion.Target().Equals(p.money) {
+		return fmt.Errorf("conversion is for %s, payment is %s", conversion.Target().String(), p.money.String())
+	}
+
+	p.conversion = &conversion
+	return nil
+}
+
+// FailureReasonTimeout is the failure reason of payments the gateway never answered in time
+const FailureReasonTimeout = "TIMEOUT"
+
+// MarkProcessing records that the funds are held and the payment went to the gateway
+func (p *Payment) MarkProcessing() error {
+	if err := p.status.ValidateTransition(vo.PaymentStatusProcessing); err != nil {
+		return err
+	}
+
+	p.status = vo.PaymentStatusProcessing
+	p.updatedAt = time.Now().UTC()
+
+	return nil
+}
+
 // MarkCompleted transitions the payment to completed status
 func (p *Payment) MarkCompleted(externalTxID string) error {
 	// Validate state transition
@@ -131,10 +271,23 @@ func (p *Payment) MarkCompleted(externalTxID string) error {
 	return nil
 }
 
-// MarkFailed transitions the payment to failed status
+// Reject refuses a payment before any funds were held, so there is nothing to compensate
+func (p *Payment) Reject(reason string) error {
+	return p.fail(vo.PaymentStatusRejected, reason)
+}
+
+// MarkFailed transitions a processing payment to failed status
+// A timeout marks it expired instead, since the gateway never gave an answer
 func (p *Payment) MarkFailed(reason string) error {
+	if reason == FailureReasonTimeout {
+		return p.fail(vo.PaymentStatusExpired, reason)
+	}
+	return p.fail(vo.PaymentStatusFailed, reason)
+}
+
+func (p *Payment) fail(target vo.PaymentStatus, reason string) error {
 	// Validate state transition
-	if err := p.status.ValidateTransition(vo.PaymentStatusFailed); err != nil {
+	if err := p.status.ValidateTransition(target); err != nil {
 		return err
 	}
 
@@ -143,13 +296,135 @@ func (p *Payment) MarkFailed(reason string) error {
 	}
 
 	// Apply state change
-	p.status = vo.PaymentStatusFailed
+	p.status = target
 	p.failureReason = reason
 	p.updatedAt = time.Now().UTC()
 
 	return nil
 }
 
+// Cancel aborts a payment on behalf of the client before the gateway settled it
+// Funds already held for it are released by the saga compensation, not here
+func (p *Payment) Cancel(reason string) error {
+	if !p.status.CanTransitionTo(vo.PaymentStatusCancelled) {
+		return domerrors.PaymentNotCancellableError(p.id.String(), p.status.String())
+	}
+	return p.fail(vo.PaymentStatusCancelled, reason)
+}
+
+// Refund gives back part or all of a completed payment
+// Refunds accumulate: the payment is PARTIALLY_REFUNDED until the whole amount is refunded
+func (p *Payment) Refund(amount vo.Money) error {
+	if !p.status.IsRefundable() {
+		return domerrors.PaymentNotRefundableError(p.id.String(), p.status.String())
+	}
+	if !amount.IsPositive() {
+		return domerrors.NewDomainError(domerrors.ErrCodeInvalidAmount, "refund amount must be greater than zero")
+	}
+	if !amount.Currency().Equals(p.money.Currency()) {
+		return domerrors.CurrencyMismatchError(p.money.Currency().String(), amount.Currency().String())
+	}
+
+	refundable := p.RefundableAmount()
+	exceeds, err := amount.IsGreaterThan(refundable)
+	if err != nil {
+		return err
+	}
+	if exceeds {
+		return domerrors.RefundExceedsAmountError(p.id.String(), amount.String(), refundable.String())
+	}
+
+	refunded, err := p.refundedAmount.Add(amount)
+	if err != nil {
+		return err
+	}
+
+	target := vo.PaymentStatusPartiallyRefunded
+	if refunded.Equals(p.money) {
+		target = vo.PaymentStatusRefunded
+	}
+	if err := p.status.ValidateTransition(target); err != nil {
+		return err
+	}
+
+	p.status = target
+	p.refundedAmount = refunded
+	p.updatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// RefundedFees returns the fees given back by the refunds that took the refunded total from
+// previous to the current one, according to each fee's refund policy
+func (p *Payment) RefundedFees(previous vo.Money) ([]vo.FeeLine, error) {
+	lines := make([]vo.FeeLine, 0, len(p.fees))
+	for _, fee := range p.fees {
+		before, err := fee.RefundedAt(previous, p.money)
+		if err != nil {
+			return nil, err
+		}
+		after, err := fee.RefundedAt(p.refundedAmount, p.money)
+		if err != nil {
+			return nil, err
+		}
+		given, err := after.Subtract(before)
+		if err != nil {
+			return nil, err
+		}
+		if !given.IsPositive() {
+			continue
+		}
+
+		line, err := vo.NewFeeLine(fee.Code(), given, fee.RefundPolicy())
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// RefundCredit returns what the wallet gets back for the refunds between two cumulative refunded
+// totals, fees included, in the funding currency
+// Both totals are converted and subtracted, so partial refunds never round past what the wallet paid
+func (p *Payment) RefundCredit(previous, refunded vo.Money) (vo.Money, error) {
+	before, err := p.refundGross(previous)
+	if err != nil {
+		return vo.Money{}, err
+	}
+	after, err := p.refundGross(refunded)
+	if err != nil {
+		return vo.Money{}, err
+	}
+
+	if p.conversion != nil {
+		if before, err = p.conversion.SourceFor(before); err != nil {
+			return vo.Money{}, err
+		}
+		if after, err = p.conversion.SourceFor(after); err != nil {
+			return vo.Money{}, err
+		}
+	}
+
+	return after.Subtract(before)
+}
+
+// refundGross returns what the user has got back once refunded has been refunded: the amount
+// plus the fees it gives back, in the payment currency
+func (p *Payment) refundGross(refunded vo.Money) (vo.Money, error) {
+	gross := refunded
+	for _, fee := range p.fees {
+		given, err := fee.RefundedAt(refunded, p.money)
+		if err != nil {
+			return vo.Money{}, err
+		}
+		if gross, err = gross.Add(given); err != nil {
+			return vo.Money{}, err
+		}
+	}
+	return gross, nil
+}
+
 // Query methods
 
 // IsPending checks if payment is in pending status
@@ -162,6 +437,11 @@ func (p *Payment) IsCompleted() bool {
 	return p.status.IsCompleted()
 }
 
+// IsProcessing checks if the payment is waiting for the gateway with its funds held
+func (p *Payment) IsProcessing() bool {
+	return p.status.IsProcessing()
+}
+
 // IsFailed checks if payment has failed
 func (p *Payment) IsFailed() bool {
 	return p.status.IsFailed()
@@ -172,10 +452,10 @@ func (p *Payment) IsTerminal() bool {
 	return p.status.IsTerminal()
 }
 
-// CanBeRefunded checks if this payment can be refunded
-// Business rule: Only failed payments that had wallet debit can be refunded
+// CanBeRefunded checks if a merchant refund is still possible
+// Business rule: only completed payments with something left to refund
 func (p *Payment) CanBeRefunded() bool {
-	return p.status.IsFailed()
+	return p.RefundableAmount().IsPositive()
 }
 
 // CanBeProcessed checks if payment can be processed
@@ -194,6 +474,9 @@ func ReconstructPayment(
 	money vo.Money,
 	idempotencyKey vo.IdempotencyKey,
 	status vo.PaymentStatus,
+	refundedAmount vo.Money,
+	fees []vo.FeeLine,
+	conversion *vo.Conversion,
 	failureReason string,
 	externalTxID string,
 	createdAt time.Time,
@@ -206,9 +489,47 @@ func ReconstructPayment(
 		money:          money,
 		idempotencyKey: idempotencyKey,
 		status:         status,
+		refundedAmount: refundedAmount,
+		fees:           fees,
+		conversion:     conversion,
 		failureReason:  failureReason,
 		externalTxID:   externalTxID,
 		createdAt:      createdAt,
 		updatedAt:      updatedAt,
 	}
 }
+
+// FeeDetailsOf converts fee lines to their event form, nil when there are none
+func FeeDetailsOf(fees []vo.FeeLine) []shared.FeeDetail {
+	if len(fees) == 0 {
+		return nil
+	}
+
+	details := make([]shared.FeeDetail, 0, len(fees))
+	for _, fee := range fees {
+		details = append(details, shared.FeeDetail{
+			Code:         fee.Code(),
+			Amount:       fee.Amount().Amount(),
+			Currency:     fee.Amount().Currency().Code(),
+			RefundPolicy: fee.RefundPolicy().String(),
+		})
+	}
+	return details
+}
+
+// FeeLinesOf rebuilds fee lines from their event form, in the payment currency
+func FeeLinesOf(details []shared.FeeDetail, currency vo.Currency) ([]vo.FeeLine, error) {
+	fees := make([]vo.FeeLine, 0, len(details))
+	for _, detail := range details {
+		amount, err := vo.NewMoney(detail.Amount, currency)
+		if err != nil {
+			return nil, err
+		}
+		fee, err := vo.NewFeeLine(detail.Code, amount, vo.FeeRefundPolicy(detail.RefundPolicy))
+		if err != nil {
+			return nil, err
+		}
+		fees = append(fees, fee)
+	}
+	return fees, nil
+}
@@ -1,13 +1,16 @@
 package payment
 
-import "github.com/franco/payment-api/internal/domain/shared"
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
 
 // ExternalPaymentRequestedEvent is emitted when a payment is sent to external gateway
 type ExternalPaymentRequestedEvent struct {
 	shared.BaseEvent
 	paymentID string
 	userID    string
-	amount    float64
+	amount    decimal.Decimal
 	currency  string
 	serviceID string
 }
@@ -15,7 +18,7 @@ type ExternalPaymentRequestedEvent struct {
 // NewExternalPaymentRequestedEvent creates a new ExternalPaymentRequestedEvent
 func NewExternalPaymentRequestedEvent(
 	paymentID, userID string,
-	amount float64,
+	amount decimal.Decimal,
 	currency, serviceID string,
 	metadata shared.Metadata,
 ) *ExternalPaymentRequestedEvent {
@@ -37,7 +40,7 @@ func (e *ExternalPaymentRequestedEvent) UserID() string {
 	return e.userID
 }
 
-func (e *ExternalPaymentRequestedEvent) Amount() float64 {
+func (e *ExternalPaymentRequestedEvent) Amount() decimal.Decimal {
 	return e.amount
 }
 
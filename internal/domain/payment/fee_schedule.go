@@ -0,0 +1,210 @@
+package payment
+
+import (
+	"fmt"
+	"strings"
+
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+)
+
+// feeWildcard matches any service ID; a trailing wildcard makes a prefix ("telecom-*")
+const feeWildcard = "*"
+
+// FeeRule prices one fee for the payments it matches
+// Amounts (band, fixed, caps) are in Currency, so a rule that sets any of them must fix the currency
+type FeeRule struct {
+	Code         string             // fee line name, e.g. "service_fee"
+	ServiceID    string             // exact ID, prefix ending in "*" or "*" for any service
+	ClientID     string             // empty for any client
+	Currency     string             // ISO 4217 code, empty for any currency
+	MinAmount    decimal.Decimal    // amount band start, inclusive
+	MaxAmount    decimal.Decimal    // amount band end, exclusive; zero for no upper bound
+	Fixed        decimal.Decimal    // flat part of the fee
+	Percentage   decimal.Decimal    // fraction of the amount, 0.015 is 1.5%
+	MinFee       decimal.Decimal    // floor, zero for none
+	MaxFee       decimal.Decimal    // cap, zero for none
+	RefundPolicy vo.FeeRefundPolicy // empty uses the schedule default
+}
+
+// FeeSchedule computes the fees of a payment from a table of rules
+// Every fee code is priced by the most specific rule matching the payment; codes nothing matches are not charged
+type FeeSchedule struct {
+	rules        []FeeRule
+	refundPolicy vo.FeeRefundPolicy
+}
+
+// NewFeeSchedule creates a FeeSchedule, checking every rule
+// refundPolicy applies to the fees of rules that do not set their own
+func NewFeeSchedule(rules []FeeRule, refundPolicy vo.FeeRefundPolicy) (*FeeSchedule, error) {
+	if _, err := vo.ParseFeeRefundPolicy(refundPolicy.String()); err != nil {
+		return nil, err
+	}
+
+	for i, rule := range rules {
+		if err := rule.validate(); err != nil {
+			return nil, fmt.Errorf("fee rule %d (%s): %w", i, rule.Code, err)
+		}
+	}
+
+	return &FeeSchedule{rules: rules, refundPolicy: refundPolicy}, nil
+}
+
+// NewEmptyFeeSchedule creates a FeeSchedule that charges no fees
+func NewEmptyFeeSchedule() *FeeSchedule {
+	return &FeeSchedule{refundPolicy: vo.FeeRefundNever}
+}
+
+// Evaluate returns the fee lines for a payment, in the order their codes first appear in the table
+// A rule for the exact service beats a prefix and a longer prefix a shorter one; between equally
+// specific services, one for the client beats one for any client, then one for the currency
+func (s *FeeSchedule) Evaluate(serviceID, clientID string, amount vo.Money) ([]vo.FeeLine, error) {
+	best := make(map[string]*FeeRule)
+	bestScore := make(map[string]int)
+	codes := make([]string, 0)
+
+	for i := range s.rules {
+		rule := &s.rules[i]
+		score := rule.matchScore(serviceID, clientID, amount)
+		if score < 0 {
+			continue
+		}
+
+		current, seen := bestScore[rule.Code]
+		if !seen {
+			codes = append(codes, rule.Code)
+		}
+		if !seen || score > current {
+			best[rule.Code], bestScore[rule.Code] = rule, score
+		}
+	}
+
+	lines := make([]vo.FeeLine, 0, len(codes))
+	for _, code := range codes {
+		line, err := s.price(best[code], amount)
+		if err != nil {
+			return nil, err
+		}
+		// Fees priced at zero (e.g. a free band) are not charged at all
+		if line.Amount().IsPositive() {
+			lines = append(lines, line)
+		}
+	}
+	return lines, nil
+}
+
+// price computes the fee of a rule: fixed plus percentage, kept within the caps
+func (s *FeeSchedule) price(rule *FeeRule, amount vo.Money) (vo.FeeLine, error) {
+	fee := rule.Fixed.Add(amount.Amount().Mul(rule.Percentage))
+	if rule.MinFee.IsPositive() && fee.LessThan(rule.MinFee) {
+		fee = rule.MinFee
+	}
+	if rule.MaxFee.IsPositive() && fee.GreaterThan(rule.MaxFee) {
+		fee = rule.MaxFee
+	}
+
+	money, err := vo.NewMoneyRounded(fee, amount.Currency())
+	if err != nil {
+		return vo.FeeLine{}, err
+	}
+
+	policy := rule.RefundPolicy
+	if policy == "" {
+		policy = s.refundPolicy
+	}
+	return vo.NewFeeLine(rule.Code, money, policy)
+}
+
+func (r FeeRule) validate() error {
+	if r.Code == "" {
+		return fmt.Errorf("code is required")
+	}
+	if r.ServiceID == "" {
+		return fmt.Errorf("serviceId is required")
+	}
+	if strings.Contains(strings.TrimSuffix(r.ServiceID, feeWildcard), feeWildcard) {
+		return fmt.Errorf("'*' is only allowed at the end of serviceId")
+	}
+
+	for _, field := range []struct {
+		name  string
+		value decimal.Decimal
+	}{
+		{"minAmount", r.MinAmount},
+		{"maxAmount", r.MaxAmount},
+		{"fixed", r.Fixed},
+		{"percentage", r.Percentage},
+		{"minFee", r.MinFee},
+		{"maxFee", r.MaxFee},
+	} {
+		if field.value.IsNegative() {
+			return fmt.Errorf("%s cannot be negative", field.name)
+		}
+	}
+
+	if r.Currency == "" {
+		if !r.MinAmount.IsZero() || !r.MaxAmount.IsZero() || !r.Fixed.IsZero() || !r.MinFee.IsZero() || !r.MaxFee.IsZero() {
+			return fmt.Errorf("currency is required for amount bands, fixed fees and caps")
+		}
+	} else if _, err := vo.NewCurrency(r.Currency); err != nil {
+		return err
+	}
+
+	if r.MaxAmount.IsPositive() && !r.MaxAmount.GreaterThan(r.MinAmount) {
+		return fmt.Errorf("maxAmount must be greater than minAmount")
+	}
+	if r.MaxFee.IsPositive() && r.MaxFee.LessThan(r.MinFee) {
+		return fmt.Errorf("maxFee cannot be lower than minFee")
+	}
+	if r.RefundPolicy != "" {
+		if _, err := vo.ParseFeeRefundPolicy(r.RefundPolicy.String()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matchScore returns how specific the rule is for a payment, or -1 when it does not apply
+func (r FeeRule) matchScore(serviceID, clientID string, amount vo.Money) int {
+	if r.Currency != "" && !strings.EqualFold(r.Currency, amount.Currency().Code()) {
+		return -1
+	}
+	if r.ClientID != "" && r.ClientID != clientID {
+		return -1
+	}
+	if amount.Amount().LessThan(r.MinAmount) {
+		return -1
+	}
+	if r.MaxAmount.IsPositive() && !amount.Amount().LessThan(r.MaxAmount) {
+		return -1
+	}
+
+	score := serviceMatchScore(r.ServiceID, serviceID)
+	if score < 0 {
+		return -1
+	}
+	score *= 4
+	if r.ClientID != "" {
+		score += 2
+	}
+	if r.Currency != "" {
+		score++
+	}
+	return score
+}
+
+// serviceMatchScore returns how specific pattern is for serviceID, or -1 when it does not match
+func serviceMatchScore(pattern, serviceID string) int {
+	if prefix, ok := strings.CutSuffix(pattern, feeWildcard); ok {
+		if !strings.HasPrefix(serviceID, prefix) {
+			return -1
+		}
+		return len(prefix)
+	}
+
+	if pattern != serviceID {
+		return -1
+	}
+	// Above any prefix, which is at most as long as the ID itself
+	return len(serviceID) + 1
+}
@@ -0,0 +1,53 @@
+package payment
+
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentCancelledEvent is emitted when the client cancels a payment, always before its funds were held
+type PaymentCancelledEvent struct {
+	shared.BaseEvent
+	paymentID string
+	userID    string
+	amount    decimal.Decimal
+	currency  string
+	reason    string
+}
+
+// NewPaymentCancelledEvent creates a new PaymentCancelledEvent
+func NewPaymentCancelledEvent(
+	paymentID, userID string,
+	amount decimal.Decimal,
+	currency, reason string,
+	metadata shared.Metadata,
+) *PaymentCancelledEvent {
+	return &PaymentCancelledEvent{
+		BaseEvent: shared.NewBaseEvent("PaymentCancelled", metadata),
+		paymentID: paymentID,
+		userID:    userID,
+		amount:    amount,
+		currency:  currency,
+		reason:    reason,
+	}
+}
+
+func (e *PaymentCancelledEvent) PaymentID() string {
+	return e.paymentID
+}
+
+func (e *PaymentCancelledEvent) UserID() string {
+	return e.userID
+}
+
+func (e *PaymentCancelledEvent) Amount() decimal.Decimal {
+	return e.amount
+}
+
+func (e *PaymentCancelledEvent) Currency() string {
+	return e.currency
+}
+
+func (e *PaymentCancelledEvent) Reason() string {
+	return e.reason
+}
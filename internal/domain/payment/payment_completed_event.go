@@ -1,21 +1,25 @@
 package payment
 
-import "github.com/franco/payment-api/internal/domain/shared"
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
 
 // PaymentCompletedEvent is emitted when a payment is successfully completed
 type PaymentCompletedEvent struct {
 	shared.BaseEvent
 	paymentID             string
 	userID                string
-	amount                float64
+	amount                decimal.Decimal
+	currency              string
 	externalTransactionID string
 }
 
 // NewPaymentCompletedEvent creates a new PaymentCompletedEvent
 func NewPaymentCompletedEvent(
 	paymentID, userID string,
-	amount float64,
-	externalTransactionID string,
+	amount decimal.Decimal,
+	currency, externalTransactionID string,
 	metadata shared.Metadata,
 ) *PaymentCompletedEvent {
 	return &PaymentCompletedEvent{
@@ -23,6 +27,7 @@ func NewPaymentCompletedEvent(
 		paymentID:             paymentID,
 		userID:                userID,
 		amount:                amount,
+		currency:              currency,
 		externalTransactionID: externalTransactionID,
 	}
 }
@@ -35,10 +40,14 @@ func (e *PaymentCompletedEvent) UserID() string {
 	return e.userID
 }
 
-func (e *PaymentCompletedEvent) Amount() float64 {
+func (e *PaymentCompletedEvent) Amount() decimal.Decimal {
 	return e.amount
 }
 
+func (e *PaymentCompletedEvent) Currency() string {
+	return e.currency
+}
+
 func (e *PaymentCompletedEvent) ExternalTransactionID() string {
 	return e.externalTransactionID
 }
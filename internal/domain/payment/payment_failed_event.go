@@ -1,21 +1,25 @@
 package payment
 
-import "github.com/franco/payment-api/internal/domain/shared"
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
 
 // PaymentFailedEvent is emitted when a payment fails
 type PaymentFailedEvent struct {
 	shared.BaseEvent
 	paymentID string
 	userID    string
-	amount    float64
+	amount    decimal.Decimal
+	currency  string
 	reason    string
 }
 
 // NewPaymentFailedEvent creates a new PaymentFailedEvent
 func NewPaymentFailedEvent(
 	paymentID, userID string,
-	amount float64,
-	reason string,
+	amount decimal.Decimal,
+	currency, reason string,
 	metadata shared.Metadata,
 ) *PaymentFailedEvent {
 	return &PaymentFailedEvent{
@@ -23,6 +27,7 @@ func NewPaymentFailedEvent(
 		paymentID: paymentID,
 		userID:    userID,
 		amount:    amount,
+		currency:  currency,
 		reason:    reason,
 	}
 }
@@ -35,10 +40,14 @@ func (e *PaymentFailedEvent) UserID() string {
 	return e.userID
 }
 
-func (e *PaymentFailedEvent) Amount() float64 {
+func (e *PaymentFailedEvent) Amount() decimal.Decimal {
 	return e.amount
 }
 
+func (e *PaymentFailedEvent) Currency() string {
+	return e.currency
+}
+
 func (e *PaymentFailedEvent) Reason() string {
 	return e.reason
 }
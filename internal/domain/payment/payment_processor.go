@@ -3,6 +3,7 @@ package payment
 import (
 	"errors"
 
+	"github.com/franco/payment-api/internal/domain/ledger"
 	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 	"github.com/franco/payment-api/internal/domain/wallet"
 )
@@ -17,15 +18,19 @@ func NewProcessor() *Processor {
 }
 
 // ProcessResult contains the result of processing a payment
+// A successful process only reserves the funds; the balance moves when the hold is captured
 type ProcessResult struct {
-	Success         bool
-	FailureReason   string
-	WalletDebited   bool
-	PreviousBalance vo.Money
-	NewBalance      vo.Money
+	Success           bool
+	FailureReason     string
+	FundsHeld         bool
+	Held              vo.Money // reserved for this payment, in the currency the wallet pays with
+	PreviousAvailable vo.Money
+	NewAvailable      vo.Money
+	HeldBalance       vo.Money // total held in that currency, this payment included
 }
 
-// Process validates and processes a payment against a wallet
+// Process validates a payment against a wallet and authorizes it by holding the funds
+// A converted payment holds the source amount of its locked conversion, otherwise the payment amount
 // This is the core business logic for payment processing
 func (p *Processor) Process(
 	pmt *Payment,
@@ -41,46 +46,115 @@ func (p *Processor) Process(
 		return &ProcessResult{
 			Success:       false,
 			FailureReason: "USER_MISMATCH",
-			WalletDebited: false,
-		}, nil
-	}
-
-	// Business Rule 3: Currencies must match
-	if !wlt.Balance().Currency().Equals(pmt.Money().Currency()) {
-		return &ProcessResult{
-			Success:       false,
-			FailureReason: "CURRENCY_MISMATCH",
-			WalletDebited: false,
 		}, nil
 	}
 
-	// Business Rule 4: Sufficient funds required
-	if !wlt.CanDebit(pmt.Money()) {
+	// Business Rule 3: Sufficient available funds in the funding currency, unless this payment already holds them
+	funding := pmt.FundingMoney()
+	if !wlt.HasHold(pmt.ID().String()) && !wlt.CanDebit(funding) {
 		return &ProcessResult{
 			Success:       false,
 			FailureReason: "INSUFFICIENT_FUNDS",
-			WalletDebited: false,
 		}, nil
 	}
 
-	// Business Rule 5: Debit wallet
-	prevBalance, newBalance, err := wlt.Debit(pmt.Money())
+	// Business Rule 4: Hold the funds until the gateway answers
+	prevAvailable, newAvailable, err := wlt.PlaceHold(pmt.ID().String(), funding)
 	if err != nil {
 		return &ProcessResult{
 			Success:       false,
-			FailureReason: "DEBIT_FAILED",
-			WalletDebited: false,
+			FailureReason: "HOLD_FAILED",
 		}, nil
 	}
 
 	return &ProcessResult{
-		Success:         true,
-		WalletDebited:   true,
+		Success:           true,
+		FundsHeld:         true,
+		Held:              funding,
+		PreviousAvailable: prevAvailable,
+		NewAvailable:      newAvailable,
+		HeldBalance:       wlt.HeldBalance(funding.Currency()),
+	}, nil
+}
+
+// CaptureResult contains the result of capturing a payment's hold
+// Captured is false when the payment had no hold left, e.g. it was already captured
+type CaptureResult struct {
+	Captured        bool
+	Amount          vo.Money // the hold that left the wallet
+	PreviousBalance vo.Money
+	NewBalance      vo.Money
+}
+
+// Capture settles the hold of a payment the gateway accepted, debiting the wallet
+// The debit is recorded on the ledger: the held funds go to settlement and to each fee's account
+func (p *Processor) Capture(
+	pmt *Payment,
+	wlt *wallet.Wallet,
+) (*CaptureResult, error) {
+	if !pmt.UserID().Equals(wlt.UserID()) {
+		return nil, errors.New("user ID mismatch: payment and wallet belong to different users")
+	}
+
+	if !wlt.HasHold(pmt.ID().String()) {
+		return &CaptureResult{Captured: false}, nil
+	}
+
+	hold, prevBalance, newBalance, err := wlt.CaptureHold(pmt.ID().String())
+	if err != nil {
+		return nil, err
+	}
+
+	entry, err := ledger.NewCaptureEntry(pmt.ID().String(), pmt.UserID().String(), hold.Amount, pmt.Money(), pmt.Fees())
+	if err != nil {
+		return nil, err
+	}
+	if err := wlt.RecordEntry(entry); err != nil {
+		return nil, err
+	}
+
+	return &CaptureResult{
+		Captured:        true,
+		Amount:          hold.Amount,
 		PreviousBalance: prevBalance,
 		NewBalance:      newBalance,
 	}, nil
 }
 
+// ReleaseResult contains the result of voiding a payment's hold
+// Released is false when the payment had no hold left, e.g. it was already released
+type ReleaseResult struct {
+	Released         bool
+	Amount           vo.Money // the hold given back
+	AvailableBalance vo.Money
+}
+
+// Release voids the hold of a payment that failed or timed out
+// This is the compensating transaction for authorized payments; the balance never moved
+func (p *Processor) Release(
+	pmt *Payment,
+	wlt *wallet.Wallet,
+) (*ReleaseResult, error) {
+	if !pmt.UserID().Equals(wlt.UserID()) {
+		return nil, errors.New("user ID mismatch: payment and wallet belong to different users")
+	}
+
+	if !wlt.HasHold(pmt.ID().String()) {
+		return &ReleaseResult{Released: false}, nil
+	}
+
+	hold, err := wlt.ReleaseHold(pmt.ID().String())
+	if err != nil {
+		return nil, err
+	}
+
+	return &ReleaseResult{
+		Released:         true,
+		Amount:           hold.Amount,
+		AvailableBalance: wlt.AvailableBalance(hold.Amount.Currency()),
+	}, nil
+}
+
 // RefundResult contains the result of a refund operation
 type RefundResult struct {
 	Success         bool
@@ -88,15 +162,15 @@ type RefundResult struct {
 	NewBalance      vo.Money
 }
 
-// Refund processes a refund by crediting the wallet
-// This implements the compensating transaction for failed payments
-func (p *Processor) Refund(
+// RefundDebit compensates a failed payment by crediting the wallet
+// Only payments debited before holds existed need it; newer ones are released instead
+func (p *Processor) RefundDebit(
 	pmt *Payment,
 	wlt *wallet.Wallet,
 ) (*RefundResult, error) {
-	// Business Rule 1: Can only refund payments that are eligible
-	if !pmt.CanBeRefunded() {
-		return nil, errors.New("payment cannot be refunded in current state")
+	// Business Rule 1: Only payments that failed or expired after authorization are compensated
+	if !pmt.Status().IsCompensable() {
+		return nil, errors.New("payment cannot be compensated in current state")
 	}
 
 	// Business Rule 2: User IDs must match
@@ -105,11 +179,19 @@ func (p *Processor) Refund(
 	}
 
 	// Business Rule 3: Credit the wallet
-	prevBalance, newBalance, err := wlt.Credit(pmt.Money())
+	prevBalance, newBalance, err := wlt.Credit(pmt.FundingMoney())
 	if err != nil {
 		return nil, err
 	}
 
+	entry, err := ledger.NewCompensationEntry(pmt.ID().String(), pmt.UserID().String(), pmt.FundingMoney())
+	if err != nil {
+		return nil, err
+	}
+	if err := wlt.RecordEntry(entry); err != nil {
+		return nil, err
+	}
+
 	return &RefundResult{
 		Success:         true,
 		PreviousBalance: prevBalance,
@@ -1,21 +1,25 @@
 package payment
 
-import "github.com/franco/payment-api/internal/domain/shared"
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
 
 // PaymentRefundRequestedEvent is emitted when a payment needs to be refunded
 type PaymentRefundRequestedEvent struct {
 	shared.BaseEvent
 	paymentID string
 	userID    string
-	amount    float64
+	amount    decimal.Decimal
+	currency  string
 	reason    string
 }
 
 // NewPaymentRefundRequestedEvent creates a new PaymentRefundRequestedEvent
 func NewPaymentRefundRequestedEvent(
 	paymentID, userID string,
-	amount float64,
-	reason string,
+	amount decimal.Decimal,
+	currency, reason string,
 	metadata shared.Metadata,
 ) *PaymentRefundRequestedEvent {
 	return &PaymentRefundRequestedEvent{
@@ -23,6 +27,7 @@ func NewPaymentRefundRequestedEvent(
 		paymentID: paymentID,
 		userID:    userID,
 		amount:    amount,
+		currency:  currency,
 		reason:    reason,
 	}
 }
@@ -35,10 +40,14 @@ func (e *PaymentRefundRequestedEvent) UserID() string {
 	return e.userID
 }
 
-func (e *PaymentRefundRequestedEvent) Amount() float64 {
+func (e *PaymentRefundRequestedEvent) Amount() decimal.Decimal {
 	return e.amount
 }
 
+func (e *PaymentRefundRequestedEvent) Currency() string {
+	return e.currency
+}
+
 func (e *PaymentRefundRequestedEvent) Reason() string {
 	return e.reason
 }
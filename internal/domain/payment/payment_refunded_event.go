@@ -0,0 +1,80 @@
+package payment
+
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
+
+// PaymentRefundedEvent is emitted when a merchant refunds part or all of a completed payment
+// The wallet consumer credits Amount back to the user, plus the fees the refund gives back
+type PaymentRefundedEvent struct {
+	shared.BaseEvent
+	paymentID     string
+	userID        string
+	refundID      string
+	amount        decimal.Decimal
+	refundedTotal decimal.Decimal
+	currency      string
+	reason        string
+	fees          []shared.FeeDetail
+}
+
+// NewPaymentRefundedEvent creates a new PaymentRefundedEvent
+func NewPaymentRefundedEvent(
+	paymentID, userID, refundID string,
+	amount, refundedTotal decimal.Decimal,
+	currency, reason string,
+	metadata shared.Metadata,
+) *PaymentRefundedEvent {
+	return &PaymentRefundedEvent{
+		BaseEvent:     shared.NewBaseEvent("PaymentRefunded", metadata),
+		paymentID:     paymentID,
+		userID:        userID,
+		refundID:      refundID,
+		amount:        amount,
+		refundedTotal: refundedTotal,
+		currency:      currency,
+		reason:        reason,
+	}
+}
+
+func (e *PaymentRefundedEvent) PaymentID() string {
+	return e.paymentID
+}
+
+func (e *PaymentRefundedEvent) UserID() string {
+	return e.userID
+}
+
+func (e *PaymentRefundedEvent) RefundID() string {
+	return e.refundID
+}
+
+// Amount is what this refund gives back
+func (e *PaymentRefundedEvent) Amount() decimal.Decimal {
+	return e.amount
+}
+
+// RefundedTotal is the cumulative refunded amount after this refund
+func (e *PaymentRefundedEvent) RefundedTotal() decimal.Decimal {
+	return e.refundedTotal
+}
+
+func (e *PaymentRefundedEvent) Currency() string {
+	return e.currency
+}
+
+func (e *PaymentRefundedEvent) Reason() string {
+	return e.reason
+}
+
+// WithFees records the fees this refund gives back
+func (e *PaymentRefundedEvent) WithFees(fees []shared.FeeDetail) *PaymentRefundedEvent {
+	e.fees = fees
+	return e
+}
+
+// Fees returns the fee lines given back, empty when the refund returns no fees
+func (e *PaymentRefundedEvent) Fees() []shared.FeeDetail {
+	return e.fees
+}
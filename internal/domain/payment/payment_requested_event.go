@@ -1,22 +1,27 @@
 package payment
 
-import "github.com/franco/payment-api/internal/domain/shared"
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
 
 // PaymentRequestedEvent is emitted when a new payment is requested
 type PaymentRequestedEvent struct {
 	shared.BaseEvent
 	paymentID      string
 	userID         string
-	amount         float64
+	amount         decimal.Decimal
 	currency       string
 	serviceID      string
 	idempotencyKey string
+	fx             *shared.FXDetails
+	fees           []shared.FeeDetail
 }
 
 // NewPaymentRequestedEvent creates a new PaymentRequestedEvent
 func NewPaymentRequestedEvent(
 	paymentID, userID string,
-	amount float64,
+	amount decimal.Decimal,
 	currency, serviceID, idempotencyKey string,
 	metadata shared.Metadata,
 ) *PaymentRequestedEvent {
@@ -39,7 +44,7 @@ func (e *PaymentRequestedEvent) UserID() string {
 	return e.userID
 }
 
-func (e *PaymentRequestedEvent) Amount() float64 {
+func (e *PaymentRequestedEvent) Amount() decimal.Decimal {
 	return e.amount
 }
 
@@ -54,3 +59,25 @@ func (e *PaymentRequestedEvent) ServiceID() string {
 func (e *PaymentRequestedEvent) IdempotencyKey() string {
 	return e.idempotencyKey
 }
+
+// WithFX records the conversion applied when the payment is funded from another currency
+func (e *PaymentRequestedEvent) WithFX(fx *shared.FXDetails) *PaymentRequestedEvent {
+	e.fx = fx
+	return e
+}
+
+// FX returns the conversion applied, nil when none was
+func (e *PaymentRequestedEvent) FX() *shared.FXDetails {
+	return e.fx
+}
+
+// WithFees records the fees charged on top of the payment amount
+func (e *PaymentRequestedEvent) WithFees(fees []shared.FeeDetail) *PaymentRequestedEvent {
+	e.fees = fees
+	return e
+}
+
+// Fees returns the fee lines, empty when the payment has none
+func (e *PaymentRequestedEvent) Fees() []shared.FeeDetail {
+	return e.fees
+}
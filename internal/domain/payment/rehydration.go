@@ -0,0 +1,181 @@
+package payment
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/franco/payment-api/internal/domain/shared"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+)
+
+// RehydratePayment rebuilds a Payment by replaying its event stream in order
+// The first event must be the PaymentRequested that created the payment
+func RehydratePayment(events []shared.Event) (*Payment, error) {
+	if len(events) == 0 {
+		return nil, errors.New("cannot rehydrate payment from an empty event stream")
+	}
+
+	requested, ok := events[0].(*PaymentRequestedEvent)
+	if !ok {
+		return nil, fmt.Errorf("event stream must start with PaymentRequested, got %s", events[0].EventType())
+	}
+
+	pmt, err := paymentFromRequested(requested)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, event := range events[1:] {
+		if err := pmt.Apply(event); err != nil {
+			return nil, fmt.Errorf("failed to apply %s: %w", event.EventType(), err)
+		}
+	}
+
+	return pmt, nil
+}
+
+// Apply mutates the aggregate with an event that already happened
+// Events that do not change payment state (wallet movements, gateway requests) are ignored
+// Refunds carry the cumulative total, so replaying one sets it rather than adding to it
+func (p *Payment) Apply(event shared.Event) error {
+	switch e := event.(type) {
+	case *PaymentRequestedEvent:
+		return errors.New("payment already created")
+
+	case *ExternalPaymentRequestedEvent:
+		// Sent once the funds are held
+		if p.status.CanTransitionTo(vo.PaymentStatusProcessing) {
+			p.status = vo.PaymentStatusProcessing
+			p.updatedAt = e.OccurredAt()
+		}
+
+	case *PaymentCompletedEvent:
+		if err := p.status.ValidateTransition(vo.PaymentStatusCompleted); err != nil {
+			return err
+		}
+		p.status = vo.PaymentStatusCompleted
+		p.externalTxID = e.ExternalTransactionID()
+		p.updatedAt = e.OccurredAt()
+
+	case *PaymentFailedEvent:
+		// Only payments refused before their funds were held end with PaymentFailed
+		if err := p.status.ValidateTransition(vo.PaymentStatusRejected); err != nil {
+			return err
+		}
+		p.status = vo.PaymentStatusRejected
+		p.failureReason = e.Reason()
+		p.updatedAt = e.OccurredAt()
+
+	case *PaymentCancelledEvent:
+		if err := p.status.ValidateTransition(vo.PaymentStatusCancelled); err != nil {
+			return err
+		}
+		p.status = vo.PaymentStatusCancelled
+		p.failureReason = e.Reason()
+		p.updatedAt = e.OccurredAt()
+
+	case *PaymentRefundRequestedEvent:
+		// The orchestrator marks the payment failed (or expired) right before requesting the
+		// refund, and keeps going when it is already terminal, so replay does the same.
+		target := vo.PaymentStatusFailed
+		if e.Reason() == FailureReasonTimeout {
+			target = vo.PaymentStatusExpired
+		}
+		if p.status.CanTransitionTo(target) {
+			p.status = target
+			p.failureReason = e.Reason()
+			p.updatedAt = e.OccurredAt()
+		}
+
+	case *PaymentRefundedEvent:
+		refunded, err := vo.NewMoney(e.RefundedTotal(), p.money.Currency())
+		if err != nil {
+			return err
+		}
+		target := vo.PaymentStatusPartiallyRefunded
+		if refunded.Equals(p.money) {
+			target = vo.PaymentStatusRefunded
+		}
+		if err := p.status.ValidateTransition(target); err != nil {
+			return err
+		}
+		p.status = target
+		p.refundedAmount = refunded
+		p.updatedAt = e.OccurredAt()
+	}
+
+	return nil
+}
+
+func paymentFromRequested(e *PaymentRequestedEvent) (*Payment, error) {
+	id, err := vo.NewPaymentID(e.PaymentID())
+	if err != nil {
+		return nil, err
+	}
+
+	userID, err := vo.NewUserID(e.UserID())
+	if err != nil {
+		return nil, err
+	}
+
+	serviceID, err := vo.NewServiceID(e.ServiceID())
+	if err != nil {
+		return nil, err
+	}
+
+	currency, err := vo.NewCurrency(e.Currency())
+	if err != nil {
+		return nil, err
+	}
+
+	money, err := vo.NewMoney(e.Amount(), currency)
+	if err != nil {
+		return nil, err
+	}
+
+	idempotencyKey, err := vo.NewIdempotencyKey(e.IdempotencyKey())
+	if err != nil {
+		return nil, err
+	}
+
+	pmt, err := NewPayment(id, userID, serviceID, money, idempotencyKey)
+	if err != nil {
+		return nil, err
+	}
+
+	fees, err := FeeLinesOf(e.Fees(), currency)
+	if err != nil {
+		return nil, err
+	}
+	if err := pmt.ApplyFees(fees); err != nil {
+		return nil, err
+	}
+
+	if e.FX() != nil {
+		conversion, err := conversionFromFX(e.FX(), money)
+		if err != nil {
+			return nil, err
+		}
+		pmt.conversion = &conversion
+	}
+
+	pmt.createdAt = e.OccurredAt()
+	pmt.updatedAt = e.OccurredAt()
+
+	return pmt, nil
+}
+
+// conversionFromFX rebuilds the conversion recorded on PaymentRequested
+func conversionFromFX(fx *shared.FXDetails, target vo.Money) (vo.Conversion, error) {
+	currency, err := vo.NewCurrency(fx.SourceCurrency)
+	if err != nil {
+		return vo.Conversion{}, err
+	}
+
+	source, err := vo.NewMoney(fx.SourceAmount, currency)
+	if err != nil {
+		return vo.Conversion{}, err
+	}
+
+	return vo.ReconstructConversion(fx.QuoteID, fx.Rate, fx.Spread, source, target), nil
+}
@@ -0,0 +1,297 @@
+package saga
+
+import (
+	"errors"
+	"time"
+
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// Step is the position of a payment in the hold → external → capture/release flow
+// Step values predate holds and are kept as stored; "debit" in them now means the hold
+type Step string
+
+const (
+	StepAwaitingDebit   Step = "AWAITING_DEBIT"   // PaymentRequested accepted, funds not held yet
+	StepAwaitingGateway Step = "AWAITING_GATEWAY" // funds held, waiting for the external gateway
+	StepCompensating    Step = "COMPENSATING"     // gateway failed or timed out, release requested
+	StepCompleted       Step = "COMPLETED"        // gateway confirmed the payment, hold captured
+	StepCompensated     Step = "COMPENSATED"      // hold released (or, for older payments, debit refunded)
+	StepFailed          Step = "FAILED"           // rejected before any hold, nothing to undo
+	StepCancelled       Step = "CANCELLED"        // cancelled by the client before any hold, nothing to undo
+)
+
+// EventPaymentCancelled is the event type recorded when the client cancels the payment
+const EventPaymentCancelled = "PaymentCancelled"
+
+// Compensation actions recorded on the saga
+const (
+	CompensationHoldRelease  = "HOLD_RELEASE"
+	CompensationWalletRefund = "WALLET_REFUND" // payments debited before holds existed
+)
+
+// transitions lists, per step, the events it accepts and the steps each event may lead to
+var transitions = map[Step]map[string][]Step{
+	StepAwaitingDebit: {
+		"PaymentRequested":    {StepAwaitingGateway, StepFailed},
+		EventPaymentCancelled: {StepCancelled},
+	},
+	StepAwaitingGateway: {
+		"ExternalPaymentSucceeded": {StepCompleted},
+		"ExternalPaymentFailed":    {StepCompensating},
+		"ExternalPaymentTimeout":   {StepCompensating},
+	},
+	StepCompensating: {
+		"PaymentRefundRequested": {StepCompensated},
+	},
+}
+
+// IsTerminal checks if no further event can move the saga
+func (s Step) IsTerminal() bool {
+	_, ok := transitions[s]
+	return !ok
+}
+
+func (s Step) String() string {
+	return string(s)
+}
+
+// Transition is one step change, kept so operators can see the path a payment took
+type Transition struct {
+	EventType  string
+	From       Step
+	To         Step
+	OccurredAt time.Time
+}
+
+// Compensation is an undo action that was applied for the payment
+type Compensation struct {
+	Action     string
+	Reason     string
+	OccurredAt time.Time
+}
+
+// Saga is the persisted state of the payment flow for one payment
+// The orchestrator checks every incoming event against it, so duplicated or
+// out-of-order deliveries are rejected the same way on every instance
+type Saga struct {
+	paymentID     string
+	step          Step
+	attempts      int       // deliveries accepted for the current step
+	leaseUntil    time.Time // until when the last accepted delivery may still be running; zero once it advanced
+	deadline      time.Time
+	compensations []Compensation
+	history       []Transition
+	startedAt     time.Time
+	updatedAt     time.Time
+	version       int64 // optimistic concurrency token, 0 until first persisted
+}
+
+// NewSaga starts the flow of a payment in StepAwaitingDebit
+func NewSaga(paymentID string, deadline time.Time) (*Saga, error) {
+	if paymentID == "" {
+		return nil, errors.New("payment ID is required")
+	}
+
+	now := time.Now().UTC()
+
+	return &Saga{
+		paymentID:     paymentID,
+		step:          StepAwaitingDebit,
+		deadline:      deadline,
+		compensations: make([]Compensation, 0),
+		history:       make([]Transition, 0),
+		startedAt:     now,
+		updatedAt:     now,
+	}, nil
+}
+
+// Getters
+
+func (s *Saga) PaymentID() string {
+	return s.paymentID
+}
+
+func (s *Saga) Step() Step {
+	return s.step
+}
+
+func (s *Saga) Attempts() int {
+	return s.attempts
+}
+
+// LeaseUntil is until when the handler of the last accepted delivery may still be running
+func (s *Saga) LeaseUntil() time.Time {
+	return s.leaseUntil
+}
+
+// Deadline is when the current step should have moved on; zero once terminal
+func (s *Saga) Deadline() time.Time {
+	return s.deadline
+}
+
+func (s *Saga) Compensations() []Compensation {
+	return append([]Compensation(nil), s.compensations...)
+}
+
+func (s *Saga) History() []Transition {
+	return append([]Transition(nil), s.history...)
+}
+
+func (s *Saga) StartedAt() time.Time {
+	return s.startedAt
+}
+
+func (s *Saga) UpdatedAt() time.Time {
+	return s.updatedAt
+}
+
+func (s *Saga) Version() int64 {
+	return s.version
+}
+
+// Domain Behaviors
+
+// Accept checks that an event may drive the current step, counts the attempt and leases the
+// step to its handler until leaseUntil
+// An event for a step the saga already left is stale (a duplicate, or the loser of a race
+// such as a timeout after completion); any other unexpected event is out of order
+func (s *Saga) Accept(eventType string, leaseUntil time.Time) error {
+	for _, transition := range s.history {
+		if _, ok := transitions[transition.From][eventType]; ok {
+			return domerrors.SagaStaleEventError(s.paymentID, eventType, s.step.String())
+		}
+	}
+
+	if _, ok := transitions[s.step][eventType]; !ok {
+		return domerrors.SagaOutOfOrderError(s.paymentID, eventType, s.step.String())
+	}
+
+	s.attempts++
+	s.leaseUntil = leaseUntil
+	s.updatedAt = time.Now().UTC()
+
+	return nil
+}
+
+// Advance moves the saga to the step produced by an accepted event
+// deadline is ignored when the new step is terminal
+func (s *Saga) Advance(eventType string, to Step, deadline time.Time) error {
+	allowed := false
+	for _, candidate := range transitions[s.step][eventType] {
+		if candidate == to {
+			allowed = true
+			break
+		}
+	}
+	if !allowed {
+		return domerrors.InvalidStateTransitionError(s.step.String(), to.String()).
+			WithDetail("eventType", eventType)
+	}
+
+	now := time.Now().UTC()
+
+	s.history = append(s.history, Transition{
+		EventType:  eventType,
+		From:       s.step,
+		To:         to,
+		OccurredAt: now,
+	})
+	s.step = to
+	s.attempts = 0
+	s.leaseUntil = time.Time{}
+	s.updatedAt = now
+
+	if to.IsTerminal() {
+		s.deadline = time.Time{}
+	} else {
+		s.deadline = deadline
+	}
+
+	return nil
+}
+
+// Cancel stops the flow on behalf of the client, which is only possible before the hold
+// Once the charge is sent the gateway may still approve it, and releasing the hold then would
+// pay the provider with funds the user keeps. A step leased to a delivery is refused: that
+// handler may be moving the wallet right now, and its own save would conflict with ours only
+// after the fact. A lease that ran out belongs to a delivery that failed or was abandoned
+func (s *Saga) Cancel(deadline time.Time) error {
+	if time.Now().UTC().Before(s.leaseUntil) {
+		return domerrors.SagaStepInProgressError(s.paymentID, s.step.String())
+	}
+	if s.step != StepAwaitingDebit {
+		return domerrors.PaymentNotCancellableError(s.paymentID, s.step.String())
+	}
+
+	return s.Advance(EventPaymentCancelled, StepCancelled, deadline)
+}
+
+// RecordCompensation notes an undo action applied for the payment
+func (s *Saga) RecordCompensation(action, reason string) {
+	now := time.Now().UTC()
+
+	s.compensations = append(s.compensations, Compensation{
+		Action:     action,
+		Reason:     reason,
+		OccurredAt: now,
+	})
+	s.updatedAt = now
+}
+
+// Query methods
+
+// IsTerminal checks if the flow has finished
+func (s *Saga) IsTerminal() bool {
+	return s.step.IsTerminal()
+}
+
+// IsCancelled checks if the client cancelled the payment, whatever the compensation got to
+func (s *Saga) IsCancelled() bool {
+	for _, transition := range s.history {
+		if transition.EventType == EventPaymentCancelled {
+			return true
+		}
+	}
+	return false
+}
+
+// IsStuck checks if the saga is still running past its step deadline
+func (s *Saga) IsStuck(now time.Time) bool {
+	return !s.IsTerminal() && now.After(s.deadline)
+}
+
+// Reconstruction for repositories
+
+// ReconstructSaga reconstructs a Saga from persistence
+func ReconstructSaga(
+	paymentID string,
+	step Step,
+	attempts int,
+	leaseUntil time.Time,
+	deadline time.Time,
+	compensations []Compensation,
+	history []Transition,
+	startedAt time.Time,
+	updatedAt time.Time,
+	version int64,
+) *Saga {
+	return &Saga{
+		paymentID:     paymentID,
+		step:          step,
+		attempts:      attempts,
+		leaseUntil:    leaseUntil,
+		deadline:      deadline,
+		compensations: compensations,
+		history:       history,
+		startedAt:     startedAt,
+		updatedAt:     updatedAt,
+		version:       version,
+	}
+}
+
+// IncrementVersion advances the concurrency token after a conditional write succeeds
+// Only repositories should call it
+func (s *Saga) IncrementVersion() {
+	s.version++
+}
@@ -1,6 +1,7 @@
 package errors
 
 import (
+	stderrors "errors"
 	"fmt"
 )
 
@@ -9,19 +10,41 @@ type ErrorCode string
 
 const (
 	// Domain errors - Payment
-	ErrCodeInsufficientFunds    ErrorCode = "INSUFFICIENT_FUNDS"
-	ErrCodeInvalidAmount        ErrorCode = "INVALID_AMOUNT"
-	ErrCodeInvalidCurrency      ErrorCode = "INVALID_CURRENCY"
-	ErrCodeCurrencyMismatch     ErrorCode = "CURRENCY_MISMATCH"
-	ErrCodeInvalidTransition    ErrorCode = "INVALID_STATE_TRANSITION"
-	ErrCodePaymentNotFound      ErrorCode = "PAYMENT_NOT_FOUND"
-	ErrCodePaymentAlreadyExists ErrorCode = "PAYMENT_ALREADY_EXISTS"
-	ErrCodePaymentNotPending    ErrorCode = "PAYMENT_NOT_PENDING"
+	ErrCodeInsufficientFunds     ErrorCode = "INSUFFICIENT_FUNDS"
+	ErrCodeInvalidAmount         ErrorCode = "INVALID_AMOUNT"
+	ErrCodeInvalidCurrency       ErrorCode = "INVALID_CURRENCY"
+	ErrCodeCurrencyMismatch      ErrorCode = "CURRENCY_MISMATCH"
+	ErrCodeInvalidTransition     ErrorCode = "INVALID_STATE_TRANSITION"
+	ErrCodePaymentNotFound       ErrorCode = "PAYMENT_NOT_FOUND"
+	ErrCodePaymentAlreadyExists  ErrorCode = "PAYMENT_ALREADY_EXISTS"
+	ErrCodePaymentNotPending     ErrorCode = "PAYMENT_NOT_PENDING"
+	ErrCodePaymentNotRefundable  ErrorCode = "PAYMENT_NOT_REFUNDABLE"
+	ErrCodePaymentNotCancellable ErrorCode = "PAYMENT_NOT_CANCELLABLE"
+	ErrCodeRefundExceedsAmount   ErrorCode = "REFUND_EXCEEDS_AMOUNT"
 
 	// Domain errors - Wallet
-	ErrCodeWalletNotFound   ErrorCode = "WALLET_NOT_FOUND"
-	ErrCodeWalletDebitError ErrorCode = "WALLET_DEBIT_ERROR"
-	ErrCodeNegativeBalance  ErrorCode = "NEGATIVE_BALANCE"
+	ErrCodeWalletNotFound      ErrorCode = "WALLET_NOT_FOUND"
+	ErrCodeWalletAlreadyExists ErrorCode = "WALLET_ALREADY_EXISTS"
+	ErrCodeWalletDebitError    ErrorCode = "WALLET_DEBIT_ERROR"
+	ErrCodeNegativeBalance     ErrorCode = "NEGATIVE_BALANCE"
+
+	// Domain errors - FX
+	ErrCodeFXRateUnavailable ErrorCode = "FX_RATE_UNAVAILABLE"
+	ErrCodeFXQuoteNotFound   ErrorCode = "FX_QUOTE_NOT_FOUND"
+	ErrCodeFXQuoteExpired    ErrorCode = "FX_QUOTE_EXPIRED"
+	ErrCodeFXQuoteUsed       ErrorCode = "FX_QUOTE_ALREADY_USED"
+
+	// Domain errors - Ledger
+	ErrCodeLedgerEntryExists   ErrorCode = "LEDGER_ENTRY_EXISTS"
+	ErrCodeLedgerEntryNotFound ErrorCode = "LEDGER_ENTRY_NOT_FOUND"
+
+	// Domain errors - Saga
+	ErrCodeSagaNotFound   ErrorCode = "SAGA_NOT_FOUND"
+	ErrCodeSagaOutOfOrder ErrorCode = "SAGA_OUT_OF_ORDER"
+	ErrCodeSagaStaleEvent ErrorCode = "SAGA_STALE_EVENT"
+
+	// Concurrency errors
+	ErrCodeConcurrentModification ErrorCode = "CONCURRENT_MODIFICATION"
 
 	// Domain errors - User
 	ErrCodeUserMismatch ErrorCode = "USER_MISMATCH"
@@ -30,6 +53,8 @@ const (
 	ErrCodeValidationFailed ErrorCode = "VALIDATION_FAILED"
 	ErrCodeDuplicateRequest ErrorCode = "DUPLICATE_REQUEST"
 	ErrCodeIdempotencyError ErrorCode = "IDEMPOTENCY_ERROR"
+	ErrCodeInvalidSignature ErrorCode = "INVALID_SIGNATURE"
+	ErrCodeGatewayMismatch  ErrorCode = "GATEWAY_MISMATCH"
 
 	// Infrastructure errors
 	ErrCodeDatabaseError     ErrorCode = "DATABASE_ERROR"
@@ -40,6 +65,8 @@ const (
 	// External service errors
 	ErrCodeExternalGatewayError ErrorCode = "EXTERNAL_GATEWAY_ERROR"
 	ErrCodeExternalTimeout      ErrorCode = "EXTERNAL_TIMEOUT"
+	ErrCodeGatewayUnavailable   ErrorCode = "GATEWAY_UNAVAILABLE"
+	ErrCodeNoGatewayRoute       ErrorCode = "NO_GATEWAY_ROUTE"
 
 	// Generic errors
 	ErrCodeInternal ErrorCode = "INTERNAL_ERROR"
@@ -113,6 +140,30 @@ func PaymentNotFoundError(paymentID string) *DomainError {
 	).WithDetail("paymentId", paymentID)
 }
 
+// PaymentNotRefundableError creates an error for a refund of a payment that was not completed
+func PaymentNotRefundableError(paymentID, status string) *DomainError {
+	return NewDomainError(
+		ErrCodePaymentNotRefundable,
+		fmt.Sprintf("Payment %s cannot be refunded in status %s", paymentID, status),
+	).WithDetail("paymentId", paymentID).WithDetail("status", status)
+}
+
+// PaymentNotCancellableError creates an error for a cancellation of a payment already sent to the gateway
+func PaymentNotCancellableError(paymentID, status string) *DomainError {
+	return NewDomainError(
+		ErrCodePaymentNotCancellable,
+		fmt.Sprintf("Payment %s cannot be cancelled in status %s", paymentID, status),
+	).WithDetail("paymentId", paymentID).WithDetail("status", status)
+}
+
+// RefundExceedsAmountError creates an error for a refund larger than what is left to refund
+func RefundExceedsAmountError(paymentID, requested, refundable string) *DomainError {
+	return NewDomainError(
+		ErrCodeRefundExceedsAmount,
+		"Refund exceeds the refundable amount of the payment",
+	).WithDetail("paymentId", paymentID).WithDetail("requested", requested).WithDetail("refundable", refundable)
+}
+
 // WalletNotFoundError creates a wallet not found error
 func WalletNotFoundError(userID string) *DomainError {
 	return NewDomainError(
@@ -121,6 +172,63 @@ func WalletNotFoundError(userID string) *DomainError {
 	).WithDetail("userId", userID)
 }
 
+// WalletAlreadyExistsError creates a wallet already exists error
+func WalletAlreadyExistsError(userID string) *DomainError {
+	return NewDomainError(
+		ErrCodeWalletAlreadyExists,
+		fmt.Sprintf("Wallet already exists for user: %s", userID),
+	).WithDetail("userId", userID)
+}
+
+// WalletVersionConflictError creates a concurrent modification error for a stale wallet write
+func WalletVersionConflictError(userID string, expectedVersion int64) *DomainError {
+	return NewDomainError(
+		ErrCodeConcurrentModification,
+		fmt.Sprintf("Wallet was modified concurrently for user: %s", userID),
+	).WithDetail("userId", userID).WithDetail("expectedVersion", expectedVersion)
+}
+
+// SagaVersionConflictError creates a concurrent modification error for a stale saga write
+func SagaVersionConflictError(paymentID string, expectedVersion int64) *DomainError {
+	return NewDomainError(
+		ErrCodeConcurrentModification,
+		fmt.Sprintf("Saga was modified concurrently for payment: %s", paymentID),
+	).WithDetail("paymentId", paymentID).WithDetail("expectedVersion", expectedVersion)
+}
+
+// SagaStepInProgressError creates a concurrent modification error for a saga whose current step
+// is being handled, so it cannot be changed from outside until that handler finishes
+func SagaStepInProgressError(paymentID, step string) *DomainError {
+	return NewDomainError(
+		ErrCodeConcurrentModification,
+		fmt.Sprintf("Payment %s is being processed in step %s, retry later", paymentID, step),
+	).WithDetail("paymentId", paymentID).WithDetail("step", step)
+}
+
+// SagaNotFoundError creates a saga not found error
+func SagaNotFoundError(paymentID string) *DomainError {
+	return NewDomainError(
+		ErrCodeSagaNotFound,
+		fmt.Sprintf("Saga not found for payment: %s", paymentID),
+	).WithDetail("paymentId", paymentID)
+}
+
+// SagaOutOfOrderError creates an error for an event the current saga step does not expect
+func SagaOutOfOrderError(paymentID, eventType, step string) *DomainError {
+	return NewDomainError(
+		ErrCodeSagaOutOfOrder,
+		fmt.Sprintf("Event %s is not expected in step %s", eventType, step),
+	).WithDetail("paymentId", paymentID).WithDetail("eventType", eventType).WithDetail("step", step)
+}
+
+// SagaStaleEventError creates an error for an event whose step the saga already left
+func SagaStaleEventError(paymentID, eventType, step string) *DomainError {
+	return NewDomainError(
+		ErrCodeSagaStaleEvent,
+		fmt.Sprintf("Event %s arrived after the saga moved on to %s", eventType, step),
+	).WithDetail("paymentId", paymentID).WithDetail("eventType", eventType).WithDetail("step", step)
+}
+
 // InvalidStateTransitionError creates an invalid state transition error
 func InvalidStateTransitionError(from, to string) *DomainError {
 	return NewDomainError(
@@ -137,6 +245,55 @@ func CurrencyMismatchError(expected, actual string) *DomainError {
 	).WithDetail("expected", expected).WithDetail("actual", actual)
 }
 
+// FXRateUnavailableError creates an error for a currency pair without an exchange rate
+func FXRateUnavailableError(from, to string) *DomainError {
+	return NewDomainError(
+		ErrCodeFXRateUnavailable,
+		fmt.Sprintf("No exchange rate from %s to %s", from, to),
+	).WithDetail("from", from).WithDetail("to", to)
+}
+
+// FXQuoteNotFoundError creates an error for an unknown exchange rate quote
+func FXQuoteNotFoundError(quoteID string) *DomainError {
+	return NewDomainError(
+		ErrCodeFXQuoteNotFound,
+		fmt.Sprintf("FX quote not found: %s", quoteID),
+	).WithDetail("quoteId", quoteID)
+}
+
+// FXQuoteExpiredError creates an error for a quote whose locked rate is no longer honoured
+func FXQuoteExpiredError(quoteID, expiredAt string) *DomainError {
+	return NewDomainError(
+		ErrCodeFXQuoteExpired,
+		fmt.Sprintf("FX quote %s expired at %s", quoteID, expiredAt),
+	).WithDetail("quoteId", quoteID).WithDetail("expiredAt", expiredAt)
+}
+
+// FXQuoteUsedError creates an error for a quote that already funded another payment
+func FXQuoteUsedError(quoteID string) *DomainError {
+	return NewDomainError(
+		ErrCodeFXQuoteUsed,
+		fmt.Sprintf("FX quote %s was already used by another payment", quoteID),
+	).WithDetail("quoteId", quoteID)
+}
+
+// LedgerEntryExistsError creates an error for a journal entry that was already posted
+// Entry IDs come from the movement they record, so this means the movement was applied before
+func LedgerEntryExistsError(entryID string) *DomainError {
+	return NewDomainError(
+		ErrCodeLedgerEntryExists,
+		fmt.Sprintf("Journal entry already posted: %s", entryID),
+	).WithDetail("entryId", entryID)
+}
+
+// LedgerEntryNotFoundError creates an error for a journal entry that was never posted
+func LedgerEntryNotFoundError(entryID string) *DomainError {
+	return NewDomainError(
+		ErrCodeLedgerEntryNotFound,
+		fmt.Sprintf("Journal entry not found: %s", entryID),
+	).WithDetail("entryId", entryID)
+}
+
 // ValidationError creates a validation error
 func ValidationError(field, reason string) *DomainError {
 	return NewDomainError(
@@ -153,6 +310,22 @@ func DuplicateRequestError(idempotencyKey string) *DomainError {
 	).WithDetail("idempotencyKey", idempotencyKey)
 }
 
+// InvalidSignatureError creates an error for a callback whose signature cannot be trusted
+func InvalidSignatureError(reason string) *DomainError {
+	return NewDomainError(
+		ErrCodeInvalidSignature,
+		fmt.Sprintf("Invalid signature: %s", reason),
+	).WithDetail("reason", reason)
+}
+
+// GatewayMismatchError creates an error for a callback from a gateway the payment was not sent to
+func GatewayMismatchError(paymentID, provider string) *DomainError {
+	return NewDomainError(
+		ErrCodeGatewayMismatch,
+		fmt.Sprintf("Payment %s was not sent to gateway %s", paymentID, provider),
+	).WithDetail("paymentId", paymentID).WithDetail("provider", provider)
+}
+
 // DatabaseError creates a database error
 func DatabaseError(operation string, cause error) *DomainError {
 	return WrapError(
@@ -171,9 +344,45 @@ func EventPublishError(eventType string, cause error) *DomainError {
 	).WithDetail("eventType", eventType)
 }
 
+// ExternalGatewayError creates an error for a gateway call whose outcome is unknown
+func ExternalGatewayError(operation string, cause error) *DomainError {
+	return WrapError(
+		ErrCodeExternalGatewayError,
+		fmt.Sprintf("Payment gateway error during %s", operation),
+		cause,
+	).WithDetail("operation", operation)
+}
+
+// GatewayUnavailableError creates an error for a gateway that did not process the charge at all
+// (connection refused, 503, 429), so the charge can safely go to another gateway
+func GatewayUnavailableError(operation string, cause error) *DomainError {
+	return WrapError(
+		ErrCodeGatewayUnavailable,
+		fmt.Sprintf("Payment gateway unavailable during %s", operation),
+		cause,
+	).WithDetail("operation", operation)
+}
+
+// NoGatewayRouteError creates an error for a payment no gateway is configured to settle
+func NoGatewayRouteError(serviceID, currency string) *DomainError {
+	return NewDomainError(
+		ErrCodeNoGatewayRoute,
+		fmt.Sprintf("No payment gateway route for service %s in %s", serviceID, currency),
+	).WithDetail("serviceId", serviceID).WithDetail("currency", currency)
+}
+
+// AsDomainError finds the first DomainError in an error chain
+func AsDomainError(err error) (*DomainError, bool) {
+	var domainErr *DomainError
+	if stderrors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	return nil, false
+}
+
 // IsErrorCode checks if an error has a specific error code
 func IsErrorCode(err error, code ErrorCode) bool {
-	if domainErr, ok := err.(*DomainError); ok {
+	if domainErr, ok := AsDomainError(err); ok {
 		return domainErr.Code == code
 	}
 	return false
@@ -181,7 +390,7 @@ func IsErrorCode(err error, code ErrorCode) bool {
 
 // GetErrorCode extracts the error code from an error
 func GetErrorCode(err error) ErrorCode {
-	if domainErr, ok := err.(*DomainError); ok {
+	if domainErr, ok := AsDomainError(err); ok {
 		return domainErr.Code
 	}
 	return ErrCodeUnknown
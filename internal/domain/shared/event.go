@@ -1,9 +1,15 @@
 package shared
 
-import "time"
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
 
 // Event represents the base interface for all domain events
 type Event interface {
+	EventID() string
 	EventType() string
 	OccurredAt() time.Time
 	Metadata() Metadata
@@ -17,13 +23,52 @@ type Metadata struct {
 	Extra     map[string]string `json:"extra,omitempty"`
 }
 
+// WithExtra returns a copy of the metadata with an extra key set
+// The Extra map is copied so events built from the same metadata never share it
+func (m Metadata) WithExtra(key, value string) Metadata {
+	extra := make(map[string]string, len(m.Extra)+1)
+	for k, v := range m.Extra {
+		extra[k] = v
+	}
+	extra[key] = value
+	m.Extra = extra
+	return m
+}
+
+// FXDetails records the currency conversion behind a payment or wallet movement
+// Events that carry it leave it nil when no conversion took place
+type FXDetails struct {
+	QuoteID        string          `json:"quoteId"`
+	Rate           decimal.Decimal `json:"rate"` // applied rate, source units per target unit
+	Spread         decimal.Decimal `json:"spread"`
+	SourceAmount   decimal.Decimal `json:"sourceAmount"` // debited from the wallet
+	SourceCurrency string          `json:"sourceCurrency"`
+	TargetAmount   decimal.Decimal `json:"targetAmount"` // charged for the payment
+	TargetCurrency string          `json:"targetCurrency"`
+}
+
+// FeeDetail records one fee line charged on a payment, or given back by a refund
+type FeeDetail struct {
+	Code         string          `json:"code"`
+	Amount       decimal.Decimal `json:"amount"`
+	Currency     string          `json:"currency"`
+	RefundPolicy string          `json:"refundPolicy"`
+}
+
 // BaseEvent provides common functionality for all events
 type BaseEvent struct {
+	eventID    string
 	eventType  string
 	occurredAt time.Time
 	metadata   Metadata
 }
 
+// EventID is a stable identifier that travels with the event through the EventStore and SNS,
+// so consumers can recognise redeliveries
+func (e BaseEvent) EventID() string {
+	return e.eventID
+}
+
 func (e BaseEvent) EventType() string {
 	return e.eventType
 }
@@ -36,9 +81,21 @@ func (e BaseEvent) Metadata() Metadata {
 	return e.metadata
 }
 
+// RestoreOccurredAt sets the original timestamp on a deserialized event
+// Constructors stamp events with the current time, which is wrong when reading them back
+func (e *BaseEvent) RestoreOccurredAt(occurredAt time.Time) {
+	e.occurredAt = occurredAt
+}
+
+// RestoreEventID sets the original identifier on a deserialized event
+func (e *BaseEvent) RestoreEventID(eventID string) {
+	e.eventID = eventID
+}
+
 // NewBaseEvent creates a new base event (exported for use in other packages)
 func NewBaseEvent(eventType string, metadata Metadata) BaseEvent {
 	return BaseEvent{
+		eventID:    uuid.New().String(),
 		eventType:  eventType,
 		occurredAt: time.Now().UTC(),
 		metadata:   metadata,
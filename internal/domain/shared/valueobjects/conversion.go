@@ -0,0 +1,104 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/shopspring/decimal"
+)
+
+// Conversion is a currency exchange at a locked rate: the source amount leaves the wallet
+// to settle the target amount of a payment
+// This is a Value Object - immutable and comparable by value
+type Conversion struct {
+	quoteID string
+	rate    decimal.Decimal // applied rate, source units per target unit, spread included
+	spread  decimal.Decimal // fraction added on top of the market rate, 0.01 is 1%
+	source  Money
+	target  Money
+}
+
+// NewConversion prices a target amount in the source currency
+// The applied rate is marketRate * (1 + spread); the source amount is rounded the source currency's way
+func NewConversion(quoteID string, target Money, source Currency, marketRate, spread decimal.Decimal) (Conversion, error) {
+	if quoteID == "" {
+		return Conversion{}, errors.New("quote ID is required")
+	}
+	if !target.IsPositive() {
+		return Conversion{}, errors.New("converted amount must be greater than zero")
+	}
+	if source.Equals(target.Currency()) {
+		return Conversion{}, fmt.Errorf("cannot convert %s into itself", source.Code())
+	}
+	if !marketRate.IsPositive() {
+		return Conversion{}, errors.New("exchange rate must be greater than zero")
+	}
+	if spread.IsNegative() {
+		return Conversion{}, errors.New("spread cannot be negative")
+	}
+
+	rate := marketRate.Mul(decimal.NewFromInt(1).Add(spread))
+	sourceAmount, err := NewMoneyRounded(target.Amount().Mul(rate), source)
+	if err != nil {
+		return Conversion{}, err
+	}
+
+	return Conversion{
+		quoteID: quoteID,
+		rate:    rate,
+		spread:  spread,
+		source:  sourceAmount,
+		target:  target,
+	}, nil
+}
+
+// ReconstructConversion rebuilds a Conversion from persistence or events
+// This bypasses validation for data that was valid when recorded
+func ReconstructConversion(quoteID string, rate, spread decimal.Decimal, source, target Money) Conversion {
+	return Conversion{
+		quoteID: quoteID,
+		rate:    rate,
+		spread:  spread,
+		source:  source,
+		target:  target,
+	}
+}
+
+// QuoteID returns the quote that locked the rate
+func (c Conversion) QuoteID() string {
+	return c.quoteID
+}
+
+// Rate returns the applied rate in source units per target unit, spread included
+func (c Conversion) Rate() decimal.Decimal {
+	return c.rate
+}
+
+// Spread returns the fraction charged on top of the market rate
+func (c Conversion) Spread() decimal.Decimal {
+	return c.spread
+}
+
+// Source returns the amount paid in the funding currency
+func (c Conversion) Source() Money {
+	return c.source
+}
+
+// Target returns the converted amount in the payment currency
+func (c Conversion) Target() Money {
+	return c.target
+}
+
+// SourceFor prices part of the target amount at the same rate, e.g. a partial refund
+// The whole target always maps back to exactly the source that was paid
+func (c Conversion) SourceFor(target Money) (Money, error) {
+	if !target.Currency().Equals(c.target.Currency()) {
+		return Money{}, fmt.Errorf("cannot convert %s with a %s conversion",
+			target.Currency().Code(), c.target.Currency().Code())
+	}
+	if target.Equals(c.target) {
+		return c.source, nil
+	}
+
+	return NewMoneyRounded(target.Amount().Mul(c.rate), c.source.Currency())
+}
@@ -11,14 +11,32 @@ type Currency struct {
 	code string
 }
 
-var validCurrencies = map[string]bool{
-	"ARS": true,
-	"USD": true,
-	"EUR": true,
-	"BRL": true,
-	"MXN": true,
-	"CLP": true,
-	"COP": true,
+// RoundingMode tells how an amount is brought to the precision of its currency
+type RoundingMode int
+
+const (
+	// RoundHalfUp rounds halves away from zero (1.005 -> 1.01)
+	RoundHalfUp RoundingMode = iota
+	// RoundHalfEven rounds halves to the even neighbour (1.005 -> 1.00, 1.015 -> 1.02)
+	RoundHalfEven
+)
+
+// currencyInfo holds the ISO 4217 metadata the domain needs
+// exponent is the number of decimals of the minor unit (2 for cents, 0 for CLP)
+type currencyInfo struct {
+	exponent int32
+	symbol   string
+	rounding RoundingMode
+}
+
+var validCurrencies = map[string]currencyInfo{
+	"ARS": {exponent: 2, symbol: "$", rounding: RoundHalfUp},
+	"USD": {exponent: 2, symbol: "US$", rounding: RoundHalfEven},
+	"EUR": {exponent: 2, symbol: "€", rounding: RoundHalfEven},
+	"BRL": {exponent: 2, symbol: "R$", rounding: RoundHalfUp},
+	"MXN": {exponent: 2, symbol: "$", rounding: RoundHalfUp},
+	"CLP": {exponent: 0, symbol: "$", rounding: RoundHalfUp},
+	"COP": {exponent: 2, symbol: "$", rounding: RoundHalfUp},
 }
 
 // NewCurrency creates a new Currency value object
@@ -33,7 +51,7 @@ func NewCurrency(code string) (Currency, error) {
 		return Currency{}, errors.New("currency code must be 3 characters")
 	}
 
-	if !validCurrencies[code] {
+	if _, ok := validCurrencies[code]; !ok {
 		return Currency{}, fmt.Errorf("unsupported currency: %s", code)
 	}
 
@@ -54,6 +72,21 @@ func (c Currency) Code() string {
 	return c.code
 }
 
+// Exponent returns the number of decimals of the minor unit (ISO 4217)
+func (c Currency) Exponent() int32 {
+	return validCurrencies[c.code].exponent
+}
+
+// Symbol returns the display symbol of the currency
+func (c Currency) Symbol() string {
+	return validCurrencies[c.code].symbol
+}
+
+// Rounding returns the rounding mode used for amounts in this currency
+func (c Currency) Rounding() RoundingMode {
+	return validCurrencies[c.code].rounding
+}
+
 // Equals checks if two currencies are equal
 func (c Currency) Equals(other Currency) bool {
 	return c.code == other.code
@@ -0,0 +1,112 @@
+package valueobjects
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// FeeRefundPolicy decides how much of a fee goes back to the user when the payment is refunded
+type FeeRefundPolicy string
+
+const (
+	// FeeRefundNever keeps the fee whatever is refunded
+	FeeRefundNever FeeRefundPolicy = "NEVER"
+	// FeeRefundProportional gives back the share of the fee matching the refunded share of the payment
+	FeeRefundProportional FeeRefundPolicy = "PROPORTIONAL"
+	// FeeRefundOnFullRefund gives back the whole fee once the whole payment is refunded, nothing before
+	FeeRefundOnFullRefund FeeRefundPolicy = "ON_FULL_REFUND"
+)
+
+// ParseFeeRefundPolicy parses a policy name, case insensitive
+func ParseFeeRefundPolicy(s string) (FeeRefundPolicy, error) {
+	policy := FeeRefundPolicy(strings.ToUpper(strings.TrimSpace(s)))
+	switch policy {
+	case FeeRefundNever, FeeRefundProportional, FeeRefundOnFullRefund:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("invalid fee refund policy: %s", s)
+	}
+}
+
+// String returns the policy name
+func (p FeeRefundPolicy) String() string {
+	return string(p)
+}
+
+// FeeLine is one fee charged on top of a payment, e.g. the service fee
+// This is a Value Object - immutable and comparable by value
+type FeeLine struct {
+	code         string
+	amount       Money
+	refundPolicy FeeRefundPolicy
+}
+
+// NewFeeLine creates a fee line; a zero amount is allowed for fees waived by their caps
+func NewFeeLine(code string, amount Money, refundPolicy FeeRefundPolicy) (FeeLine, error) {
+	if code == "" {
+		return FeeLine{}, errors.New("fee code is required")
+	}
+	if amount.Currency().IsEmpty() {
+		return FeeLine{}, errors.New("fee currency is required")
+	}
+	if _, err := ParseFeeRefundPolicy(refundPolicy.String()); err != nil {
+		return FeeLine{}, err
+	}
+
+	return FeeLine{
+		code:         code,
+		amount:       amount,
+		refundPolicy: refundPolicy,
+	}, nil
+}
+
+// Code returns the fee name, unique within a payment
+func (l FeeLine) Code() string {
+	return l.code
+}
+
+// Amount returns the fee charged, in the payment currency
+func (l FeeLine) Amount() Money {
+	return l.amount
+}
+
+// RefundPolicy returns how the fee is given back on refunds
+func (l FeeLine) RefundPolicy() FeeRefundPolicy {
+	return l.refundPolicy
+}
+
+// RefundedAt returns how much of the fee has been given back once refunded out of paid has been refunded
+// It is cumulative, so the fee given back by one refund is the difference between two totals
+func (l FeeLine) RefundedAt(refunded, paid Money) (Money, error) {
+	if !refunded.Currency().Equals(l.amount.Currency()) || !paid.Currency().Equals(l.amount.Currency()) {
+		return Money{}, fmt.Errorf("fee %s is in %s", l.code, l.amount.Currency().Code())
+	}
+	fullyRefunded := refunded.Equals(paid)
+
+	switch l.refundPolicy {
+	case FeeRefundProportional:
+		if fullyRefunded {
+			return l.amount, nil
+		}
+		share := l.amount.Amount().Mul(refunded.Amount()).Div(paid.Amount())
+		return NewMoneyRounded(share, l.amount.Currency())
+	case FeeRefundOnFullRefund:
+		if fullyRefunded {
+			return l.amount, nil
+		}
+	}
+	return Zero(l.amount.Currency()), nil
+}
+
+// SumFees adds up fee lines, zero in currency when there are none
+func SumFees(lines []FeeLine, currency Currency) (Money, error) {
+	total := Zero(currency)
+	for _, line := range lines {
+		var err error
+		if total, err = total.Add(line.amount); err != nil {
+			return Money{}, err
+		}
+	}
+	return total, nil
+}
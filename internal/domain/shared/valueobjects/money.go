@@ -16,6 +16,7 @@ type Money struct {
 }
 
 // NewMoney creates a new Money value object
+// Amounts finer than the currency's minor unit are rejected (10.5 CLP, 1.001 USD)
 func NewMoney(amount decimal.Decimal, currency Currency) (Money, error) {
 	if amount.IsNegative() {
 		return Money{}, errors.New("amount cannot be negative")
@@ -25,19 +26,26 @@ func NewMoney(amount decimal.Decimal, currency Currency) (Money, error) {
 		return Money{}, errors.New("currency is required")
 	}
 
+	if !amount.Equal(amount.Truncate(currency.Exponent())) {
+		return Money{}, fmt.Errorf("%s amounts allow at most %d decimals, got %s",
+			currency.Code(), currency.Exponent(), amount.String())
+	}
+
 	return Money{
 		amount:   amount,
 		currency: currency,
 	}, nil
 }
 
-// NewMoneyFromFloat creates Money from float64 (use with caution)
-func NewMoneyFromFloat(amount float64, currency Currency) (Money, error) {
-	if amount < 0 {
-		return Money{}, errors.New("amount cannot be negative")
-	}
+// NewMoneyRounded creates Money rounding the amount to the currency's precision
+// Use it for computed amounts (rates, percentages); input from clients goes through NewMoney
+func NewMoneyRounded(amount decimal.Decimal, currency Currency) (Money, error) {
+	return NewMoney(roundTo(amount, currency), currency)
+}
 
-	return NewMoney(decimal.NewFromFloat(amount), currency)
+// FromMinorUnits creates Money from an integer count of minor units (cents for USD, pesos for CLP)
+func FromMinorUnits(units int64, currency Currency) (Money, error) {
+	return NewMoney(decimal.New(units, -currency.Exponent()), currency)
 }
 
 // NewMoneyFromString creates Money from string
@@ -84,10 +92,9 @@ func (m Money) Currency() Currency {
 	return m.currency
 }
 
-// AmountFloat returns amount as float64 (use only for display/legacy)
-func (m Money) AmountFloat() float64 {
-	f, _ := m.amount.Float64()
-	return f
+// ToMinorUnits returns the amount as an integer count of minor units
+func (m Money) ToMinorUnits() int64 {
+	return m.amount.Shift(m.currency.Exponent()).IntPart()
 }
 
 // Operations
@@ -123,7 +130,7 @@ func (m Money) Subtract(other Money) (Money, error) {
 	}, nil
 }
 
-// Multiply multiplies money by a factor
+// Multiply multiplies money by a factor, rounding to the currency's precision
 func (m Money) Multiply(factor decimal.Decimal) (Money, error) {
 	result := m.amount.Mul(factor)
 	if result.IsNegative() {
@@ -131,11 +138,65 @@ func (m Money) Multiply(factor decimal.Decimal) (Money, error) {
 	}
 
 	return Money{
-		amount:   result,
+		amount:   roundTo(result, m.currency),
 		currency: m.currency,
 	}, nil
 }
 
+// Allocate splits the amount by ratios without losing minor units
+// The remainder left by rounding down goes one unit at a time to the first parts
+// with a non-zero ratio, so 100.00 by (1, 1, 1) gives 33.34, 33.33, 33.33
+func (m Money) Allocate(ratios ...int) ([]Money, error) {
+	if len(ratios) == 0 {
+		return nil, errors.New("at least one ratio is required")
+	}
+
+	total := int64(0)
+	for _, ratio := range ratios {
+		if ratio < 0 {
+			return nil, errors.New("ratios cannot be negative")
+		}
+		total += int64(ratio)
+	}
+	if total == 0 {
+		return nil, errors.New("ratios must add up to more than zero")
+	}
+
+	units := decimal.NewFromInt(m.ToMinorUnits())
+	shares := make([]int64, len(ratios))
+	left := units
+	for i, ratio := range ratios {
+		share, _ := units.Mul(decimal.NewFromInt(int64(ratio))).QuoRem(decimal.NewFromInt(total), 0)
+		shares[i] = share.IntPart()
+		left = left.Sub(share)
+	}
+	for i := 0; left.IsPositive(); i++ {
+		if ratios[i] > 0 {
+			shares[i]++
+			left = left.Sub(decimal.NewFromInt(1))
+		}
+	}
+
+	parts := make([]Money, len(shares))
+	for i, share := range shares {
+		parts[i] = Money{amount: decimal.New(share, -m.currency.Exponent()), currency: m.currency}
+	}
+	return parts, nil
+}
+
+// Split divides the amount into n parts that differ by at most one minor unit
+func (m Money) Split(n int) ([]Money, error) {
+	if n <= 0 {
+		return nil, errors.New("cannot split into less than one part")
+	}
+
+	ratios := make([]int, n)
+	for i := range ratios {
+		ratios[i] = 1
+	}
+	return m.Allocate(ratios...)
+}
+
 // Comparisons
 
 // IsGreaterThan checks if this amount is greater than another
@@ -179,7 +240,15 @@ func (m Money) IsPositive() bool {
 
 // String returns a formatted string representation
 func (m Money) String() string {
-	return fmt.Sprintf("%s %s", m.amount.StringFixed(2), m.currency.Code())
+	return fmt.Sprintf("%s %s", m.amount.StringFixed(m.currency.Exponent()), m.currency.Code())
+}
+
+// roundTo rounds an amount to the precision of the currency using its rounding mode
+func roundTo(amount decimal.Decimal, currency Currency) decimal.Decimal {
+	if currency.Rounding() == RoundHalfEven {
+		return amount.RoundBank(currency.Exponent())
+	}
+	return amount.Round(currency.Exponent())
 }
 
 // JSON Serialization
@@ -9,37 +9,69 @@ import (
 type PaymentStatus int
 
 const (
+	// PaymentStatusPending is a created payment whose funds are not held yet
 	PaymentStatusPending PaymentStatus = iota
+	// PaymentStatusProcessing has its funds held (or debited) and is waiting for the gateway
+	PaymentStatusProcessing
 	PaymentStatusCompleted
+	// PaymentStatusRejected was refused before any funds were held: nothing to compensate
+	PaymentStatusRejected
+	// PaymentStatusFailed was declined by the gateway after its funds were held
 	PaymentStatusFailed
+	// PaymentStatusExpired got no gateway answer in time after its funds were held
+	PaymentStatusExpired
+	// PaymentStatusCancelled was aborted by the client
+	PaymentStatusCancelled
+	PaymentStatusPartiallyRefunded
+	PaymentStatusRefunded
 )
 
+// paymentStatusNames are the persisted names, also used by String and ParsePaymentStatus
+var paymentStatusNames = map[PaymentStatus]string{
+	PaymentStatusPending:           "PENDING",
+	PaymentStatusProcessing:        "PROCESSING",
+	PaymentStatusCompleted:         "COMPLETED",
+	PaymentStatusRejected:          "REJECTED",
+	PaymentStatusFailed:            "FAILED",
+	PaymentStatusExpired:           "EXPIRED",
+	PaymentStatusCancelled:         "CANCELLED",
+	PaymentStatusPartiallyRefunded: "PARTIALLY_REFUNDED",
+	PaymentStatusRefunded:          "REFUNDED",
+}
+
+// paymentTransitions is the payment state machine; statuses without an entry are terminal
+var paymentTransitions = map[PaymentStatus][]PaymentStatus{
+	PaymentStatusPending:           {PaymentStatusProcessing, PaymentStatusRejected, PaymentStatusCancelled},
+	PaymentStatusProcessing:        {PaymentStatusCompleted, PaymentStatusFailed, PaymentStatusExpired},
+	PaymentStatusCompleted:         {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+	PaymentStatusPartiallyRefunded: {PaymentStatusPartiallyRefunded, PaymentStatusRefunded},
+}
+
+// PaymentStatuses returns every payment status, in lifecycle order
+func PaymentStatuses() []PaymentStatus {
+	statuses := make([]PaymentStatus, 0, len(paymentStatusNames))
+	for s := PaymentStatusPending; s <= PaymentStatusRefunded; s++ {
+		statuses = append(statuses, s)
+	}
+	return statuses
+}
+
 // String returns the string representation
 func (s PaymentStatus) String() string {
-	switch s {
-	case PaymentStatusPending:
-		return "PENDING"
-	case PaymentStatusCompleted:
-		return "COMPLETED"
-	case PaymentStatusFailed:
-		return "FAILED"
-	default:
-		return "UNKNOWN"
+	if name, ok := paymentStatusNames[s]; ok {
+		return name
 	}
+	return "UNKNOWN"
 }
 
-// ParsePaymentStatus parses a string into PaymentStatus
+// ParsePaymentStatus converts string to PaymentStatus
 func ParsePaymentStatus(s string) (PaymentStatus, error) {
-	switch s {
-	case "PENDING":
-		return PaymentStatusPending, nil
-	case "COMPLETED":
-		return PaymentStatusCompleted, nil
-	case "FAILED":
-		return PaymentStatusFailed, nil
-	default:
-		return 0, fmt.Errorf("unknown payment status: %s", s)
+	for status, name := range paymentStatusNames {
+		if name == s {
+			return status, nil
+		}
 	}
+	return 0, fmt.Errorf("unknown payment status: %s", s)
 }
 
 // IsPending checks if status is pending
@@ -47,36 +79,74 @@ func (s PaymentStatus) IsPending() bool {
 	return s == PaymentStatusPending
 }
 
+// IsProcessing checks if the funds are held and the gateway has not answered yet
+func (s PaymentStatus) IsProcessing() bool {
+	return s == PaymentStatusProcessing
+}
+
 // IsCompleted checks if status is completed
 func (s PaymentStatus) IsCompleted() bool {
 	return s == PaymentStatusCompleted
 }
 
+// IsRejected checks if the payment was refused before its funds were held
+func (s PaymentStatus) IsRejected() bool {
+	return s == PaymentStatusRejected
+}
+
 // IsFailed checks if status is failed
 func (s PaymentStatus) IsFailed() bool {
 	return s == PaymentStatusFailed
 }
 
+// IsExpired checks if the gateway never answered in time
+func (s PaymentStatus) IsExpired() bool {
+	return s == PaymentStatusExpired
+}
+
+// IsCancelled checks if the client aborted the payment
+func (s PaymentStatus) IsCancelled() bool {
+	return s == PaymentStatusCancelled
+}
+
+// IsPartiallyRefunded checks if part of a completed payment was refunded
+func (s PaymentStatus) IsPartiallyRefunded() bool {
+	return s == PaymentStatusPartiallyRefunded
+}
+
+// IsRefunded checks if a completed payment was refunded in full
+func (s PaymentStatus) IsRefunded() bool {
+	return s == PaymentStatusRefunded
+}
+
+// IsSettled checks if the gateway outcome is known, whatever happened afterwards
+func (s PaymentStatus) IsSettled() bool {
+	return s != PaymentStatusPending && s != PaymentStatusProcessing
+}
+
+// IsCompensable checks if the payment ended without being charged, so any funds held for it must be given back
+func (s PaymentStatus) IsCompensable() bool {
+	return s == PaymentStatusFailed || s == PaymentStatusExpired || s == PaymentStatusCancelled
+}
+
+// IsRefundable checks if the status still accepts merchant refunds
+func (s PaymentStatus) IsRefundable() bool {
+	return s == PaymentStatusCompleted || s == PaymentStatusPartiallyRefunded
+}
+
 // IsTerminal checks if the status is terminal (no more transitions allowed)
 func (s PaymentStatus) IsTerminal() bool {
-	return s == PaymentStatusCompleted || s == PaymentStatusFailed
+	return len(paymentTransitions[s]) == 0
 }
 
 // CanTransitionTo checks if transition to target status is valid
 func (s PaymentStatus) CanTransitionTo(target PaymentStatus) bool {
-	switch s {
-	case PaymentStatusPending:
-		// Pending can transition to Completed or Failed
-		return target == PaymentStatusCompleted || target == PaymentStatusFailed
-	case PaymentStatusCompleted:
-		// Terminal state - no transitions allowed
-		return false
-	case PaymentStatusFailed:
-		// Terminal state - no transitions allowed
-		return false
-	default:
-		return false
+	for _, allowed := range paymentTransitions[s] {
+		if allowed == target {
+			return true
+		}
 	}
+	return false
 }
 
 // ValidateTransition returns an error if transition is invalid
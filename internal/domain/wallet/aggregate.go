@@ -2,20 +2,45 @@ package wallet
 
 import (
 	"errors"
+	"sort"
 	"time"
 
+	"github.com/franco/payment-api/internal/domain/ledger"
 	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 )
 
+// EventStreamID returns the event store stream that holds a wallet's movements
+// Wallet events are also appended to their payment stream; this one backs the wallet history
+func EventStreamID(userID string) string {
+	return "wallet#" + userID
+}
+
+// Hold is an amount reserved on a wallet for a payment until the gateway answers
+type Hold struct {
+	PaymentID string
+	Amount    vo.Money
+	CreatedAt time.Time
+}
+
 // Wallet is an aggregate root representing a user's wallet
 // Uses Value Objects for type safety and protection of invariants
+// A wallet keeps one balance per currency. A balance is everything the user owns
+// in that currency, held funds included; only the available part (balance minus
+// the holds in the same currency) can be debited or held again
+// Balances are the projection of the wallet's ledger account: every change to them records the
+// journal entry behind it, and repositories write both together
 type Wallet struct {
-	userID    vo.UserID
-	balance   vo.Money
-	updatedAt time.Time
+	userID          vo.UserID
+	defaultCurrency vo.Currency         // currency the wallet was opened with
+	balances        map[string]vo.Money // by currency code, missing means zero
+	holds           map[string]Hold     // by payment ID
+	updatedAt       time.Time
+	version         int64                  // optimistic concurrency token, 0 until first persisted update
+	pendingEntries  []*ledger.JournalEntry // recorded since the last write, not persisted
 }
 
 // NewWallet creates a new Wallet aggregate
+// The currency of the initial balance becomes the wallet's default currency
 func NewWallet(userID vo.UserID, initialBalance vo.Money) (*Wallet, error) {
 	if userID.IsEmpty() {
 		return nil, errors.New("user ID is required")
@@ -27,9 +52,11 @@ func NewWallet(userID vo.UserID, initialBalance vo.Money) (*Wallet, error) {
 	}
 
 	return &Wallet{
-		userID:    userID,
-		balance:   initialBalance,
-		updatedAt: time.Now().UTC(),
+		userID:          userID,
+		defaultCurrency: initialBalance.Currency(),
+		balances:        map[string]vo.Money{initialBalance.Currency().Code(): initialBalance},
+		holds:           make(map[string]Hold),
+		updatedAt:       time.Now().UTC(),
 	}, nil
 }
 
@@ -39,25 +66,91 @@ func (w *Wallet) UserID() vo.UserID {
 	return w.userID
 }
 
-func (w *Wallet) Balance() vo.Money {
-	return w.balance
+// DefaultCurrency is the currency the wallet was opened with
+func (w *Wallet) DefaultCurrency() vo.Currency {
+	return w.defaultCurrency
+}
+
+// Balance returns the balance in a currency, zero if the wallet never held it
+func (w *Wallet) Balance(currency vo.Currency) vo.Money {
+	if balance, ok := w.balances[currency.Code()]; ok {
+		return balance
+	}
+	return vo.Zero(currency)
+}
+
+// Balances returns every balance the wallet holds, the default currency first
+// and the rest ordered by currency code
+func (w *Wallet) Balances() []vo.Money {
+	balances := []vo.Money{w.Balance(w.defaultCurrency)}
+	codes := make([]string, 0, len(w.balances))
+	for code := range w.balances {
+		if code != w.defaultCurrency.Code() {
+			codes = append(codes, code)
+		}
+	}
+	sort.Strings(codes)
+	for _, code := range codes {
+		balances = append(balances, w.balances[code])
+	}
+	return balances
+}
+
+// HeldBalance is the sum of the open holds in a currency
+func (w *Wallet) HeldBalance(currency vo.Currency) vo.Money {
+	held := vo.Zero(currency)
+	for _, hold := range w.holds {
+		if hold.Amount.Currency().Equals(currency) {
+			held, _ = held.Add(hold.Amount)
+		}
+	}
+	return held
+}
+
+// AvailableBalance is the balance in a currency that is not reserved by a hold
+func (w *Wallet) AvailableBalance(currency vo.Currency) vo.Money {
+	available, err := w.Balance(currency).Subtract(w.HeldBalance(currency))
+	if err != nil {
+		return vo.Zero(currency)
+	}
+	return available
+}
+
+// Holds returns the open holds ordered by creation
+func (w *Wallet) Holds() []Hold {
+	holds := make([]Hold, 0, len(w.holds))
+	for _, hold := range w.holds {
+		holds = append(holds, hold)
+	}
+	sort.Slice(holds, func(i, j int) bool {
+		if holds[i].CreatedAt.Equal(holds[j].CreatedAt) {
+			return holds[i].PaymentID < holds[j].PaymentID
+		}
+		return holds[i].CreatedAt.Before(holds[j].CreatedAt)
+	})
+	return holds
+}
+
+// HasHold checks if a hold is open for the payment
+func (w *Wallet) HasHold(paymentID string) bool {
+	_, ok := w.holds[paymentID]
+	return ok
 }
 
 func (w *Wallet) UpdatedAt() time.Time {
 	return w.updatedAt
 }
 
+func (w *Wallet) Version() int64 {
+	return w.version
+}
+
 // Domain Behaviors
 
-// CanDebit checks if the wallet has sufficient balance for a debit
+// CanDebit checks if the balance in the amount's currency has enough available
+// funds for a debit or a hold
 func (w *Wallet) CanDebit(amount vo.Money) bool {
-	// Currency must match
-	if !w.balance.Currency().Equals(amount.Currency()) {
-		return false
-	}
-
-	// Must have sufficient balance
-	hasEnough, err := w.balance.IsGreaterThanOrEqual(amount)
+	hasEnough, err := w.AvailableBalance(amount.Currency()).IsGreaterThanOrEqual(amount)
 	if err != nil {
 		return false
 	}
@@ -65,77 +158,164 @@ func (w *Wallet) CanDebit(amount vo.Money) bool {
 	return hasEnough
 }
 
-// Debit removes funds from the wallet
+// Debit removes funds from the balance in the amount's currency
 // Returns previous balance and new balance on success
 func (w *Wallet) Debit(amount vo.Money) (previousBalance vo.Money, newBalance vo.Money, err error) {
-	// Validate currency match
-	if !w.balance.Currency().Equals(amount.Currency()) {
-		return vo.Money{}, vo.Money{}, errors.New("currency mismatch: cannot debit different currency")
-	}
-
 	// Validate sufficient funds
 	if !w.CanDebit(amount) {
 		return vo.Money{}, vo.Money{}, errors.New("insufficient funds")
 	}
 
 	// Capture previous state
-	previousBalance = w.balance
+	previousBalance = w.Balance(amount.Currency())
 
 	// Perform debit
-	w.balance, err = w.balance.Subtract(amount)
+	newBalance, err = previousBalance.Subtract(amount)
 	if err != nil {
 		return vo.Money{}, vo.Money{}, err
 	}
+	w.setBalance(newBalance)
 
-	// Update timestamp
-	w.updatedAt = time.Now().UTC()
-
-	return previousBalance, w.balance, nil
+	return previousBalance, newBalance, nil
 }
 
-// Credit adds funds to the wallet
+// Credit adds funds to the balance in the amount's currency, opening it if needed
 // Returns previous balance and new balance on success
 func (w *Wallet) Credit(amount vo.Money) (previousBalance vo.Money, newBalance vo.Money, err error) {
-	// Validate currency match
-	if !w.balance.Currency().Equals(amount.Currency()) {
-		return vo.Money{}, vo.Money{}, errors.New("currency mismatch: cannot credit different currency")
-	}
-
 	// Validate amount is positive
 	if !amount.IsPositive() {
 		return vo.Money{}, vo.Money{}, errors.New("credit amount must be positive")
 	}
 
 	// Capture previous state
-	previousBalance = w.balance
+	previousBalance = w.Balance(amount.Currency())
 
 	// Perform credit
-	w.balance, err = w.balance.Add(amount)
+	newBalance, err = previousBalance.Add(amount)
 	if err != nil {
 		return vo.Money{}, vo.Money{}, err
 	}
+	w.setBalance(newBalance)
 
-	// Update timestamp
+	return previousBalance, newBalance, nil
+}
+
+// setBalance replaces the balance of one currency and touches the wallet
+func (w *Wallet) setBalance(balance vo.Money) {
+	if w.balances == nil {
+		w.balances = make(map[string]vo.Money)
+	}
+	w.balances[balance.Currency().Code()] = balance
 	w.updatedAt = time.Now().UTC()
+}
+
+// PlaceHold reserves funds for a payment without taking them from the balance
+// Returns the available balance before and after the hold. Placing the same hold
+// again is a no-op, so a redelivered authorization does not reserve twice.
+func (w *Wallet) PlaceHold(paymentID string, amount vo.Money) (previousAvailable vo.Money, newAvailable vo.Money, err error) {
+	if paymentID == "" {
+		return vo.Money{}, vo.Money{}, errors.New("payment ID is required")
+	}
+
+	if existing, ok := w.holds[paymentID]; ok {
+		if !existing.Amount.Equals(amount) {
+			return vo.Money{}, vo.Money{}, errors.New("a different hold is already open for this payment")
+		}
+		available := w.AvailableBalance(amount.Currency())
+		return available, available, nil
+	}
 
-	return previousBalance, w.balance, nil
+	if !amount.IsPositive() {
+		return vo.Money{}, vo.Money{}, errors.New("hold amount must be positive")
+	}
+
+	// Validate sufficient funds
+	if !w.CanDebit(amount) {
+		return vo.Money{}, vo.Money{}, errors.New("insufficient funds")
+	}
+
+	previousAvailable = w.AvailableBalance(amount.Currency())
+
+	now := time.Now().UTC()
+	if w.holds == nil {
+		w.holds = make(map[string]Hold)
+	}
+	w.holds[paymentID] = Hold{PaymentID: paymentID, Amount: amount, CreatedAt: now}
+	w.updatedAt = now
+
+	return previousAvailable, w.AvailableBalance(amount.Currency()), nil
+}
+
+// CaptureHold turns a hold into a debit of the balance
+// Returns the captured hold and the balance before and after the capture
+func (w *Wallet) CaptureHold(paymentID string) (hold Hold, previousBalance vo.Money, newBalance vo.Money, err error) {
+	hold, ok := w.holds[paymentID]
+	if !ok {
+		return Hold{}, vo.Money{}, vo.Money{}, errors.New("no hold open for this payment")
+	}
+
+	previousBalance = w.Balance(hold.Amount.Currency())
+
+	newBalance, err = previousBalance.Subtract(hold.Amount)
+	if err != nil {
+		return Hold{}, vo.Money{}, vo.Money{}, err
+	}
+	delete(w.holds, paymentID)
+	w.setBalance(newBalance)
+
+	return hold, previousBalance, newBalance, nil
+}
+
+// ReleaseHold drops a hold and gives its funds back to the available balance
+// The balance itself never moved, so nothing has to be refunded
+func (w *Wallet) ReleaseHold(paymentID string) (Hold, error) {
+	hold, ok := w.holds[paymentID]
+	if !ok {
+		return Hold{}, errors.New("no hold open for this payment")
+	}
+
+	delete(w.holds, paymentID)
+	w.updatedAt = time.Now().UTC()
+
+	return hold, nil
 }
 
 // Query methods
 
-// IsBalanceZero checks if balance is exactly zero
+// IsBalanceZero checks if every balance is exactly zero
 func (w *Wallet) IsBalanceZero() bool {
-	return w.balance.IsZero()
+	for _, balance := range w.balances {
+		if !balance.IsZero() {
+			return false
+		}
+	}
+	return true
 }
 
-// HasMinimumBalance checks if wallet has at least the minimum required amount
-func (w *Wallet) HasMinimumBalance(minimum vo.Money) (bool, error) {
-	// Currency must match
-	if !w.balance.Currency().Equals(minimum.Currency()) {
-		return false, errors.New("currency mismatch")
+// RecordEntry queues the journal entry behind a balance change, to be written with the wallet
+// The entry must post to this wallet's account
+func (w *Wallet) RecordEntry(entry *ledger.JournalEntry) error {
+	if entry == nil {
+		return errors.New("journal entry is required")
+	}
+	if !entry.Touches(ledger.WalletAccount(w.userID.String())) {
+		return errors.New("journal entry " + entry.ID() + " does not post to this wallet")
 	}
 
-	return w.balance.IsGreaterThanOrEqual(minimum)
+	w.pendingEntries = append(w.pendingEntries, entry)
+	return nil
+}
+
+// PendingEntries returns the journal entries recorded since the wallet was last written
+func (w *Wallet) PendingEntries() []*ledger.JournalEntry {
+	entries := make([]*ledger.JournalEntry, len(w.pendingEntries))
+	copy(entries, w.pendingEntries)
+	return entries
+}
+
+// HasMinimumBalance checks if the balance in the minimum's currency reaches it
+func (w *Wallet) HasMinimumBalance(minimum vo.Money) (bool, error) {
+	return w.Balance(minimum.Currency()).IsGreaterThanOrEqual(minimum)
 }
 
 // HasSufficientBalanceFor checks if wallet can cover a specific amount
@@ -148,12 +328,40 @@ func (w *Wallet) HasSufficientBalanceFor(amount vo.Money) bool {
 // ReconstructWallet reconstructs a Wallet from persistence
 func ReconstructWallet(
 	userID vo.UserID,
-	balance vo.Money,
+	defaultCurrency vo.Currency,
+	balances []vo.Money,
+	holds []Hold,
 	updatedAt time.Time,
+	version int64,
 ) *Wallet {
+	byCurrency := make(map[string]vo.Money, len(balances))
+	for _, balance := range balances {
+		byCurrency[balance.Currency().Code()] = balance
+	}
+
+	byPayment := make(map[string]Hold, len(holds))
+	for _, hold := range holds {
+		byPayment[hold.PaymentID] = hold
+	}
+
 	return &Wallet{
-		userID:    userID,
-		balance:   balance,
-		updatedAt: updatedAt,
+		userID:          userID,
+		defaultCurrency: defaultCurrency,
+		balances:        byCurrency,
+		holds:           byPayment,
+		updatedAt:       updatedAt,
+		version:         version,
 	}
 }
+
+// IncrementVersion advances the concurrency token after a conditional write succeeds
+// Only repositories should call it
+func (w *Wallet) IncrementVersion() {
+	w.version++
+}
+
+// ClearPendingEntries forgets the recorded journal entries once they are written
+// Only repositories should call it
+func (w *Wallet) ClearPendingEntries() {
+	w.pendingEntries = nil
+}
@@ -1,23 +1,27 @@
 package wallet
 
-import "github.com/franco/payment-api/internal/domain/shared"
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
 
 // WalletCreditedEvent is emitted when funds are credited to a wallet
 type WalletCreditedEvent struct {
 	shared.BaseEvent
 	paymentID   string
 	userID      string
-	amount      float64
-	newBalance  float64
-	prevBalance float64
+	amount      decimal.Decimal
+	newBalance  decimal.Decimal
+	prevBalance decimal.Decimal
+	currency    string
 	reason      string
 }
 
 // NewWalletCreditedEvent creates a new WalletCreditedEvent
 func NewWalletCreditedEvent(
 	paymentID, userID string,
-	amount, prevBalance, newBalance float64,
-	reason string,
+	amount, prevBalance, newBalance decimal.Decimal,
+	currency, reason string,
 	metadata shared.Metadata,
 ) *WalletCreditedEvent {
 	return &WalletCreditedEvent{
@@ -26,6 +30,7 @@ func NewWalletCreditedEvent(
 		userID:      userID,
 		amount:      amount,
 		prevBalance: prevBalance,
+		currency:    currency,
 		newBalance:  newBalance,
 		reason:      reason,
 	}
@@ -39,15 +44,19 @@ func (e *WalletCreditedEvent) UserID() string {
 	return e.userID
 }
 
-func (e *WalletCreditedEvent) Amount() float64 {
+func (e *WalletCreditedEvent) Amount() decimal.Decimal {
 	return e.amount
 }
 
-func (e *WalletCreditedEvent) PrevBalance() float64 {
+func (e *WalletCreditedEvent) PrevBalance() decimal.Decimal {
 	return e.prevBalance
 }
 
-func (e *WalletCreditedEvent) NewBalance() float64 {
+func (e *WalletCreditedEvent) Currency() string {
+	return e.currency
+}
+
+func (e *WalletCreditedEvent) NewBalance() decimal.Decimal {
 	return e.newBalance
 }
 
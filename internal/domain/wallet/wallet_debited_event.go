@@ -1,21 +1,27 @@
 package wallet
 
-import "github.com/franco/payment-api/internal/domain/shared"
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
 
 // WalletDebitedEvent is emitted when funds are debited from a wallet
 type WalletDebitedEvent struct {
 	shared.BaseEvent
 	paymentID   string
 	userID      string
-	amount      float64
-	newBalance  float64
-	prevBalance float64
+	amount      decimal.Decimal
+	newBalance  decimal.Decimal
+	prevBalance decimal.Decimal
+	currency    string
+	fx          *shared.FXDetails
 }
 
 // NewWalletDebitedEvent creates a new WalletDebitedEvent
 func NewWalletDebitedEvent(
 	paymentID, userID string,
-	amount, prevBalance, newBalance float64,
+	amount, prevBalance, newBalance decimal.Decimal,
+	currency string,
 	metadata shared.Metadata,
 ) *WalletDebitedEvent {
 	return &WalletDebitedEvent{
@@ -24,6 +30,7 @@ func NewWalletDebitedEvent(
 		userID:      userID,
 		amount:      amount,
 		prevBalance: prevBalance,
+		currency:    currency,
 		newBalance:  newBalance,
 	}
 }
@@ -36,14 +43,29 @@ func (e *WalletDebitedEvent) UserID() string {
 	return e.userID
 }
 
-func (e *WalletDebitedEvent) Amount() float64 {
+func (e *WalletDebitedEvent) Amount() decimal.Decimal {
 	return e.amount
 }
 
-func (e *WalletDebitedEvent) PrevBalance() float64 {
+func (e *WalletDebitedEvent) PrevBalance() decimal.Decimal {
 	return e.prevBalance
 }
 
-func (e *WalletDebitedEvent) NewBalance() float64 {
+func (e *WalletDebitedEvent) Currency() string {
+	return e.currency
+}
+
+func (e *WalletDebitedEvent) NewBalance() decimal.Decimal {
 	return e.newBalance
 }
+
+// WithFX records the conversion applied when the debit paid a payment in another currency
+func (e *WalletDebitedEvent) WithFX(fx *shared.FXDetails) *WalletDebitedEvent {
+	e.fx = fx
+	return e
+}
+
+// FX returns the conversion applied, nil when none was
+func (e *WalletDebitedEvent) FX() *shared.FXDetails {
+	return e.fx
+}
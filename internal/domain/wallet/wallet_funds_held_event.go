@@ -0,0 +1,86 @@
+package wallet
+
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
+
+// WalletFundsHeldEvent is emitted when funds are reserved for a payment awaiting the gateway
+// The balance does not change, only the available part of it
+type WalletFundsHeldEvent struct {
+	shared.BaseEvent
+	paymentID        string
+	userID           string
+	amount           decimal.Decimal
+	availableBalance decimal.Decimal
+	heldBalance      decimal.Decimal
+	currency         string
+	fx               *shared.FXDetails
+	fees             []shared.FeeDetail
+}
+
+// NewWalletFundsHeldEvent creates a new WalletFundsHeldEvent
+func NewWalletFundsHeldEvent(
+	paymentID, userID string,
+	amount, availableBalance, heldBalance decimal.Decimal,
+	currency string,
+	metadata shared.Metadata,
+) *WalletFundsHeldEvent {
+	return &WalletFundsHeldEvent{
+		BaseEvent:        shared.NewBaseEvent("WalletFundsHeld", metadata),
+		paymentID:        paymentID,
+		userID:           userID,
+		amount:           amount,
+		availableBalance: availableBalance,
+		heldBalance:      heldBalance,
+		currency:         currency,
+	}
+}
+
+func (e *WalletFundsHeldEvent) PaymentID() string {
+	return e.paymentID
+}
+
+func (e *WalletFundsHeldEvent) UserID() string {
+	return e.userID
+}
+
+func (e *WalletFundsHeldEvent) Amount() decimal.Decimal {
+	return e.amount
+}
+
+// AvailableBalance is the balance left outside holds after this one was placed
+func (e *WalletFundsHeldEvent) AvailableBalance() decimal.Decimal {
+	return e.availableBalance
+}
+
+// HeldBalance is the total held on the wallet after this hold was placed
+func (e *WalletFundsHeldEvent) HeldBalance() decimal.Decimal {
+	return e.heldBalance
+}
+
+func (e *WalletFundsHeldEvent) Currency() string {
+	return e.currency
+}
+
+// WithFX records the conversion applied when the hold funds a payment in another currency
+func (e *WalletFundsHeldEvent) WithFX(fx *shared.FXDetails) *WalletFundsHeldEvent {
+	e.fx = fx
+	return e
+}
+
+// FX returns the conversion applied, nil when none was
+func (e *WalletFundsHeldEvent) FX() *shared.FXDetails {
+	return e.fx
+}
+
+// WithFees records the fees included in the held amount
+func (e *WalletFundsHeldEvent) WithFees(fees []shared.FeeDetail) *WalletFundsHeldEvent {
+	e.fees = fees
+	return e
+}
+
+// Fees returns the fee lines, empty when the payment has none
+func (e *WalletFundsHeldEvent) Fees() []shared.FeeDetail {
+	return e.fees
+}
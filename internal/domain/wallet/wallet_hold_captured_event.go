@@ -0,0 +1,83 @@
+package wallet
+
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
+
+// WalletHoldCapturedEvent is emitted when a hold is settled and its funds leave the wallet
+type WalletHoldCapturedEvent struct {
+	shared.BaseEvent
+	paymentID   string
+	userID      string
+	amount      decimal.Decimal
+	newBalance  decimal.Decimal
+	prevBalance decimal.Decimal
+	currency    string
+	fx          *shared.FXDetails
+	fees        []shared.FeeDetail
+}
+
+// NewWalletHoldCapturedEvent creates a new WalletHoldCapturedEvent
+func NewWalletHoldCapturedEvent(
+	paymentID, userID string,
+	amount, prevBalance, newBalance decimal.Decimal,
+	currency string,
+	metadata shared.Metadata,
+) *WalletHoldCapturedEvent {
+	return &WalletHoldCapturedEvent{
+		BaseEvent:   shared.NewBaseEvent("WalletHoldCaptured", metadata),
+		paymentID:   paymentID,
+		userID:      userID,
+		amount:      amount,
+		prevBalance: prevBalance,
+		currency:    currency,
+		newBalance:  newBalance,
+	}
+}
+
+func (e *WalletHoldCapturedEvent) PaymentID() string {
+	return e.paymentID
+}
+
+func (e *WalletHoldCapturedEvent) UserID() string {
+	return e.userID
+}
+
+func (e *WalletHoldCapturedEvent) Amount() decimal.Decimal {
+	return e.amount
+}
+
+func (e *WalletHoldCapturedEvent) PrevBalance() decimal.Decimal {
+	return e.prevBalance
+}
+
+func (e *WalletHoldCapturedEvent) Currency() string {
+	return e.currency
+}
+
+func (e *WalletHoldCapturedEvent) NewBalance() decimal.Decimal {
+	return e.newBalance
+}
+
+// WithFX records the conversion applied when the captured hold paid a payment in another currency
+func (e *WalletHoldCapturedEvent) WithFX(fx *shared.FXDetails) *WalletHoldCapturedEvent {
+	e.fx = fx
+	return e
+}
+
+// FX returns the conversion applied, nil when none was
+func (e *WalletHoldCapturedEvent) FX() *shared.FXDetails {
+	return e.fx
+}
+
+// WithFees records the fees included in the captured amount
+func (e *WalletHoldCapturedEvent) WithFees(fees []shared.FeeDetail) *WalletHoldCapturedEvent {
+	e.fees = fees
+	return e
+}
+
+// Fees returns the fee lines, empty when the payment has none
+func (e *WalletHoldCapturedEvent) Fees() []shared.FeeDetail {
+	return e.fees
+}
@@ -0,0 +1,60 @@
+package wallet
+
+import (
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/shopspring/decimal"
+)
+
+// WalletHoldReleasedEvent is emitted when a hold is voided and its funds are available again
+type WalletHoldReleasedEvent struct {
+	shared.BaseEvent
+	paymentID        string
+	userID           string
+	amount           decimal.Decimal
+	availableBalance decimal.Decimal
+	currency         string
+	reason           string
+}
+
+// NewWalletHoldReleasedEvent creates a new WalletHoldReleasedEvent
+func NewWalletHoldReleasedEvent(
+	paymentID, userID string,
+	amount, availableBalance decimal.Decimal,
+	currency, reason string,
+	metadata shared.Metadata,
+) *WalletHoldReleasedEvent {
+	return &WalletHoldReleasedEvent{
+		BaseEvent:        shared.NewBaseEvent("WalletHoldReleased", metadata),
+		paymentID:        paymentID,
+		userID:           userID,
+		amount:           amount,
+		availableBalance: availableBalance,
+		currency:         currency,
+		reason:           reason,
+	}
+}
+
+func (e *WalletHoldReleasedEvent) PaymentID() string {
+	return e.paymentID
+}
+
+func (e *WalletHoldReleasedEvent) UserID() string {
+	return e.userID
+}
+
+func (e *WalletHoldReleasedEvent) Amount() decimal.Decimal {
+	return e.amount
+}
+
+// AvailableBalance is the balance outside holds once this one was released
+func (e *WalletHoldReleasedEvent) AvailableBalance() decimal.Decimal {
+	return e.availableBalance
+}
+
+func (e *WalletHoldReleasedEvent) Currency() string {
+	return e.currency
+}
+
+func (e *WalletHoldReleasedEvent) Reason() string {
+	return e.reason
+}
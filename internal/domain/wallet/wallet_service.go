@@ -21,19 +21,14 @@ func NewService(minimumBalance vo.Money) *Service {
 
 // ValidateDebit validates if a debit operation can be performed
 func (s *Service) ValidateDebit(wlt *Wallet, amount vo.Money) error {
-	// Check currency match
-	if !wlt.Balance().Currency().Equals(amount.Currency()) {
-		return errors.New("currency mismatch")
-	}
-
-	// Check sufficient balance
+	// Check sufficient balance in the amount's currency
 	if !wlt.CanDebit(amount) {
 		return errors.New("insufficient funds")
 	}
 
-	// Check minimum balance requirement (if configured)
-	if !s.minimumBalance.IsZero() {
-		potentialBalance, err := wlt.Balance().Subtract(amount)
+	// Check minimum balance requirement (if configured for this currency)
+	if !s.minimumBalance.IsZero() && s.minimumBalance.Currency().Equals(amount.Currency()) {
+		potentialBalance, err := wlt.AvailableBalance(amount.Currency()).Subtract(amount)
 		if err != nil {
 			return err
 		}
@@ -0,0 +1,69 @@
+package fees
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+)
+
+// ScheduleFile is the JSON fee schedule: one rule per fee, service, client, currency and amount band
+type ScheduleFile struct {
+	Rules []RuleConfig `json:"rules"`
+}
+
+// RuleConfig is one row of the fee schedule, see payment.FeeRule
+// Omitted amounts are zero: no band limit, no fixed part, no floor or cap
+type RuleConfig struct {
+	Code         string          `json:"code"`
+	ServiceID    string          `json:"serviceId"`
+	ClientID     string          `json:"clientId"`
+	Currency     string          `json:"currency"`
+	MinAmount    decimal.Decimal `json:"minAmount"`
+	MaxAmount    decimal.Decimal `json:"maxAmount"`
+	Fixed        decimal.Decimal `json:"fixed"`
+	Percentage   decimal.Decimal `json:"percentage"` // fraction, 0.015 is 1.5%
+	MinFee       decimal.Decimal `json:"minFee"`
+	MaxFee       decimal.Decimal `json:"maxFee"`
+	RefundPolicy string          `json:"refundPolicy"` // empty uses the default policy
+}
+
+// LoadScheduleFile reads a fee schedule file
+// refundPolicy applies to the fees of rules that do not set their own
+func LoadScheduleFile(path string, refundPolicy vo.FeeRefundPolicy) (*payment.FeeSchedule, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fee schedule file: %w", err)
+	}
+
+	var file ScheduleFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse fee schedule file %s: %w", path, err)
+	}
+
+	rules := make([]payment.FeeRule, 0, len(file.Rules))
+	for _, rc := range file.Rules {
+		rules = append(rules, payment.FeeRule{
+			Code:         rc.Code,
+			ServiceID:    rc.ServiceID,
+			ClientID:     rc.ClientID,
+			Currency:     rc.Currency,
+			MinAmount:    rc.MinAmount,
+			MaxAmount:    rc.MaxAmount,
+			Fixed:        rc.Fixed,
+			Percentage:   rc.Percentage,
+			MinFee:       rc.MinFee,
+			MaxFee:       rc.MaxFee,
+			RefundPolicy: vo.FeeRefundPolicy(rc.RefundPolicy),
+		})
+	}
+
+	schedule, err := payment.NewFeeSchedule(rules, refundPolicy)
+	if err != nil {
+		return nil, fmt.Errorf("fee schedule file %s: %w", path, err)
+	}
+	return schedule, nil
+}
@@ -0,0 +1,69 @@
+package fx
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/shopspring/decimal"
+)
+
+// inversePrecision is the number of decimals kept when a rate is derived from the opposite pair
+const inversePrecision = 10
+
+// RatesFile is the JSON rates file: for each base currency, how many units of each quote
+// currency one unit buys, e.g. {"rates": {"USD": {"ARS": "1000.00"}}}
+type RatesFile struct {
+	Rates map[string]map[string]decimal.Decimal `json:"rates"`
+}
+
+// StaticRateProvider serves a fixed table of market rates
+// A pair missing from the table is answered with the inverse of the opposite pair when present
+type StaticRateProvider struct {
+	rates map[string]map[string]decimal.Decimal
+}
+
+// NewStaticRateProvider creates a provider over a base -> quote -> rate table
+func NewStaticRateProvider(rates map[string]map[string]decimal.Decimal) *StaticRateProvider {
+	if rates == nil {
+		rates = make(map[string]map[string]decimal.Decimal)
+	}
+	return &StaticRateProvider{rates: rates}
+}
+
+// LoadRatesFile reads a rates file, rejecting rates that are not positive
+func LoadRatesFile(path string) (*StaticRateProvider, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read fx rates file: %w", err)
+	}
+
+	var file RatesFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parse fx rates file %s: %w", path, err)
+	}
+
+	for base, quotes := range file.Rates {
+		for quote, rate := range quotes {
+			if !rate.IsPositive() {
+				return nil, fmt.Errorf("fx rate %s/%s must be greater than zero, got %s", base, quote, rate)
+			}
+		}
+	}
+
+	return NewStaticRateProvider(file.Rates), nil
+}
+
+// Rate returns how many units of quote buy one unit of base
+func (p *StaticRateProvider) Rate(ctx context.Context, base, quote string) (decimal.Decimal, error) {
+	if rate, ok := p.rates[base][quote]; ok {
+		return rate, nil
+	}
+	if inverse, ok := p.rates[quote][base]; ok {
+		return decimal.NewFromInt(1).DivRound(inverse, inversePrecision), nil
+	}
+
+	return decimal.Decimal{}, domerrors.FXRateUnavailableError(base, quote)
+}
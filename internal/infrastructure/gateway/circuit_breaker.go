@@ -0,0 +1,213 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/observability"
+)
+
+// ErrCircuitOpen is the cause of the GATEWAY_UNAVAILABLE returned while a breaker rejects calls
+var ErrCircuitOpen = errors.New("circuit open")
+
+// CircuitState is the state of a gateway circuit breaker
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "CLOSED"    // calls flow, consecutive failures are counted
+	CircuitOpen     CircuitState = "OPEN"      // calls are rejected without reaching the gateway
+	CircuitHalfOpen CircuitState = "HALF_OPEN" // a few probe calls decide whether to close again
+)
+
+// CircuitBreakerConfig tunes a CircuitBreakerGateway
+type CircuitBreakerConfig struct {
+	FailureThreshold int           // consecutive failures that open the circuit
+	OpenTimeout      time.Duration // time open before probing
+	HalfOpenProbes   int           // concurrent probe calls allowed while half open
+}
+
+// DefaultCircuitBreakerConfig returns the settings used by the API
+func DefaultCircuitBreakerConfig() CircuitBreakerConfig {
+	return CircuitBreakerConfig{
+		FailureThreshold: 5,
+		OpenTimeout:      30 * time.Second,
+		HalfOpenProbes:   1,
+	}
+}
+
+// CircuitBreakerGateway stops calling a gateway that keeps failing
+// Only errors count as failures: a declined charge is a healthy answer.
+// While open every charge fails with GATEWAY_UNAVAILABLE, which lets the router fail over
+// and lets new payments fail before their wallet is debited.
+type CircuitBreakerGateway struct {
+	name   string
+	next   port.PaymentGateway
+	config CircuitBreakerConfig
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probes   int // probe calls in flight while half open
+}
+
+// NewCircuitBreakerGateway wraps next with a circuit breaker named after the gateway
+func NewCircuitBreakerGateway(name string, next port.PaymentGateway, config CircuitBreakerConfig) *CircuitBreakerGateway {
+	return &CircuitBreakerGateway{
+		name:   name,
+		next:   next,
+		config: config,
+		state:  CircuitClosed,
+	}
+}
+
+// Charge calls the gateway unless the circuit rejects the call
+func (g *CircuitBreakerGateway) Charge(ctx context.Context, req port.ChargeRequest) (*port.ChargeResult, error) {
+	if !g.acquire() {
+		return nil, domerrors.GatewayUnavailableError("charge", ErrCircuitOpen).WithDetail("gateway", g.name)
+	}
+
+	result, err := g.next.Charge(ctx, req)
+	if errors.Is(err, context.Canceled) {
+		// Our own cancellation says nothing about the gateway
+		g.abandon()
+		return result, err
+	}
+	g.release(err == nil)
+	return result, err
+}
+
+// Available reports whether a charge would reach the gateway now
+func (g *CircuitBreakerGateway) Available() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	switch g.state {
+	case CircuitOpen:
+		return time.Since(g.openedAt) >= g.config.OpenTimeout
+	case CircuitHalfOpen:
+		return g.probes < g.config.HalfOpenProbes
+	default:
+		return true
+	}
+}
+
+// State returns the current circuit state
+func (g *CircuitBreakerGateway) State() CircuitState {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	return g.state
+}
+
+// acquire decides whether a call may go through, moving open to half open once the timeout passed
+func (g *CircuitBreakerGateway) acquire() bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.state == CircuitOpen {
+		if time.Since(g.openedAt) < g.config.OpenTimeout {
+			return false
+		}
+		g.transition(CircuitHalfOpen)
+	}
+
+	if g.state == CircuitHalfOpen {
+		if g.probes >= g.config.HalfOpenProbes {
+			return false
+		}
+		g.probes++
+	}
+	return true
+}
+
+// release records the outcome of a call let through by acquire
+func (g *CircuitBreakerGateway) release(success bool) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.state == CircuitHalfOpen {
+		g.probes--
+		if success {
+			g.transition(CircuitClosed)
+		} else {
+			g.transition(CircuitOpen)
+		}
+		return
+	}
+
+	if success {
+		g.failures = 0
+		return
+	}
+
+	g.failures++
+	if g.state == CircuitClosed && g.failures >= g.config.FailureThreshold {
+		g.transition(CircuitOpen)
+	}
+}
+
+// abandon gives back a call let through by acquire without recording an outcome
+// A probe slot is freed so another call can probe, and the state stays as it is
+func (g *CircuitBreakerGateway) abandon() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if g.state == CircuitHalfOpen {
+		g.probes--
+	}
+}
+
+// transition changes state and reports it; the caller holds the lock
+func (g *CircuitBreakerGateway) transition(to CircuitState) {
+	from := g.state
+	if from == to {
+		return
+	}
+
+	failures := g.failures
+	g.state = to
+	switch to {
+	case CircuitOpen:
+		g.openedAt = time.Now()
+	case CircuitClosed:
+		g.failures = 0
+	case CircuitHalfOpen:
+		g.probes = 0
+	}
+
+	observability.RecordCustomEvent("GatewayCircuitStateChanged", map[string]interface{}{
+		"gateway":             g.name,
+		"from":                string(from),
+		"to":                  string(to),
+		"consecutiveFailures": failures,
+	})
+}
+
+// CircuitBreakers reports gateway availability from their breakers, by gateway name
+// A gateway without a breaker is always available
+type CircuitBreakers map[string]*CircuitBreakerGateway
+
+// Available reports whether the named gateway accepts charges
+func (b CircuitBreakers) Available(gateway string) bool {
+	breaker, ok := b[gateway]
+	return !ok || breaker.Available()
+}
+
+// ResilienceConfig holds the breaker and retry settings applied to every gateway
+type ResilienceConfig struct {
+	Breaker CircuitBreakerConfig
+	Retry   RetryConfig
+}
+
+// WrapResilient puts next behind retries and a circuit breaker
+// The breaker sits inside the retries so every attempt counts and an open circuit stops them.
+// It is returned as well, for the router's health checks.
+func WrapResilient(name string, next port.PaymentGateway, config ResilienceConfig) (port.PaymentGateway, *CircuitBreakerGateway) {
+	breaker := NewCircuitBreakerGateway(name, next, config.Breaker)
+	return NewRetryingGateway(breaker, config.Retry), breaker
+}
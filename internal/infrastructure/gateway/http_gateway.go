@@ -0,0 +1,154 @@
+package gateway
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// Decline reason used when the gateway refuses the request itself (400/422)
+const declineReasonRequestRejected = "GATEWAY_REQUEST_REJECTED"
+
+// HTTPGateway implements PaymentGateway against the v1 REST protocol
+type HTTPGateway struct {
+	client  *http.Client
+	baseURL string
+	apiKey  string
+}
+
+// NewHTTPGateway creates a new HTTPGateway
+// timeout bounds each charge call; a call that times out is reported as EXTERNAL_TIMEOUT
+func NewHTTPGateway(baseURL, apiKey string, timeout time.Duration) *HTTPGateway {
+	return &HTTPGateway{
+		client:  &http.Client{Timeout: timeout},
+		baseURL: strings.TrimRight(baseURL, "/"),
+		apiKey:  apiKey,
+	}
+}
+
+// Charge posts a charge and maps the answer to a ChargeResult
+func (g *HTTPGateway) Charge(ctx context.Context, req port.ChargeRequest) (*port.ChargeResult, error) {
+	body, err := json.Marshal(chargeRequest{
+		Reference:  req.PaymentID,
+		Amount:     req.Amount.String(),
+		Currency:   req.Currency,
+		CustomerID: req.UserID,
+		ServiceID:  req.ServiceID,
+	})
+	if err != nil {
+		return nil, domerrors.ExternalGatewayError("encode charge", err)
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.baseURL+chargesPath, bytes.NewReader(body))
+	if err != nil {
+		return nil, domerrors.ExternalGatewayError("build charge request", err)
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.Header.Set(authorizationHeader, "Bearer "+g.apiKey)
+	httpReq.Header.Set(idempotencyKeyHeader, req.IdempotencyKey)
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		if isTimeout(err) {
+			return nil, domerrors.WrapError(domerrors.ErrCodeExternalTimeout, "payment gateway did not answer in time", err).
+				WithDetail("paymentId", req.PaymentID)
+		}
+		if isDialError(err) {
+			// The request never left, the charge certainly did not happen
+			return nil, domerrors.GatewayUnavailableError("charge", err)
+		}
+		return nil, domerrors.ExternalGatewayError("charge", err)
+	}
+	defer resp.Body.Close()
+
+	payload, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, domerrors.ExternalGatewayError("read charge response", err)
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusOK:
+		return decodeChargeResponse(payload)
+	case resp.StatusCode == http.StatusBadRequest || resp.StatusCode == http.StatusUnprocessableEntity:
+		// The gateway will never accept this request, retrying cannot help
+		code, _ := decodeErrorResponse(payload)
+		return &port.ChargeResult{
+			Status:        port.ChargeDeclined,
+			DeclineReason: declineReasonRequestRejected,
+			DeclineCode:   code,
+		}, nil
+	case resp.StatusCode == http.StatusServiceUnavailable || resp.StatusCode == http.StatusTooManyRequests:
+		// The gateway refused to take the charge, another gateway may take it
+		code, message := decodeErrorResponse(payload)
+		return nil, domerrors.GatewayUnavailableError(
+			"charge",
+			fmt.Errorf("gateway answered %d: %s %s", resp.StatusCode, code, message),
+		).WithDetail("statusCode", resp.StatusCode)
+	default:
+		code, message := decodeErrorResponse(payload)
+		return nil, domerrors.ExternalGatewayError(
+			"charge",
+			fmt.Errorf("gateway answered %d: %s %s", resp.StatusCode, code, message),
+		).WithDetail("statusCode", resp.StatusCode)
+	}
+}
+
+func decodeChargeResponse(payload []byte) (*port.ChargeResult, error) {
+	var body chargeResponse
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return nil, domerrors.ExternalGatewayError("decode charge response", err)
+	}
+
+	switch body.Status {
+	case chargeStatusApproved:
+		return &port.ChargeResult{
+			Status:        port.ChargeApproved,
+			TransactionID: body.ID,
+		}, nil
+	case chargeStatusDeclined:
+		return &port.ChargeResult{
+			Status:        port.ChargeDeclined,
+			DeclineReason: body.DeclineReason,
+			DeclineCode:   body.DeclineCode,
+		}, nil
+	case chargeStatusPending:
+		return &port.ChargeResult{
+			Status:        port.ChargePending,
+			TransactionID: body.ID,
+		}, nil
+	default:
+		return nil, domerrors.ExternalGatewayError("decode charge response", fmt.Errorf("unknown status %q", body.Status))
+	}
+}
+
+// decodeErrorResponse extracts code and message from an error body, tolerating bodies that are not JSON
+func decodeErrorResponse(payload []byte) (string, string) {
+	var body errorResponse
+	if err := json.Unmarshal(payload, &body); err != nil {
+		return "", strings.TrimSpace(string(payload))
+	}
+	return body.Error.Code, body.Error.Message
+}
+
+func isDialError(err error) bool {
+	var opErr *net.OpError
+	return errors.As(err, &opErr) && opErr.Op == "dial"
+}
+
+func isTimeout(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+	var netErr interface{ Timeout() bool }
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
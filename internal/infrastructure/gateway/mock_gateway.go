@@ -0,0 +1,157 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/google/uuid"
+)
+
+// defaultMockTimeout is how long a simulated timeout hangs when the scenario does not say
+const defaultMockTimeout = 10 * time.Second
+
+// MockGateway is an in-process PaymentGateway that simulates a provider from a MockScenario
+// Approvals, declines and pending answers are stored per idempotency key and replayed,
+// like a real gateway; errors and timeouts are not, so a retry draws a new outcome.
+type MockGateway struct {
+	scenario MockScenario
+
+	mu      sync.Mutex
+	rng     *rand.Rand
+	answers map[string]*port.ChargeResult // by idempotency key
+}
+
+// NewMockGateway creates a new MockGateway
+func NewMockGateway(scenario MockScenario) (*MockGateway, error) {
+	if err := scenario.validate(); err != nil {
+		return nil, err
+	}
+
+	seed := scenario.Seed
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	return &MockGateway{
+		scenario: scenario,
+		rng:      rand.New(rand.NewSource(seed)),
+		answers:  make(map[string]*port.ChargeResult),
+	}, nil
+}
+
+// Charge answers as the scenario dictates, after the simulated latency
+func (g *MockGateway) Charge(ctx context.Context, req port.ChargeRequest) (*port.ChargeResult, error) {
+	g.mu.Lock()
+	if answer, ok := g.answers[req.IdempotencyKey]; ok {
+		g.mu.Unlock()
+		return answer, nil
+	}
+	outcome, decline := g.decide(req)
+	latency := g.latency()
+	g.mu.Unlock()
+
+	if outcome == OutcomeTimeout {
+		timeout := time.Duration(g.scenario.TimeoutAfter)
+		if timeout <= 0 {
+			timeout = defaultMockTimeout
+		}
+		sleep(ctx, timeout)
+		return nil, domerrors.WrapError(domerrors.ErrCodeExternalTimeout, "payment gateway did not answer in time", errors.New("simulated timeout")).
+			WithDetail("paymentId", req.PaymentID)
+	}
+
+	if err := sleep(ctx, latency); err != nil {
+		return nil, domerrors.ExternalGatewayError("charge", err)
+	}
+
+	var answer *port.ChargeResult
+	switch outcome {
+	case OutcomeError:
+		return nil, domerrors.ExternalGatewayError("charge", errors.New("simulated gateway error"))
+	case OutcomeUnavailable:
+		return nil, domerrors.GatewayUnavailableError("charge", errors.New("simulated unavailability"))
+	case OutcomeDecline:
+		answer = &port.ChargeResult{
+			Status:        port.ChargeDeclined,
+			DeclineReason: decline.Reason,
+			DeclineCode:   decline.Code,
+		}
+	case OutcomePending:
+		answer = &port.ChargeResult{Status: port.ChargePending, TransactionID: uuid.New().String()}
+	default:
+		answer = &port.ChargeResult{Status: port.ChargeApproved, TransactionID: uuid.New().String()}
+	}
+
+	g.mu.Lock()
+	g.answers[req.IdempotencyKey] = answer
+	g.mu.Unlock()
+	return answer, nil
+}
+
+// decide picks the outcome from the first matching rule or from the rates; the caller holds the lock
+func (g *MockGateway) decide(req port.ChargeRequest) (string, MockDecline) {
+	for _, rule := range g.scenario.Rules {
+		if !rule.matches(req.Amount, req.ServiceID) {
+			continue
+		}
+		decline := rule.Decline
+		if decline.Code == "" {
+			decline = defaultDecline
+		}
+		return rule.Outcome, decline
+	}
+
+	draw := g.rng.Float64()
+	switch {
+	case draw < g.scenario.TimeoutRate:
+		return OutcomeTimeout, MockDecline{}
+	case draw < g.scenario.TimeoutRate+g.scenario.ErrorRate:
+		return OutcomeError, MockDecline{}
+	case draw < g.scenario.TimeoutRate+g.scenario.ErrorRate+g.scenario.DeclineRate:
+		if len(g.scenario.DeclineCodes) == 0 {
+			return OutcomeDecline, defaultDecline
+		}
+		return OutcomeDecline, g.scenario.DeclineCodes[g.rng.Intn(len(g.scenario.DeclineCodes))]
+	default:
+		return OutcomeApprove, MockDecline{}
+	}
+}
+
+// latency draws the simulated delay; the caller holds the lock
+func (g *MockGateway) latency() time.Duration {
+	l := g.scenario.Latency
+	var delay time.Duration
+	switch l.Distribution {
+	case LatencyFixed:
+		delay = time.Duration(l.Mean)
+	case LatencyUniform:
+		delay = time.Duration(l.Min) + time.Duration(g.rng.Int63n(int64(l.Max-l.Min)+1))
+	case LatencyNormal:
+		delay = time.Duration(l.Mean) + time.Duration(g.rng.NormFloat64()*float64(l.StdDev))
+	}
+	if delay < 0 {
+		return 0
+	}
+	return delay
+}
+
+// sleep waits for d or until ctx is done
+func sleep(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
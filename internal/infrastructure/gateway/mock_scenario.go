@@ -0,0 +1,216 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/shopspring/decimal"
+)
+
+// Outcomes a mock scenario can force
+const (
+	OutcomeApprove     = "approve"
+	OutcomeDecline     = "decline"
+	OutcomeError       = "error"       // unknown outcome, like a 500
+	OutcomeUnavailable = "unavailable" // not taken, like a 503
+	OutcomeTimeout     = "timeout"     // hangs for TimeoutAfter, then EXTERNAL_TIMEOUT
+	OutcomePending     = "pending"     // taken, the result would come by webhook
+)
+
+// Latency distributions
+const (
+	LatencyNone    = ""
+	LatencyFixed   = "fixed"   // always Mean
+	LatencyUniform = "uniform" // between Min and Max
+	LatencyNormal  = "normal"  // Mean ± StdDev, never below zero
+)
+
+// MockScenario configures how MockGateway answers
+// Rules are checked first, in order; a charge no rule matches gets a random outcome
+// from the rates, drawn from an RNG seeded with Seed so runs can be reproduced.
+type MockScenario struct {
+	Seed         int64         `json:"seed"` // 0 seeds from the clock
+	DeclineRate  float64       `json:"declineRate"`
+	ErrorRate    float64       `json:"errorRate"`
+	TimeoutRate  float64       `json:"timeoutRate"`
+	TimeoutAfter Duration      `json:"timeoutAfter"` // how long a timeout hangs, default 10s
+	Latency      MockLatency   `json:"latency"`
+	DeclineCodes []MockDecline `json:"declineCodes"` // picked at random for random declines
+	Rules        []MockRule    `json:"rules"`
+}
+
+// MockLatency is the delay added before every answer
+type MockLatency struct {
+	Distribution string   `json:"distribution"`
+	Mean         Duration `json:"mean"`
+	StdDev       Duration `json:"stdDev"`
+	Min          Duration `json:"min"`
+	Max          Duration `json:"max"`
+}
+
+// MockDecline is a decline code with its reason
+type MockDecline struct {
+	Code   string `json:"code"`
+	Reason string `json:"reason"`
+}
+
+// MockRule forces the outcome of the charges it matches, like card network test amounts
+// Amount and ServiceID both have to match when set; ServiceID may end in "*" for a prefix
+type MockRule struct {
+	Amount    string      `json:"amount"` // decimal, e.g. "13.13"
+	ServiceID string      `json:"serviceId"`
+	Outcome   string      `json:"outcome"`
+	Decline   MockDecline `json:"decline"` // for decline outcomes, default do_not_honor
+}
+
+// Duration is a time.Duration written as a Go duration string in JSON ("250ms")
+type Duration time.Duration
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Duration) UnmarshalJSON(data []byte) error {
+	var raw string
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return fmt.Errorf("duration must be a string like \"250ms\": %w", err)
+	}
+	parsed, err := time.ParseDuration(raw)
+	if err != nil {
+		return err
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+// MarshalJSON implements json.Marshaler
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// DefaultMockScenario is the preset used when none is configured
+const DefaultMockScenario = "always-success"
+
+var defaultDecline = MockDecline{Code: "do_not_honor", Reason: "CARD_DECLINED"}
+
+// mockPresets are the scenarios selectable by name
+var mockPresets = map[string]MockScenario{
+	// Every charge approved instantly, the historical demo behaviour
+	"always-success": {},
+
+	// Mostly approved with realistic latency; the stub service ID prefixes and a few
+	// test amounts force each path of the saga
+	"realistic": {
+		DeclineRate:  0.10,
+		ErrorRate:    0.03,
+		TimeoutRate:  0.02,
+		TimeoutAfter: Duration(10 * time.Second),
+		Latency:      MockLatency{Distribution: LatencyNormal, Mean: Duration(300 * time.Millisecond), StdDev: Duration(100 * time.Millisecond)},
+		DeclineCodes: []MockDecline{
+			{Code: "insufficient_funds", Reason: "INSUFFICIENT_FUNDS"},
+			{Code: "do_not_honor", Reason: "CARD_DECLINED"},
+			{Code: "expired_card", Reason: "CARD_EXPIRED"},
+		},
+		Rules: []MockRule{
+			{ServiceID: StubDeclinePrefix + "*", Outcome: OutcomeDecline},
+			{ServiceID: StubUnavailablePrefix + "*", Outcome: OutcomeUnavailable},
+			{ServiceID: StubPendingPrefix + "*", Outcome: OutcomePending},
+			{ServiceID: "timeout-*", Outcome: OutcomeTimeout},
+			{Amount: "13.13", Outcome: OutcomeDecline, Decline: MockDecline{Code: "insufficient_funds", Reason: "INSUFFICIENT_FUNDS"}},
+			{Amount: "14.14", Outcome: OutcomeDecline, Decline: MockDecline{Code: "expired_card", Reason: "CARD_EXPIRED"}},
+			{Amount: "50.50", Outcome: OutcomeError},
+			{Amount: "60.60", Outcome: OutcomeTimeout},
+		},
+	},
+
+	// Half the charges fail, to exercise retries, the circuit breaker and failover
+	"flaky": {
+		ErrorRate: 0.5,
+		Latency:   MockLatency{Distribution: LatencyUniform, Min: Duration(50 * time.Millisecond), Max: Duration(500 * time.Millisecond)},
+	},
+}
+
+// MockPresets returns the names of the built-in scenarios
+func MockPresets() []string {
+	names := make([]string, 0, len(mockPresets))
+	for name := range mockPresets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// LoadMockScenario returns a built-in scenario by name, or reads one from a JSON file
+func LoadMockScenario(nameOrPath string) (MockScenario, error) {
+	if scenario, ok := mockPresets[nameOrPath]; ok {
+		return scenario, nil
+	}
+	if !strings.HasSuffix(nameOrPath, ".json") {
+		return MockScenario{}, fmt.Errorf("unknown mock scenario %q (presets: %s)", nameOrPath, strings.Join(MockPresets(), ", "))
+	}
+
+	data, err := os.ReadFile(nameOrPath)
+	if err != nil {
+		return MockScenario{}, fmt.Errorf("read mock scenario: %w", err)
+	}
+
+	var scenario MockScenario
+	if err := json.Unmarshal(data, &scenario); err != nil {
+		return MockScenario{}, fmt.Errorf("parse mock scenario %s: %w", nameOrPath, err)
+	}
+	return scenario, nil
+}
+
+// validate checks rates, outcomes and amounts before the gateway is used
+func (s MockScenario) validate() error {
+	for name, rate := range map[string]float64{"declineRate": s.DeclineRate, "errorRate": s.ErrorRate, "timeoutRate": s.TimeoutRate} {
+		if rate < 0 || rate > 1 {
+			return fmt.Errorf("%s must be between 0 and 1", name)
+		}
+	}
+	if s.DeclineRate+s.ErrorRate+s.TimeoutRate > 1 {
+		return fmt.Errorf("declineRate + errorRate + timeoutRate must not exceed 1")
+	}
+
+	switch s.Latency.Distribution {
+	case LatencyNone, LatencyFixed, LatencyNormal:
+	case LatencyUniform:
+		if s.Latency.Max < s.Latency.Min {
+			return fmt.Errorf("latency max must not be below min")
+		}
+	default:
+		return fmt.Errorf("unknown latency distribution %q", s.Latency.Distribution)
+	}
+
+	for i, rule := range s.Rules {
+		switch rule.Outcome {
+		case OutcomeApprove, OutcomeDecline, OutcomeError, OutcomeUnavailable, OutcomeTimeout, OutcomePending:
+		default:
+			return fmt.Errorf("rule %d: unknown outcome %q", i, rule.Outcome)
+		}
+		if rule.Amount == "" && rule.ServiceID == "" {
+			return fmt.Errorf("rule %d: amount or serviceId is required", i)
+		}
+		if rule.Amount != "" {
+			if _, err := decimal.NewFromString(rule.Amount); err != nil {
+				return fmt.Errorf("rule %d: invalid amount %q", i, rule.Amount)
+			}
+		}
+	}
+	return nil
+}
+
+// matches reports whether the rule applies to a charge
+func (r MockRule) matches(amount decimal.Decimal, serviceID string) bool {
+	if r.Amount != "" && !decimal.RequireFromString(r.Amount).Equal(amount) {
+		return false
+	}
+	if r.ServiceID == "" {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(r.ServiceID, "*"); ok {
+		return strings.HasPrefix(serviceID, prefix)
+	}
+	return r.ServiceID == serviceID
+}
@@ -0,0 +1,49 @@
+package gateway
+
+// Gateway REST protocol (v1), shared by HTTPGateway and the stub server
+//
+//	POST {baseURL}/v1/charges
+//	Authorization:   Bearer {apiKey}
+//	Idempotency-Key: {paymentId}   same key → same charge, the stored answer is replayed
+//	Content-Type:    application/json
+//
+//	200 OK                 chargeResponse with status "approved", "declined" or "pending"
+//	                       (pending: the outcome arrives later by webhook, see webhook.go)
+//	400, 422               errorResponse, the request can never succeed (reported as a decline)
+//	429, 503               errorResponse, the charge was not taken; it may fail over to another gateway
+//	401, 403, 409, 5xx     errorResponse, the outcome is unknown and the charge is retried on the same gateway
+
+const (
+	chargesPath          = "/v1/charges"
+	authorizationHeader  = "Authorization"
+	idempotencyKeyHeader = "Idempotency-Key"
+
+	chargeStatusApproved = "approved"
+	chargeStatusDeclined = "declined"
+	chargeStatusPending  = "pending"
+)
+
+// chargeRequest is the JSON body of POST /v1/charges
+type chargeRequest struct {
+	Reference  string `json:"reference"`  // our payment ID
+	Amount     string `json:"amount"`     // decimal string, e.g. "100.50"
+	Currency   string `json:"currency"`   // ISO 4217
+	CustomerID string `json:"customerId"` // our user ID
+	ServiceID  string `json:"serviceId"`
+}
+
+// chargeResponse is the JSON body of a 200 answer
+type chargeResponse struct {
+	ID            string `json:"id"`
+	Status        string `json:"status"`
+	DeclineCode   string `json:"declineCode,omitempty"`
+	DeclineReason string `json:"declineReason,omitempty"`
+}
+
+// errorResponse is the JSON body of any non-200 answer
+type errorResponse struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
@@ -0,0 +1,83 @@
+package gateway
+
+import (
+	"context"
+	"errors"
+	"log"
+	"math/rand"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+)
+
+// RetryConfig tunes a RetryingGateway
+// MaxAttempts × gateway timeout plus the delays must stay under the SQS visibility timeout,
+// otherwise the message is redelivered while the first copy is still retrying
+type RetryConfig struct {
+	MaxAttempts int           // calls per charge, including the first
+	BaseDelay   time.Duration // ceiling of the first wait, doubled on every retry
+	MaxDelay    time.Duration // upper bound for the ceiling
+}
+
+// DefaultRetryConfig returns the settings used by the API
+func DefaultRetryConfig() RetryConfig {
+	return RetryConfig{
+		MaxAttempts: 2,
+		BaseDelay:   200 * time.Millisecond,
+		MaxDelay:    2 * time.Second,
+	}
+}
+
+// RetryingGateway retries failed charges with exponential backoff and full jitter
+// Retrying is safe because every attempt carries the same idempotency key. An open
+// circuit is not retried: the breaker already decided the gateway is down.
+type RetryingGateway struct {
+	next   port.PaymentGateway
+	config RetryConfig
+}
+
+// NewRetryingGateway wraps next with retries
+func NewRetryingGateway(next port.PaymentGateway, config RetryConfig) *RetryingGateway {
+	return &RetryingGateway{
+		next:   next,
+		config: config,
+	}
+}
+
+// Charge calls the gateway until it answers, the attempts run out or ctx is done
+func (g *RetryingGateway) Charge(ctx context.Context, req port.ChargeRequest) (*port.ChargeResult, error) {
+	for attempt := 1; ; attempt++ {
+		result, err := g.next.Charge(ctx, req)
+		if err == nil {
+			return result, nil
+		}
+		if attempt >= g.config.MaxAttempts || errors.Is(err, ErrCircuitOpen) {
+			return nil, err
+		}
+
+		delay := Backoff(attempt, g.config.BaseDelay, g.config.MaxDelay)
+		log.Printf("Charge attempt %d for payment %s failed, retrying in %s: %v", attempt, req.PaymentID, delay, err)
+
+		select {
+		case <-ctx.Done():
+			return nil, err
+		case <-time.After(delay):
+		}
+	}
+}
+
+// Backoff returns the wait before retry number attempt (1-based): a random duration
+// between zero and min(maxDelay, base × 2^(attempt-1)), so retrying callers spread out
+func Backoff(attempt int, base, maxDelay time.Duration) time.Duration {
+	ceiling := base
+	for i := 1; i < attempt && ceiling < maxDelay; i++ {
+		ceiling *= 2
+	}
+	if ceiling > maxDelay {
+		ceiling = maxDelay
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling) + 1))
+}
@@ -0,0 +1,136 @@
+package gateway
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/port"
+)
+
+// Gateway types accepted in the routing file
+const (
+	gatewayTypeHTTP = "http"
+	gatewayTypeMock = "mock"
+)
+
+// RoutingConfig is the JSON routing file: the gateways and the routes between them
+type RoutingConfig struct {
+	Gateways []GatewayConfig `json:"gateways"`
+	Routes   []RouteConfig   `json:"routes"`
+}
+
+// GatewayConfig declares one named gateway
+type GatewayConfig struct {
+	Name      string `json:"name"`
+	Type      string `json:"type"`      // "http" or "mock"
+	URL       string `json:"url"`       // http only
+	APIKeyEnv string `json:"apiKeyEnv"` // env var with the API key, keeps secrets out of the file
+	Timeout   string `json:"timeout"`   // Go duration, empty uses the default
+	Scenario  string `json:"scenario"`  // mock only: preset name or JSON file, default always-success
+}
+
+// RouteConfig is one row of the routing table, see orchestrator.GatewayRoute
+type RouteConfig struct {
+	ServiceID string   `json:"serviceId"`
+	Currency  string   `json:"currency"`
+	Gateways  []string `json:"gateways"`
+}
+
+// LoadRoutingConfig reads a routing file
+func LoadRoutingConfig(path string) (*RoutingConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read gateway routing file: %w", err)
+	}
+
+	var config RoutingConfig
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parse gateway routing file %s: %w", path, err)
+	}
+	return &config, nil
+}
+
+// Build creates the gateways and the router, checking that every route names a declared gateway
+// Every gateway is wrapped with retries and a circuit breaker; the router skips open circuits
+func (c *RoutingConfig) Build(
+	defaultTimeout time.Duration,
+	resilience ResilienceConfig,
+) (map[string]port.PaymentGateway, *orchestrator.GatewayRouter, error) {
+	gateways := make(map[string]port.PaymentGateway, len(c.Gateways))
+	breakers := make(CircuitBreakers, len(c.Gateways))
+	for _, gc := range c.Gateways {
+		if gc.Name == "" {
+			return nil, nil, fmt.Errorf("gateway without name")
+		}
+		if _, exists := gateways[gc.Name]; exists {
+			return nil, nil, fmt.Errorf("gateway %s declared twice", gc.Name)
+		}
+
+		gw, err := gc.build(defaultTimeout)
+		if err != nil {
+			return nil, nil, err
+		}
+		gateways[gc.Name], breakers[gc.Name] = WrapResilient(gc.Name, gw, resilience)
+	}
+
+	routes := make([]orchestrator.GatewayRoute, 0, len(c.Routes))
+	for _, rc := range c.Routes {
+		for _, name := range rc.Gateways {
+			if _, ok := gateways[name]; !ok {
+				return nil, nil, fmt.Errorf("route %s uses undeclared gateway %s", rc.ServiceID, name)
+			}
+		}
+		routes = append(routes, orchestrator.GatewayRoute{
+			ServiceID: rc.ServiceID,
+			Currency:  rc.Currency,
+			Gateways:  rc.Gateways,
+		})
+	}
+
+	router, err := orchestrator.NewGatewayRouter(routes)
+	if err != nil {
+		return nil, nil, err
+	}
+	return gateways, router.WithHealth(breakers), nil
+}
+
+func (gc GatewayConfig) build(defaultTimeout time.Duration) (port.PaymentGateway, error) {
+	switch gc.Type {
+	case gatewayTypeMock:
+		name := gc.Scenario
+		if name == "" {
+			name = DefaultMockScenario
+		}
+		scenario, err := LoadMockScenario(name)
+		if err != nil {
+			return nil, fmt.Errorf("gateway %s: %w", gc.Name, err)
+		}
+		mock, err := NewMockGateway(scenario)
+		if err != nil {
+			return nil, fmt.Errorf("gateway %s: scenario %s: %w", gc.Name, name, err)
+		}
+		return mock, nil
+	case gatewayTypeHTTP:
+		if gc.URL == "" {
+			return nil, fmt.Errorf("gateway %s: url is required", gc.Name)
+		}
+
+		timeout := defaultTimeout
+		if gc.Timeout != "" {
+			parsed, err := time.ParseDuration(gc.Timeout)
+			if err != nil {
+				return nil, fmt.Errorf("gateway %s: invalid timeout %q", gc.Name, gc.Timeout)
+			}
+			timeout = parsed
+		}
+		if gc.APIKeyEnv == "" || os.Getenv(gc.APIKeyEnv) == "" {
+			return nil, fmt.Errorf("gateway %s: api key env %q is not set", gc.Name, gc.APIKeyEnv)
+		}
+		return NewHTTPGateway(gc.URL, os.Getenv(gc.APIKeyEnv), timeout), nil
+	default:
+		return nil, fmt.Errorf("gateway %s: unknown type %q", gc.Name, gc.Type)
+	}
+}
@@ -0,0 +1,135 @@
+package gateway
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/google/uuid"
+	"github.com/shopspring/decimal"
+)
+
+// Service ID prefixes that make the stub misbehave on purpose
+const (
+	StubDeclinePrefix     = "decline-"     // answered with a declined charge
+	StubUnavailablePrefix = "unavailable-" // answered with 503
+	StubPendingPrefix     = "pending-"     // answered as pending, the outcome is left to a webhook
+)
+
+// StubServer is a local implementation of the gateway v1 protocol for tests and development
+// It checks the auth and idempotency headers, replays the stored answer for a repeated
+// idempotency key and approves every valid charge except the service IDs listed above
+type StubServer struct {
+	apiKey string
+
+	mu      sync.Mutex
+	charges map[string]chargeResponse // by idempotency key
+}
+
+// NewStubServer creates a new StubServer that accepts the given API key
+func NewStubServer(apiKey string) *StubServer {
+	return &StubServer{
+		apiKey:  apiKey,
+		charges: make(map[string]chargeResponse),
+	}
+}
+
+// ServeHTTP implements http.Handler
+func (s *StubServer) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != chargesPath {
+		writeStubError(w, http.StatusNotFound, "not_found", "unknown path")
+		return
+	}
+	if r.Method != http.MethodPost {
+		writeStubError(w, http.StatusMethodNotAllowed, "method_not_allowed", "use POST")
+		return
+	}
+	if r.Header.Get(authorizationHeader) != "Bearer "+s.apiKey {
+		writeStubError(w, http.StatusUnauthorized, "unauthorized", "invalid API key")
+		return
+	}
+
+	idempotencyKey := r.Header.Get(idempotencyKeyHeader)
+	if idempotencyKey == "" {
+		writeStubError(w, http.StatusBadRequest, "missing_idempotency_key", "Idempotency-Key header is required")
+		return
+	}
+
+	var req chargeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeStubError(w, http.StatusBadRequest, "invalid_body", "body is not valid JSON")
+		return
+	}
+	if code, message := validateStubCharge(req); code != "" {
+		writeStubError(w, http.StatusUnprocessableEntity, code, message)
+		return
+	}
+
+	if strings.HasPrefix(req.ServiceID, StubUnavailablePrefix) {
+		writeStubError(w, http.StatusServiceUnavailable, "unavailable", "gateway temporarily unavailable")
+		return
+	}
+
+	s.mu.Lock()
+	charge, seen := s.charges[idempotencyKey]
+	if !seen {
+		charge = decideStubCharge(req)
+		s.charges[idempotencyKey] = charge
+	}
+	s.mu.Unlock()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(charge)
+}
+
+// ChargeCount returns how many distinct charges the stub has answered
+func (s *StubServer) ChargeCount() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.charges)
+}
+
+func validateStubCharge(req chargeRequest) (string, string) {
+	amount, err := decimal.NewFromString(req.Amount)
+	if err != nil || !amount.IsPositive() {
+		return "invalid_amount", "amount must be a positive decimal string"
+	}
+	if len(req.Currency) != 3 {
+		return "invalid_currency", "currency must be an ISO 4217 code"
+	}
+	if req.Reference == "" {
+		return "missing_reference", "reference is required"
+	}
+	return "", ""
+}
+
+func decideStubCharge(req chargeRequest) chargeResponse {
+	charge := chargeResponse{ID: "ch_" + uuid.New().String()}
+
+	if strings.HasPrefix(req.ServiceID, StubDeclinePrefix) {
+		charge.Status = chargeStatusDeclined
+		charge.DeclineCode = "do_not_honor"
+		charge.DeclineReason = "CARD_DECLINED"
+		return charge
+	}
+	if strings.HasPrefix(req.ServiceID, StubPendingPrefix) {
+		charge.Status = chargeStatusPending
+		return charge
+	}
+
+	charge.Status = chargeStatusApproved
+	return charge
+}
+
+func writeStubError(w http.ResponseWriter, status int, code, message string) {
+	var body errorResponse
+	body.Error.Code = code
+	body.Error.Message = message
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
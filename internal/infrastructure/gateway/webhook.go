@@ -0,0 +1,106 @@
+package gateway
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// Gateway webhook protocol (v1), the asynchronous side of the charge protocol
+//
+//	POST {ourBaseURL}/webhooks/gateway/{provider}
+//	X-Gateway-Timestamp: {unix seconds}
+//	X-Gateway-Signature: v1={hex HMAC-SHA256(secret, timestamp + "." + body)}
+//	Content-Type:        application/json
+//
+//	{"id": "evt_...", "type": "charge.succeeded" | "charge.failed",
+//	 "data": {"chargeId", "reference", "declineCode", "declineReason"}}
+//
+// The timestamp is signed with the body so a captured request cannot be replayed
+// once it falls outside the tolerance window.
+
+const (
+	WebhookTimestampHeader = "X-Gateway-Timestamp"
+	WebhookSignatureHeader = "X-Gateway-Signature"
+
+	webhookSignatureVersion = "v1="
+)
+
+// Webhook event types
+const (
+	WebhookChargeSucceeded = "charge.succeeded"
+	WebhookChargeFailed    = "charge.failed"
+)
+
+// WebhookEvent is the JSON body of a gateway callback
+type WebhookEvent struct {
+	ID   string `json:"id"`
+	Type string `json:"type"`
+	Data struct {
+		ChargeID      string `json:"chargeId"`
+		Reference     string `json:"reference"` // our payment ID
+		DeclineCode   string `json:"declineCode,omitempty"`
+		DeclineReason string `json:"declineReason,omitempty"`
+	} `json:"data"`
+}
+
+// SignWebhook returns the signature header value for a body sent at timestamp
+func SignWebhook(secret string, timestamp time.Time, body []byte) string {
+	return webhookSignatureVersion + webhookMAC(secret, strconv.FormatInt(timestamp.Unix(), 10), body)
+}
+
+// VerifyWebhook checks the signature headers of a callback against the provider secret
+// A timestamp further than tolerance from now, in either direction, is rejected
+func VerifyWebhook(secret string, header http.Header, body []byte, now time.Time, tolerance time.Duration) error {
+	rawTimestamp := header.Get(WebhookTimestampHeader)
+	seconds, err := strconv.ParseInt(rawTimestamp, 10, 64)
+	if err != nil {
+		return domerrors.InvalidSignatureError("missing or malformed timestamp")
+	}
+
+	skew := now.Sub(time.Unix(seconds, 0))
+	if skew > tolerance || skew < -tolerance {
+		return domerrors.InvalidSignatureError("timestamp outside tolerance").WithDetail("timestamp", seconds)
+	}
+
+	signature, ok := strings.CutPrefix(header.Get(WebhookSignatureHeader), webhookSignatureVersion)
+	if !ok {
+		return domerrors.InvalidSignatureError("missing or unsupported signature")
+	}
+
+	expected := webhookMAC(secret, rawTimestamp, body)
+	if !hmac.Equal([]byte(signature), []byte(expected)) {
+		return domerrors.InvalidSignatureError("signature mismatch")
+	}
+	return nil
+}
+
+// ParseWebhook decodes a verified callback body
+func ParseWebhook(body []byte) (*WebhookEvent, error) {
+	var event WebhookEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return nil, domerrors.ValidationError("body", "is not valid JSON")
+	}
+	if event.ID == "" {
+		return nil, domerrors.ValidationError("id", "is required")
+	}
+	if event.Type == "" {
+		return nil, domerrors.ValidationError("type", "is required")
+	}
+	return &event, nil
+}
+
+func webhookMAC(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
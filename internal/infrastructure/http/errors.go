@@ -0,0 +1,141 @@
+package http
+
+import (
+	"log"
+	"net/http"
+
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/google/uuid"
+)
+
+// RequestIDHeader carries the correlation ID echoed back in error bodies
+const RequestIDHeader = "X-Request-ID"
+
+// Error codes produced by the HTTP layer itself (not by the domain)
+const (
+	codeMethodNotAllowed domerrors.ErrorCode = "METHOD_NOT_ALLOWED"
+	codeRouteNotFound    domerrors.ErrorCode = "NOT_FOUND"
+)
+
+// ErrorResponse represents an error response
+// The shape is stable: clients should branch on Code, never on Message
+type ErrorResponse struct {
+	Code      string                 `json:"code"`
+	Message   string                 `json:"message"`
+	Details   map[string]interface{} `json:"details,omitempty"`
+	RequestID string                 `json:"requestId"`
+}
+
+// statusByCode maps domain error codes to HTTP status codes
+var statusByCode = map[domerrors.ErrorCode]int{
+	// 400 - the request itself is malformed
+	domerrors.ErrCodeValidationFailed: http.StatusBadRequest,
+	domerrors.ErrCodeInvalidAmount:    http.StatusBadRequest,
+	domerrors.ErrCodeInvalidCurrency:  http.StatusBadRequest,
+
+	// 401 - the caller could not be authenticated
+	domerrors.ErrCodeInvalidSignature: http.StatusUnauthorized,
+
+	// 403 - the caller is known but may not act on the resource
+	domerrors.ErrCodeGatewayMismatch: http.StatusForbidden,
+
+	// 404 - the referenced resource does not exist
+	domerrors.ErrCodePaymentNotFound:     http.StatusNotFound,
+	domerrors.ErrCodeWalletNotFound:      http.StatusNotFound,
+	domerrors.ErrCodeSagaNotFound:        http.StatusNotFound,
+	domerrors.ErrCodeFXQuoteNotFound:     http.StatusNotFound,
+	domerrors.ErrCodeLedgerEntryNotFound: http.StatusNotFound,
+	codeRouteNotFound:                    http.StatusNotFound,
+
+	// 405
+	codeMethodNotAllowed: http.StatusMethodNotAllowed,
+
+	// 409 - the request conflicts with the current state
+	domerrors.ErrCodePaymentAlreadyExists:   http.StatusConflict,
+	domerrors.ErrCodeWalletAlreadyExists:    http.StatusConflict,
+	domerrors.ErrCodeDuplicateRequest:       http.StatusConflict,
+	domerrors.ErrCodeIdempotencyError:       http.StatusConflict,
+	domerrors.ErrCodeInvalidTransition:      http.StatusConflict,
+	domerrors.ErrCodePaymentNotPending:      http.StatusConflict,
+	domerrors.ErrCodePaymentNotRefundable:   http.StatusConflict,
+	domerrors.ErrCodePaymentNotCancellable:  http.StatusConflict,
+	domerrors.ErrCodeConcurrentModification: http.StatusConflict,
+	domerrors.ErrCodeLedgerEntryExists:      http.StatusConflict,
+	domerrors.ErrCodeFXQuoteUsed:            http.StatusConflict,
+	domerrors.ErrCodeSagaOutOfOrder:         http.StatusConflict,
+	domerrors.ErrCodeSagaStaleEvent:         http.StatusConflict,
+
+	// 422 - well formed, but business rules reject it
+	domerrors.ErrCodeInsufficientFunds:   http.StatusUnprocessableEntity,
+	domerrors.ErrCodeCurrencyMismatch:    http.StatusUnprocessableEntity,
+	domerrors.ErrCodeUserMismatch:        http.StatusUnprocessableEntity,
+	domerrors.ErrCodeRefundExceedsAmount: http.StatusUnprocessableEntity,
+	domerrors.ErrCodeNegativeBalance:     http.StatusUnprocessableEntity,
+	domerrors.ErrCodeWalletDebitError:    http.StatusUnprocessableEntity,
+	domerrors.ErrCodeFXRateUnavailable:   http.StatusUnprocessableEntity,
+	domerrors.ErrCodeFXQuoteExpired:      http.StatusUnprocessableEntity,
+
+	// 5xx - our side or a dependency failed
+	domerrors.ErrCodeExternalGatewayError: http.StatusBadGateway,
+	domerrors.ErrCodeExternalTimeout:      http.StatusGatewayTimeout,
+	domerrors.ErrCodeNoGatewayRoute:       http.StatusBadGateway,
+	domerrors.ErrCodeGatewayUnavailable:   http.StatusServiceUnavailable,
+	domerrors.ErrCodeDatabaseError:        http.StatusInternalServerError,
+	domerrors.ErrCodeEventPublishError:    http.StatusInternalServerError,
+	domerrors.ErrCodeEventStoreError:      http.StatusInternalServerError,
+	domerrors.ErrCodeRepositoryError:      http.StatusInternalServerError,
+	domerrors.ErrCodeInternal:             http.StatusInternalServerError,
+	domerrors.ErrCodeUnknown:              http.StatusInternalServerError,
+}
+
+// StatusForCode returns the HTTP status for a domain error code, 500 for codes without a mapping
+func StatusForCode(code domerrors.ErrorCode) int {
+	if status, ok := LookupStatus(code); ok {
+		return status
+	}
+	return http.StatusInternalServerError
+}
+
+// LookupStatus returns the HTTP status mapped to a domain error code, and whether there is one
+func LookupStatus(code domerrors.ErrorCode) (int, bool) {
+	status, ok := statusByCode[code]
+	return status, ok
+}
+
+// writeError translates any error returned by an application service into a JSON error response
+// Errors that are not DomainErrors are treated as internal and their message is not exposed
+func writeError(w http.ResponseWriter, r *http.Request, err error) {
+	domainErr, ok := domerrors.AsDomainError(err)
+	if !ok {
+		domainErr = domerrors.WrapError(domerrors.ErrCodeInternal, "internal server error", err)
+	}
+
+	status := StatusForCode(domainErr.Code)
+	requestID := requestIDFrom(w, r)
+
+	if status >= http.StatusInternalServerError {
+		log.Printf("[%s] %s %s failed: %v", requestID, r.Method, r.URL.Path, err)
+	}
+
+	respondJSON(w, ErrorResponse{
+		Code:      string(domainErr.Code),
+		Message:   domainErr.Message,
+		Details:   domainErr.Details,
+		RequestID: requestID,
+	}, status)
+}
+
+// respondError writes an error raised by the HTTP layer itself (routing, decoding)
+func respondError(w http.ResponseWriter, r *http.Request, code domerrors.ErrorCode, message string) {
+	writeError(w, r, domerrors.NewDomainError(code, message))
+}
+
+// requestIDFrom reuses the caller's request ID or generates one, and echoes it in the response headers
+func requestIDFrom(w http.ResponseWriter, r *http.Request) string {
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = uuid.New().String()
+	}
+	w.Header().Set(RequestIDHeader, requestID)
+	return requestID
+}
@@ -0,0 +1,106 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/shopspring/decimal"
+)
+
+// FXHandler handles HTTP requests for currency conversion quotes
+type FXHandler struct {
+	quoteFXService *command.QuoteFXService
+}
+
+// NewFXHandler creates a new FXHandler
+func NewFXHandler(quoteFXService *command.QuoteFXService) *FXHandler {
+	return &FXHandler{
+		quoteFXService: quoteFXService,
+	}
+}
+
+// QuoteFXRequest represents the HTTP request body of POST /fx/quotes
+// Amount and currency are the payment's; fundingCurrency defaults to the wallet currency
+type QuoteFXRequest struct {
+	UserID          string          `json:"userId"`
+	Amount          decimal.Decimal `json:"amount"`
+	Currency        string          `json:"currency"`
+	FundingCurrency string          `json:"fundingCurrency"`
+}
+
+// FXResponse represents a conversion: the wallet pays sourceAmount to settle targetAmount
+// Rate is the applied rate, spread included, in source units per target unit
+type FXResponse struct {
+	QuoteID        string `json:"quoteId"`
+	Rate           string `json:"rate"`
+	Spread         string `json:"spread"`
+	SourceAmount   string `json:"sourceAmount"`
+	SourceCurrency string `json:"sourceCurrency"`
+	TargetAmount   string `json:"targetAmount"`
+	TargetCurrency string `json:"targetCurrency"`
+}
+
+// FXQuoteResponse represents a locked conversion, usable as fxQuoteId until expiresAt
+type FXQuoteResponse struct {
+	FXResponse
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// HandleCreateQuote handles POST /fx/quotes requests
+func (h *FXHandler) HandleCreateQuote(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req QuoteFXRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, domerrors.ErrCodeValidationFailed, "invalid request body")
+		return
+	}
+
+	result, err := h.quoteFXService.Execute(r.Context(), command.QuoteFXRequest{
+		UserID:          req.UserID,
+		Amount:          req.Amount,
+		Currency:        req.Currency,
+		FundingCurrency: req.FundingCurrency,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, FXQuoteResponse{
+		FXResponse: FXResponse{
+			QuoteID:        result.QuoteID,
+			Rate:           result.Rate.String(),
+			Spread:         result.Spread.String(),
+			SourceAmount:   result.SourceAmount.String(),
+			SourceCurrency: result.SourceCurrency,
+			TargetAmount:   result.TargetAmount.String(),
+			TargetCurrency: result.TargetCurrency,
+		},
+		ExpiresAt: result.ExpiresAt,
+	}, http.StatusCreated)
+}
+
+// toFXResponse converts the conversion of a payment, nil when it has none
+func toFXResponse(fx *shared.FXDetails) *FXResponse {
+	if fx == nil {
+		return nil
+	}
+	return &FXResponse{
+		QuoteID:        fx.QuoteID,
+		Rate:           fx.Rate.String(),
+		Spread:         fx.Spread.String(),
+		SourceAmount:   fx.SourceAmount.String(),
+		SourceCurrency: fx.SourceCurrency,
+		TargetAmount:   fx.TargetAmount.String(),
+		TargetCurrency: fx.TargetCurrency,
+	}
+}
@@ -2,32 +2,54 @@ package http
 
 import (
 	"encoding/json"
-	"log"
+	"io"
 	"net/http"
+	"strings"
+	"time"
 
 	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/shopspring/decimal"
 )
 
 // PaymentHandler handles HTTP requests for payments
 type PaymentHandler struct {
 	createPaymentService *command.CreatePaymentService
+	refundPaymentService *command.RefundPaymentService
+	cancelPaymentService *command.CancelPaymentService
+	getPaymentService    *query.GetPaymentService
+	auditPaymentService  *query.AuditPaymentService
 }
 
 // NewPaymentHandler creates a new PaymentHandler
-func NewPaymentHandler(createPaymentService *command.CreatePaymentService) *PaymentHandler {
+func NewPaymentHandler(
+	createPaymentService *command.CreatePaymentService,
+	refundPaymentService *command.RefundPaymentService,
+	cancelPaymentService *command.CancelPaymentService,
+	getPaymentService *query.GetPaymentService,
+	auditPaymentService *query.AuditPaymentService,
+) *PaymentHandler {
 	return &PaymentHandler{
 		createPaymentService: createPaymentService,
+		refundPaymentService: refundPaymentService,
+		cancelPaymentService: cancelPaymentService,
+		getPaymentService:    getPaymentService,
+		auditPaymentService:  auditPaymentService,
 	}
 }
 
 // CreatePaymentRequest represents the HTTP request body
+// Amount is a decimal string such as "100.50"; plain JSON numbers are still accepted
 type CreatePaymentRequest struct {
-	UserID         string  `json:"userId"`
-	Amount         float64 `json:"amount"`
-	Currency       string  `json:"currency"`
-	ServiceID      string  `json:"serviceId"`
-	IdempotencyKey string  `json:"idempotencyKey"`
-	ClientID       string  `json:"clientId"`
+	UserID         string          `json:"userId"`
+	Amount         decimal.Decimal `json:"amount"`
+	Currency       string          `json:"currency"`
+	ServiceID      string          `json:"serviceId"`
+	IdempotencyKey string          `json:"idempotencyKey"`
+	ClientID       string          `json:"clientId"`
+	FXQuoteID      string          `json:"fxQuoteId"` // optional, from POST /fx/quotes
 }
 
 // CreatePaymentResponse represents the HTTP response body
@@ -36,21 +58,94 @@ type CreatePaymentResponse struct {
 	Status    string `json:"status"`
 }
 
-// ErrorResponse represents an error response
-type ErrorResponse struct {
-	Error string `json:"error"`
+// RefundPaymentRequest represents the body of POST /payments/{id}/refunds
+// Amount is optional: without it the whole remaining amount is refunded
+type RefundPaymentRequest struct {
+	Amount         decimal.Decimal `json:"amount"`
+	Reason         string          `json:"reason"`
+	IdempotencyKey string          `json:"idempotencyKey"`
+	ClientID       string          `json:"clientId"`
+}
+
+// RefundPaymentResponse represents the HTTP response body of a refund
+type RefundPaymentResponse struct {
+	RefundID         string        `json:"refundId,omitempty"`
+	PaymentID        string        `json:"paymentId"`
+	Amount           string        `json:"amount,omitempty"`
+	RefundedAmount   string        `json:"refundedAmount,omitempty"`
+	RefundableAmount string        `json:"refundableAmount"`
+	Currency         string        `json:"currency,omitempty"`
+	Status           string        `json:"status"`
+	Fees             []FeeResponse `json:"fees,omitempty"` // fees given back with the refund
+}
+
+// CancelPaymentRequest represents the optional body of POST /payments/{id}/cancel
+type CancelPaymentRequest struct {
+	Reason   string `json:"reason"`
+	ClientID string `json:"clientId"`
+}
+
+// CancelPaymentResponse represents the HTTP response body of a cancellation
+type CancelPaymentResponse struct {
+	PaymentID string `json:"paymentId"`
+	Status    string `json:"status"`
+}
+
+// GetPaymentResponse represents the payment status returned by GET /payments/{id}
+type GetPaymentResponse struct {
+	PaymentID      string          `json:"paymentId"`
+	UserID         string          `json:"userId"`
+	ServiceID      string          `json:"serviceId"`
+	Amount         string          `json:"amount"`
+	Currency       string          `json:"currency"`
+	Status         string          `json:"status"`
+	Fees           []FeeResponse   `json:"fees,omitempty"`
+	TotalAmount    string          `json:"totalAmount"` // amount plus fees, what the wallet pays
+	RefundedAmount string          `json:"refundedAmount,omitempty"`
+	FailureReason  string          `json:"failureReason,omitempty"`
+	ExternalTxID   string          `json:"externalTransactionId,omitempty"`
+	FX             *FXResponse     `json:"fx,omitempty"`
+	CreatedAt      time.Time       `json:"createdAt"`
+	UpdatedAt      time.Time       `json:"updatedAt"`
+	Events         []EventResponse `json:"events,omitempty"`
+}
+
+// FeeResponse represents one fee line of a payment or a refund
+type FeeResponse struct {
+	Code         string `json:"code"`
+	Amount       string `json:"amount"`
+	Currency     string `json:"currency"`
+	RefundPolicy string `json:"refundPolicy"`
+}
+
+// AuditPaymentResponse represents the result of replaying a payment's event stream
+type AuditPaymentResponse struct {
+	PaymentID      string   `json:"paymentId"`
+	Consistent     bool     `json:"consistent"`
+	SnapshotStatus string   `json:"snapshotStatus"`
+	ReplayedStatus string   `json:"replayedStatus"`
+	Mismatches     []string `json:"mismatches"`
+}
+
+// EventResponse represents a single entry of the payment event timeline
+type EventResponse struct {
+	EventID    string          `json:"eventId"`
+	EventType  string          `json:"eventType"`
+	OccurredAt string          `json:"occurredAt"`
+	Payload    json.RawMessage `json:"payload,omitempty"`
+	Metadata   json.RawMessage `json:"metadata,omitempty"`
 }
 
 // HandleCreatePayment handles POST /payments requests
 func (h *PaymentHandler) HandleCreatePayment(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		h.respondError(w, "method not allowed", http.StatusMethodNotAllowed)
+		respondError(w, r, codeMethodNotAllowed, "method not allowed")
 		return
 	}
 
 	var req CreatePaymentRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		h.respondError(w, "invalid request body", http.StatusBadRequest)
+		respondError(w, r, domerrors.ErrCodeValidationFailed, "invalid request body")
 		return
 	}
 
@@ -62,26 +157,209 @@ func (h *PaymentHandler) HandleCreatePayment(w http.ResponseWriter, r *http.Requ
 		ServiceID:      req.ServiceID,
 		IdempotencyKey: req.IdempotencyKey,
 		ClientID:       req.ClientID,
+		FXQuoteID:      req.FXQuoteID,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, CreatePaymentResponse{
+		PaymentID: result.PaymentID,
+		Status:    result.Status,
+	}, http.StatusOK)
+}
+
+// HandlePaymentResource routes requests under /payments/{id}
+//
+//	GET /payments/{id}        current status (?include=events adds the event timeline)
+//	GET /payments/{id}/audit  snapshot vs. replayed event stream
+//	POST /payments/{id}/refunds  full or partial refund of a completed payment
+//	POST /payments/{id}/cancel   cancellation of a payment the gateway has not settled
+func (h *PaymentHandler) HandlePaymentResource(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/payments/"), "/"), "/")
+	if parts[0] == "" || len(parts) > 2 {
+		respondError(w, r, codeRouteNotFound, "not found")
+		return
+	}
+
+	paymentID := parts[0]
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.handleGetPayment(w, r, paymentID)
+	case action == "audit" && r.Method == http.MethodGet:
+		h.handleAuditPayment(w, r, paymentID)
+	case action == "refunds" && r.Method == http.MethodPost:
+		h.handleRefundPayment(w, r, paymentID)
+	case action == "cancel" && r.Method == http.MethodPost:
+		h.handleCancelPayment(w, r, paymentID)
+	case action == "" || action == "audit" || action == "refunds" || action == "cancel":
+		respondError(w, r, codeMethodNotAllowed, "method not allowed")
+	default:
+		respondError(w, r, codeRouteNotFound, "not found")
+	}
+}
+
+func (h *PaymentHandler) handleGetPayment(w http.ResponseWriter, r *http.Request, paymentID string) {
+	result, err := h.getPaymentService.Execute(r.Context(), query.GetPaymentRequest{
+		PaymentID:     paymentID,
+		IncludeEvents: r.URL.Query().Get("include") == "events",
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := GetPaymentResponse{
+		PaymentID:      result.PaymentID,
+		UserID:         result.UserID,
+		ServiceID:      result.ServiceID,
+		Amount:         result.Amount.String(),
+		Currency:       result.Currency,
+		Status:         result.Status,
+		Fees:           toFeeResponses(result.Fees),
+		TotalAmount:    result.TotalAmount.String(),
+		RefundedAmount: optionalAmount(result.RefundedAmount),
+		FailureReason:  result.FailureReason,
+		ExternalTxID:   result.ExternalTxID,
+		FX:             toFXResponse(result.FX),
+		CreatedAt:      result.CreatedAt,
+		UpdatedAt:      result.UpdatedAt,
+	}
+
+	response.Events = toEventResponses(result.Events)
+
+	respondJSON(w, response, http.StatusOK)
+}
+
+func (h *PaymentHandler) handleAuditPayment(w http.ResponseWriter, r *http.Request, paymentID string) {
+	result, err := h.auditPaymentService.Execute(r.Context(), paymentID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, AuditPaymentResponse{
+		PaymentID:      result.PaymentID,
+		Consistent:     result.Consistent,
+		SnapshotStatus: result.SnapshotStatus,
+		ReplayedStatus: result.ReplayedStatus,
+		Mismatches:     result.Mismatches,
+	}, http.StatusOK)
+}
+
+func (h *PaymentHandler) handleRefundPayment(w http.ResponseWriter, r *http.Request, paymentID string) {
+	var req RefundPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, domerrors.ErrCodeValidationFailed, "invalid request body")
+		return
+	}
+
+	result, err := h.refundPaymentService.Execute(r.Context(), command.RefundPaymentRequest{
+		PaymentID:      paymentID,
+		Amount:         req.Amount,
+		Reason:         req.Reason,
+		IdempotencyKey: req.IdempotencyKey,
+		ClientID:       req.ClientID,
 	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
 
+	respondJSON(w, RefundPaymentResponse{
+		RefundID:         result.RefundID,
+		PaymentID:        result.PaymentID,
+		Amount:           optionalAmount(result.Amount),
+		RefundedAmount:   optionalAmount(result.RefundedAmount),
+		RefundableAmount: result.RefundableAmount.String(),
+		Currency:         result.Currency,
+		Status:           result.Status,
+		Fees:             toFeeResponses(result.Fees),
+	}, http.StatusOK)
+}
+
+func (h *PaymentHandler) handleCancelPayment(w http.ResponseWriter, r *http.Request, paymentID string) {
+	// The body is optional
+	var req CancelPaymentRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		respondError(w, r, domerrors.ErrCodeValidationFailed, "invalid request body")
+		return
+	}
+
+	result, err := h.cancelPaymentService.Execute(r.Context(), command.CancelPaymentRequest{
+		PaymentID: paymentID,
+		Reason:    req.Reason,
+		ClientID:  req.ClientID,
+	})
 	if err != nil {
-		log.Printf("Error creating payment: %v", err)
-		h.respondError(w, err.Error(), http.StatusInternalServerError)
+		writeError(w, r, err)
 		return
 	}
 
-	h.respondJSON(w, CreatePaymentResponse{
+	respondJSON(w, CancelPaymentResponse{
 		PaymentID: result.PaymentID,
 		Status:    result.Status,
 	}, http.StatusOK)
 }
 
-func (h *PaymentHandler) respondJSON(w http.ResponseWriter, data interface{}, statusCode int) {
+func toEventResponses(events []shared.StoredEvent) []EventResponse {
+	responses := make([]EventResponse, 0, len(events))
+	for _, event := range events {
+		responses = append(responses, EventResponse{
+			EventID:    event.EventID,
+			EventType:  event.EventType,
+			OccurredAt: event.OccurredAt,
+			Payload:    rawJSON(event.Payload),
+			Metadata:   rawJSON(event.Metadata),
+		})
+	}
+	return responses
+}
+
+// rawJSON embeds a stored JSON document as-is, dropping it if it is not valid JSON
+func rawJSON(value string) json.RawMessage {
+	if value == "" || !json.Valid([]byte(value)) {
+		return nil
+	}
+	return json.RawMessage(value)
+}
+
+// toFeeResponses converts fee lines, nil when there are none
+func toFeeResponses(fees []shared.FeeDetail) []FeeResponse {
+	if len(fees) == 0 {
+		return nil
+	}
+
+	responses := make([]FeeResponse, 0, len(fees))
+	for _, fee := range fees {
+		responses = append(responses, FeeResponse{
+			Code:         fee.Code,
+			Amount:       fee.Amount.String(),
+			Currency:     fee.Currency,
+			RefundPolicy: fee.RefundPolicy,
+		})
+	}
+	return responses
+}
+
+// optionalAmount renders an amount, leaving zero empty so omitempty drops it
+func optionalAmount(amount decimal.Decimal) string {
+	if amount.IsZero() {
+		return ""
+	}
+	return amount.String()
+}
+
+func respondJSON(w http.ResponseWriter, data interface{}, statusCode int) {
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(statusCode)
 	json.NewEncoder(w).Encode(data)
 }
-
-func (h *PaymentHandler) respondError(w http.ResponseWriter, message string, statusCode int) {
-	h.respondJSON(w, ErrorResponse{Error: message}, statusCode)
-}
@@ -0,0 +1,154 @@
+package http
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/query"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// SagaHandler exposes payment sagas to operators
+type SagaHandler struct {
+	getSagaService *query.GetSagaService
+}
+
+// NewSagaHandler creates a new SagaHandler
+func NewSagaHandler(getSagaService *query.GetSagaService) *SagaHandler {
+	return &SagaHandler{
+		getSagaService: getSagaService,
+	}
+}
+
+// SagaResponse represents the state of a payment saga
+type SagaResponse struct {
+	PaymentID     string                 `json:"paymentId"`
+	Step          string                 `json:"step"`
+	Attempts      int                    `json:"attempts"`
+	Deadline      *time.Time             `json:"deadline,omitempty"`
+	Stuck         bool                   `json:"stuck"`
+	Compensations []CompensationResponse `json:"compensations"`
+	History       []TransitionResponse   `json:"history"`
+	StartedAt     time.Time              `json:"startedAt"`
+	UpdatedAt     time.Time              `json:"updatedAt"`
+}
+
+// TransitionResponse represents one saga step change
+type TransitionResponse struct {
+	EventType  string    `json:"eventType"`
+	From       string    `json:"from"`
+	To         string    `json:"to"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// CompensationResponse represents a compensation applied by the saga
+type CompensationResponse struct {
+	Action     string    `json:"action"`
+	Reason     string    `json:"reason"`
+	OccurredAt time.Time `json:"occurredAt"`
+}
+
+// StuckSagasResponse represents the sagas past their step deadline
+type StuckSagasResponse struct {
+	Sagas []SagaResponse `json:"sagas"`
+}
+
+// HandleSagaResource routes requests under /sagas/
+//
+//	GET /sagas/stuck        running sagas past their step deadline (?step=AWAITING_GATEWAY&limit=50)
+//	GET /sagas/{paymentId}  saga of a payment
+func (h *SagaHandler) HandleSagaResource(w http.ResponseWriter, r *http.Request) {
+	resource := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sagas/"), "/")
+	if resource == "" || strings.Contains(resource, "/") {
+		respondError(w, r, codeRouteNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodGet {
+		respondError(w, r, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	if resource == "stuck" {
+		h.handleListStuck(w, r)
+		return
+	}
+	h.handleGetSaga(w, r, resource)
+}
+
+func (h *SagaHandler) handleGetSaga(w http.ResponseWriter, r *http.Request, paymentID string) {
+	result, err := h.getSagaService.Execute(r.Context(), paymentID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, toSagaResponse(*result), http.StatusOK)
+}
+
+func (h *SagaHandler) handleListStuck(w http.ResponseWriter, r *http.Request) {
+	req := query.ListStuckSagasRequest{
+		Step: r.URL.Query().Get("step"),
+	}
+
+	if raw := r.URL.Query().Get("limit"); raw != "" {
+		limit, err := strconv.Atoi(raw)
+		if err != nil || limit <= 0 {
+			writeError(w, r, domerrors.ValidationError("limit", "must be a positive integer"))
+			return
+		}
+		req.Limit = limit
+	}
+
+	results, err := h.getSagaService.ListStuck(r.Context(), req)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	response := StuckSagasResponse{Sagas: make([]SagaResponse, 0, len(results))}
+	for _, result := range results {
+		response.Sagas = append(response.Sagas, toSagaResponse(result))
+	}
+
+	respondJSON(w, response, http.StatusOK)
+}
+
+func toSagaResponse(result query.SagaResponse) SagaResponse {
+	response := SagaResponse{
+		PaymentID:     result.PaymentID,
+		Step:          result.Step,
+		Attempts:      result.Attempts,
+		Stuck:         result.Stuck,
+		Compensations: make([]CompensationResponse, 0, len(result.Compensations)),
+		History:       make([]TransitionResponse, 0, len(result.History)),
+		StartedAt:     result.StartedAt,
+		UpdatedAt:     result.UpdatedAt,
+	}
+
+	if !result.Deadline.IsZero() {
+		deadline := result.Deadline
+		response.Deadline = &deadline
+	}
+
+	for _, compensation := range result.Compensations {
+		response.Compensations = append(response.Compensations, CompensationResponse{
+			Action:     compensation.Action,
+			Reason:     compensation.Reason,
+			OccurredAt: compensation.OccurredAt,
+		})
+	}
+
+	for _, transition := range result.History {
+		response.History = append(response.History, TransitionResponse{
+			EventType:  transition.EventType,
+			From:       transition.From.String(),
+			To:         transition.To.String(),
+			OccurredAt: transition.OccurredAt,
+		})
+	}
+
+	return response
+}
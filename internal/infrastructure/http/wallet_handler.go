@@ -0,0 +1,220 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/query"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/shopspring/decimal"
+)
+
+// WalletHandler handles HTTP requests for wallets
+type WalletHandler struct {
+	createWalletService *command.CreateWalletService
+	topUpWalletService  *command.TopUpWalletService
+	getWalletService    *query.GetWalletService
+}
+
+// NewWalletHandler creates a new WalletHandler
+func NewWalletHandler(
+	createWalletService *command.CreateWalletService,
+	topUpWalletService *command.TopUpWalletService,
+	getWalletService *query.GetWalletService,
+) *WalletHandler {
+	return &WalletHandler{
+		createWalletService: createWalletService,
+		topUpWalletService:  topUpWalletService,
+		getWalletService:    getWalletService,
+	}
+}
+
+// CreateWalletRequest represents the HTTP request body for opening a wallet
+type CreateWalletRequest struct {
+	UserID   string `json:"userId"`
+	Currency string `json:"currency"`
+}
+
+// TopUpWalletRequest represents the HTTP request body for a top-up
+type TopUpWalletRequest struct {
+	Amount         decimal.Decimal `json:"amount"`
+	Currency       string          `json:"currency"`
+	IdempotencyKey string          `json:"idempotencyKey"`
+	ClientID       string          `json:"clientId"`
+}
+
+// WalletResponse represents a wallet balance
+// Balance includes funds held for payments awaiting the gateway; only the available part can be spent
+// The top-level fields are the default currency; Balances lists every currency the wallet holds
+type WalletResponse struct {
+	UserID           string                    `json:"userId"`
+	Balance          string                    `json:"balance"`
+	AvailableBalance string                    `json:"availableBalance"`
+	HeldBalance      string                    `json:"heldBalance"`
+	Currency         string                    `json:"currency"`
+	Balances         []CurrencyBalanceResponse `json:"balances,omitempty"`
+	UpdatedAt        *time.Time                `json:"updatedAt,omitempty"`
+}
+
+// CurrencyBalanceResponse represents the balance of a wallet in one currency
+type CurrencyBalanceResponse struct {
+	Currency         string `json:"currency"`
+	Balance          string `json:"balance"`
+	AvailableBalance string `json:"availableBalance"`
+	HeldBalance      string `json:"heldBalance"`
+}
+
+// TopUpWalletResponse represents the result of a top-up
+type TopUpWalletResponse struct {
+	TopUpID  string `json:"topUpId"`
+	UserID   string `json:"userId"`
+	Balance  string `json:"balance"`
+	Currency string `json:"currency"`
+	Status   string `json:"status"`
+}
+
+// WalletHistoryResponse represents the movements applied to a wallet
+type WalletHistoryResponse struct {
+	UserID string          `json:"userId"`
+	Events []EventResponse `json:"events"`
+}
+
+// HandleCreateWallet handles POST /wallets requests
+func (h *WalletHandler) HandleCreateWallet(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		respondError(w, r, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	var req CreateWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, domerrors.ErrCodeValidationFailed, "invalid request body")
+		return
+	}
+
+	result, err := h.createWalletService.Execute(r.Context(), command.CreateWalletRequest{
+		UserID:   req.UserID,
+		Currency: req.Currency,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, WalletResponse{
+		UserID:           result.UserID,
+		Balance:          result.Balance.String(),
+		AvailableBalance: result.Balance.String(),
+		HeldBalance:      "0",
+		Currency:         result.Currency,
+	}, http.StatusCreated)
+}
+
+// HandleWalletResource routes requests under /wallets/{userId}
+//
+//	GET  /wallets/{userId}          current, available and held balance
+//	POST /wallets/{userId}/topups   add funds
+//	GET  /wallets/{userId}/history  ordered holds, debits and credits
+func (h *WalletHandler) HandleWalletResource(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/wallets/"), "/"), "/")
+	if parts[0] == "" || len(parts) > 2 {
+		respondError(w, r, codeRouteNotFound, "not found")
+		return
+	}
+
+	userID := parts[0]
+	action := ""
+	if len(parts) == 2 {
+		action = parts[1]
+	}
+
+	switch {
+	case action == "" && r.Method == http.MethodGet:
+		h.handleGetWallet(w, r, userID)
+	case action == "topups" && r.Method == http.MethodPost:
+		h.handleTopUp(w, r, userID)
+	case action == "history" && r.Method == http.MethodGet:
+		h.handleHistory(w, r, userID)
+	case action == "" || action == "topups" || action == "history":
+		respondError(w, r, codeMethodNotAllowed, "method not allowed")
+	default:
+		respondError(w, r, codeRouteNotFound, "not found")
+	}
+}
+
+func (h *WalletHandler) handleGetWallet(w http.ResponseWriter, r *http.Request, userID string) {
+	result, err := h.getWalletService.Execute(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, WalletResponse{
+		UserID:           result.UserID,
+		Balance:          result.Balance.String(),
+		AvailableBalance: result.AvailableBalance.String(),
+		HeldBalance:      result.HeldBalance.String(),
+		Currency:         result.Currency,
+		Balances:         toCurrencyBalanceResponses(result.Balances),
+		UpdatedAt:        &result.UpdatedAt,
+	}, http.StatusOK)
+}
+
+func toCurrencyBalanceResponses(balances []query.CurrencyBalance) []CurrencyBalanceResponse {
+	responses := make([]CurrencyBalanceResponse, 0, len(balances))
+	for _, balance := range balances {
+		responses = append(responses, CurrencyBalanceResponse{
+			Currency:         balance.Currency,
+			Balance:          balance.Balance.String(),
+			AvailableBalance: balance.AvailableBalance.String(),
+			HeldBalance:      balance.HeldBalance.String(),
+		})
+	}
+	return responses
+}
+
+func (h *WalletHandler) handleTopUp(w http.ResponseWriter, r *http.Request, userID string) {
+	var req TopUpWalletRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondError(w, r, domerrors.ErrCodeValidationFailed, "invalid request body")
+		return
+	}
+
+	result, err := h.topUpWalletService.Execute(r.Context(), command.TopUpWalletRequest{
+		UserID:         userID,
+		Amount:         req.Amount,
+		Currency:       req.Currency,
+		IdempotencyKey: req.IdempotencyKey,
+		ClientID:       req.ClientID,
+	})
+
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, TopUpWalletResponse{
+		TopUpID:  result.TopUpID,
+		UserID:   result.UserID,
+		Balance:  result.Balance.String(),
+		Currency: result.Currency,
+		Status:   result.Status,
+	}, http.StatusOK)
+}
+
+func (h *WalletHandler) handleHistory(w http.ResponseWriter, r *http.Request, userID string) {
+	events, err := h.getWalletService.History(r.Context(), userID)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, WalletHistoryResponse{
+		UserID: userID,
+		Events: toEventResponses(events),
+	}, http.StatusOK)
+}
@@ -0,0 +1,119 @@
+package http
+
+import (
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/infrastructure/gateway"
+)
+
+// maxWebhookBodyBytes bounds the body read before the signature is checked
+const maxWebhookBodyBytes = 64 << 10
+
+// webhookIgnored is answered for event types that carry no charge result
+const webhookIgnored = "IGNORED"
+
+// WebhookHandler receives gateway callbacks
+type WebhookHandler struct {
+	processWebhookService *command.ProcessGatewayWebhookService
+	secrets               map[string]string // webhook secret by provider
+	tolerance             time.Duration
+}
+
+// NewWebhookHandler creates a new WebhookHandler
+// tolerance is how far the signed timestamp may be from our clock
+func NewWebhookHandler(
+	processWebhookService *command.ProcessGatewayWebhookService,
+	secrets map[string]string,
+	tolerance time.Duration,
+) *WebhookHandler {
+	return &WebhookHandler{
+		processWebhookService: processWebhookService,
+		secrets:               secrets,
+		tolerance:             tolerance,
+	}
+}
+
+// WebhookResponse represents the acknowledgement of a callback
+type WebhookResponse struct {
+	EventID   string `json:"eventId"`
+	PaymentID string `json:"paymentId,omitempty"`
+	Status    string `json:"status"`
+}
+
+// HandleGatewayWebhook handles POST /webhooks/gateway/{provider}
+// Any 2xx tells the gateway to stop redelivering, so only a processed or deliberately
+// ignored callback is acknowledged
+func (h *WebhookHandler) HandleGatewayWebhook(w http.ResponseWriter, r *http.Request) {
+	provider := strings.Trim(strings.TrimPrefix(r.URL.Path, "/webhooks/gateway/"), "/")
+	if provider == "" || strings.Contains(provider, "/") {
+		respondError(w, r, codeRouteNotFound, "not found")
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		respondError(w, r, codeMethodNotAllowed, "method not allowed")
+		return
+	}
+
+	secret, ok := h.secrets[provider]
+	if !ok {
+		respondError(w, r, codeRouteNotFound, "unknown gateway provider")
+		return
+	}
+
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxWebhookBodyBytes))
+	if err != nil {
+		writeError(w, r, domerrors.ValidationError("body", "could not be read"))
+		return
+	}
+
+	if err := gateway.VerifyWebhook(secret, r.Header, body, time.Now(), h.tolerance); err != nil {
+		log.Printf("Rejected %s webhook: %v", provider, err)
+		writeError(w, r, err)
+		return
+	}
+
+	event, err := gateway.ParseWebhook(body)
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	var status port.ChargeStatus
+	switch event.Type {
+	case gateway.WebhookChargeSucceeded:
+		status = port.ChargeApproved
+	case gateway.WebhookChargeFailed:
+		status = port.ChargeDeclined
+	default:
+		respondJSON(w, WebhookResponse{EventID: event.ID, Status: webhookIgnored}, http.StatusOK)
+		return
+	}
+
+	result, err := h.processWebhookService.Execute(r.Context(), command.GatewayWebhookRequest{
+		Provider:      provider,
+		EventID:       event.ID,
+		Status:        status,
+		PaymentID:     event.Data.Reference,
+		TransactionID: event.Data.ChargeID,
+		DeclineReason: event.Data.DeclineReason,
+		DeclineCode:   event.Data.DeclineCode,
+	})
+	if err != nil {
+		writeError(w, r, err)
+		return
+	}
+
+	respondJSON(w, WebhookResponse{
+		EventID:   result.EventID,
+		PaymentID: result.PaymentID,
+		Status:    result.Status,
+	}, http.StatusOK)
+}
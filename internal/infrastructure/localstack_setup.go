@@ -71,6 +71,8 @@ func createDynamoDBTables(ctx context.Context, client *dynamodb.Client) error {
 		name      string
 		keySchema []dynamodbtypes.KeySchemaElement
 		attrDefs  []dynamodbtypes.AttributeDefinition
+		indexes   []dynamodbtypes.GlobalSecondaryIndex
+		ttl       string // attribute DynamoDB TTL expires items by, empty for none
 	}{
 		{
 			name: "Payments",
@@ -110,14 +112,122 @@ func createDynamoDBTables(ctx context.Context, client *dynamodb.Client) error {
 				{AttributeName: aws.String("eventId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
 			},
 		},
+		{
+			name: "Inbox",
+			keySchema: []dynamodbtypes.KeySchemaElement{
+				{AttributeName: aws.String("inboxKey"), KeyType: dynamodbtypes.KeyTypeHash},
+			},
+			attrDefs: []dynamodbtypes.AttributeDefinition{
+				{AttributeName: aws.String("inboxKey"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+			},
+		},
+		{
+			name: "PaymentTimeouts",
+			keySchema: []dynamodbtypes.KeySchemaElement{
+				{AttributeName: aws.String("paymentId"), KeyType: dynamodbtypes.KeyTypeHash},
+			},
+			attrDefs: []dynamodbtypes.AttributeDefinition{
+				{AttributeName: aws.String("paymentId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+				{AttributeName: aws.String("status"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+				{AttributeName: aws.String("deadline"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+			},
+			// The timeout scheduler polls pending deadlines that have passed
+			indexes: []dynamodbtypes.GlobalSecondaryIndex{
+				{
+					IndexName: aws.String("status-deadline-index"),
+					KeySchema: []dynamodbtypes.KeySchemaElement{
+						{AttributeName: aws.String("status"), KeyType: dynamodbtypes.KeyTypeHash},
+						{AttributeName: aws.String("deadline"), KeyType: dynamodbtypes.KeyTypeRange},
+					},
+					Projection: &dynamodbtypes.Projection{ProjectionType: dynamodbtypes.ProjectionTypeAll},
+				},
+			},
+		},
+		{
+			name: "Outbox",
+			keySchema: []dynamodbtypes.KeySchemaElement{
+				{AttributeName: aws.String("outboxId"), KeyType: dynamodbtypes.KeyTypeHash},
+			},
+			attrDefs: []dynamodbtypes.AttributeDefinition{
+				{AttributeName: aws.String("outboxId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+				{AttributeName: aws.String("status"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+				{AttributeName: aws.String("createdAt"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+			},
+			// The relay polls pending entries oldest first
+			indexes: []dynamodbtypes.GlobalSecondaryIndex{
+				{
+					IndexName: aws.String("status-createdAt-index"),
+					KeySchema: []dynamodbtypes.KeySchemaElement{
+						{AttributeName: aws.String("status"), KeyType: dynamodbtypes.KeyTypeHash},
+						{AttributeName: aws.String("createdAt"), KeyType: dynamodbtypes.KeyTypeRange},
+					},
+					Projection: &dynamodbtypes.Projection{ProjectionType: dynamodbtypes.ProjectionTypeAll},
+				},
+			},
+		},
+		{
+			name: "PaymentSagas",
+			keySchema: []dynamodbtypes.KeySchemaElement{
+				{AttributeName: aws.String("paymentId"), KeyType: dynamodbtypes.KeyTypeHash},
+			},
+			attrDefs: []dynamodbtypes.AttributeDefinition{
+				{AttributeName: aws.String("paymentId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+				{AttributeName: aws.String("active"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+				{AttributeName: aws.String("deadline"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+			},
+			// Sparse index of running sagas, used to find the ones stuck past their step deadline
+			indexes: []dynamodbtypes.GlobalSecondaryIndex{
+				{
+					IndexName: aws.String("active-deadline-index"),
+					KeySchema: []dynamodbtypes.KeySchemaElement{
+						{AttributeName: aws.String("active"), KeyType: dynamodbtypes.KeyTypeHash},
+						{AttributeName: aws.String("deadline"), KeyType: dynamodbtypes.KeyTypeRange},
+					},
+					Projection: &dynamodbtypes.Projection{ProjectionType: dynamodbtypes.ProjectionTypeAll},
+				},
+			},
+		},
+		{
+			name: "FXQuotes",
+			keySchema: []dynamodbtypes.KeySchemaElement{
+				{AttributeName: aws.String("quoteId"), KeyType: dynamodbtypes.KeyTypeHash},
+			},
+			attrDefs: []dynamodbtypes.AttributeDefinition{
+				{AttributeName: aws.String("quoteId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+			},
+			// Quotes are useless once expired; TTL cleans them up
+			ttl: "ttl",
+		},
+		{
+			name: "JournalEntries",
+			keySchema: []dynamodbtypes.KeySchemaElement{
+				{AttributeName: aws.String("entryId"), KeyType: dynamodbtypes.KeyTypeHash},
+			},
+			attrDefs: []dynamodbtypes.AttributeDefinition{
+				{AttributeName: aws.String("entryId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+			},
+		},
+		{
+			// One row per posting, so an account's balance is a query on its partition
+			name: "LedgerPostings",
+			keySchema: []dynamodbtypes.KeySchemaElement{
+				{AttributeName: aws.String("accountId"), KeyType: dynamodbtypes.KeyTypeHash},
+				{AttributeName: aws.String("postingId"), KeyType: dynamodbtypes.KeyTypeRange},
+			},
+			attrDefs: []dynamodbtypes.AttributeDefinition{
+				{AttributeName: aws.String("accountId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+				{AttributeName: aws.String("postingId"), AttributeType: dynamodbtypes.ScalarAttributeTypeS},
+			},
+		},
 	}
 
 	for _, table := range tables {
 		_, err := client.CreateTable(ctx, &dynamodb.CreateTableInput{
-			TableName:            aws.String(table.name),
-			KeySchema:            table.keySchema,
-			AttributeDefinitions: table.attrDefs,
-			BillingMode:          dynamodbtypes.BillingModePayPerRequest,
+			TableName:              aws.String(table.name),
+			KeySchema:              table.keySchema,
+			AttributeDefinitions:   table.attrDefs,
+			GlobalSecondaryIndexes: table.indexes,
+			BillingMode:            dynamodbtypes.BillingModePayPerRequest,
 		})
 
 		if err != nil {
@@ -126,6 +236,20 @@ func createDynamoDBTables(ctx context.Context, client *dynamodb.Client) error {
 		} else {
 			log.Printf("Created DynamoDB table: %s", table.name)
 		}
+
+		if table.ttl != "" {
+			_, err := client.UpdateTimeToLive(ctx, &dynamodb.UpdateTimeToLiveInput{
+				TableName: aws.String(table.name),
+				TimeToLiveSpecification: &dynamodbtypes.TimeToLiveSpecification{
+					AttributeName: aws.String(table.ttl),
+					Enabled:       aws.Bool(true),
+				},
+			})
+			if err != nil {
+				// Already enabled on an existing table
+				log.Printf("Table %s TTL: %v", table.name, err)
+			}
+		}
 	}
 
 	return nil
@@ -0,0 +1,378 @@
+package codec
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/shopspring/decimal"
+)
+
+// ParseEvent parses a JSON event into a domain event
+// Amounts are read as exact decimals, whether written as strings or, by older publishers, as
+// JSON numbers; payloads from before events carried a currency parse with an empty one
+// TODO: This should be refactored into an Event Serializer with Strategy Pattern
+func ParseEvent(eventType string, payload []byte) (shared.Event, error) {
+	event, err := parseEventData(eventType, payload)
+	if err != nil {
+		return nil, err
+	}
+
+	// Keep the original identity and timestamp; constructors would stamp new ones
+	var envelope struct {
+		EventID    string    `json:"eventId"`
+		OccurredAt time.Time `json:"occurredAt"`
+	}
+	if err := json.Unmarshal(payload, &envelope); err != nil {
+		return event, nil
+	}
+
+	// Payloads written before event IDs existed restore an empty ID, which consumers treat as unknown
+	if restorable, ok := event.(interface{ RestoreEventID(string) }); ok {
+		restorable.RestoreEventID(envelope.EventID)
+	}
+	if !envelope.OccurredAt.IsZero() {
+		if restorable, ok := event.(interface{ RestoreOccurredAt(time.Time) }); ok {
+			restorable.RestoreOccurredAt(envelope.OccurredAt)
+		}
+	}
+
+	return event, nil
+}
+
+func parseEventData(eventType string, payload []byte) (shared.Event, error) {
+	switch eventType {
+	case "PaymentRequested":
+		var data struct {
+			PaymentID      string             `json:"paymentID"`
+			UserID         string             `json:"userID"`
+			Amount         decimal.Decimal    `json:"amount"`
+			Currency       string             `json:"currency"`
+			ServiceID      string             `json:"serviceID"`
+			IdempotencyKey string             `json:"idempotencyKey"`
+			FX             *shared.FXDetails  `json:"fx"`
+			Fees           []shared.FeeDetail `json:"fees"`
+			Metadata       shared.Metadata    `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewPaymentRequestedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.Currency,
+			data.ServiceID,
+			data.IdempotencyKey,
+			data.Metadata,
+		).WithFX(data.FX).WithFees(data.Fees), nil
+
+	case "ExternalPaymentSucceeded":
+		var data struct {
+			PaymentID             string          `json:"paymentID"`
+			ExternalTransactionID string          `json:"externalTransactionID"`
+			Metadata              shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewExternalPaymentSucceededEvent(
+			data.PaymentID,
+			data.ExternalTransactionID,
+			data.Metadata,
+		), nil
+
+	case "ExternalPaymentFailed":
+		var data struct {
+			PaymentID string          `json:"paymentID"`
+			Reason    string          `json:"reason"`
+			ErrorCode string          `json:"errorCode"`
+			Metadata  shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewExternalPaymentFailedEvent(
+			data.PaymentID,
+			data.Reason,
+			data.ErrorCode,
+			data.Metadata,
+		), nil
+
+	case "ExternalPaymentRequested":
+		var data struct {
+			PaymentID string          `json:"paymentID"`
+			UserID    string          `json:"userID"`
+			Amount    decimal.Decimal `json:"amount"`
+			Currency  string          `json:"currency"`
+			ServiceID string          `json:"serviceID"`
+			Metadata  shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewExternalPaymentRequestedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.Currency,
+			data.ServiceID,
+			data.Metadata,
+		), nil
+
+	case "PaymentRefundRequested":
+		var data struct {
+			PaymentID string          `json:"paymentID"`
+			UserID    string          `json:"userID"`
+			Amount    decimal.Decimal `json:"amount"`
+			Currency  string          `json:"currency"`
+			Reason    string          `json:"reason"`
+			Metadata  shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewPaymentRefundRequestedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.Currency,
+			data.Reason,
+			data.Metadata,
+		), nil
+
+	case "PaymentRefunded":
+		var data struct {
+			PaymentID     string             `json:"paymentID"`
+			UserID        string             `json:"userID"`
+			RefundID      string             `json:"refundID"`
+			Amount        decimal.Decimal    `json:"amount"`
+			RefundedTotal decimal.Decimal    `json:"refundedTotal"`
+			Currency      string             `json:"currency"`
+			Reason        string             `json:"reason"`
+			Fees          []shared.FeeDetail `json:"fees"`
+			Metadata      shared.Metadata    `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewPaymentRefundedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.RefundID,
+			data.Amount,
+			data.RefundedTotal,
+			data.Currency,
+			data.Reason,
+			data.Metadata,
+		).WithFees(data.Fees), nil
+
+	case "PaymentCompleted":
+		var data struct {
+			PaymentID             string          `json:"paymentID"`
+			UserID                string          `json:"userID"`
+			Amount                decimal.Decimal `json:"amount"`
+			Currency              string          `json:"currency"`
+			ExternalTransactionID string          `json:"externalTransactionID"`
+			Metadata              shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewPaymentCompletedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.Currency,
+			data.ExternalTransactionID,
+			data.Metadata,
+		), nil
+
+	case "PaymentFailed":
+		var data struct {
+			PaymentID string          `json:"paymentID"`
+			UserID    string          `json:"userID"`
+			Amount    decimal.Decimal `json:"amount"`
+			Currency  string          `json:"currency"`
+			Reason    string          `json:"reason"`
+			Metadata  shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewPaymentFailedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.Currency,
+			data.Reason,
+			data.Metadata,
+		), nil
+
+	case "PaymentCancelled":
+		var data struct {
+			PaymentID string          `json:"paymentID"`
+			UserID    string          `json:"userID"`
+			Amount    decimal.Decimal `json:"amount"`
+			Currency  string          `json:"currency"`
+			Reason    string          `json:"reason"`
+			Metadata  shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return payment.NewPaymentCancelledEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.Currency,
+			data.Reason,
+			data.Metadata,
+		), nil
+
+	case "WalletDebited":
+		var data struct {
+			PaymentID   string            `json:"paymentID"`
+			UserID      string            `json:"userID"`
+			Amount      decimal.Decimal   `json:"amount"`
+			PrevBalance decimal.Decimal   `json:"prevBalance"`
+			NewBalance  decimal.Decimal   `json:"newBalance"`
+			Currency    string            `json:"currency"`
+			FX          *shared.FXDetails `json:"fx"`
+			Metadata    shared.Metadata   `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return wallet.NewWalletDebitedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.PrevBalance,
+			data.NewBalance,
+			data.Currency,
+			data.Metadata,
+		).WithFX(data.FX), nil
+
+	case "WalletFundsHeld":
+		var data struct {
+			PaymentID        string             `json:"paymentID"`
+			UserID           string             `json:"userID"`
+			Amount           decimal.Decimal    `json:"amount"`
+			AvailableBalance decimal.Decimal    `json:"availableBalance"`
+			HeldBalance      decimal.Decimal    `json:"heldBalance"`
+			Currency         string             `json:"currency"`
+			FX               *shared.FXDetails  `json:"fx"`
+			Fees             []shared.FeeDetail `json:"fees"`
+			Metadata         shared.Metadata    `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return wallet.NewWalletFundsHeldEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.AvailableBalance,
+			data.HeldBalance,
+			data.Currency,
+			data.Metadata,
+		).WithFX(data.FX).WithFees(data.Fees), nil
+
+	case "WalletHoldCaptured":
+		var data struct {
+			PaymentID   string             `json:"paymentID"`
+			UserID      string             `json:"userID"`
+			Amount      decimal.Decimal    `json:"amount"`
+			PrevBalance decimal.Decimal    `json:"prevBalance"`
+			NewBalance  decimal.Decimal    `json:"newBalance"`
+			Currency    string             `json:"currency"`
+			FX          *shared.FXDetails  `json:"fx"`
+			Fees        []shared.FeeDetail `json:"fees"`
+			Metadata    shared.Metadata    `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return wallet.NewWalletHoldCapturedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.PrevBalance,
+			data.NewBalance,
+			data.Currency,
+			data.Metadata,
+		).WithFX(data.FX).WithFees(data.Fees), nil
+
+	case "WalletHoldReleased":
+		var data struct {
+			PaymentID        string          `json:"paymentID"`
+			UserID           string          `json:"userID"`
+			Amount           decimal.Decimal `json:"amount"`
+			AvailableBalance decimal.Decimal `json:"availableBalance"`
+			Currency         string          `json:"currency"`
+			Reason           string          `json:"reason"`
+			Metadata         shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return wallet.NewWalletHoldReleasedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.AvailableBalance,
+			data.Currency,
+			data.Reason,
+			data.Metadata,
+		), nil
+
+	case "WalletCredited":
+		var data struct {
+			PaymentID   string          `json:"paymentID"`
+			UserID      string          `json:"userID"`
+			Amount      decimal.Decimal `json:"amount"`
+			PrevBalance decimal.Decimal `json:"prevBalance"`
+			NewBalance  decimal.Decimal `json:"newBalance"`
+			Currency    string          `json:"currency"`
+			Reason      string          `json:"reason"`
+			Metadata    shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		return wallet.NewWalletCreditedEvent(
+			data.PaymentID,
+			data.UserID,
+			data.Amount,
+			data.PrevBalance,
+			data.NewBalance,
+			data.Currency,
+			data.Reason,
+			data.Metadata,
+		), nil
+
+	case "ExternalPaymentTimeout":
+		var data struct {
+			PaymentID       string          `json:"paymentID"`
+			TimeoutDuration string          `json:"timeoutDuration"`
+			Metadata        shared.Metadata `json:"metadata"`
+		}
+		if err := json.Unmarshal(payload, &data); err != nil {
+			return nil, err
+		}
+		// Parse duration from string
+		duration, _ := time.ParseDuration(data.TimeoutDuration)
+		return payment.NewExternalPaymentTimeoutEvent(
+			data.PaymentID,
+			duration,
+			data.Metadata,
+		), nil
+
+	default:
+		return nil, fmt.Errorf("unknown event type: %s", eventType)
+	}
+}
@@ -0,0 +1,164 @@
+package codec
+
+import (
+	"encoding/json"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/domain/wallet"
+)
+
+// SerializeEvent converts a domain event into its wire format
+// Amounts are written as exact decimal strings next to their currency; converted payments
+// and their wallet movements add an "fx" object with the rate, spread and both amounts;
+// payments with fees, their holds and their refunds add a "fees" list
+// The same document is published to SNS and persisted in the EventStore,
+// so anything written here can be read back with ParseEvent
+func SerializeEvent(event shared.Event) ([]byte, error) {
+	return json.Marshal(eventData(event))
+}
+
+// eventData returns the wire fields of a domain event
+func eventData(event shared.Event) map[string]interface{} {
+	data := map[string]interface{}{
+		"eventId":    event.EventID(),
+		"eventType":  event.EventType(),
+		"occurredAt": event.OccurredAt(),
+		"metadata":   event.Metadata(),
+	}
+
+	// Add event-specific data based on type
+	switch e := event.(type) {
+	case *payment.PaymentRequestedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["currency"] = e.Currency()
+		data["serviceID"] = e.ServiceID()
+		data["idempotencyKey"] = e.IdempotencyKey()
+		if e.FX() != nil {
+			data["fx"] = e.FX()
+		}
+		if len(e.Fees()) > 0 {
+			data["fees"] = e.Fees()
+		}
+
+	case *wallet.WalletDebitedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["prevBalance"] = e.PrevBalance().String()
+		data["newBalance"] = e.NewBalance().String()
+		data["currency"] = e.Currency()
+		if e.FX() != nil {
+			data["fx"] = e.FX()
+		}
+
+	case *wallet.WalletFundsHeldEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["availableBalance"] = e.AvailableBalance().String()
+		data["heldBalance"] = e.HeldBalance().String()
+		data["currency"] = e.Currency()
+		if e.FX() != nil {
+			data["fx"] = e.FX()
+		}
+		if len(e.Fees()) > 0 {
+			data["fees"] = e.Fees()
+		}
+
+	case *wallet.WalletHoldCapturedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["prevBalance"] = e.PrevBalance().String()
+		data["newBalance"] = e.NewBalance().String()
+		data["currency"] = e.Currency()
+		if e.FX() != nil {
+			data["fx"] = e.FX()
+		}
+		if len(e.Fees()) > 0 {
+			data["fees"] = e.Fees()
+		}
+
+	case *wallet.WalletHoldReleasedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["availableBalance"] = e.AvailableBalance().String()
+		data["currency"] = e.Currency()
+		data["reason"] = e.Reason()
+
+	case *wallet.WalletCreditedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["prevBalance"] = e.PrevBalance().String()
+		data["newBalance"] = e.NewBalance().String()
+		data["currency"] = e.Currency()
+		data["reason"] = e.Reason()
+
+	case *payment.ExternalPaymentRequestedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["currency"] = e.Currency()
+		data["serviceID"] = e.ServiceID()
+
+	case *payment.ExternalPaymentSucceededEvent:
+		data["paymentID"] = e.PaymentID()
+		data["externalTransactionID"] = e.ExternalTransactionID()
+
+	case *payment.ExternalPaymentFailedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["reason"] = e.Reason()
+		data["errorCode"] = e.ErrorCode()
+
+	case *payment.ExternalPaymentTimeoutEvent:
+		data["paymentID"] = e.PaymentID()
+		data["timeoutDuration"] = e.TimeoutDuration().String()
+
+	case *payment.PaymentCompletedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["currency"] = e.Currency()
+		data["externalTransactionID"] = e.ExternalTransactionID()
+
+	case *payment.PaymentFailedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["currency"] = e.Currency()
+		data["reason"] = e.Reason()
+
+	case *payment.PaymentCancelledEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["currency"] = e.Currency()
+		data["reason"] = e.Reason()
+
+	case *payment.PaymentRefundRequestedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["amount"] = e.Amount().String()
+		data["currency"] = e.Currency()
+		data["reason"] = e.Reason()
+
+	case *payment.PaymentRefundedEvent:
+		data["paymentID"] = e.PaymentID()
+		data["userID"] = e.UserID()
+		data["refundID"] = e.RefundID()
+		data["amount"] = e.Amount().String()
+		data["refundedTotal"] = e.RefundedTotal().String()
+		data["currency"] = e.Currency()
+		data["reason"] = e.Reason()
+		if len(e.Fees()) > 0 {
+			data["fees"] = e.Fees()
+		}
+	}
+
+	return data
+}
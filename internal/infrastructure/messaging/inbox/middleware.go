@@ -0,0 +1,47 @@
+package inbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/shared"
+)
+
+// Handler processes a consumed event
+type Handler func(ctx context.Context, event shared.Event) error
+
+// Deduplicate wraps a handler so an event redelivered by SQS is only handled once per consumer
+// The inbox is checked before invoking the handler and written only after it succeeds,
+// so a failed attempt is still retried. Events without an ID (published before IDs existed)
+// are passed through unchanged.
+func Deduplicate(store port.InboxStore, consumer string, handler Handler) Handler {
+	return func(ctx context.Context, event shared.Event) error {
+		eventID := event.EventID()
+		if eventID == "" {
+			return handler(ctx, event)
+		}
+
+		processed, err := store.IsProcessed(ctx, consumer, eventID)
+		if err != nil {
+			return fmt.Errorf("failed to check inbox for event %s: %w", eventID, err)
+		}
+		if processed {
+			log.Printf("Skipping duplicate %s event %s for %s", event.EventType(), eventID, consumer)
+			return nil
+		}
+
+		if err := handler(ctx, event); err != nil {
+			return err
+		}
+
+		// The handler already succeeded: returning an error here would make SQS redeliver
+		// and run it a second time, so the failure is only logged
+		if err := store.MarkProcessed(ctx, consumer, eventID, event.EventType()); err != nil {
+			log.Printf("Failed to record event %s in inbox for %s: %v", eventID, consumer, err)
+		}
+
+		return nil
+	}
+}
@@ -0,0 +1,26 @@
+package outbox
+
+import (
+	"context"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/shared"
+)
+
+// Publisher implements EventPublisher by queueing events in the outbox
+// Delivery to SNS happens later in the Relay, so a bus outage does not fail the caller
+type Publisher struct {
+	store port.OutboxStore
+}
+
+// NewPublisher creates a new outbox Publisher
+func NewPublisher(store port.OutboxStore) *Publisher {
+	return &Publisher{
+		store: store,
+	}
+}
+
+// Publish stores the event as a pending outbox entry
+func (p *Publisher) Publish(ctx context.Context, event shared.Event, topicArn string) error {
+	return p.store.Add(ctx, event, topicArn)
+}
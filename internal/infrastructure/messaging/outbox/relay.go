@@ -0,0 +1,180 @@
+package outbox
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
+	"github.com/franco/payment-api/internal/observability"
+)
+
+// RelayConfig tunes how the relay polls and retries
+type RelayConfig struct {
+	PollInterval time.Duration // wait between passes over the outbox
+	BatchSize    int           // entries read per pass
+	MaxAttempts  int           // publish attempts per entry within a pass
+	RetryBackoff time.Duration // base wait between attempts, grows linearly
+}
+
+// DefaultRelayConfig returns the settings used by the API
+func DefaultRelayConfig() RelayConfig {
+	return RelayConfig{
+		PollInterval: 1 * time.Second,
+		BatchSize:    25,
+		MaxAttempts:  3,
+		RetryBackoff: 200 * time.Millisecond,
+	}
+}
+
+// RelayStats is a snapshot of the relay counters
+type RelayStats struct {
+	Dispatched int64
+	Failed     int64
+	Dead       int64         // entries that could not be parsed and were taken off the pending list
+	Lag        time.Duration // age of the oldest entry still pending after the last pass
+}
+
+// Relay delivers pending outbox entries to the message bus
+type Relay struct {
+	store     port.OutboxStore
+	publisher port.EventPublisher
+	config    RelayConfig
+
+	mu    sync.Mutex
+	stats RelayStats
+}
+
+// NewRelay creates a new Relay
+func NewRelay(store port.OutboxStore, publisher port.EventPublisher, config RelayConfig) *Relay {
+	return &Relay{
+		store:     store,
+		publisher: publisher,
+		config:    config,
+	}
+}
+
+// Start runs the relay in the background until ctx is cancelled
+func (r *Relay) Start(ctx context.Context) {
+	go func() {
+		ticker := time.NewTicker(r.config.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if _, err := r.DispatchPending(ctx); err != nil {
+				log.Printf("Outbox relay: %v", err)
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+// DispatchPending publishes one batch of pending entries in creation order
+// It stops at the first entry that cannot be delivered so events are never reordered;
+// that entry is retried on the next pass. An entry whose payload cannot be parsed will
+// never be delivered, so it is marked dead and the pass moves on past it
+func (r *Relay) DispatchPending(ctx context.Context) (int, error) {
+	entries, err := r.store.ListPending(ctx, r.config.BatchSize)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list pending entries: %w", err)
+	}
+
+	dispatched, dead, done := 0, 0, 0
+	var dispatchErr error
+
+	for _, entry := range entries {
+		event, err := codec.ParseEvent(entry.EventType, []byte(entry.Payload))
+		if err != nil {
+			if err := r.store.MarkDead(ctx, entry.ID, err.Error()); err != nil {
+				dispatchErr = fmt.Errorf("failed to mark unparseable outbox entry %s as dead: %w", entry.ID, err)
+				break
+			}
+			log.Printf("Outbox relay: entry %s (%s) cannot be parsed, marked dead: %v", entry.ID, entry.EventType, err)
+			dead++
+			done++
+			continue
+		}
+
+		if err := r.dispatch(ctx, entry, event); err != nil {
+			dispatchErr = err
+			break
+		}
+		dispatched++
+		done++
+	}
+
+	r.recordPass(entries, done, dispatched, dead, dispatchErr != nil)
+
+	return dispatched, dispatchErr
+}
+
+func (r *Relay) dispatch(ctx context.Context, entry port.OutboxEntry, event shared.Event) error {
+	var publishErr error
+	for attempt := 1; attempt <= r.config.MaxAttempts; attempt++ {
+		if publishErr = r.publisher.Publish(ctx, event, entry.TopicArn); publishErr == nil {
+			break
+		}
+
+		if attempt < r.config.MaxAttempts {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(r.config.RetryBackoff * time.Duration(attempt)):
+			}
+		}
+	}
+
+	if publishErr != nil {
+		if err := r.store.RecordFailure(ctx, entry.ID, publishErr.Error()); err != nil {
+			log.Printf("Outbox relay: failed to record failure for %s: %v", entry.ID, err)
+		}
+		return fmt.Errorf("failed to publish outbox entry %s: %w", entry.ID, publishErr)
+	}
+
+	// If this fails the entry is published again on the next pass; consumers must tolerate duplicates
+	if err := r.store.MarkDispatched(ctx, entry.ID); err != nil {
+		return fmt.Errorf("failed to mark outbox entry %s as dispatched: %w", entry.ID, err)
+	}
+
+	return nil
+}
+
+// recordPass updates the counters; done is how many entries left the pending list, in order
+func (r *Relay) recordPass(entries []port.OutboxEntry, done, dispatched, dead int, failed bool) {
+	lag := time.Duration(0)
+	if done < len(entries) {
+		lag = time.Since(entries[done].CreatedAt)
+	}
+
+	r.mu.Lock()
+	r.stats.Dispatched += int64(dispatched)
+	r.stats.Dead += int64(dead)
+	if failed {
+		r.stats.Failed++
+	}
+	r.stats.Lag = lag
+	r.mu.Unlock()
+
+	if len(entries) > 0 {
+		observability.RecordMetric("Custom/Outbox/LagSeconds", lag.Seconds())
+	}
+	if dead > 0 {
+		observability.RecordMetric("Custom/Outbox/Dead", float64(dead))
+	}
+}
+
+// Stats returns the current relay counters
+func (r *Relay) Stats() RelayStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
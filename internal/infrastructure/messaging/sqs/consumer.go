@@ -8,8 +8,8 @@ import (
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/sqs"
 	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
-	"github.com/franco/payment-api/internal/application/orchestrator"
 	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
 	"github.com/franco/payment-api/internal/observability"
 )
 
@@ -95,7 +95,7 @@ func (c *SQSConsumer) processMessage(message types.Message, handler func(ctx con
 
 	// Parse event using application parser
 	eventBytes, _ := json.Marshal(eventData)
-	event, err := orchestrator.ParseEvent(eventType, eventBytes)
+	event, err := codec.ParseEvent(eventType, eventBytes)
 	if err != nil {
 		log.Printf("Error parsing event type %s: %v", eventType, err)
 		return nil
@@ -103,6 +103,7 @@ func (c *SQSConsumer) processMessage(message types.Message, handler func(ctx con
 
 	// Record observability event
 	observability.RecordCustomEvent("EventConsumed", map[string]interface{}{
+		"eventId":   event.EventID(),
 		"eventType": eventType,
 		"metadata":  event.Metadata(),
 	})
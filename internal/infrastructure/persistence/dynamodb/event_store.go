@@ -3,6 +3,7 @@ package dynamodb
 import (
 	"context"
 	"encoding/json"
+	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -10,6 +11,7 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
 	"github.com/google/uuid"
 )
 
@@ -38,42 +40,46 @@ type eventItem struct {
 
 // Append stores an event in DynamoDB
 func (s *DynamoDBEventStore) Append(ctx context.Context, event shared.Event, paymentID string) error {
-	eventID := uuid.New().String()
-
-	// Marshal event to JSON (simplified - in production would use proper serialization)
-	payloadBytes, err := json.Marshal(event)
+	av, err := marshalEventItem(event, paymentID)
 	if err != nil {
 		return err
 	}
 
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      av,
+	})
+
+	return err
+}
+
+// marshalEventItem builds the EventStore row for an event
+// Shared with the unit of work so transactional writes store the exact same document
+func marshalEventItem(event shared.Event, paymentID string) (map[string]types.AttributeValue, error) {
+	// Store the same document that is published, so the stream can be replayed with ParseEvent
+	payloadBytes, err := codec.SerializeEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
 	metadataBytes, err := json.Marshal(event.Metadata())
 	if err != nil {
-		return err
+		return nil, err
 	}
 
 	item := eventItem{
-		EventID:    eventID,
+		EventID:    eventStoreID(event),
 		PaymentID:  paymentID,
 		EventType:  event.EventType(),
 		Payload:    string(payloadBytes),
 		Metadata:   string(metadataBytes),
-		OccurredAt: event.OccurredAt().Format(time.RFC3339),
-	}
-
-	av, err := attributevalue.MarshalMap(item)
-	if err != nil {
-		return err
+		OccurredAt: event.OccurredAt().Format(time.RFC3339Nano),
 	}
 
-	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(s.tableName),
-		Item:      av,
-	})
-
-	return err
+	return attributevalue.MarshalMap(item)
 }
 
-// ListByPaymentID retrieves all events for a payment
+// ListByPaymentID retrieves all events for a payment ordered by occurrence
 func (s *DynamoDBEventStore) ListByPaymentID(ctx context.Context, paymentID string) ([]shared.StoredEvent, error) {
 	result, err := s.client.Query(ctx, &dynamodb.QueryInput{
 		TableName:              aws.String(s.tableName),
@@ -104,5 +110,26 @@ func (s *DynamoDBEventStore) ListByPaymentID(ctx context.Context, paymentID stri
 		})
 	}
 
+	// eventId is a UUID, so the sort key does not reflect insertion order
+	sort.SliceStable(events, func(i, j int) bool {
+		return occurredAtOf(events[i]).Before(occurredAtOf(events[j]))
+	})
+
 	return events, nil
 }
+
+// eventStoreID uses the event's own ID as sort key, falling back to a random one for events without it
+func eventStoreID(event shared.Event) string {
+	if event.EventID() != "" {
+		return event.EventID()
+	}
+	return uuid.New().String()
+}
+
+func occurredAtOf(event shared.StoredEvent) time.Time {
+	t, err := time.Parse(time.RFC3339Nano, event.OccurredAt)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
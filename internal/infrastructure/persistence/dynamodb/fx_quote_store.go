@@ -0,0 +1,140 @@
+package dynamodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+)
+
+// DynamoDBFXQuoteStore implements FXQuoteStore using DynamoDB
+type DynamoDBFXQuoteStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBFXQuoteStore creates a new DynamoDBFXQuoteStore
+func NewDynamoDBFXQuoteStore(client *dynamodb.Client, tableName string) *DynamoDBFXQuoteStore {
+	return &DynamoDBFXQuoteStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+type fxQuoteItem struct {
+	QuoteID        string `dynamodbav:"quoteId"`
+	UserID         string `dynamodbav:"userId"`
+	Rate           string `dynamodbav:"rate"`
+	Spread         string `dynamodbav:"spread"`
+	SourceAmount   string `dynamodbav:"sourceAmount"`
+	SourceCurrency string `dynamodbav:"sourceCurrency"`
+	TargetAmount   string `dynamodbav:"targetAmount"`
+	TargetCurrency string `dynamodbav:"targetCurrency"`
+	CreatedAt      string `dynamodbav:"createdAt"`
+	ExpiresAt      string `dynamodbav:"expiresAt"`
+	TTL            int64  `dynamodbav:"ttl"`              // epoch seconds, DynamoDB TTL deletes expired quotes by it
+	UsedBy         string `dynamodbav:"usedBy,omitempty"` // payment ID, set by the create-payment transaction
+}
+
+// Save stores a quote; DynamoDB TTL removes it some time after it expires
+func (s *DynamoDBFXQuoteStore) Save(ctx context.Context, quote port.FXQuote) error {
+	conversion := quote.Conversion
+	av, err := attributevalue.MarshalMap(fxQuoteItem{
+		QuoteID:        quote.ID,
+		UserID:         quote.UserID,
+		Rate:           conversion.Rate().String(),
+		Spread:         conversion.Spread().String(),
+		SourceAmount:   conversion.Source().Amount().String(),
+		SourceCurrency: conversion.Source().Currency().Code(),
+		TargetAmount:   conversion.Target().Amount().String(),
+		TargetCurrency: conversion.Target().Currency().Code(),
+		CreatedAt:      quote.CreatedAt.UTC().Format(time.RFC3339Nano),
+		ExpiresAt:      quote.ExpiresAt.UTC().Format(time.RFC3339Nano),
+		TTL:            quote.ExpiresAt.Unix(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      av,
+	})
+
+	return err
+}
+
+// Get loads a quote by ID
+func (s *DynamoDBFXQuoteStore) Get(ctx context.Context, quoteID string) (*port.FXQuote, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"quoteId": &types.AttributeValueMemberS{Value: quoteID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, domerrors.DatabaseError("get fx quote", err)
+	}
+	if result.Item == nil {
+		return nil, domerrors.FXQuoteNotFoundError(quoteID)
+	}
+
+	var item fxQuoteItem
+	if err := attributevalue.UnmarshalMap(result.Item, &item); err != nil {
+		return nil, err
+	}
+
+	return item.toQuote()
+}
+
+func (i fxQuoteItem) toQuote() (*port.FXQuote, error) {
+	rate, err := decimal.NewFromString(i.Rate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fx rate: %w", err)
+	}
+	spread, err := decimal.NewFromString(i.Spread)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fx spread: %w", err)
+	}
+
+	source, err := quoteMoney(i.SourceAmount, i.SourceCurrency)
+	if err != nil {
+		return nil, err
+	}
+	target, err := quoteMoney(i.TargetAmount, i.TargetCurrency)
+	if err != nil {
+		return nil, err
+	}
+
+	createdAt, _ := time.Parse(time.RFC3339Nano, i.CreatedAt)
+	expiresAt, err := time.Parse(time.RFC3339Nano, i.ExpiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fx quote expiry: %w", err)
+	}
+
+	return &port.FXQuote{
+		ID:         i.QuoteID,
+		UserID:     i.UserID,
+		Conversion: vo.ReconstructConversion(i.QuoteID, rate, spread, source, target),
+		CreatedAt:  createdAt,
+		ExpiresAt:  expiresAt,
+		UsedBy:     i.UsedBy,
+	}, nil
+}
+
+func quoteMoney(amount, code string) (vo.Money, error) {
+	currency, err := vo.NewCurrency(code)
+	if err != nil {
+		return vo.Money{}, fmt.Errorf("invalid fx quote currency: %w", err)
+	}
+	return vo.NewMoneyFromString(amount, currency)
+}
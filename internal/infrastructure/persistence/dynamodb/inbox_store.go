@@ -0,0 +1,80 @@
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DynamoDBInboxStore implements InboxStore using DynamoDB
+type DynamoDBInboxStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBInboxStore creates a new DynamoDBInboxStore
+func NewDynamoDBInboxStore(client *dynamodb.Client, tableName string) *DynamoDBInboxStore {
+	return &DynamoDBInboxStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+type inboxItem struct {
+	InboxKey    string `dynamodbav:"inboxKey"`
+	Consumer    string `dynamodbav:"consumer"`
+	EventID     string `dynamodbav:"eventId"`
+	EventType   string `dynamodbav:"eventType"`
+	ProcessedAt string `dynamodbav:"processedAt"`
+}
+
+// IsProcessed reports whether the consumer already handled the event
+func (s *DynamoDBInboxStore) IsProcessed(ctx context.Context, consumer, eventID string) (bool, error) {
+	result, err := s.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"inboxKey": &types.AttributeValueMemberS{Value: inboxKey(consumer, eventID)},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		return false, err
+	}
+
+	return result.Item != nil, nil
+}
+
+// MarkProcessed records that the consumer handled the event
+func (s *DynamoDBInboxStore) MarkProcessed(ctx context.Context, consumer, eventID, eventType string) error {
+	av, err := marshalInboxItem(consumer, eventID, eventType)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      av,
+	})
+
+	return err
+}
+
+// marshalInboxItem builds the inbox row of a handled event, shared with the unit of work
+func marshalInboxItem(consumer, eventID, eventType string) (map[string]types.AttributeValue, error) {
+	return attributevalue.MarshalMap(inboxItem{
+		InboxKey:    inboxKey(consumer, eventID),
+		Consumer:    consumer,
+		EventID:     eventID,
+		EventType:   eventType,
+		ProcessedAt: time.Now().UTC().Format(time.RFC3339Nano),
+	})
+}
+
+func inboxKey(consumer, eventID string) string {
+	return consumer + "#" + eventID
+}
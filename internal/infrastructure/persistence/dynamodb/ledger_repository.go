@@ -0,0 +1,134 @@
+package dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/domain/ledger"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+)
+
+// DynamoDBLedgerRepository reads the ledger and builds the writes that post journal entries
+// Entries are never written on their own: they go in the same transaction as the wallet whose
+// balance they change, see DynamoDBWalletRepository
+type DynamoDBLedgerRepository struct {
+	client        *dynamodb.Client
+	entriesTable  string
+	postingsTable string
+	mapper        *mappers.LedgerMapper
+}
+
+// NewDynamoDBLedgerRepository creates a new DynamoDBLedgerRepository
+func NewDynamoDBLedgerRepository(client *dynamodb.Client, entriesTable, postingsTable string) *DynamoDBLedgerRepository {
+	return &DynamoDBLedgerRepository{
+		client:        client,
+		entriesTable:  entriesTable,
+		postingsTable: postingsTable,
+		mapper:        mappers.NewLedgerMapper(),
+	}
+}
+
+// AccountPostings returns every posting of an account, in no particular order
+// A shared account is read from each of its buckets
+func (r *DynamoDBLedgerRepository) AccountPostings(ctx context.Context, account ledger.AccountID) ([]ledger.Posting, error) {
+	postings := make([]ledger.Posting, 0)
+	for _, partition := range r.mapper.PostingPartitions(account) {
+		paginator := dynamodb.NewQueryPaginator(r.client, &dynamodb.QueryInput{
+			TableName:              aws.String(r.postingsTable),
+			KeyConditionExpression: aws.String("accountId = :accountId"),
+			ExpressionAttributeValues: map[string]types.AttributeValue{
+				":accountId": &types.AttributeValueMemberS{Value: partition},
+			},
+			ConsistentRead: aws.Bool(true),
+		})
+
+		for paginator.HasMorePages() {
+			page, err := paginator.NextPage(ctx)
+			if err != nil {
+				return nil, domerrors.DatabaseError("query ledger postings", err)
+			}
+
+			for _, item := range page.Items {
+				var row mappers.LedgerPostingDBModel
+				if err := attributevalue.UnmarshalMap(item, &row); err != nil {
+					return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to unmarshal ledger posting", err)
+				}
+
+				posting, err := r.mapper.PostingToDomain(account, &row)
+				if err != nil {
+					return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert ledger posting "+row.PostingID, err)
+				}
+				postings = append(postings, posting)
+			}
+		}
+	}
+
+	return postings, nil
+}
+
+// FindEntry reads back a posted journal entry with all its postings
+func (r *DynamoDBLedgerRepository) FindEntry(ctx context.Context, entryID string) (*ledger.JournalEntry, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.entriesTable),
+		Key: map[string]types.AttributeValue{
+			"entryId": &types.AttributeValueMemberS{Value: entryID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+	if err != nil {
+		return nil, domerrors.DatabaseError("get journal entry", err)
+	}
+	if result.Item == nil {
+		return nil, domerrors.LedgerEntryNotFoundError(entryID)
+	}
+
+	var dbModel mappers.JournalEntryDBModel
+	if err := attributevalue.UnmarshalMap(result.Item, &dbModel); err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to unmarshal journal entry", err)
+	}
+
+	return r.mapper.ToDomain(&dbModel)
+}
+
+// transactItems returns the writes that post an entry: its entry row first, then one row per posting
+// The entry row is conditional, so posting the same movement twice cancels the whole transaction
+func (r *DynamoDBLedgerRepository) transactItems(entry *ledger.JournalEntry) ([]types.TransactWriteItem, error) {
+	dbModel, err := r.mapper.ToDBModel(entry)
+	if err != nil {
+		return nil, err
+	}
+
+	entryItem, err := attributevalue.MarshalMap(dbModel)
+	if err != nil {
+		return nil, err
+	}
+
+	items := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(r.entriesTable),
+				Item:                entryItem,
+				ConditionExpression: aws.String("attribute_not_exists(entryId)"),
+			},
+		},
+	}
+
+	for _, row := range r.mapper.ToPostingRows(entry) {
+		postingItem, err := attributevalue.MarshalMap(row)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(r.postingsTable),
+				Item:      postingItem,
+			},
+		})
+	}
+
+	return items, nil
+}
@@ -0,0 +1,180 @@
+package mappers
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/ledger"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+)
+
+// JournalEntryDBModel represents a journal entry with all its postings
+// The row exists to make an entry ID unique and to read an entry back whole
+type JournalEntryDBModel struct {
+	EntryID   string           `dynamodbav:"entryId"`
+	Type      string           `dynamodbav:"type"`
+	Reference string           `dynamodbav:"reference"`
+	Postings  []PostingDBModel `dynamodbav:"postings"`
+	PostedAt  string           `dynamodbav:"postedAt"`
+}
+
+// PostingDBModel represents one posting inside a journal entry row
+type PostingDBModel struct {
+	AccountID string `dynamodbav:"accountId"`
+	Side      string `dynamodbav:"side"`
+	Amount    string `dynamodbav:"amount"` // Decimal as string
+	Currency  string `dynamodbav:"currency"`
+}
+
+// sharedAccountBuckets is how many partitions the postings of a shared account are spread over
+// Every payment posts to settlement (and fx, and the fee accounts), so a single partition per
+// shared account would take the write load of the whole platform
+const sharedAccountBuckets = 16
+
+// LedgerPostingDBModel represents a posting stored under its account
+// Wallet accounts are their own partition; shared accounts are split into accountId#bucket
+type LedgerPostingDBModel struct {
+	AccountID string `dynamodbav:"accountId"`
+	PostingID string `dynamodbav:"postingId"` // entryId#index, unique within the account
+	EntryID   string `dynamodbav:"entryId"`
+	EntryType string `dynamodbav:"entryType"`
+	Side      string `dynamodbav:"side"`
+	Amount    string `dynamodbav:"amount"` // Decimal as string
+	Currency  string `dynamodbav:"currency"`
+	PostedAt  string `dynamodbav:"postedAt"`
+}
+
+// LedgerMapper handles mapping between journal entries and persistence models
+type LedgerMapper struct{}
+
+// NewLedgerMapper creates a new LedgerMapper
+func NewLedgerMapper() *LedgerMapper {
+	return &LedgerMapper{}
+}
+
+// ToDBModel converts a journal entry to its entry row
+func (m *LedgerMapper) ToDBModel(entry *ledger.JournalEntry) (*JournalEntryDBModel, error) {
+	if entry == nil {
+		return nil, fmt.Errorf("journal entry cannot be nil")
+	}
+
+	postings := make([]PostingDBModel, 0, len(entry.Postings()))
+	for _, posting := range entry.Postings() {
+		postings = append(postings, PostingDBModel{
+			AccountID: posting.Account.String(),
+			Side:      posting.Side.String(),
+			Amount:    posting.Amount.Amount().String(),
+			Currency:  posting.Amount.Currency().Code(),
+		})
+	}
+
+	return &JournalEntryDBModel{
+		EntryID:   entry.ID(),
+		Type:      entry.Type().String(),
+		Reference: entry.Reference(),
+		Postings:  postings,
+		PostedAt:  entry.PostedAt().Format(time.RFC3339Nano),
+	}, nil
+}
+
+// ToPostingRows converts a journal entry to the rows stored under each account
+func (m *LedgerMapper) ToPostingRows(entry *ledger.JournalEntry) []LedgerPostingDBModel {
+	rows := make([]LedgerPostingDBModel, 0, len(entry.Postings()))
+	for i, posting := range entry.Postings() {
+		rows = append(rows, LedgerPostingDBModel{
+			AccountID: postingPartition(posting.Account, entry.ID()),
+			PostingID: entry.ID() + "#" + strconv.Itoa(i),
+			EntryID:   entry.ID(),
+			EntryType: entry.Type().String(),
+			Side:      posting.Side.String(),
+			Amount:    posting.Amount.Amount().String(),
+			Currency:  posting.Amount.Currency().Code(),
+			PostedAt:  entry.PostedAt().Format(time.RFC3339Nano),
+		})
+	}
+	return rows
+}
+
+// PostingPartitions returns every partition the postings of an account may be stored under
+// A shared account also includes its unbucketed partition, where it was posted before sharding
+func (m *LedgerMapper) PostingPartitions(account ledger.AccountID) []string {
+	if account.IsWallet() {
+		return []string{account.String()}
+	}
+
+	partitions := make([]string, 0, sharedAccountBuckets+1)
+	partitions = append(partitions, account.String())
+	for bucket := 0; bucket < sharedAccountBuckets; bucket++ {
+		partitions = append(partitions, bucketPartition(account, bucket))
+	}
+	return partitions
+}
+
+// ToDomain converts an entry row to a journal entry
+func (m *LedgerMapper) ToDomain(model *JournalEntryDBModel) (*ledger.JournalEntry, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model cannot be nil")
+	}
+
+	postings := make([]ledger.Posting, 0, len(model.Postings))
+	for _, row := range model.Postings {
+		posting, err := postingToDomain(row.AccountID, row.Side, row.Amount, row.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("entry %s: %w", model.EntryID, err)
+		}
+		postings = append(postings, posting)
+	}
+
+	postedAt, err := time.Parse(time.RFC3339Nano, model.PostedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid postedAt: %w", err)
+	}
+
+	return ledger.ReconstructJournalEntry(model.EntryID, ledger.EntryType(model.Type), model.Reference, postings, postedAt), nil
+}
+
+// PostingToDomain converts a posting row read from the partitions of account to a posting
+func (m *LedgerMapper) PostingToDomain(account ledger.AccountID, model *LedgerPostingDBModel) (ledger.Posting, error) {
+	if model == nil {
+		return ledger.Posting{}, fmt.Errorf("model cannot be nil")
+	}
+	return postingToDomain(account.String(), model.Side, model.Amount, model.Currency)
+}
+
+// postingPartition picks the partition a posting is stored under
+// The bucket comes from the entry ID, so a rewrite of the same entry lands on the same row
+func postingPartition(account ledger.AccountID, entryID string) string {
+	if account.IsWallet() {
+		return account.String()
+	}
+
+	hash := fnv.New32a()
+	hash.Write([]byte(entryID))
+	return bucketPartition(account, int(hash.Sum32()%sharedAccountBuckets))
+}
+
+func bucketPartition(account ledger.AccountID, bucket int) string {
+	return fmt.Sprintf("%s#%02d", account.String(), bucket)
+}
+
+func postingToDomain(accountID, side, amount, currencyCode string) (ledger.Posting, error) {
+	currency, err := vo.NewCurrency(currencyCode)
+	if err != nil {
+		return ledger.Posting{}, fmt.Errorf("invalid currency: %w", err)
+	}
+
+	value, err := decimal.NewFromString(amount)
+	if err != nil {
+		return ledger.Posting{}, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	money, err := vo.NewMoney(value, currency)
+	if err != nil {
+		return ledger.Posting{}, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	return ledger.Posting{Account: ledger.AccountID(accountID), Side: ledger.Side(side), Amount: money}, nil
+}
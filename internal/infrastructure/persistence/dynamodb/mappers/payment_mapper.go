@@ -18,10 +18,26 @@ type PaymentDBModel struct {
 	ServiceID      string `dynamodbav:"serviceId"`
 	Status         string `dynamodbav:"status"`
 	IdempotencyKey string `dynamodbav:"idempotencyKey"`
-	FailureReason  string `dynamodbav:"failureReason,omitempty"`
-	ExternalTxID   string `dynamodbav:"externalTxId,omitempty"`
-	CreatedAt      string `dynamodbav:"createdAt"`
-	UpdatedAt      string `dynamodbav:"updatedAt"`
+	RefundedAmount string `dynamodbav:"refundedAmount,omitempty"` // empty until the first refund
+	// Fees charged on top of the amount, in its currency
+	Fees []FeeLineDBModel `dynamodbav:"fees,omitempty"`
+	// Conversion of payments funded from another currency, empty otherwise
+	FXQuoteID       string `dynamodbav:"fxQuoteId,omitempty"`
+	FXRate          string `dynamodbav:"fxRate,omitempty"`
+	FXSpread        string `dynamodbav:"fxSpread,omitempty"`
+	FundingAmount   string `dynamodbav:"fundingAmount,omitempty"`
+	FundingCurrency string `dynamodbav:"fundingCurrency,omitempty"`
+	FailureReason   string `dynamodbav:"failureReason,omitempty"`
+	ExternalTxID    string `dynamodbav:"externalTxId,omitempty"`
+	CreatedAt       string `dynamodbav:"createdAt"`
+	UpdatedAt       string `dynamodbav:"updatedAt"`
+}
+
+// FeeLineDBModel represents one fee line of a payment
+type FeeLineDBModel struct {
+	Code         string `dynamodbav:"code"`
+	Amount       string `dynamodbav:"amount"`
+	RefundPolicy string `dynamodbav:"refundPolicy"`
 }
 
 // PaymentMapper handles mapping between domain and persistence models
@@ -38,7 +54,12 @@ func (m *PaymentMapper) ToDBModel(pmt *payment.Payment) (*PaymentDBModel, error)
 		return nil, fmt.Errorf("payment cannot be nil")
 	}
 
-	return &PaymentDBModel{
+	var refundedAmount string
+	if pmt.RefundedAmount().IsPositive() {
+		refundedAmount = pmt.RefundedAmount().Amount().String()
+	}
+
+	model := &PaymentDBModel{
 		ID:             pmt.ID().String(),
 		UserID:         pmt.UserID().String(),
 		Amount:         pmt.Money().Amount().String(),
@@ -46,11 +67,30 @@ func (m *PaymentMapper) ToDBModel(pmt *payment.Payment) (*PaymentDBModel, error)
 		ServiceID:      pmt.ServiceID().String(),
 		Status:         pmt.Status().String(),
 		IdempotencyKey: pmt.IdempotencyKey().String(),
+		RefundedAmount: refundedAmount,
 		FailureReason:  pmt.FailureReason(),
 		ExternalTxID:   pmt.ExternalTxID(),
 		CreatedAt:      pmt.CreatedAt().Format(time.RFC3339),
 		UpdatedAt:      pmt.UpdatedAt().Format(time.RFC3339),
-	}, nil
+	}
+
+	for _, fee := range pmt.Fees() {
+		model.Fees = append(model.Fees, FeeLineDBModel{
+			Code:         fee.Code(),
+			Amount:       fee.Amount().Amount().String(),
+			RefundPolicy: fee.RefundPolicy().String(),
+		})
+	}
+
+	if conversion := pmt.Conversion(); conversion != nil {
+		model.FXQuoteID = conversion.QuoteID()
+		model.FXRate = conversion.Rate().String()
+		model.FXSpread = conversion.Spread().String()
+		model.FundingAmount = conversion.Source().Amount().String()
+		model.FundingCurrency = conversion.Source().Currency().Code()
+	}
+
+	return model, nil
 }
 
 // ToDomain converts database model to domain Payment
@@ -94,6 +134,24 @@ func (m *PaymentMapper) ToDomain(model *PaymentDBModel) (*payment.Payment, error
 		return nil, fmt.Errorf("invalid money: %w", err)
 	}
 
+	refundedAmount := vo.Zero(currency)
+	if model.RefundedAmount != "" {
+		refundedAmount, err = vo.NewMoneyFromString(model.RefundedAmount, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid refunded amount: %w", err)
+		}
+	}
+
+	fees, err := m.feesToDomain(model.Fees, currency)
+	if err != nil {
+		return nil, err
+	}
+
+	conversion, err := m.conversionToDomain(model, money)
+	if err != nil {
+		return nil, err
+	}
+
 	status, err := vo.ParsePaymentStatus(model.Status)
 	if err != nil {
 		return nil, fmt.Errorf("invalid status: %w", err)
@@ -116,6 +174,9 @@ func (m *PaymentMapper) ToDomain(model *PaymentDBModel) (*payment.Payment, error
 		money,
 		idempotencyKey,
 		status,
+		refundedAmount,
+		fees,
+		conversion,
 		model.FailureReason,
 		model.ExternalTxID,
 		createdAt,
@@ -124,3 +185,50 @@ func (m *PaymentMapper) ToDomain(model *PaymentDBModel) (*payment.Payment, error
 
 	return pmt, nil
 }
+
+// feesToDomain rebuilds the fee lines of a payment, in the payment currency
+func (m *PaymentMapper) feesToDomain(models []FeeLineDBModel, currency vo.Currency) ([]vo.FeeLine, error) {
+	fees := make([]vo.FeeLine, 0, len(models))
+	for _, fm := range models {
+		amount, err := vo.NewMoneyFromString(fm.Amount, currency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee amount: %w", err)
+		}
+		fee, err := vo.NewFeeLine(fm.Code, amount, vo.FeeRefundPolicy(fm.RefundPolicy))
+		if err != nil {
+			return nil, fmt.Errorf("invalid fee: %w", err)
+		}
+		fees = append(fees, fee)
+	}
+	return fees, nil
+}
+
+// conversionToDomain rebuilds the FX conversion of a payment, nil when it has none
+func (m *PaymentMapper) conversionToDomain(model *PaymentDBModel, target vo.Money) (*vo.Conversion, error) {
+	if model.FXQuoteID == "" {
+		return nil, nil
+	}
+
+	rate, err := decimal.NewFromString(model.FXRate)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fx rate: %w", err)
+	}
+
+	spread, err := decimal.NewFromString(model.FXSpread)
+	if err != nil {
+		return nil, fmt.Errorf("invalid fx spread: %w", err)
+	}
+
+	currency, err := vo.NewCurrency(model.FundingCurrency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid funding currency: %w", err)
+	}
+
+	source, err := vo.NewMoneyFromString(model.FundingAmount, currency)
+	if err != nil {
+		return nil, fmt.Errorf("invalid funding amount: %w", err)
+	}
+
+	conversion := vo.ReconstructConversion(model.FXQuoteID, rate, spread, source, target)
+	return &conversion, nil
+}
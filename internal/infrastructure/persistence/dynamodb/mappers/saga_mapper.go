@@ -0,0 +1,168 @@
+package mappers
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/saga"
+)
+
+// SagaActiveMarker is stored only on running sagas, so the stuck-saga index skips finished ones
+const SagaActiveMarker = "ACTIVE"
+
+// Fixed-width timestamps so deadlines order lexicographically in the index
+const sagaTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+
+// SagaDBModel represents the database persistence model for Saga
+type SagaDBModel struct {
+	PaymentID     string               `dynamodbav:"paymentId"`
+	Step          string               `dynamodbav:"step"`
+	Attempts      int                  `dynamodbav:"attempts"`
+	LeaseUntil    string               `dynamodbav:"leaseUntil,omitempty"` // empty when no delivery holds the step
+	Active        string               `dynamodbav:"active,omitempty"`     // index partition key, empty once terminal
+	Deadline      string               `dynamodbav:"deadline,omitempty"`   // index sort key, empty once terminal
+	Compensations []SagaCompensationDB `dynamodbav:"compensations"`
+	History       []SagaTransitionDB   `dynamodbav:"history"`
+	StartedAt     string               `dynamodbav:"startedAt"`
+	UpdatedAt     string               `dynamodbav:"updatedAt"`
+	Version       int64                `dynamodbav:"version"`
+}
+
+// SagaTransitionDB represents a saga step change
+type SagaTransitionDB struct {
+	EventType  string `dynamodbav:"eventType"`
+	From       string `dynamodbav:"from"`
+	To         string `dynamodbav:"to"`
+	OccurredAt string `dynamodbav:"occurredAt"`
+}
+
+// SagaCompensationDB represents a compensation applied by the saga
+type SagaCompensationDB struct {
+	Action     string `dynamodbav:"action"`
+	Reason     string `dynamodbav:"reason"`
+	OccurredAt string `dynamodbav:"occurredAt"`
+}
+
+// SagaMapper handles mapping between domain and persistence models
+type SagaMapper struct{}
+
+// NewSagaMapper creates a new SagaMapper
+func NewSagaMapper() *SagaMapper {
+	return &SagaMapper{}
+}
+
+// FormatDeadline formats a deadline the way it is stored in the index
+func (m *SagaMapper) FormatDeadline(t time.Time) string {
+	return t.UTC().Format(sagaTimeLayout)
+}
+
+// ToDBModel converts domain Saga to database model
+func (m *SagaMapper) ToDBModel(sg *saga.Saga) (*SagaDBModel, error) {
+	if sg == nil {
+		return nil, fmt.Errorf("saga cannot be nil")
+	}
+
+	model := &SagaDBModel{
+		PaymentID:     sg.PaymentID(),
+		Step:          sg.Step().String(),
+		Attempts:      sg.Attempts(),
+		Compensations: make([]SagaCompensationDB, 0),
+		History:       make([]SagaTransitionDB, 0),
+		StartedAt:     sg.StartedAt().UTC().Format(sagaTimeLayout),
+		UpdatedAt:     sg.UpdatedAt().UTC().Format(sagaTimeLayout),
+		Version:       sg.Version(),
+	}
+
+	if !sg.LeaseUntil().IsZero() {
+		model.LeaseUntil = sg.LeaseUntil().UTC().Format(sagaTimeLayout)
+	}
+
+	if !sg.IsTerminal() {
+		model.Active = SagaActiveMarker
+		model.Deadline = m.FormatDeadline(sg.Deadline())
+	}
+
+	for _, compensation := range sg.Compensations() {
+		model.Compensations = append(model.Compensations, SagaCompensationDB{
+			Action:     compensation.Action,
+			Reason:     compensation.Reason,
+			OccurredAt: compensation.OccurredAt.UTC().Format(sagaTimeLayout),
+		})
+	}
+
+	for _, transition := range sg.History() {
+		model.History = append(model.History, SagaTransitionDB{
+			EventType:  transition.EventType,
+			From:       transition.From.String(),
+			To:         transition.To.String(),
+			OccurredAt: transition.OccurredAt.UTC().Format(sagaTimeLayout),
+		})
+	}
+
+	return model, nil
+}
+
+// ToDomain converts database model to domain Saga
+func (m *SagaMapper) ToDomain(model *SagaDBModel) (*saga.Saga, error) {
+	if model == nil {
+		return nil, fmt.Errorf("model cannot be nil")
+	}
+
+	startedAt, err := time.Parse(sagaTimeLayout, model.StartedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid startedAt: %w", err)
+	}
+
+	updatedAt, err := time.Parse(sagaTimeLayout, model.UpdatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("invalid updatedAt: %w", err)
+	}
+
+	var deadline time.Time
+	if model.Deadline != "" {
+		if deadline, err = time.Parse(sagaTimeLayout, model.Deadline); err != nil {
+			return nil, fmt.Errorf("invalid deadline: %w", err)
+		}
+	}
+
+	var leaseUntil time.Time
+	if model.LeaseUntil != "" {
+		if leaseUntil, err = time.Parse(sagaTimeLayout, model.LeaseUntil); err != nil {
+			return nil, fmt.Errorf("invalid leaseUntil: %w", err)
+		}
+	}
+
+	compensations := make([]saga.Compensation, 0, len(model.Compensations))
+	for _, item := range model.Compensations {
+		occurredAt, _ := time.Parse(sagaTimeLayout, item.OccurredAt)
+		compensations = append(compensations, saga.Compensation{
+			Action:     item.Action,
+			Reason:     item.Reason,
+			OccurredAt: occurredAt,
+		})
+	}
+
+	history := make([]saga.Transition, 0, len(model.History))
+	for _, item := range model.History {
+		occurredAt, _ := time.Parse(sagaTimeLayout, item.OccurredAt)
+		history = append(history, saga.Transition{
+			EventType:  item.EventType,
+			From:       saga.Step(item.From),
+			To:         saga.Step(item.To),
+			OccurredAt: occurredAt,
+		})
+	}
+
+	return saga.ReconstructSaga(
+		model.PaymentID,
+		saga.Step(model.Step),
+		model.Attempts,
+		leaseUntil,
+		deadline,
+		compensations,
+		history,
+		startedAt,
+		updatedAt,
+		model.Version,
+	), nil
+}
@@ -10,11 +10,29 @@ import (
 )
 
 // WalletDBModel represents the database persistence model for Wallet
+// Rows written before multi-currency wallets have a single balance in Currency and
+// no Balances map; they are read as a wallet holding only that currency
 type WalletDBModel struct {
-	UserID    string `dynamodbav:"userId"`
-	Balance   string `dynamodbav:"balance"` // Decimal as string
-	Currency  string `dynamodbav:"currency"`
-	UpdatedAt string `dynamodbav:"updatedAt"`
+	UserID    string            `dynamodbav:"userId"`
+	Currency  string            `dynamodbav:"currency"`           // Default currency
+	Balances  map[string]string `dynamodbav:"balances,omitempty"` // Decimal as string, by currency code
+	Balance   string            `dynamodbav:"balance,omitempty"`  // Legacy single balance, never written
+	Holds     []HoldDBModel     `dynamodbav:"holds,omitempty"`    // Missing on legacy rows, read as none
+	UpdatedAt string            `dynamodbav:"updatedAt"`
+	Version   int64             `dynamodbav:"version"` // Missing on legacy rows, read as 0
+}
+
+// IsLegacy reports whether the row still stores a single balance
+func (m *WalletDBModel) IsLegacy() bool {
+	return len(m.Balances) == 0
+}
+
+// HoldDBModel represents an open hold stored with its wallet
+type HoldDBModel struct {
+	PaymentID string `dynamodbav:"paymentId"`
+	Amount    string `dynamodbav:"amount"`             // Decimal as string
+	Currency  string `dynamodbav:"currency,omitempty"` // Missing on legacy rows, read as the default currency
+	CreatedAt string `dynamodbav:"createdAt"`
 }
 
 // WalletMapper handles mapping between domain and persistence models
@@ -31,11 +49,28 @@ func (m *WalletMapper) ToDBModel(wlt *wallet.Wallet) (*WalletDBModel, error) {
 		return nil, fmt.Errorf("wallet cannot be nil")
 	}
 
+	holds := make([]HoldDBModel, 0, len(wlt.Holds()))
+	for _, hold := range wlt.Holds() {
+		holds = append(holds, HoldDBModel{
+			PaymentID: hold.PaymentID,
+			Amount:    hold.Amount.Amount().String(),
+			Currency:  hold.Amount.Currency().Code(),
+			CreatedAt: hold.CreatedAt.Format(time.RFC3339Nano),
+		})
+	}
+
+	balances := make(map[string]string, len(wlt.Balances()))
+	for _, balance := range wlt.Balances() {
+		balances[balance.Currency().Code()] = balance.Amount().String()
+	}
+
 	return &WalletDBModel{
 		UserID:    wlt.UserID().String(),
-		Balance:   wlt.Balance().Amount().String(),
-		Currency:  wlt.Balance().Currency().Code(),
+		Currency:  wlt.DefaultCurrency().Code(),
+		Balances:  balances,
+		Holds:     holds,
 		UpdatedAt: wlt.UpdatedAt().Format(time.RFC3339),
+		Version:   wlt.Version(),
 	}, nil
 }
 
@@ -50,19 +85,23 @@ func (m *WalletMapper) ToDomain(model *WalletDBModel) (*wallet.Wallet, error) {
 		return nil, fmt.Errorf("invalid user ID: %w", err)
 	}
 
-	amount, err := decimal.NewFromString(model.Balance)
-	if err != nil {
-		return nil, fmt.Errorf("invalid balance amount: %w", err)
-	}
-
 	currency, err := vo.NewCurrency(model.Currency)
 	if err != nil {
 		return nil, fmt.Errorf("invalid currency: %w", err)
 	}
 
-	money, err := vo.NewMoney(amount, currency)
-	if err != nil {
-		return nil, fmt.Errorf("invalid money: %w", err)
+	stored := model.Balances
+	if model.IsLegacy() {
+		stored = map[string]string{model.Currency: model.Balance}
+	}
+
+	balances := make([]vo.Money, 0, len(stored))
+	for code, value := range stored {
+		money, err := parseMoney(value, code)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s balance: %w", code, err)
+		}
+		balances = append(balances, money)
 	}
 
 	updatedAt, err := time.Parse(time.RFC3339, model.UpdatedAt)
@@ -70,7 +109,39 @@ func (m *WalletMapper) ToDomain(model *WalletDBModel) (*wallet.Wallet, error) {
 		return nil, fmt.Errorf("invalid updatedAt: %w", err)
 	}
 
-	wlt := wallet.ReconstructWallet(userID, money, updatedAt)
+	holds := make([]wallet.Hold, 0, len(model.Holds))
+	for _, h := range model.Holds {
+		holdCurrency := h.Currency
+		if holdCurrency == "" {
+			holdCurrency = currency.Code()
+		}
+		holdMoney, err := parseMoney(h.Amount, holdCurrency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hold for payment %s: %w", h.PaymentID, err)
+		}
+		createdAt, err := time.Parse(time.RFC3339Nano, h.CreatedAt)
+		if err != nil {
+			return nil, fmt.Errorf("invalid hold createdAt for payment %s: %w", h.PaymentID, err)
+		}
+		holds = append(holds, wallet.Hold{PaymentID: h.PaymentID, Amount: holdMoney, CreatedAt: createdAt})
+	}
+
+	wlt := wallet.ReconstructWallet(userID, currency, balances, holds, updatedAt, model.Version)
 
 	return wlt, nil
 }
+
+// parseMoney reads a stored decimal string in the given currency
+func parseMoney(amount string, currencyCode string) (vo.Money, error) {
+	dec, err := decimal.NewFromString(amount)
+	if err != nil {
+		return vo.Money{}, fmt.Errorf("invalid amount: %w", err)
+	}
+
+	currency, err := vo.NewCurrency(currencyCode)
+	if err != nil {
+		return vo.Money{}, fmt.Errorf("invalid currency: %w", err)
+	}
+
+	return vo.NewMoney(dec, currency)
+}
@@ -0,0 +1,188 @@
+package dynamodb
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
+	"github.com/google/uuid"
+)
+
+const (
+	outboxStatusPending    = "PENDING"
+	outboxStatusDispatched = "DISPATCHED"
+	outboxStatusDead       = "DEAD"
+
+	// OutboxPendingIndex lists entries by status ordered by creation time
+	OutboxPendingIndex = "status-createdAt-index"
+
+	// Fixed-width timestamps so time-based sort keys order lexicographically
+	sortableTimeLayout = "2006-01-02T15:04:05.000000000Z07:00"
+)
+
+// DynamoDBOutboxStore implements OutboxStore using DynamoDB
+type DynamoDBOutboxStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBOutboxStore creates a new DynamoDBOutboxStore
+func NewDynamoDBOutboxStore(client *dynamodb.Client, tableName string) *DynamoDBOutboxStore {
+	return &DynamoDBOutboxStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+type outboxItem struct {
+	OutboxID     string `dynamodbav:"outboxId"`
+	Status       string `dynamodbav:"status"`
+	EventType    string `dynamodbav:"eventType"`
+	TopicArn     string `dynamodbav:"topicArn"`
+	Payload      string `dynamodbav:"payload"`
+	Attempts     int    `dynamodbav:"attempts"`
+	LastError    string `dynamodbav:"lastError,omitempty"`
+	CreatedAt    string `dynamodbav:"createdAt"`
+	DispatchedAt string `dynamodbav:"dispatchedAt,omitempty"`
+	DeadAt       string `dynamodbav:"deadAt,omitempty"`
+}
+
+// Add stores an event as a pending outbox entry
+func (s *DynamoDBOutboxStore) Add(ctx context.Context, event shared.Event, topicArn string) error {
+	av, err := marshalOutboxItem(event, topicArn)
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName: aws.String(s.tableName),
+		Item:      av,
+	})
+
+	return err
+}
+
+// ListPending returns the oldest entries that have not been dispatched yet
+func (s *DynamoDBOutboxStore) ListPending(ctx context.Context, limit int) ([]port.OutboxEntry, error) {
+	result, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		IndexName:              aws.String(OutboxPendingIndex),
+		KeyConditionExpression: aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: outboxStatusPending},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(int32(limit)),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	entries := make([]port.OutboxEntry, 0, len(result.Items))
+	for _, av := range result.Items {
+		var item outboxItem
+		if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+			return nil, err
+		}
+
+		createdAt, _ := time.Parse(sortableTimeLayout, item.CreatedAt)
+		entries = append(entries, port.OutboxEntry{
+			ID:        item.OutboxID,
+			EventType: item.EventType,
+			TopicArn:  item.TopicArn,
+			Payload:   item.Payload,
+			Attempts:  item.Attempts,
+			LastError: item.LastError,
+			CreatedAt: createdAt,
+		})
+	}
+
+	return entries, nil
+}
+
+// MarkDispatched flags an entry as delivered so the relay skips it
+func (s *DynamoDBOutboxStore) MarkDispatched(ctx context.Context, entryID string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"outboxId": &types.AttributeValueMemberS{Value: entryID},
+		},
+		UpdateExpression: aws.String("SET #status = :dispatched, dispatchedAt = :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":dispatched": &types.AttributeValueMemberS{Value: outboxStatusDispatched},
+			":now":        &types.AttributeValueMemberS{Value: time.Now().UTC().Format(sortableTimeLayout)},
+		},
+	})
+
+	return err
+}
+
+// RecordFailure counts a failed delivery attempt and keeps the entry pending
+func (s *DynamoDBOutboxStore) RecordFailure(ctx context.Context, entryID string, reason string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"outboxId": &types.AttributeValueMemberS{Value: entryID},
+		},
+		UpdateExpression: aws.String("SET attempts = attempts + :one, lastError = :reason"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":one":    &types.AttributeValueMemberN{Value: "1"},
+			":reason": &types.AttributeValueMemberS{Value: reason},
+		},
+	})
+
+	return err
+}
+
+// MarkDead moves an entry to DEAD, off the pending index, recording why it cannot be delivered
+func (s *DynamoDBOutboxStore) MarkDead(ctx context.Context, entryID string, reason string) error {
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"outboxId": &types.AttributeValueMemberS{Value: entryID},
+		},
+		UpdateExpression: aws.String("SET #status = :dead, attempts = attempts + :one, lastError = :reason, deadAt = :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":dead":   &types.AttributeValueMemberS{Value: outboxStatusDead},
+			":one":    &types.AttributeValueMemberN{Value: "1"},
+			":reason": &types.AttributeValueMemberS{Value: reason},
+			":now":    &types.AttributeValueMemberS{Value: time.Now().UTC().Format(sortableTimeLayout)},
+		},
+	})
+
+	return err
+}
+
+// marshalOutboxItem builds a pending outbox row for an event
+// Shared with the unit of work so the entry can be written in the same transaction as domain state
+func marshalOutboxItem(event shared.Event, topicArn string) (map[string]types.AttributeValue, error) {
+	payload, err := codec.SerializeEvent(event)
+	if err != nil {
+		return nil, err
+	}
+
+	return attributevalue.MarshalMap(outboxItem{
+		OutboxID:  uuid.New().String(),
+		Status:    outboxStatusPending,
+		EventType: event.EventType(),
+		TopicArn:  topicArn,
+		Payload:   string(payload),
+		CreatedAt: time.Now().UTC().Format(sortableTimeLayout),
+	})
+}
@@ -35,16 +35,9 @@ func (r *DynamoDBPaymentRepository) Save(ctx context.Context, payment *payment.P
 		return domerrors.NewDomainError(domerrors.ErrCodeValidationFailed, "payment cannot be nil")
 	}
 
-	// Convert to DB model
-	dbModel, err := r.mapper.ToDBModel(payment)
-	if err != nil {
-		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert payment to DB model", err)
-	}
-
-	// Marshal to DynamoDB attributes
-	av, err := attributevalue.MarshalMap(dbModel)
+	av, err := r.marshal(payment)
 	if err != nil {
-		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal payment", err)
+		return err
 	}
 
 	// Save to DynamoDB
@@ -60,6 +53,23 @@ func (r *DynamoDBPaymentRepository) Save(ctx context.Context, payment *payment.P
 	return nil
 }
 
+// marshal builds the payment row, shared with the unit of work
+func (r *DynamoDBPaymentRepository) marshal(payment *payment.Payment) (map[string]types.AttributeValue, error) {
+	// Convert to DB model
+	dbModel, err := r.mapper.ToDBModel(payment)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert payment to DB model", err)
+	}
+
+	// Marshal to DynamoDB attributes
+	av, err := attributevalue.MarshalMap(dbModel)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal payment", err)
+	}
+
+	return av, nil
+}
+
 // FindByID retrieves a payment by its ID
 func (r *DynamoDBPaymentRepository) FindByID(ctx context.Context, paymentID string) (*payment.Payment, error) {
 	if paymentID == "" {
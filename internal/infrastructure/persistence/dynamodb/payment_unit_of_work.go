@@ -0,0 +1,276 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+)
+
+// Positions of the conditional writes inside the transactions, used to read their cancellation reasons
+const (
+	paymentTransactItem     = 0
+	idempotencyTransactItem = 1
+	fxQuoteTransactItem     = 4
+)
+
+// DynamoDBPaymentUnitOfWork writes a payment change, its idempotency key, its event and the
+// matching outbox entry in a single TransactWriteItems call, so either all of them exist or none do
+type DynamoDBPaymentUnitOfWork struct {
+	client           *dynamodb.Client
+	paymentsTable    string
+	idempotencyTable string
+	eventStoreTable  string
+	outboxTable      string
+	fxQuotesTable    string
+	mapper           *mappers.PaymentMapper
+}
+
+// NewDynamoDBPaymentUnitOfWork creates a new DynamoDBPaymentUnitOfWork
+func NewDynamoDBPaymentUnitOfWork(
+	client *dynamodb.Client,
+	paymentsTable string,
+	idempotencyTable string,
+	eventStoreTable string,
+	outboxTable string,
+	fxQuotesTable string,
+) *DynamoDBPaymentUnitOfWork {
+	return &DynamoDBPaymentUnitOfWork{
+		client:           client,
+		paymentsTable:    paymentsTable,
+		idempotencyTable: idempotencyTable,
+		eventStoreTable:  eventStoreTable,
+		outboxTable:      outboxTable,
+		fxQuotesTable:    fxQuotesTable,
+		mapper:           mappers.NewPaymentMapper(),
+	}
+}
+
+// CreatePayment atomically stores the payment, claims its idempotency key, appends the event
+// and queues it in the outbox for delivery to topicArn
+// A converted payment also marks its FX quote as used, so one locked rate funds one payment
+// Returns a DUPLICATE_REQUEST error if another request already claimed the key, and an
+// FX_QUOTE_ALREADY_USED error if another payment consumed the quote
+func (u *DynamoDBPaymentUnitOfWork) CreatePayment(ctx context.Context, pmt *payment.Payment, event shared.Event, topicArn string) error {
+	if pmt == nil {
+		return domerrors.NewDomainError(domerrors.ErrCodeValidationFailed, "payment cannot be nil")
+	}
+
+	dbModel, err := u.mapper.ToDBModel(pmt)
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert payment to DB model", err)
+	}
+
+	paymentItem, err := attributevalue.MarshalMap(dbModel)
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal payment", err)
+	}
+
+	keyItem, err := attributevalue.MarshalMap(idempotencyItem{
+		IdempotencyKey: pmt.IdempotencyKey().String(),
+		PaymentID:      pmt.ID().String(),
+	})
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal idempotency key", err)
+	}
+
+	eventItem, err := marshalEventItem(event, pmt.ID().String())
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to marshal event", err)
+	}
+
+	outboxItem, err := marshalOutboxItem(event, topicArn)
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to marshal outbox entry", err)
+	}
+
+	// Item order must match idempotencyTransactItem and fxQuoteTransactItem
+	items := []types.TransactWriteItem{
+		{
+			Put: &types.Put{
+				TableName:           aws.String(u.paymentsTable),
+				Item:                paymentItem,
+				ConditionExpression: aws.String("attribute_not_exists(id)"),
+			},
+		},
+		{
+			Put: &types.Put{
+				TableName:           aws.String(u.idempotencyTable),
+				Item:                keyItem,
+				ConditionExpression: aws.String("attribute_not_exists(idempotencyKey)"),
+			},
+		},
+		{
+			Put: &types.Put{
+				TableName: aws.String(u.eventStoreTable),
+				Item:      eventItem,
+			},
+		},
+		{
+			Put: &types.Put{
+				TableName: aws.String(u.outboxTable),
+				Item:      outboxItem,
+			},
+		},
+	}
+
+	var quoteID string
+	if conversion := pmt.Conversion(); conversion != nil {
+		quoteID = conversion.QuoteID()
+		items = append(items, types.TransactWriteItem{
+			Update: &types.Update{
+				TableName: aws.String(u.fxQuotesTable),
+				Key: map[string]types.AttributeValue{
+					"quoteId": &types.AttributeValueMemberS{Value: quoteID},
+				},
+				UpdateExpression:    aws.String("SET usedBy = :paymentId"),
+				ConditionExpression: aws.String("attribute_exists(quoteId) AND attribute_not_exists(usedBy)"),
+				ExpressionAttributeValues: map[string]types.AttributeValue{
+					":paymentId": &types.AttributeValueMemberS{Value: pmt.ID().String()},
+				},
+			},
+		})
+	}
+
+	_, err = u.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+
+	if err != nil {
+		// Checked first: a retry with the same key and quote is a duplicate, not a reused quote
+		if isIdempotencyConflict(err) {
+			return domerrors.DuplicateRequestError(pmt.IdempotencyKey().String())
+		}
+		if quoteID != "" && isConditionFailedAt(err, fxQuoteTransactItem) {
+			return domerrors.FXQuoteUsedError(quoteID)
+		}
+		return domerrors.DatabaseError("create payment transaction", err)
+	}
+
+	return nil
+}
+
+// RecordRefund atomically stores a refunded payment, claims the refund idempotency key, appends the
+// PaymentRefunded event and queues it in the outbox for delivery to topicArn
+// The payment write only succeeds while the stored refunded total is still previousRefunded, so two
+// concurrent refunds cannot both pass the over-refund check
+func (u *DynamoDBPaymentUnitOfWork) RecordRefund(
+	ctx context.Context,
+	pmt *payment.Payment,
+	previousRefunded vo.Money,
+	idempotencyKey string,
+	event shared.Event,
+	topicArn string,
+) error {
+	if pmt == nil {
+		return domerrors.NewDomainError(domerrors.ErrCodeValidationFailed, "payment cannot be nil")
+	}
+
+	dbModel, err := u.mapper.ToDBModel(pmt)
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert payment to DB model", err)
+	}
+
+	paymentItem, err := attributevalue.MarshalMap(dbModel)
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal payment", err)
+	}
+
+	keyItem, err := attributevalue.MarshalMap(idempotencyItem{
+		IdempotencyKey: idempotencyKey,
+		PaymentID:      pmt.ID().String(),
+	})
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal idempotency key", err)
+	}
+
+	eventItem, err := marshalEventItem(event, pmt.ID().String())
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to marshal event", err)
+	}
+
+	outboxItem, err := marshalOutboxItem(event, topicArn)
+	if err != nil {
+		return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to marshal outbox entry", err)
+	}
+
+	// The refunded total is only written once there is one
+	paymentPut := &types.Put{
+		TableName:           aws.String(u.paymentsTable),
+		Item:                paymentItem,
+		ConditionExpression: aws.String("attribute_exists(id) AND attribute_not_exists(refundedAmount)"),
+	}
+	if previousRefunded.IsPositive() {
+		paymentPut.ConditionExpression = aws.String("refundedAmount = :previousRefunded")
+		paymentPut.ExpressionAttributeValues = map[string]types.AttributeValue{
+			":previousRefunded": &types.AttributeValueMemberS{Value: previousRefunded.Amount().String()},
+		}
+	}
+
+	// Item order must match paymentTransactItem and idempotencyTransactItem
+	_, err = u.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: []types.TransactWriteItem{
+			{Put: paymentPut},
+			{
+				Put: &types.Put{
+					TableName:           aws.String(u.idempotencyTable),
+					Item:                keyItem,
+					ConditionExpression: aws.String("attribute_not_exists(idempotencyKey)"),
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName: aws.String(u.eventStoreTable),
+					Item:      eventItem,
+				},
+			},
+			{
+				Put: &types.Put{
+					TableName: aws.String(u.outboxTable),
+					Item:      outboxItem,
+				},
+			},
+		},
+	})
+
+	if err != nil {
+		if isIdempotencyConflict(err) {
+			return domerrors.DuplicateRequestError(idempotencyKey)
+		}
+		if isConditionFailedAt(err, paymentTransactItem) {
+			return domerrors.NewDomainError(
+				domerrors.ErrCodeConcurrentModification,
+				"Payment was refunded concurrently",
+			).WithDetail("paymentId", pmt.ID().String())
+		}
+		return domerrors.DatabaseError("refund payment transaction", err)
+	}
+
+	return nil
+}
+
+// isIdempotencyConflict reports whether the transaction was cancelled because the key already exists
+func isIdempotencyConflict(err error) bool {
+	return isConditionFailedAt(err, idempotencyTransactItem)
+}
+
+// isConditionFailedAt reports whether the transaction was cancelled by the condition of item index
+func isConditionFailedAt(err error, index int) bool {
+	var canceled *types.TransactionCanceledException
+	if !errors.As(err, &canceled) {
+		return false
+	}
+
+	reasons := canceled.CancellationReasons
+	if len(reasons) <= index {
+		return false
+	}
+
+	return aws.ToString(reasons[index].Code) == "ConditionalCheckFailed"
+}
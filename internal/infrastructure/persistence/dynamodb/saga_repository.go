@@ -0,0 +1,170 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/domain/saga"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+)
+
+// SagaStuckIndex lists running sagas ordered by step deadline
+// It is sparse: terminal sagas drop the "active" attribute and leave the index
+const SagaStuckIndex = "active-deadline-index"
+
+// DynamoDBSagaRepository implements SagaRepository using DynamoDB
+type DynamoDBSagaRepository struct {
+	client    *dynamodb.Client
+	tableName string
+	mapper    *mappers.SagaMapper
+}
+
+// NewDynamoDBSagaRepository creates a new DynamoDBSagaRepository
+func NewDynamoDBSagaRepository(client *dynamodb.Client, tableName string) *DynamoDBSagaRepository {
+	return &DynamoDBSagaRepository{
+		client:    client,
+		tableName: tableName,
+		mapper:    mappers.NewSagaMapper(),
+	}
+}
+
+// FindByPaymentID retrieves the saga of a payment
+func (r *DynamoDBSagaRepository) FindByPaymentID(ctx context.Context, paymentID string) (*saga.Saga, error) {
+	result, err := r.client.GetItem(ctx, &dynamodb.GetItemInput{
+		TableName: aws.String(r.tableName),
+		Key: map[string]types.AttributeValue{
+			"paymentId": &types.AttributeValueMemberS{Value: paymentID},
+		},
+		ConsistentRead: aws.Bool(true),
+	})
+
+	if err != nil {
+		return nil, domerrors.DatabaseError("get saga", err)
+	}
+
+	if result.Item == nil {
+		return nil, domerrors.SagaNotFoundError(paymentID)
+	}
+
+	return r.toDomain(result.Item)
+}
+
+// Save persists a saga using optimistic concurrency
+// A new saga (version 0) is only written if none exists yet for the payment
+func (r *DynamoDBSagaRepository) Save(ctx context.Context, sg *saga.Saga) error {
+	if sg == nil {
+		return domerrors.NewDomainError(domerrors.ErrCodeValidationFailed, "saga cannot be nil")
+	}
+
+	put, err := r.versionedPut(sg)
+	if err != nil {
+		return err
+	}
+
+	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:                 put.TableName,
+		Item:                      put.Item,
+		ConditionExpression:       put.ConditionExpression,
+		ExpressionAttributeNames:  put.ExpressionAttributeNames,
+		ExpressionAttributeValues: put.ExpressionAttributeValues,
+	})
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return domerrors.SagaVersionConflictError(sg.PaymentID(), sg.Version())
+		}
+		return domerrors.DatabaseError("save saga", err)
+	}
+
+	sg.IncrementVersion()
+
+	return nil
+}
+
+// versionedPut builds the write of the next saga version, shared with the unit of work
+// A new saga (version 0) is only written if none exists yet for the payment
+func (r *DynamoDBSagaRepository) versionedPut(sg *saga.Saga) (*types.Put, error) {
+	expectedVersion := sg.Version()
+
+	dbModel, err := r.mapper.ToDBModel(sg)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert saga to DB model", err)
+	}
+	dbModel.Version = expectedVersion + 1
+
+	av, err := attributevalue.MarshalMap(dbModel)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal saga", err)
+	}
+
+	if expectedVersion == 0 {
+		return &types.Put{
+			TableName:           aws.String(r.tableName),
+			Item:                av,
+			ConditionExpression: aws.String("attribute_not_exists(paymentId)"),
+		}, nil
+	}
+
+	return &types.Put{
+		TableName:           aws.String(r.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("#version = :expected"),
+		ExpressionAttributeNames: map[string]string{
+			"#version": "version",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":expected": &types.AttributeValueMemberN{Value: strconv.FormatInt(expectedVersion, 10)},
+		},
+	}, nil
+}
+
+// ListStuck returns running sagas whose step deadline passed before now, oldest deadline first
+func (r *DynamoDBSagaRepository) ListStuck(ctx context.Context, now time.Time, limit int) ([]*saga.Saga, error) {
+	result, err := r.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(r.tableName),
+		IndexName:              aws.String(SagaStuckIndex),
+		KeyConditionExpression: aws.String("active = :active AND deadline < :now"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":active": &types.AttributeValueMemberS{Value: mappers.SagaActiveMarker},
+			":now":    &types.AttributeValueMemberS{Value: r.mapper.FormatDeadline(now)},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(int32(limit)),
+	})
+
+	if err != nil {
+		return nil, domerrors.DatabaseError("list stuck sagas", err)
+	}
+
+	sagas := make([]*saga.Saga, 0, len(result.Items))
+	for _, item := range result.Items {
+		sg, err := r.toDomain(item)
+		if err != nil {
+			return nil, err
+		}
+		sagas = append(sagas, sg)
+	}
+
+	return sagas, nil
+}
+
+func (r *DynamoDBSagaRepository) toDomain(item map[string]types.AttributeValue) (*saga.Saga, error) {
+	var dbModel mappers.SagaDBModel
+	if err := attributevalue.UnmarshalMap(item, &dbModel); err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to unmarshal saga", err)
+	}
+
+	sg, err := r.mapper.ToDomain(&dbModel)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert to domain model", err)
+	}
+
+	return sg, nil
+}
@@ -0,0 +1,156 @@
+package dynamodb
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/application/port"
+)
+
+const (
+	timeoutStatusPending = "PENDING"
+
+	// TimeoutDueIndex lists timeouts by status ordered by deadline
+	TimeoutDueIndex = "status-deadline-index"
+)
+
+// DynamoDBTimeoutStore implements TimeoutStore using DynamoDB
+type DynamoDBTimeoutStore struct {
+	client    *dynamodb.Client
+	tableName string
+}
+
+// NewDynamoDBTimeoutStore creates a new DynamoDBTimeoutStore
+func NewDynamoDBTimeoutStore(client *dynamodb.Client, tableName string) *DynamoDBTimeoutStore {
+	return &DynamoDBTimeoutStore{
+		client:    client,
+		tableName: tableName,
+	}
+}
+
+type timeoutItem struct {
+	PaymentID     string `dynamodbav:"paymentId"`
+	Status        string `dynamodbav:"status"`
+	Deadline      string `dynamodbav:"deadline"`
+	TimeoutMillis int64  `dynamodbav:"timeoutMillis"`
+	ClosedAt      string `dynamodbav:"closedAt,omitempty"`
+}
+
+// Schedule stores a pending deadline, replacing a previous pending one for the payment
+// A deadline that was already fired or resolved is kept closed: the request was redelivered
+func (s *DynamoDBTimeoutStore) Schedule(ctx context.Context, timeout port.ScheduledTimeout) error {
+	av, err := attributevalue.MarshalMap(timeoutItem{
+		PaymentID:     timeout.PaymentID,
+		Status:        timeoutStatusPending,
+		Deadline:      timeout.Deadline.UTC().Format(sortableTimeLayout),
+		TimeoutMillis: timeout.Timeout.Milliseconds(),
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = s.client.PutItem(ctx, &dynamodb.PutItemInput{
+		TableName:           aws.String(s.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(paymentId) OR #status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: timeoutStatusPending},
+		},
+	})
+
+	var conditionFailed *types.ConditionalCheckFailedException
+	if errors.As(err, &conditionFailed) {
+		return nil
+	}
+	return err
+}
+
+// ListDue returns pending timeouts whose deadline is at or before now, oldest first
+func (s *DynamoDBTimeoutStore) ListDue(ctx context.Context, now time.Time, limit int) ([]port.ScheduledTimeout, error) {
+	result, err := s.client.Query(ctx, &dynamodb.QueryInput{
+		TableName:              aws.String(s.tableName),
+		IndexName:              aws.String(TimeoutDueIndex),
+		KeyConditionExpression: aws.String("#status = :pending AND deadline <= :now"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":pending": &types.AttributeValueMemberS{Value: timeoutStatusPending},
+			":now":     &types.AttributeValueMemberS{Value: now.UTC().Format(sortableTimeLayout)},
+		},
+		ScanIndexForward: aws.Bool(true),
+		Limit:            aws.Int32(int32(limit)),
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	timeouts := make([]port.ScheduledTimeout, 0, len(result.Items))
+	for _, av := range result.Items {
+		var item timeoutItem
+		if err := attributevalue.UnmarshalMap(av, &item); err != nil {
+			return nil, err
+		}
+
+		deadline, _ := time.Parse(sortableTimeLayout, item.Deadline)
+		timeouts = append(timeouts, port.ScheduledTimeout{
+			PaymentID: item.PaymentID,
+			Deadline:  deadline,
+			Timeout:   time.Duration(item.TimeoutMillis) * time.Millisecond,
+		})
+	}
+
+	return timeouts, nil
+}
+
+// Close moves a pending timeout to its outcome with a conditional write
+func (s *DynamoDBTimeoutStore) Close(ctx context.Context, paymentID, outcome string) (bool, error) {
+	update := s.closeUpdate(paymentID, outcome)
+	_, err := s.client.UpdateItem(ctx, &dynamodb.UpdateItemInput{
+		TableName:                 update.TableName,
+		Key:                       update.Key,
+		UpdateExpression:          update.UpdateExpression,
+		ConditionExpression:       update.ConditionExpression,
+		ExpressionAttributeNames:  update.ExpressionAttributeNames,
+		ExpressionAttributeValues: update.ExpressionAttributeValues,
+	})
+
+	if err != nil {
+		var conditionFailed *types.ConditionalCheckFailedException
+		if errors.As(err, &conditionFailed) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	return true, nil
+}
+
+// closeUpdate builds the write that closes a pending timeout, shared with the unit of work
+func (s *DynamoDBTimeoutStore) closeUpdate(paymentID, outcome string) *types.Update {
+	return &types.Update{
+		TableName: aws.String(s.tableName),
+		Key: map[string]types.AttributeValue{
+			"paymentId": &types.AttributeValueMemberS{Value: paymentID},
+		},
+		UpdateExpression:    aws.String("SET #status = :outcome, closedAt = :now"),
+		ConditionExpression: aws.String("#status = :pending"),
+		ExpressionAttributeNames: map[string]string{
+			"#status": "status",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":outcome": &types.AttributeValueMemberS{Value: outcome},
+			":pending": &types.AttributeValueMemberS{Value: timeoutStatusPending},
+			":now":     &types.AttributeValueMemberS{Value: time.Now().UTC().Format(sortableTimeLayout)},
+		},
+	}
+}
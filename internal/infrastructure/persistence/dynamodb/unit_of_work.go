@@ -0,0 +1,191 @@
+package dynamodb
+
+import (
+	"context"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// DynamoDBUnitOfWork commits the changes of a step in a single TransactWriteItems call
+// Each row is built by the adapter that owns its table, so it looks the same whether it was
+// written on its own or as part of a step
+type DynamoDBUnitOfWork struct {
+	client     *dynamodb.Client
+	payments   *DynamoDBPaymentRepository
+	wallets    *DynamoDBWalletRepository
+	sagas      *DynamoDBSagaRepository
+	eventStore *DynamoDBEventStore
+	outbox     *DynamoDBOutboxStore
+	inbox      *DynamoDBInboxStore
+	timeouts   *DynamoDBTimeoutStore
+}
+
+// NewDynamoDBUnitOfWork creates a new DynamoDBUnitOfWork over the adapters of each table
+func NewDynamoDBUnitOfWork(
+	client *dynamodb.Client,
+	payments *DynamoDBPaymentRepository,
+	wallets *DynamoDBWalletRepository,
+	sagas *DynamoDBSagaRepository,
+	eventStore *DynamoDBEventStore,
+	outbox *DynamoDBOutboxStore,
+	inbox *DynamoDBInboxStore,
+	timeouts *DynamoDBTimeoutStore,
+) *DynamoDBUnitOfWork {
+	return &DynamoDBUnitOfWork{
+		client:     client,
+		payments:   payments,
+		wallets:    wallets,
+		sagas:      sagas,
+		eventStore: eventStore,
+		outbox:     outbox,
+		inbox:      inbox,
+		timeouts:   timeouts,
+	}
+}
+
+// conditionalWrite is the position of a conditional write in the transaction and the error its
+// failed condition stands for
+type conditionalWrite struct {
+	index    int
+	conflict error
+}
+
+// Commit writes the changes, their events and the outbox entries in one transaction
+func (u *DynamoDBUnitOfWork) Commit(ctx context.Context, changes port.Changes) error {
+	items := make([]types.TransactWriteItem, 0)
+	// Entries are checked before the other conditions, as in DynamoDBWalletRepository.write
+	entries := make([]conditionalWrite, 0)
+	conditions := make([]conditionalWrite, 0)
+
+	if wlt := changes.Wallet; wlt != nil {
+		put, err := u.wallets.updatePut(wlt)
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, conditionalWrite{
+			index:    len(items),
+			conflict: domerrors.WalletVersionConflictError(wlt.UserID().String(), wlt.Version()),
+		})
+		items = append(items, types.TransactWriteItem{Put: put})
+
+		for _, entry := range wlt.PendingEntries() {
+			writes, err := u.wallets.ledger.transactItems(entry)
+			if err != nil {
+				return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal journal entry "+entry.ID(), err)
+			}
+			entries = append(entries, conditionalWrite{
+				index:    len(items),
+				conflict: domerrors.LedgerEntryExistsError(entry.ID()),
+			})
+			items = append(items, writes...)
+		}
+	}
+
+	if pmt := changes.Payment; pmt != nil {
+		paymentItem, err := u.payments.marshal(pmt)
+		if err != nil {
+			return err
+		}
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(u.payments.tableName),
+				Item:      paymentItem,
+			},
+		})
+	}
+
+	if sg := changes.Saga; sg != nil {
+		put, err := u.sagas.versionedPut(sg)
+		if err != nil {
+			return err
+		}
+		conditions = append(conditions, conditionalWrite{
+			index:    len(items),
+			conflict: domerrors.SagaVersionConflictError(sg.PaymentID(), sg.Version()),
+		})
+		items = append(items, types.TransactWriteItem{Put: put})
+	}
+
+	if timeout := changes.Timeout; timeout != nil {
+		conditions = append(conditions, conditionalWrite{
+			index: len(items),
+			conflict: domerrors.NewDomainError(
+				domerrors.ErrCodeConcurrentModification,
+				"Timeout was closed concurrently",
+			).WithDetail("paymentId", timeout.PaymentID),
+		})
+		items = append(items, types.TransactWriteItem{Update: u.timeouts.closeUpdate(timeout.PaymentID, timeout.Outcome)})
+	}
+
+	if claim := changes.Inbox; claim != nil {
+		inboxItem, err := marshalInboxItem(claim.Consumer, claim.EventID, claim.EventType)
+		if err != nil {
+			return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal inbox entry", err)
+		}
+		conditions = append(conditions, conditionalWrite{
+			index:    len(items),
+			conflict: domerrors.DuplicateRequestError(inboxKey(claim.Consumer, claim.EventID)),
+		})
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName:           aws.String(u.inbox.tableName),
+				Item:                inboxItem,
+				ConditionExpression: aws.String("attribute_not_exists(inboxKey)"),
+			},
+		})
+	}
+
+	for _, recorded := range changes.Events {
+		for _, stream := range recorded.Streams {
+			eventItem, err := marshalEventItem(recorded.Event, stream)
+			if err != nil {
+				return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to marshal event", err)
+			}
+			items = append(items, types.TransactWriteItem{
+				Put: &types.Put{
+					TableName: aws.String(u.eventStore.tableName),
+					Item:      eventItem,
+				},
+			})
+		}
+
+		outboxItem, err := marshalOutboxItem(recorded.Event, changes.TopicArn)
+		if err != nil {
+			return domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to marshal outbox entry", err)
+		}
+		items = append(items, types.TransactWriteItem{
+			Put: &types.Put{
+				TableName: aws.String(u.outbox.tableName),
+				Item:      outboxItem,
+			},
+		})
+	}
+
+	if len(items) == 0 {
+		return nil
+	}
+
+	_, err := u.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{TransactItems: items})
+	if err != nil {
+		for _, write := range append(entries, conditions...) {
+			if isConditionFailedAt(err, write.index) {
+				return write.conflict
+			}
+		}
+		return domerrors.DatabaseError("commit transaction", err)
+	}
+
+	if wlt := changes.Wallet; wlt != nil {
+		wlt.ClearPendingEntries()
+		wlt.IncrementVersion()
+	}
+	if sg := changes.Saga; sg != nil {
+		sg.IncrementVersion()
+	}
+
+	return nil
+}
@@ -2,29 +2,41 @@ package dynamodb
 
 import (
 	"context"
+	"errors"
+	"strconv"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/franco/payment-api/internal/domain/ledger"
 	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 	"github.com/franco/payment-api/internal/domain/wallet"
 	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+	"github.com/shopspring/decimal"
 )
 
+// walletTransactItem is the position of the wallet write in the transactions that post entries
+const walletTransactItem = 0
+
 // DynamoDBWalletRepository implements WalletRepository using DynamoDB
 // This version uses mappers and Value Objects
+// A wallet write also posts the journal entries the wallet recorded, in the same transaction
 type DynamoDBWalletRepository struct {
 	client    *dynamodb.Client
 	tableName string
+	ledger    *DynamoDBLedgerRepository
 	mapper    *mappers.WalletMapper
 }
 
 // NewDynamoDBWalletRepository creates a new DynamoDBWalletRepository
-func NewDynamoDBWalletRepository(client *dynamodb.Client, tableName string) *DynamoDBWalletRepository {
+// ledger holds the tables the wallet's journal entries are posted to
+func NewDynamoDBWalletRepository(client *dynamodb.Client, tableName string, ledger *DynamoDBLedgerRepository) *DynamoDBWalletRepository {
 	return &DynamoDBWalletRepository{
 		client:    client,
 		tableName: tableName,
+		ledger:    ledger,
 		mapper:    mappers.NewWalletMapper(),
 	}
 }
@@ -65,7 +77,8 @@ func (r *DynamoDBWalletRepository) GetByUserID(ctx context.Context, userID strin
 	return wallet, nil
 }
 
-// Save persists a wallet to DynamoDB
+// Save persists a new wallet to DynamoDB
+// Returns a WALLET_ALREADY_EXISTS error if the user already has one
 func (r *DynamoDBWalletRepository) Save(ctx context.Context, wallet *wallet.Wallet) error {
 	if wallet == nil {
 		return domerrors.NewDomainError(domerrors.ErrCodeValidationFailed, "wallet cannot be nil")
@@ -83,20 +96,256 @@ func (r *DynamoDBWalletRepository) Save(ctx context.Context, wallet *wallet.Wall
 		return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal wallet", err)
 	}
 
-	// Save to DynamoDB
-	_, err = r.client.PutItem(ctx, &dynamodb.PutItemInput{
-		TableName: aws.String(r.tableName),
-		Item:      av,
+	// Save to DynamoDB; a user owns a single wallet, so an existing one is never overwritten
+	return r.write(ctx, wallet, &types.Put{
+		TableName:           aws.String(r.tableName),
+		Item:                av,
+		ConditionExpression: aws.String("attribute_not_exists(userId)"),
+	}, "save wallet", domerrors.WalletAlreadyExistsError(wallet.UserID().String()))
+}
+
+// Update saves changes to an existing wallet using optimistic concurrency
+// The write only succeeds if the stored version still matches the one that was read;
+// otherwise a CONCURRENT_MODIFICATION error is returned and the caller must reload
+func (r *DynamoDBWalletRepository) Update(ctx context.Context, wallet *wallet.Wallet) error {
+	if wallet == nil {
+		return domerrors.NewDomainError(domerrors.ErrCodeValidationFailed, "wallet cannot be nil")
+	}
+
+	put, err := r.updatePut(wallet)
+	if err != nil {
+		return err
+	}
+
+	err = r.write(ctx, wallet, put, "update wallet", domerrors.WalletVersionConflictError(wallet.UserID().String(), wallet.Version()))
+	if err != nil {
+		return err
+	}
+
+	wallet.IncrementVersion()
+
+	return nil
+}
+
+// updatePut builds the versioned write of an existing wallet, shared with the unit of work
+func (r *DynamoDBWalletRepository) updatePut(wallet *wallet.Wallet) (*types.Put, error) {
+	expectedVersion := wallet.Version()
+
+	dbModel, err := r.mapper.ToDBModel(wallet)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to convert wallet to DB model", err)
+	}
+	dbModel.Version = expectedVersion + 1
+
+	av, err := attributevalue.MarshalMap(dbModel)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal wallet", err)
+	}
+
+	// Rows written before versioning have no version attribute and count as version 0
+	// The row must exist: an update never creates a wallet, that is Save's job
+	condition := "#version = :expected"
+	if expectedVersion == 0 {
+		condition = "attribute_not_exists(#version) OR " + condition
+	}
+	condition = "attribute_exists(userId) AND (" + condition + ")"
+
+	return &types.Put{
+		TableName:           aws.String(r.tableName),
+		Item:                av,
+		ConditionExpression: aws.String(condition),
+		ExpressionAttributeNames: map[string]string{
+			"#version": "version",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":expected": &types.AttributeValueMemberN{Value: strconv.FormatInt(expectedVersion, 10)},
+		},
+	}, nil
+}
+
+// ForEachWallet calls fn with every stored wallet, stopping at the first error
+func (r *DynamoDBWalletRepository) ForEachWallet(ctx context.Context, fn func(*wallet.Wallet) error) error {
+	return r.scan(ctx, &dynamodb.ScanInput{TableName: aws.String(r.tableName)}, fn)
+}
+
+// MigrateLegacyBalances rewrites single-balance rows into the per-currency format
+// Legacy rows are already read as single-currency wallets, so the migration can run
+// while the API serves traffic and can be repeated; a row that changed in between
+// was rewritten by the API in the new format and is skipped
+func (r *DynamoDBWalletRepository) MigrateLegacyBalances(ctx context.Context) (migrated int, err error) {
+	err = r.scan(ctx, &dynamodb.ScanInput{
+		TableName:        aws.String(r.tableName),
+		FilterExpression: aws.String("attribute_not_exists(balances)"),
+	}, func(wlt *wallet.Wallet) error {
+		if err := r.Update(ctx, wlt); err != nil {
+			if domerrors.IsErrorCode(err, domerrors.ErrCodeConcurrentModification) {
+				return nil
+			}
+			return err
+		}
+		migrated++
+		return nil
+	})
+
+	return migrated, err
+}
+
+// LedgerExcess is a wallet balance below what its ledger account already adds up to
+// An opening entry can only explain money the ledger is missing, so these need an operator
+type LedgerExcess struct {
+	UserID   string
+	Currency string
+	Balance  decimal.Decimal // stored on the wallet
+	Ledger   decimal.Decimal // recomputed from the postings
+}
+
+// OpenLedgerBalances posts an opening entry for the part of each balance the ledger does not
+// explain yet, which is the whole balance of wallets that predate the ledger, and returns the
+// balances the ledger already exceeds
+// Like the migration it can run while the API serves traffic and can be repeated: the postings
+// are read after the wallet, so a wallet that changed in between fails its versioned write and
+// is skipped, and a currency gets one opening entry at most
+func (r *DynamoDBWalletRepository) OpenLedgerBalances(ctx context.Context) (opened int, excess []LedgerExcess, err error) {
+	excess = make([]LedgerExcess, 0)
+	err = r.ForEachWallet(ctx, func(wlt *wallet.Wallet) error {
+		userID := wlt.UserID().String()
+
+		postings, err := r.ledger.AccountPostings(ctx, ledger.WalletAccount(userID))
+		if err != nil {
+			return err
+		}
+		posted := ledger.Balances(postings)
+
+		for _, balance := range wlt.Balances() {
+			code := balance.Currency().Code()
+			missing := balance.Amount().Sub(posted[code])
+			if missing.IsNegative() {
+				excess = append(excess, LedgerExcess{
+					UserID:   userID,
+					Currency: code,
+					Balance:  balance.Amount(),
+					Ledger:   posted[code],
+				})
+				continue
+			}
+			if missing.IsZero() {
+				continue
+			}
+
+			amount, err := vo.NewMoney(missing, balance.Currency())
+			if err != nil {
+				return err
+			}
+			entry, err := ledger.NewOpeningEntry(userID, amount)
+			if err != nil {
+				return err
+			}
+			if err := wlt.RecordEntry(entry); err != nil {
+				return err
+			}
+		}
+
+		if len(wlt.PendingEntries()) == 0 {
+			return nil
+		}
+
+		if err := r.Update(ctx, wlt); err != nil {
+			if domerrors.IsErrorCode(err, domerrors.ErrCodeConcurrentModification) ||
+				domerrors.IsErrorCode(err, domerrors.ErrCodeLedgerEntryExists) {
+				return nil
+			}
+			return err
+		}
+		opened++
+		return nil
 	})
 
+	return opened, excess, err
+}
+
+// write puts the wallet row together with the journal entries the wallet recorded, so a balance
+// never changes without the entries that explain it. Without entries it is a plain conditional put
+// conflict is returned when the condition of the wallet row fails
+func (r *DynamoDBWalletRepository) write(ctx context.Context, wlt *wallet.Wallet, put *types.Put, operation string, conflict error) error {
+	entries := wlt.PendingEntries()
+	if len(entries) == 0 {
+		_, err := r.client.PutItem(ctx, &dynamodb.PutItemInput{
+			TableName:                 put.TableName,
+			Item:                      put.Item,
+			ConditionExpression:       put.ConditionExpression,
+			ExpressionAttributeNames:  put.ExpressionAttributeNames,
+			ExpressionAttributeValues: put.ExpressionAttributeValues,
+		})
+		if err != nil {
+			var conditionFailed *types.ConditionalCheckFailedException
+			if errors.As(err, &conditionFailed) {
+				return conflict
+			}
+			return domerrors.DatabaseError(operation, err)
+		}
+		return nil
+	}
+
+	// Item order must match walletTransactItem; each entry row is followed by its postings
+	items := []types.TransactWriteItem{{Put: put}}
+	entryItems := make(map[int]string, len(entries))
+	for _, entry := range entries {
+		writes, err := r.ledger.transactItems(entry)
+		if err != nil {
+			return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to marshal journal entry "+entry.ID(), err)
+		}
+		entryItems[len(items)] = entry.ID()
+		items = append(items, writes...)
+	}
+
+	_, err := r.client.TransactWriteItems(ctx, &dynamodb.TransactWriteItemsInput{
+		TransactItems: items,
+	})
 	if err != nil {
-		return domerrors.DatabaseError("save wallet", err)
+		// An entry already posted means the movement was applied before, which matters more
+		// to the caller than the version it read being stale by now
+		for index, entryID := range entryItems {
+			if isConditionFailedAt(err, index) {
+				return domerrors.LedgerEntryExistsError(entryID)
+			}
+		}
+		if isConditionFailedAt(err, walletTransactItem) {
+			return conflict
+		}
+		return domerrors.DatabaseError(operation+" transaction", err)
 	}
 
+	wlt.ClearPendingEntries()
 	return nil
 }
 
-// Update saves changes to an existing wallet
-func (r *DynamoDBWalletRepository) Update(ctx context.Context, wallet *wallet.Wallet) error {
-	return r.Save(ctx, wallet)
+// scan calls fn with every wallet the scan returns, stopping at the first error
+func (r *DynamoDBWalletRepository) scan(ctx context.Context, input *dynamodb.ScanInput, fn func(*wallet.Wallet) error) error {
+	paginator := dynamodb.NewScanPaginator(r.client, input)
+
+	for paginator.HasMorePages() {
+		page, err := paginator.NextPage(ctx)
+		if err != nil {
+			return domerrors.DatabaseError("scan wallets", err)
+		}
+
+		for _, item := range page.Items {
+			var dbModel mappers.WalletDBModel
+			if err := attributevalue.UnmarshalMap(item, &dbModel); err != nil {
+				return domerrors.WrapError(domerrors.ErrCodeDatabaseError, "failed to unmarshal wallet", err)
+			}
+
+			wlt, err := r.mapper.ToDomain(&dbModel)
+			if err != nil {
+				return domerrors.WrapError(domerrors.ErrCodeDatabaseError,
+					"failed to convert wallet "+dbModel.UserID, err)
+			}
+
+			if err := fn(wlt); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
 }
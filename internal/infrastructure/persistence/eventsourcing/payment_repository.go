@@ -0,0 +1,67 @@
+package eventsourcing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
+)
+
+// EventSourcedPaymentRepository rebuilds payments from the EventStore instead of the Payments table
+// It is read-only: state is still written through the snapshot repository and the event stream
+type EventSourcedPaymentRepository struct {
+	eventStore shared.EventStore
+}
+
+// NewEventSourcedPaymentRepository creates a new EventSourcedPaymentRepository
+func NewEventSourcedPaymentRepository(eventStore shared.EventStore) *EventSourcedPaymentRepository {
+	return &EventSourcedPaymentRepository{
+		eventStore: eventStore,
+	}
+}
+
+// FindByID replays the event stream of a payment
+func (r *EventSourcedPaymentRepository) FindByID(ctx context.Context, paymentID string) (*payment.Payment, error) {
+	events, err := r.LoadEvents(ctx, paymentID)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, domerrors.PaymentNotFoundError(paymentID)
+	}
+
+	pmt, err := payment.RehydratePayment(events)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to rehydrate payment", err).
+			WithDetail("paymentId", paymentID)
+	}
+
+	return pmt, nil
+}
+
+// LoadEvents reads and deserializes the ordered event stream of a payment
+func (r *EventSourcedPaymentRepository) LoadEvents(ctx context.Context, paymentID string) ([]shared.Event, error) {
+	stored, err := r.eventStore.ListByPaymentID(ctx, paymentID)
+	if err != nil {
+		return nil, domerrors.WrapError(domerrors.ErrCodeEventStoreError, "failed to list payment events", err)
+	}
+
+	events := make([]shared.Event, 0, len(stored))
+	for _, storedEvent := range stored {
+		event, err := codec.ParseEvent(storedEvent.EventType, []byte(storedEvent.Payload))
+		if err != nil {
+			return nil, domerrors.WrapError(
+				domerrors.ErrCodeEventStoreError,
+				fmt.Sprintf("failed to parse stored event %s", storedEvent.EventID),
+				err,
+			)
+		}
+		events = append(events, event)
+	}
+
+	return events, nil
+}
@@ -16,3 +16,9 @@ func RecordCustomEvent(eventName string, attrs map[string]interface{}) {
 	data, _ := json.MarshalIndent(attrs, "", "  ")
 	log.Printf("[OBSERVABILITY] %s\n%s", eventName, string(data))
 }
+
+// RecordMetric records a numeric metric sample (mock implementation)
+func RecordMetric(name string, value float64) {
+	// In production, this would send to New Relic
+	log.Printf("[OBSERVABILITY] metric %s=%g", name, value)
+}
@@ -12,7 +12,7 @@ aws dynamodb put-item \
     --table-name Wallets \
     --item '{
         "userId": {"S": "user-123"},
-        "balance": {"N": "1000.00"},
+        "balances": {"M": {"ARS": {"S": "1000.00"}, "USD": {"S": "200.00"}}},
         "currency": {"S": "ARS"},
         "updatedAt": {"S": "2024-01-01T00:00:00Z"}
     }' \
@@ -24,7 +24,7 @@ aws dynamodb put-item \
     --table-name Wallets \
     --item '{
         "userId": {"S": "user-456"},
-        "balance": {"N": "50.00"},
+        "balances": {"M": {"ARS": {"S": "50.00"}}},
         "currency": {"S": "ARS"},
         "updatedAt": {"S": "2024-01-01T00:00:00Z"}
     }' \
@@ -36,7 +36,7 @@ echo ""
 echo "✅ Data seeding complete!"
 echo ""
 echo "Test users created:"
-echo "  - user-123: 1000.00 ARS (sufficient balance)"
+echo "  - user-123: 1000.00 ARS + 200.00 USD (sufficient balance)"
 echo "  - user-456: 50.00 ARS (low balance for testing insufficient funds)"
 echo ""
 
@@ -10,6 +10,7 @@ import (
 	"time"
 
 	"github.com/franco/payment-api/internal/domain/payment"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
 	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 	"github.com/franco/payment-api/internal/domain/wallet"
 	"github.com/franco/payment-api/internal/infrastructure"
@@ -43,9 +44,11 @@ func TestPaymentFlowIntegration(t *testing.T) {
 	// Give time for setup
 	time.Sleep(2 * time.Second)
 
-	// Seed test wallet
-	walletRepo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets")
-	userID, _ := vo.NewUserID("integration-user-123")
+	// Seed test wallet, new on every run since wallets are never overwritten
+	runID := time.Now().Format("20060102150405")
+	ledgerRepo := dynamodbRepo.NewDynamoDBLedgerRepository(awsClients.DynamoDB, "JournalEntries", "LedgerPostings")
+	walletRepo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets", ledgerRepo)
+	userID, _ := vo.NewUserID("integration-user-" + runID)
 	balance := vo.MustNewMoney("1000.00", "ARS")
 	testWallet, _ := wallet.NewWallet(userID, balance)
 	err = walletRepo.Save(ctx, testWallet)
@@ -53,11 +56,11 @@ func TestPaymentFlowIntegration(t *testing.T) {
 
 	// Test creating a payment via HTTP
 	paymentReq := map[string]interface{}{
-		"userId":         "integration-user-123",
-		"amount":         150.75,
+		"userId":         userID.String(),
+		"amount":         "150.75",
 		"currency":       "ARS",
 		"serviceId":      "integration-service",
-		"idempotencyKey": "integration-test-" + time.Now().Format("20060102150405"),
+		"idempotencyKey": "integration-test-" + runID,
 		"clientId":       "test-client",
 	}
 
@@ -133,6 +136,7 @@ func TestDynamoDBRepositories(t *testing.T) {
 		assert.True(t, retrieved.Money().Equals(pmt.Money()))
 
 		// Update (mark as completed)
+		require.NoError(t, retrieved.MarkProcessing())
 		err = retrieved.MarkCompleted("ext-tx-123")
 		require.NoError(t, err)
 		err = repo.Update(ctx, retrieved)
@@ -144,9 +148,11 @@ func TestDynamoDBRepositories(t *testing.T) {
 
 	// Test Wallet Repository
 	t.Run("WalletRepository", func(t *testing.T) {
-		repo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets")
+		ledgerRepo := dynamodbRepo.NewDynamoDBLedgerRepository(awsClients.DynamoDB, "JournalEntries", "LedgerPostings")
+		repo := dynamodbRepo.NewDynamoDBWalletRepository(awsClients.DynamoDB, "Wallets", ledgerRepo)
 
-		userID, _ := vo.NewUserID("wallet-user-789")
+		// Wallets are never overwritten, so every run needs a new one
+		userID, _ := vo.NewUserID("wallet-user-" + time.Now().Format("20060102150405"))
 		balance := vo.MustNewMoney("5000.00", "ARS")
 		wlt, _ := wallet.NewWallet(userID, balance)
 
@@ -155,9 +161,9 @@ func TestDynamoDBRepositories(t *testing.T) {
 		require.NoError(t, err)
 
 		// Get
-		retrieved, err := repo.GetByUserID(ctx, "wallet-user-789")
+		retrieved, err := repo.GetByUserID(ctx, userID.String())
 		require.NoError(t, err)
-		assert.True(t, retrieved.Balance().Equals(wlt.Balance()))
+		assert.True(t, retrieved.Balance(vo.ARS).Equals(wlt.Balance(vo.ARS)))
 
 		// Update Balance (debit 500)
 		debitAmount := vo.MustNewMoney("500.00", "ARS")
@@ -166,8 +172,16 @@ func TestDynamoDBRepositories(t *testing.T) {
 		err = repo.Update(ctx, retrieved)
 		require.NoError(t, err)
 
-		updated, _ := repo.GetByUserID(ctx, "wallet-user-789")
+		updated, _ := repo.GetByUserID(ctx, userID.String())
 		expectedBalance := decimal.NewFromFloat(4500.00)
-		assert.True(t, updated.Balance().Amount().Equal(expectedBalance))
+		assert.True(t, updated.Balance(vo.ARS).Amount().Equal(expectedBalance))
+
+		// Update never creates a wallet that was not saved
+		missingID, _ := vo.NewUserID("missing-" + userID.String())
+		missing, _ := wallet.NewWallet(missingID, balance)
+		err = repo.Update(ctx, missing)
+		assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeConcurrentModification))
+		_, err = repo.GetByUserID(ctx, missingID.String())
+		assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeWalletNotFound))
 	})
 }
@@ -0,0 +1,185 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/saga"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCancelPayment_BeforeHoldStopsTheSaga(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	service := command.NewCancelPaymentService(orch)
+	ctx := context.Background()
+
+	// Act: the cancellation wins, PaymentRequested is consumed afterwards
+	result, err := service.Execute(ctx, command.CancelPaymentRequest{PaymentID: paymentID})
+	require.NoError(t, err)
+	require.NoError(t, orch.HandlePaymentRequested(ctx, requestedEventFor(pmt)))
+
+	// Assert
+	assert.Equal(t, "CANCELLED", result.Status)
+	assert.Equal(t, saga.StepCancelled, stores.Sagas.GetSaga(paymentID).Step())
+
+	wlt, _ := stores.Wallets.GetByUserID(ctx, "user-123")
+	assert.True(t, wlt.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.Empty(t, queued(t, stores.Outbox, "WalletFundsHeld"))
+	assert.Empty(t, queued(t, stores.Outbox, "PaymentRefundRequested"))
+
+	cancelled := queued(t, stores.Outbox, "PaymentCancelled")
+	require.Len(t, cancelled, 1)
+	assert.Equal(t, "CANCELLED_BY_CLIENT", cancelled[0].(*payment.PaymentCancelledEvent).Reason())
+}
+
+func TestCancelPayment_AwaitingGatewayIsRefusedAndTheChargeSettles(t *testing.T) {
+	// Arrange: funds are held and the charge is on its way to the gateway
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	service := command.NewCancelPaymentService(orch)
+	ctx := context.Background()
+	require.NoError(t, orch.HandlePaymentRequested(ctx, requestedEventFor(pmt)))
+
+	// Act
+	_, err := service.Execute(ctx, command.CancelPaymentRequest{PaymentID: paymentID, Reason: "DUPLICATED_ORDER"})
+	require.Error(t, err)
+	require.NoError(t, orch.HandleExternalPaymentSucceeded(ctx,
+		payment.NewExternalPaymentSucceededEvent(paymentID, "external-tx-456", shared.Metadata{})))
+
+	// Assert: the hold stays until the gateway answers, and its approval is captured
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodePaymentNotCancellable), "got %v", err)
+	assert.Empty(t, queued(t, stores.Outbox, "PaymentCancelled"))
+	assert.Empty(t, queued(t, stores.Outbox, "PaymentRefundRequested"))
+
+	updated, _ := stores.Payments.FindByID(ctx, paymentID)
+	assert.True(t, updated.Status().IsCompleted())
+	assert.Equal(t, saga.StepCompleted, stores.Sagas.GetSaga(paymentID).Step())
+
+	wlt, _ := stores.Wallets.GetByUserID(ctx, "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.True(t, wlt.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.Len(t, queued(t, stores.Outbox, "WalletHoldCaptured"), 1)
+	assert.Empty(t, queued(t, stores.Outbox, "WalletHoldReleased"))
+}
+
+func TestCancelPayment_Refused(t *testing.T) {
+	tests := []struct {
+		name     string
+		arrange  func(t *testing.T, orch *orchestrator.PaymentOrchestrator, sagaRepo *fakes.SagaRepositoryFake, pmt *payment.Payment)
+		wantCode domerrors.ErrorCode
+	}{
+		{
+			name: "completed payment",
+			arrange: func(t *testing.T, orch *orchestrator.PaymentOrchestrator, _ *fakes.SagaRepositoryFake, pmt *payment.Payment) {
+				require.NoError(t, orch.HandlePaymentRequested(context.Background(), requestedEventFor(pmt)))
+				require.NoError(t, orch.HandleExternalPaymentSucceeded(context.Background(),
+					payment.NewExternalPaymentSucceededEvent(pmt.ID().String(), "external-tx-456", shared.Metadata{})))
+			},
+			wantCode: domerrors.ErrCodePaymentNotCancellable,
+		},
+		{
+			name: "PaymentRequested being handled",
+			arrange: func(t *testing.T, _ *orchestrator.PaymentOrchestrator, sagaRepo *fakes.SagaRepositoryFake, pmt *payment.Payment) {
+				// The orchestrator accepted the event and may be holding funds right now
+				sg, _ := saga.NewSaga(pmt.ID().String(), time.Now().Add(time.Minute))
+				require.NoError(t, sg.Accept("PaymentRequested", time.Now().Add(time.Minute)))
+				require.NoError(t, sagaRepo.Save(context.Background(), sg))
+			},
+			wantCode: domerrors.ErrCodeConcurrentModification,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stores := fakes.NewStores()
+			stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+			pmt := seedPendingPayment(t, stores.Payments)
+			orch := orchestrator.NewPaymentOrchestrator(
+				stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+				stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+			)
+			tt.arrange(t, orch, stores.Sagas, pmt)
+
+			// Act
+			_, err := command.NewCancelPaymentService(orch).Execute(context.Background(), command.CancelPaymentRequest{PaymentID: pmt.ID().String()})
+
+			// Assert
+			require.Error(t, err)
+			assert.True(t, domerrors.IsErrorCode(err, tt.wantCode), "got %v", err)
+			assert.Empty(t, queued(t, stores.Outbox, "PaymentCancelled"))
+		})
+	}
+}
+
+func TestCancelPayment_AfterTheLeaseOfAFailedDeliveryRunsOut(t *testing.T) {
+	// Arrange: a delivery of PaymentRequested was accepted and failed before holding funds
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	sg, _ := saga.NewSaga(paymentID, time.Now().Add(time.Minute))
+	require.NoError(t, sg.Accept("PaymentRequested", time.Now().Add(-time.Second)))
+	require.NoError(t, stores.Sagas.Save(context.Background(), sg))
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	// Act
+	result, err := command.NewCancelPaymentService(orch).Execute(context.Background(), command.CancelPaymentRequest{PaymentID: paymentID})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "CANCELLED", result.Status)
+	assert.Equal(t, saga.StepCancelled, stores.Sagas.GetSaga(paymentID).Step())
+	assert.Len(t, queued(t, stores.Outbox, "PaymentCancelled"), 1)
+}
+
+func TestCancelPayment_RepeatedCancelIsAcknowledged(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	service := command.NewCancelPaymentService(orch)
+	req := command.CancelPaymentRequest{PaymentID: paymentID}
+
+	// Act
+	_, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	second, err := service.Execute(context.Background(), req)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "CANCELLED", second.Status)
+	assert.Len(t, queued(t, stores.Outbox, "PaymentCancelled"), 1)
+}
@@ -2,12 +2,15 @@ package unit
 
 import (
 	"context"
+	"sync"
 	"testing"
 
 	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/domain/payment"
 	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 	"github.com/franco/payment-api/internal/domain/wallet"
 	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -18,7 +21,7 @@ func TestCreatePayment_HappyPath(t *testing.T) {
 	walletRepo := fakes.NewWalletRepositoryFake()
 	idempotencyStore := fakes.NewIdempotencyStoreFake()
 	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
+	outboxStore := fakes.NewOutboxStoreFake()
 
 	// Create wallet with sufficient balance
 	userID, _ := vo.NewUserID("user-123")
@@ -27,17 +30,17 @@ func TestCreatePayment_HappyPath(t *testing.T) {
 	walletRepo.SetWallet(wlt)
 
 	service := command.NewCreatePaymentService(
-		paymentRepo,
+		fakes.NewPaymentUnitOfWorkFake(paymentRepo, idempotencyStore, eventStore, outboxStore, fakes.NewFXQuoteStoreFake()),
 		walletRepo,
 		idempotencyStore,
-		eventStore,
-		eventPublisher,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
 		"test-topic-arn",
 	)
 
 	req := command.CreatePaymentRequest{
 		UserID:         "user-123",
-		Amount:         100.50,
+		Amount:         decimal.RequireFromString("100.50"),
 		Currency:       "ARS",
 		ServiceID:      "service-123",
 		IdempotencyKey: "unique-key-123",
@@ -56,11 +59,11 @@ func TestCreatePayment_HappyPath(t *testing.T) {
 	payment, err := paymentRepo.FindByID(context.Background(), result.PaymentID)
 	require.NoError(t, err)
 	assert.Equal(t, req.UserID, payment.UserID().String())
-	assert.Equal(t, req.Amount, payment.Money().AmountFloat())
+	assert.True(t, req.Amount.Equal(payment.Money().Amount()))
 	assert.True(t, payment.Status().IsPending())
 
-	// Verify event was published
-	events := eventPublisher.GetEventsByType("PaymentRequested")
+	// Verify event was queued for the relay
+	events := outboxStore.GetPendingByType("PaymentRequested")
 	assert.Len(t, events, 1)
 }
 
@@ -70,7 +73,7 @@ func TestCreatePayment_Idempotency(t *testing.T) {
 	walletRepo := fakes.NewWalletRepositoryFake()
 	idempotencyStore := fakes.NewIdempotencyStoreFake()
 	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
+	outboxStore := fakes.NewOutboxStoreFake()
 
 	// Create wallet with sufficient balance
 	userID, _ := vo.NewUserID("user-123")
@@ -79,17 +82,17 @@ func TestCreatePayment_Idempotency(t *testing.T) {
 	walletRepo.SetWallet(wlt)
 
 	service := command.NewCreatePaymentService(
-		paymentRepo,
+		fakes.NewPaymentUnitOfWorkFake(paymentRepo, idempotencyStore, eventStore, outboxStore, fakes.NewFXQuoteStoreFake()),
 		walletRepo,
 		idempotencyStore,
-		eventStore,
-		eventPublisher,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
 		"test-topic-arn",
 	)
 
 	req := command.CreatePaymentRequest{
 		UserID:         "user-123",
-		Amount:         100.50,
+		Amount:         decimal.RequireFromString("100.50"),
 		Currency:       "ARS",
 		ServiceID:      "service-123",
 		IdempotencyKey: "unique-key-123",
@@ -108,19 +111,20 @@ func TestCreatePayment_Idempotency(t *testing.T) {
 	assert.Equal(t, result1.PaymentID, result2.PaymentID)
 	assert.Equal(t, "ALREADY_PROCESSED", result2.Status)
 
-	// Verify only one event was published
-	events := eventPublisher.GetEventsByType("PaymentRequested")
+	// Verify only one event was queued
+	events := outboxStore.GetPendingByType("PaymentRequested")
 	assert.Len(t, events, 1)
 }
 
 func TestCreatePayment_ValidationErrors(t *testing.T) {
 	// Arrange
+	idempotencyStore := fakes.NewIdempotencyStoreFake()
 	service := command.NewCreatePaymentService(
-		fakes.NewPaymentRepositoryFake(),
+		fakes.NewPaymentUnitOfWorkFake(fakes.NewPaymentRepositoryFake(), idempotencyStore, fakes.NewEventStoreFake(), fakes.NewOutboxStoreFake(), fakes.NewFXQuoteStoreFake()),
 		fakes.NewWalletRepositoryFake(),
-		fakes.NewIdempotencyStoreFake(),
-		fakes.NewEventStoreFake(),
-		fakes.NewEventPublisherFake(),
+		idempotencyStore,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
 		"test-topic-arn",
 	)
 
@@ -132,7 +136,7 @@ func TestCreatePayment_ValidationErrors(t *testing.T) {
 		{
 			name: "Missing UserID",
 			req: command.CreatePaymentRequest{
-				Amount:         100,
+				Amount:         decimal.RequireFromString("100"),
 				Currency:       "ARS",
 				ServiceID:      "service-123",
 				IdempotencyKey: "key-123",
@@ -143,7 +147,7 @@ func TestCreatePayment_ValidationErrors(t *testing.T) {
 			name: "Invalid Amount",
 			req: command.CreatePaymentRequest{
 				UserID:         "user-123",
-				Amount:         0,
+				Amount:         decimal.RequireFromString("0"),
 				Currency:       "ARS",
 				ServiceID:      "service-123",
 				IdempotencyKey: "key-123",
@@ -154,7 +158,7 @@ func TestCreatePayment_ValidationErrors(t *testing.T) {
 			name: "Missing Currency",
 			req: command.CreatePaymentRequest{
 				UserID:         "user-123",
-				Amount:         100,
+				Amount:         decimal.RequireFromString("100"),
 				ServiceID:      "service-123",
 				IdempotencyKey: "key-123",
 			},
@@ -180,7 +184,7 @@ func TestCreatePayment_InsufficientFunds(t *testing.T) {
 	walletRepo := fakes.NewWalletRepositoryFake()
 	idempotencyStore := fakes.NewIdempotencyStoreFake()
 	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
+	outboxStore := fakes.NewOutboxStoreFake()
 
 	// Create wallet with LOW balance
 	userID, _ := vo.NewUserID("user-123")
@@ -189,17 +193,17 @@ func TestCreatePayment_InsufficientFunds(t *testing.T) {
 	walletRepo.SetWallet(wlt)
 
 	service := command.NewCreatePaymentService(
-		paymentRepo,
+		fakes.NewPaymentUnitOfWorkFake(paymentRepo, idempotencyStore, eventStore, outboxStore, fakes.NewFXQuoteStoreFake()),
 		walletRepo,
 		idempotencyStore,
-		eventStore,
-		eventPublisher,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
 		"test-topic-arn",
 	)
 
 	req := command.CreatePaymentRequest{
 		UserID:         "user-123",
-		Amount:         100.00, // More than balance
+		Amount:         decimal.RequireFromString("100.00"), // More than balance
 		Currency:       "ARS",
 		ServiceID:      "service-123",
 		IdempotencyKey: "unique-key-123",
@@ -217,8 +221,8 @@ func TestCreatePayment_InsufficientFunds(t *testing.T) {
 	// Verify payment was NOT created
 	assert.Equal(t, 0, len(paymentRepo.GetAll()))
 
-	// Verify event was NOT published
-	events := eventPublisher.GetEventsByType("PaymentRequested")
+	// Verify event was NOT queued
+	events := outboxStore.GetPendingByType("PaymentRequested")
 	assert.Len(t, events, 0)
 }
 
@@ -228,22 +232,22 @@ func TestCreatePayment_WalletNotFound(t *testing.T) {
 	walletRepo := fakes.NewWalletRepositoryFake()
 	idempotencyStore := fakes.NewIdempotencyStoreFake()
 	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
+	outboxStore := fakes.NewOutboxStoreFake()
 
 	// DO NOT create wallet - user doesn't exist
 
 	service := command.NewCreatePaymentService(
-		paymentRepo,
+		fakes.NewPaymentUnitOfWorkFake(paymentRepo, idempotencyStore, eventStore, outboxStore, fakes.NewFXQuoteStoreFake()),
 		walletRepo,
 		idempotencyStore,
-		eventStore,
-		eventPublisher,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
 		"test-topic-arn",
 	)
 
 	req := command.CreatePaymentRequest{
 		UserID:         "user-999", // Non-existent user
-		Amount:         100.00,
+		Amount:         decimal.RequireFromString("100.00"),
 		Currency:       "ARS",
 		ServiceID:      "service-123",
 		IdempotencyKey: "unique-key-123",
@@ -261,3 +265,61 @@ func TestCreatePayment_WalletNotFound(t *testing.T) {
 	// Verify payment was NOT created
 	assert.Equal(t, 0, len(paymentRepo.GetAll()))
 }
+
+func TestCreatePayment_ConcurrentDuplicatesCreateOnePayment(t *testing.T) {
+	// Arrange
+	paymentRepo := fakes.NewPaymentRepositoryFake()
+	walletRepo := fakes.NewWalletRepositoryFake()
+	idempotencyStore := fakes.NewIdempotencyStoreFake()
+	eventStore := fakes.NewEventStoreFake()
+	outboxStore := fakes.NewOutboxStoreFake()
+
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+	walletRepo.SetWallet(wlt)
+
+	service := command.NewCreatePaymentService(
+		fakes.NewPaymentUnitOfWorkFake(paymentRepo, idempotencyStore, eventStore, outboxStore, fakes.NewFXQuoteStoreFake()),
+		walletRepo,
+		idempotencyStore,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
+		"test-topic-arn",
+	)
+
+	req := command.CreatePaymentRequest{
+		UserID:         "user-123",
+		Amount:         decimal.RequireFromString("100.50"),
+		Currency:       "ARS",
+		ServiceID:      "service-123",
+		IdempotencyKey: "race-key",
+		ClientID:       "web-app",
+	}
+
+	// Act
+	const requests = 10
+	results := make([]*command.CreatePaymentResponse, requests)
+	errs := make([]error, requests)
+
+	var wg sync.WaitGroup
+	for i := 0; i < requests; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = service.Execute(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert
+	created := 0
+	for i := 0; i < requests; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, results[0].PaymentID, results[i].PaymentID)
+		if results[i].Status == vo.PaymentStatusPending.String() {
+			created++
+		}
+	}
+	assert.Equal(t, 1, created)
+	assert.Len(t, outboxStore.GetPendingByType("PaymentRequested"), 1)
+}
@@ -0,0 +1,81 @@
+package unit
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSerializeEvent_WritesExactDecimalAmounts(t *testing.T) {
+	// Arrange: 0.1 + 0.2 is not 0.3 in float64
+	amount := decimal.RequireFromString("0.1").Add(decimal.RequireFromString("0.2"))
+	event := payment.NewPaymentRequestedEvent("payment-1", "user-123", amount, "ARS", "service-123", "key-123", shared.Metadata{})
+
+	// Act
+	payload, err := codec.SerializeEvent(event)
+	require.NoError(t, err)
+	parsed, err := codec.ParseEvent(event.EventType(), payload)
+
+	// Assert
+	require.NoError(t, err)
+	var wire map[string]interface{}
+	require.NoError(t, json.Unmarshal(payload, &wire))
+	assert.Equal(t, "0.3", wire["amount"])
+	assert.Equal(t, "ARS", wire["currency"])
+
+	requested := parsed.(*payment.PaymentRequestedEvent)
+	assert.True(t, requested.Amount().Equal(decimal.RequireFromString("0.3")))
+	assert.Equal(t, "ARS", requested.Currency())
+}
+
+func TestParseEvent_ReadsLegacyFloatAmounts(t *testing.T) {
+	tests := []struct {
+		name         string
+		eventType    string
+		payload      string
+		wantAmount   string
+		wantCurrency string
+	}{
+		{
+			name:         "payment requested",
+			eventType:    "PaymentRequested",
+			payload:      `{"paymentID":"payment-1","userID":"user-123","amount":100.5,"currency":"ARS","serviceID":"service-123"}`,
+			wantAmount:   "100.5",
+			wantCurrency: "ARS",
+		},
+		{
+			name:       "wallet debited without currency",
+			eventType:  "WalletDebited",
+			payload:    `{"paymentID":"payment-1","userID":"user-123","amount":19.99,"prevBalance":500,"newBalance":480.01}`,
+			wantAmount: "19.99",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			parsed, err := codec.ParseEvent(tt.eventType, []byte(tt.payload))
+
+			// Assert
+			require.NoError(t, err)
+			var amount decimal.Decimal
+			var currency string
+			switch e := parsed.(type) {
+			case *payment.PaymentRequestedEvent:
+				amount, currency = e.Amount(), e.Currency()
+			case *wallet.WalletDebitedEvent:
+				amount, currency = e.Amount(), e.Currency()
+				assert.Equal(t, "480.01", e.NewBalance().String())
+			}
+			assert.Equal(t, tt.wantAmount, amount.String())
+			assert.Equal(t, tt.wantCurrency, currency)
+		})
+	}
+}
@@ -2,6 +2,7 @@ package fakes
 
 import (
 	"context"
+	"errors"
 	"sync"
 
 	"github.com/franco/payment-api/internal/domain/shared"
@@ -11,6 +12,7 @@ import (
 type EventPublisherFake struct {
 	mu              sync.RWMutex
 	PublishedEvents []shared.Event
+	failuresLeft    int
 }
 
 // NewEventPublisherFake creates a new EventPublisherFake
@@ -25,10 +27,23 @@ func (f *EventPublisherFake) Publish(ctx context.Context, event shared.Event, to
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
+	if f.failuresLeft > 0 {
+		f.failuresLeft--
+		return errors.New("publisher unavailable")
+	}
+
 	f.PublishedEvents = append(f.PublishedEvents, event)
 	return nil
 }
 
+// FailNextPublishes makes the next n Publish calls fail, simulating a bus outage
+func (f *EventPublisherFake) FailNextPublishes(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failuresLeft = n
+}
+
 // GetPublishedEvents returns all published events
 func (f *EventPublisherFake) GetPublishedEvents() []shared.Event {
 	f.mu.RLock()
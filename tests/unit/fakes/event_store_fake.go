@@ -4,9 +4,10 @@ import (
 	"context"
 	"encoding/json"
 	"sync"
+	"time"
 
 	"github.com/franco/payment-api/internal/domain/shared"
-	"github.com/google/uuid"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
 )
 
 // EventStoreFake is a fake implementation of EventStore for testing
@@ -27,15 +28,15 @@ func (f *EventStoreFake) Append(ctx context.Context, event shared.Event, payment
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	payloadBytes, _ := json.Marshal(event)
+	payloadBytes, _ := codec.SerializeEvent(event)
 	metadataBytes, _ := json.Marshal(event.Metadata())
 
 	storedEvent := shared.StoredEvent{
-		EventID:    uuid.New().String(),
+		EventID:    event.EventID(),
 		EventType:  event.EventType(),
 		PaymentID:  paymentID,
 		Payload:    string(payloadBytes),
-		OccurredAt: event.OccurredAt().String(),
+		OccurredAt: event.OccurredAt().Format(time.RFC3339Nano),
 		Metadata:   string(metadataBytes),
 	}
 
@@ -0,0 +1,57 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// FXQuoteStoreFake is a fake implementation of FXQuoteStore for testing
+type FXQuoteStoreFake struct {
+	mu     sync.RWMutex
+	quotes map[string]port.FXQuote
+}
+
+// NewFXQuoteStoreFake creates a new FXQuoteStoreFake
+func NewFXQuoteStoreFake() *FXQuoteStoreFake {
+	return &FXQuoteStoreFake{
+		quotes: make(map[string]port.FXQuote),
+	}
+}
+
+// Save stores a quote
+func (f *FXQuoteStoreFake) Save(ctx context.Context, quote port.FXQuote) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.quotes[quote.ID] = quote
+	return nil
+}
+
+// Get loads a quote by ID
+func (f *FXQuoteStoreFake) Get(ctx context.Context, quoteID string) (*port.FXQuote, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	quote, exists := f.quotes[quoteID]
+	if !exists {
+		return nil, domerrors.FXQuoteNotFoundError(quoteID)
+	}
+	return &quote, nil
+}
+
+// markUsed records the payment that consumed a quote, failing if another one already did
+func (f *FXQuoteStoreFake) markUsed(quoteID, paymentID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	quote, exists := f.quotes[quoteID]
+	if !exists || quote.UsedBy != "" {
+		return domerrors.FXQuoteUsedError(quoteID)
+	}
+	quote.UsedBy = paymentID
+	f.quotes[quoteID] = quote
+	return nil
+}
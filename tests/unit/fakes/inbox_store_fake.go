@@ -0,0 +1,43 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+)
+
+// InboxStoreFake is a fake implementation of InboxStore for testing
+type InboxStoreFake struct {
+	mu        sync.RWMutex
+	processed map[string]string
+}
+
+// NewInboxStoreFake creates a new InboxStoreFake
+func NewInboxStoreFake() *InboxStoreFake {
+	return &InboxStoreFake{
+		processed: make(map[string]string),
+	}
+}
+
+// IsProcessed reports whether the consumer already handled the event
+func (f *InboxStoreFake) IsProcessed(ctx context.Context, consumer, eventID string) (bool, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	_, exists := f.processed[consumer+"#"+eventID]
+	return exists, nil
+}
+
+// MarkProcessed records that the consumer handled the event
+func (f *InboxStoreFake) MarkProcessed(ctx context.Context, consumer, eventID, eventType string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.processed[consumer+"#"+eventID] = eventType
+	return nil
+}
+
+// claimedLocked reports whether the consumer already handled the event; callers hold f.mu
+func (f *InboxStoreFake) claimedLocked(consumer, eventID string) bool {
+	_, exists := f.processed[consumer+"#"+eventID]
+	return exists
+}
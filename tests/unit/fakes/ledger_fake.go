@@ -0,0 +1,103 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/franco/payment-api/internal/domain/ledger"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// LedgerFake is an in-memory ledger for testing
+// Like the JournalEntries table, it refuses an entry ID that was already posted
+type LedgerFake struct {
+	mu      sync.RWMutex
+	entries []*ledger.JournalEntry
+	ids     map[string]bool
+}
+
+// NewLedgerFake creates a new LedgerFake
+func NewLedgerFake() *LedgerFake {
+	return &LedgerFake{
+		ids: make(map[string]bool),
+	}
+}
+
+// AccountPostings returns every posting of an account
+func (f *LedgerFake) AccountPostings(ctx context.Context, account ledger.AccountID) ([]ledger.Posting, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	postings := make([]ledger.Posting, 0)
+	for _, entry := range f.entries {
+		for _, posting := range entry.Postings() {
+			if posting.Account == account {
+				postings = append(postings, posting)
+			}
+		}
+	}
+	return postings, nil
+}
+
+// Entries returns the posted entries in posting order
+func (f *LedgerFake) Entries() []*ledger.JournalEntry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	entries := make([]*ledger.JournalEntry, len(f.entries))
+	copy(entries, f.entries)
+	return entries
+}
+
+// Entry returns a posted entry by ID, nil if there is none
+func (f *LedgerFake) Entry(entryID string) *ledger.JournalEntry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	for _, entry := range f.entries {
+		if entry.ID() == entryID {
+			return entry
+		}
+	}
+	return nil
+}
+
+// FindEntry returns a posted entry by ID, like the JournalEntries table
+func (f *LedgerFake) FindEntry(ctx context.Context, entryID string) (*ledger.JournalEntry, error) {
+	if entry := f.Entry(entryID); entry != nil {
+		return entry, nil
+	}
+	return nil, domerrors.LedgerEntryNotFoundError(entryID)
+}
+
+// check fails if any of the entries was already posted
+func (f *LedgerFake) check(entries []*ledger.JournalEntry) error {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.duplicate(entries)
+}
+
+// post stores entries all or nothing, as the wallet transaction does
+func (f *LedgerFake) post(entries []*ledger.JournalEntry) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.duplicate(entries); err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		f.ids[entry.ID()] = true
+		f.entries = append(f.entries, entry)
+	}
+	return nil
+}
+
+func (f *LedgerFake) duplicate(entries []*ledger.JournalEntry) error {
+	for _, entry := range entries {
+		if f.ids[entry.ID()] {
+			return domerrors.LedgerEntryExistsError(entry.ID())
+		}
+	}
+	return nil
+}
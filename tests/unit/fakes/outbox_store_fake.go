@@ -0,0 +1,166 @@
+package fakes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
+	"github.com/google/uuid"
+)
+
+// OutboxStoreFake is a fake implementation of OutboxStore for testing
+type OutboxStoreFake struct {
+	mu         sync.RWMutex
+	entries    map[string]*port.OutboxEntry
+	dispatched map[string]bool
+	dead       map[string]bool
+}
+
+// NewOutboxStoreFake creates a new OutboxStoreFake
+func NewOutboxStoreFake() *OutboxStoreFake {
+	return &OutboxStoreFake{
+		entries:    make(map[string]*port.OutboxEntry),
+		dispatched: make(map[string]bool),
+		dead:       make(map[string]bool),
+	}
+}
+
+// Add stores a pending entry
+func (f *OutboxStoreFake) Add(ctx context.Context, event shared.Event, topicArn string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	payload, err := codec.SerializeEvent(event)
+	if err != nil {
+		return err
+	}
+
+	id := uuid.New().String()
+	f.entries[id] = &port.OutboxEntry{
+		ID:        id,
+		EventType: event.EventType(),
+		TopicArn:  topicArn,
+		Payload:   string(payload),
+		CreatedAt: time.Now().UTC(),
+	}
+	return nil
+}
+
+// ListPending returns pending entries oldest first
+func (f *OutboxStoreFake) ListPending(ctx context.Context, limit int) ([]port.OutboxEntry, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	pending := f.pendingLocked()
+	if len(pending) > limit {
+		pending = pending[:limit]
+	}
+	return pending, nil
+}
+
+// MarkDispatched flags an entry as delivered
+func (f *OutboxStoreFake) MarkDispatched(ctx context.Context, entryID string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.dispatched[entryID] = true
+	return nil
+}
+
+// RecordFailure counts a failed attempt
+func (f *OutboxStoreFake) RecordFailure(ctx context.Context, entryID string, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if entry, exists := f.entries[entryID]; exists {
+		entry.Attempts++
+		entry.LastError = reason
+	}
+	return nil
+}
+
+// MarkDead takes an entry off the pending list for good
+func (f *OutboxStoreFake) MarkDead(ctx context.Context, entryID string, reason string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if entry, exists := f.entries[entryID]; exists {
+		entry.Attempts++
+		entry.LastError = reason
+	}
+	f.dead[entryID] = true
+	return nil
+}
+
+// AddEntry stores a raw pending entry, a helper to queue payloads the relay cannot parse
+func (f *OutboxStoreFake) AddEntry(entry port.OutboxEntry) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.entries[entry.ID] = &entry
+}
+
+// GetDead returns the entries marked dead, a helper for assertions
+func (f *OutboxStoreFake) GetDead() []port.OutboxEntry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	dead := make([]port.OutboxEntry, 0)
+	for id := range f.dead {
+		if entry, exists := f.entries[id]; exists {
+			dead = append(dead, *entry)
+		}
+	}
+	return dead
+}
+
+// GetPending returns all pending entries, a helper for assertions
+func (f *OutboxStoreFake) GetPending() []port.OutboxEntry {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.pendingLocked()
+}
+
+// GetPendingByType returns pending entries of a specific event type
+func (f *OutboxStoreFake) GetPendingByType(eventType string) []port.OutboxEntry {
+	var entries []port.OutboxEntry
+	for _, entry := range f.GetPending() {
+		if entry.EventType == eventType {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// PendingEvents parses the pending entries of a specific event type back into events, oldest first
+func (f *OutboxStoreFake) PendingEvents(eventType string) ([]shared.Event, error) {
+	entries := f.GetPendingByType(eventType)
+	events := make([]shared.Event, 0, len(entries))
+	for _, entry := range entries {
+		event, err := codec.ParseEvent(eventType, []byte(entry.Payload))
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, event)
+	}
+	return events, nil
+}
+
+func (f *OutboxStoreFake) pendingLocked() []port.OutboxEntry {
+	pending := make([]port.OutboxEntry, 0)
+	for id, entry := range f.entries {
+		if !f.dispatched[id] && !f.dead[id] {
+			pending = append(pending, *entry)
+		}
+	}
+
+	sort.SliceStable(pending, func(i, j int) bool {
+		return pending[i].CreatedAt.Before(pending[j].CreatedAt)
+	})
+	return pending
+}
@@ -0,0 +1,87 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/google/uuid"
+)
+
+// PaymentGatewayFake is a fake implementation of PaymentGateway for testing
+// It approves every charge unless an error or a decline is set
+type PaymentGatewayFake struct {
+	mu          sync.Mutex
+	err         error
+	failTimes   int // charges left to fail with err, 0 fails them all
+	declineCode string
+	calls       int
+}
+
+// NewPaymentGatewayFake creates a new PaymentGatewayFake
+func NewPaymentGatewayFake() *PaymentGatewayFake {
+	return &PaymentGatewayFake{}
+}
+
+// Charge records the call and answers as configured
+func (f *PaymentGatewayFake) Charge(ctx context.Context, req port.ChargeRequest) (*port.ChargeResult, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.calls++
+	if f.err != nil {
+		err := f.err
+		if f.failTimes > 0 {
+			if f.failTimes--; f.failTimes == 0 {
+				f.err = nil
+			}
+		}
+		return nil, err
+	}
+	if f.declineCode != "" {
+		return &port.ChargeResult{
+			Status:        port.ChargeDeclined,
+			DeclineReason: "CARD_DECLINED",
+			DeclineCode:   f.declineCode,
+		}, nil
+	}
+
+	return &port.ChargeResult{
+		Status:        port.ChargeApproved,
+		TransactionID: uuid.New().String(),
+	}, nil
+}
+
+// FailWith makes every charge return err
+func (f *PaymentGatewayFake) FailWith(err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+	f.failTimes = 0
+}
+
+// FailTimes makes the next n charges return err and lets the following ones through
+func (f *PaymentGatewayFake) FailTimes(n int, err error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.err = err
+	f.failTimes = n
+}
+
+// DeclineWith makes every charge come back declined with the given code
+func (f *PaymentGatewayFake) DeclineWith(code string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.declineCode = code
+}
+
+// Calls returns how many charges were attempted
+func (f *PaymentGatewayFake) Calls() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.calls
+}
@@ -2,13 +2,14 @@ package fakes
 
 import (
 	"context"
-	"errors"
 	"sync"
 
 	"github.com/franco/payment-api/internal/domain/payment"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
 )
 
 // PaymentRepositoryFake is a fake implementation of PaymentRepository for testing
+// Like DynamoDB, it hands out copies, so changes are only seen once they are saved
 type PaymentRepositoryFake struct {
 	mu       sync.RWMutex
 	payments map[string]*payment.Payment
@@ -27,7 +28,7 @@ func (f *PaymentRepositoryFake) Save(ctx context.Context, payment *payment.Payme
 	defer f.mu.Unlock()
 
 	// Store using ID as key
-	f.payments[payment.ID().String()] = payment
+	f.payments[payment.ID().String()] = copyPayment(payment)
 	return nil
 }
 
@@ -38,10 +39,10 @@ func (f *PaymentRepositoryFake) FindByID(ctx context.Context, paymentID string)
 
 	payment, exists := f.payments[paymentID]
 	if !exists {
-		return nil, errors.New("payment not found")
+		return nil, domerrors.PaymentNotFoundError(paymentID)
 	}
 
-	return payment, nil
+	return copyPayment(payment), nil
 }
 
 // Update updates a payment (same as Save in this fake)
@@ -56,7 +57,25 @@ func (f *PaymentRepositoryFake) GetAll() []*payment.Payment {
 
 	payments := make([]*payment.Payment, 0, len(f.payments))
 	for _, p := range f.payments {
-		payments = append(payments, p)
+		payments = append(payments, copyPayment(p))
 	}
 	return payments
 }
+
+func copyPayment(pmt *payment.Payment) *payment.Payment {
+	return payment.ReconstructPayment(
+		pmt.ID(),
+		pmt.UserID(),
+		pmt.ServiceID(),
+		pmt.Money(),
+		pmt.IdempotencyKey(),
+		pmt.Status(),
+		pmt.RefundedAmount(),
+		pmt.Fees(),
+		pmt.Conversion(),
+		pmt.FailureReason(),
+		pmt.ExternalTxID(),
+		pmt.CreatedAt(),
+		pmt.UpdatedAt(),
+	)
+}
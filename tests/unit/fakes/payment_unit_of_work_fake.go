@@ -0,0 +1,101 @@
+package fakes
+
+import (
+	"context"
+	"sync"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+)
+
+// PaymentUnitOfWorkFake is a fake implementation of PaymentUnitOfWork for testing
+// It writes to the other fakes under a single lock, which gives the same all-or-nothing
+// and conditional-key guarantees as the DynamoDB transaction
+type PaymentUnitOfWorkFake struct {
+	mu               sync.Mutex
+	paymentRepo      *PaymentRepositoryFake
+	idempotencyStore *IdempotencyStoreFake
+	eventStore       *EventStoreFake
+	outbox           *OutboxStoreFake
+	quotes           *FXQuoteStoreFake
+	refunded         map[string]vo.Money // committed refunded totals, by payment ID
+}
+
+// NewPaymentUnitOfWorkFake creates a new PaymentUnitOfWorkFake over the given fakes
+func NewPaymentUnitOfWorkFake(
+	paymentRepo *PaymentRepositoryFake,
+	idempotencyStore *IdempotencyStoreFake,
+	eventStore *EventStoreFake,
+	outbox *OutboxStoreFake,
+	quotes *FXQuoteStoreFake,
+) *PaymentUnitOfWorkFake {
+	return &PaymentUnitOfWorkFake{
+		paymentRepo:      paymentRepo,
+		idempotencyStore: idempotencyStore,
+		eventStore:       eventStore,
+		outbox:           outbox,
+		quotes:           quotes,
+		refunded:         make(map[string]vo.Money),
+	}
+}
+
+// CreatePayment stores the payment, key, event and outbox entry and marks its FX quote used,
+// or nothing if the key is taken or the quote was already used
+func (f *PaymentUnitOfWorkFake) CreatePayment(ctx context.Context, pmt *payment.Payment, event shared.Event, topicArn string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	key := pmt.IdempotencyKey().String()
+	if existing, err := f.idempotencyStore.GetPaymentIDByKey(ctx, key); err == nil && existing != "" {
+		return domerrors.DuplicateRequestError(key)
+	}
+	if conversion := pmt.Conversion(); conversion != nil {
+		if err := f.quotes.markUsed(conversion.QuoteID(), pmt.ID().String()); err != nil {
+			return err
+		}
+	}
+
+	f.paymentRepo.Save(ctx, pmt)
+	f.idempotencyStore.SaveKey(ctx, key, pmt.ID().String())
+	f.eventStore.Append(ctx, event, pmt.ID().String())
+	f.outbox.Add(ctx, event, topicArn)
+
+	return nil
+}
+
+// RecordRefund stores the refunded payment, key, event and outbox entry, or nothing if the key is
+// taken or another refund was committed since previousRefunded was read
+func (f *PaymentUnitOfWorkFake) RecordRefund(
+	ctx context.Context,
+	pmt *payment.Payment,
+	previousRefunded vo.Money,
+	idempotencyKey string,
+	event shared.Event,
+	topicArn string,
+) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if existing, err := f.idempotencyStore.GetPaymentIDByKey(ctx, idempotencyKey); err == nil && existing != "" {
+		return domerrors.DuplicateRequestError(idempotencyKey)
+	}
+
+	paymentID := pmt.ID().String()
+	committed, ok := f.refunded[paymentID]
+	if !ok {
+		committed = vo.Zero(pmt.Money().Currency())
+	}
+	if !committed.Equals(previousRefunded) {
+		return domerrors.NewDomainError(domerrors.ErrCodeConcurrentModification, "Payment was refunded concurrently")
+	}
+
+	f.refunded[paymentID] = pmt.RefundedAmount()
+	f.paymentRepo.Save(ctx, pmt)
+	f.idempotencyStore.SaveKey(ctx, idempotencyKey, paymentID)
+	f.eventStore.Append(ctx, event, paymentID)
+	f.outbox.Add(ctx, event, topicArn)
+
+	return nil
+}
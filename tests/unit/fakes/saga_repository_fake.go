@@ -0,0 +1,138 @@
+package fakes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/saga"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// SagaMove is one event that moved a seeded saga to a step
+type SagaMove struct {
+	EventType string
+	To        saga.Step
+}
+
+// SagaRepositoryFake is a fake implementation of SagaRepository for testing
+// Like DynamoDB it stores copies and rejects writes from a stale version
+type SagaRepositoryFake struct {
+	mu    sync.RWMutex
+	sagas map[string]*saga.Saga
+}
+
+// NewSagaRepositoryFake creates a new SagaRepositoryFake
+func NewSagaRepositoryFake() *SagaRepositoryFake {
+	return &SagaRepositoryFake{
+		sagas: make(map[string]*saga.Saga),
+	}
+}
+
+// FindByPaymentID returns a copy of the stored saga
+func (f *SagaRepositoryFake) FindByPaymentID(ctx context.Context, paymentID string) (*saga.Saga, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	sg, exists := f.sagas[paymentID]
+	if !exists {
+		return nil, domerrors.SagaNotFoundError(paymentID)
+	}
+	return copySaga(sg), nil
+}
+
+// Save stores a copy of the saga if its version matches the stored one
+func (f *SagaRepositoryFake) Save(ctx context.Context, sg *saga.Saga) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkSaveLocked(sg); err != nil {
+		return err
+	}
+	f.applySaveLocked(sg)
+	return nil
+}
+
+// checkSaveLocked rejects a saga written from a stale version; the caller holds mu
+func (f *SagaRepositoryFake) checkSaveLocked(sg *saga.Saga) error {
+	// Version 0 means a new saga, which must not exist yet
+	storedVersion := int64(0)
+	if stored, exists := f.sagas[sg.PaymentID()]; exists {
+		storedVersion = stored.Version()
+	}
+	if sg.Version() != storedVersion {
+		return domerrors.SagaVersionConflictError(sg.PaymentID(), sg.Version())
+	}
+	return nil
+}
+
+// applySaveLocked stores the next version of a saga already checked; the caller holds mu
+func (f *SagaRepositoryFake) applySaveLocked(sg *saga.Saga) {
+	sg.IncrementVersion()
+	f.sagas[sg.PaymentID()] = copySaga(sg)
+}
+
+// ListStuck returns running sagas past their deadline, oldest deadline first
+func (f *SagaRepositoryFake) ListStuck(ctx context.Context, now time.Time, limit int) ([]*saga.Saga, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	stuck := make([]*saga.Saga, 0)
+	for _, sg := range f.sagas {
+		if sg.IsStuck(now) {
+			stuck = append(stuck, copySaga(sg))
+		}
+	}
+
+	sort.Slice(stuck, func(i, j int) bool {
+		return stuck[i].Deadline().Before(stuck[j].Deadline())
+	})
+	if len(stuck) > limit {
+		stuck = stuck[:limit]
+	}
+	return stuck, nil
+}
+
+// SeedSaga stores a saga that already went through the given moves, for tests that start mid-flow
+func (f *SagaRepositoryFake) SeedSaga(paymentID string, deadline time.Time, moves ...SagaMove) *saga.Saga {
+	sg, _ := saga.NewSaga(paymentID, deadline)
+	for _, move := range moves {
+		sg.Accept(move.EventType, time.Time{})
+		sg.Advance(move.EventType, move.To, deadline)
+	}
+	sg.IncrementVersion()
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.sagas[paymentID] = copySaga(sg)
+	return sg
+}
+
+// GetSaga returns the stored saga, or nil if there is none
+func (f *SagaRepositoryFake) GetSaga(paymentID string) *saga.Saga {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	sg, exists := f.sagas[paymentID]
+	if !exists {
+		return nil
+	}
+	return copySaga(sg)
+}
+
+func copySaga(sg *saga.Saga) *saga.Saga {
+	return saga.ReconstructSaga(
+		sg.PaymentID(),
+		sg.Step(),
+		sg.Attempts(),
+		sg.LeaseUntil(),
+		sg.Deadline(),
+		sg.Compensations(),
+		sg.History(),
+		sg.StartedAt(),
+		sg.UpdatedAt(),
+		sg.Version(),
+	)
+}
@@ -0,0 +1,39 @@
+package fakes
+
+// Stores is one set of fakes sharing state the way the DynamoDB adapters share tables
+// PaymentUnitOfWork and UnitOfWork write to the individual fakes, so whatever a service commits
+// through them can be read back from those
+type Stores struct {
+	Payments    *PaymentRepositoryFake
+	Wallets     *WalletRepositoryFake
+	Sagas       *SagaRepositoryFake
+	Idempotency *IdempotencyStoreFake
+	Events      *EventStoreFake
+	Outbox      *OutboxStoreFake
+	Inbox       *InboxStoreFake
+	Timeouts    *TimeoutStoreFake
+	Quotes      *FXQuoteStoreFake
+	Publisher   *EventPublisherFake
+
+	PaymentUnitOfWork *PaymentUnitOfWorkFake
+	UnitOfWork        *UnitOfWorkFake
+}
+
+// NewStores creates an empty set of fakes wired together
+func NewStores() *Stores {
+	s := &Stores{
+		Payments:    NewPaymentRepositoryFake(),
+		Wallets:     NewWalletRepositoryFake(),
+		Sagas:       NewSagaRepositoryFake(),
+		Idempotency: NewIdempotencyStoreFake(),
+		Events:      NewEventStoreFake(),
+		Outbox:      NewOutboxStoreFake(),
+		Inbox:       NewInboxStoreFake(),
+		Timeouts:    NewTimeoutStoreFake(),
+		Quotes:      NewFXQuoteStoreFake(),
+		Publisher:   NewEventPublisherFake(),
+	}
+	s.PaymentUnitOfWork = NewPaymentUnitOfWorkFake(s.Payments, s.Idempotency, s.Events, s.Outbox, s.Quotes)
+	s.UnitOfWork = NewUnitOfWorkFake(s.Payments, s.Wallets, s.Sagas, s.Events, s.Outbox, s.Inbox, s.Timeouts)
+	return s
+}
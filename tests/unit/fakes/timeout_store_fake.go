@@ -0,0 +1,88 @@
+package fakes
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+)
+
+// TimeoutStoreFake is a fake implementation of TimeoutStore for testing
+type TimeoutStoreFake struct {
+	mu       sync.RWMutex
+	timeouts map[string]port.ScheduledTimeout
+	outcomes map[string]string
+}
+
+// NewTimeoutStoreFake creates a new TimeoutStoreFake
+func NewTimeoutStoreFake() *TimeoutStoreFake {
+	return &TimeoutStoreFake{
+		timeouts: make(map[string]port.ScheduledTimeout),
+		outcomes: make(map[string]string),
+	}
+}
+
+// Schedule stores a pending deadline, leaving closed ones closed
+func (f *TimeoutStoreFake) Schedule(ctx context.Context, timeout port.ScheduledTimeout) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if _, closed := f.outcomes[timeout.PaymentID]; closed {
+		return nil
+	}
+	f.timeouts[timeout.PaymentID] = timeout
+	return nil
+}
+
+// ListDue returns pending deadlines at or before now
+func (f *TimeoutStoreFake) ListDue(ctx context.Context, now time.Time, limit int) ([]port.ScheduledTimeout, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	due := make([]port.ScheduledTimeout, 0)
+	for paymentID, timeout := range f.timeouts {
+		if _, closed := f.outcomes[paymentID]; !closed && !timeout.Deadline.After(now) {
+			due = append(due, timeout)
+		}
+	}
+
+	sort.Slice(due, func(i, j int) bool {
+		return due[i].Deadline.Before(due[j].Deadline)
+	})
+	if len(due) > limit {
+		due = due[:limit]
+	}
+	return due, nil
+}
+
+// Close moves a pending deadline to an outcome
+func (f *TimeoutStoreFake) Close(ctx context.Context, paymentID, outcome string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if !f.pendingLocked(paymentID) {
+		return false, nil
+	}
+
+	f.outcomes[paymentID] = outcome
+	return true, nil
+}
+
+// pendingLocked reports whether a deadline is scheduled and not closed yet; callers hold f.mu
+func (f *TimeoutStoreFake) pendingLocked(paymentID string) bool {
+	if _, exists := f.timeouts[paymentID]; !exists {
+		return false
+	}
+	_, closed := f.outcomes[paymentID]
+	return !closed
+}
+
+// GetOutcome returns how a deadline was closed, or "" while it is pending
+func (f *TimeoutStoreFake) GetOutcome(paymentID string) string {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	return f.outcomes[paymentID]
+}
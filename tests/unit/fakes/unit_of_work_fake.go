@@ -0,0 +1,121 @@
+package fakes
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+)
+
+// UnitOfWorkFake is a fake implementation of UnitOfWork for testing
+// It checks every condition before writing anything to the other fakes, which gives the same
+// all-or-nothing guarantee as the DynamoDB transaction
+type UnitOfWorkFake struct {
+	mu           sync.Mutex
+	paymentRepo  *PaymentRepositoryFake
+	walletRepo   *WalletRepositoryFake
+	sagaRepo     *SagaRepositoryFake
+	eventStore   *EventStoreFake
+	outbox       *OutboxStoreFake
+	inbox        *InboxStoreFake
+	timeouts     *TimeoutStoreFake
+	failuresLeft int
+}
+
+// NewUnitOfWorkFake creates a new UnitOfWorkFake over the given fakes
+func NewUnitOfWorkFake(
+	paymentRepo *PaymentRepositoryFake,
+	walletRepo *WalletRepositoryFake,
+	sagaRepo *SagaRepositoryFake,
+	eventStore *EventStoreFake,
+	outbox *OutboxStoreFake,
+	inbox *InboxStoreFake,
+	timeouts *TimeoutStoreFake,
+) *UnitOfWorkFake {
+	return &UnitOfWorkFake{
+		paymentRepo: paymentRepo,
+		walletRepo:  walletRepo,
+		sagaRepo:    sagaRepo,
+		eventStore:  eventStore,
+		outbox:      outbox,
+		inbox:       inbox,
+		timeouts:    timeouts,
+	}
+}
+
+// Commit writes the changes and their events, or nothing if any condition fails
+func (f *UnitOfWorkFake) Commit(ctx context.Context, changes port.Changes) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.failuresLeft > 0 {
+		f.failuresLeft--
+		return domerrors.DatabaseError("commit transaction", errors.New("transaction failed"))
+	}
+
+	f.walletRepo.mu.Lock()
+	defer f.walletRepo.mu.Unlock()
+	f.sagaRepo.mu.Lock()
+	defer f.sagaRepo.mu.Unlock()
+	f.inbox.mu.Lock()
+	defer f.inbox.mu.Unlock()
+	f.timeouts.mu.Lock()
+	defer f.timeouts.mu.Unlock()
+
+	if wlt := changes.Wallet; wlt != nil {
+		if err := f.walletRepo.checkUpdateLocked(wlt); err != nil {
+			return err
+		}
+	}
+	if sg := changes.Saga; sg != nil {
+		if err := f.sagaRepo.checkSaveLocked(sg); err != nil {
+			return err
+		}
+	}
+	if timeout := changes.Timeout; timeout != nil && !f.timeouts.pendingLocked(timeout.PaymentID) {
+		return domerrors.NewDomainError(
+			domerrors.ErrCodeConcurrentModification,
+			"Timeout was closed concurrently",
+		).WithDetail("paymentId", timeout.PaymentID)
+	}
+	if claim := changes.Inbox; claim != nil && f.inbox.claimedLocked(claim.Consumer, claim.EventID) {
+		return domerrors.DuplicateRequestError(claim.Consumer + "#" + claim.EventID)
+	}
+
+	if wlt := changes.Wallet; wlt != nil {
+		if err := f.walletRepo.applyUpdateLocked(wlt); err != nil {
+			return err
+		}
+	}
+	if pmt := changes.Payment; pmt != nil {
+		f.paymentRepo.Save(ctx, pmt)
+	}
+	if sg := changes.Saga; sg != nil {
+		f.sagaRepo.applySaveLocked(sg)
+	}
+	if timeout := changes.Timeout; timeout != nil {
+		f.timeouts.outcomes[timeout.PaymentID] = timeout.Outcome
+	}
+	if claim := changes.Inbox; claim != nil {
+		f.inbox.processed[claim.Consumer+"#"+claim.EventID] = claim.EventType
+	}
+	for _, recorded := range changes.Events {
+		for _, stream := range recorded.Streams {
+			f.eventStore.Append(ctx, recorded.Event, stream)
+		}
+		f.outbox.Add(ctx, recorded.Event, changes.TopicArn)
+	}
+
+	return nil
+}
+
+// FailNextCommits makes the next n commits fail without writing anything, simulating a crash
+// or an outage at the point the step would have been persisted
+func (f *UnitOfWorkFake) FailNextCommits(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.failuresLeft = n
+}
@@ -2,22 +2,29 @@ package fakes
 
 import (
 	"context"
-	"errors"
+	"sort"
 	"sync"
 
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
 	"github.com/franco/payment-api/internal/domain/wallet"
 )
 
 // WalletRepositoryFake is a fake implementation of WalletRepository for testing
+// Like DynamoDB, it hands out copies and rejects updates made from a stale version
+// The journal entries a wallet recorded are posted to its ledger together with the write
 type WalletRepositoryFake struct {
-	mu      sync.RWMutex
-	wallets map[string]*wallet.Wallet
+	mu              sync.RWMutex
+	wallets         map[string]*wallet.Wallet
+	ledger          *LedgerFake
+	conflictsToFail int
+	updateCalls     int
 }
 
 // NewWalletRepositoryFake creates a new WalletRepositoryFake
 func NewWalletRepositoryFake() *WalletRepositoryFake {
 	return &WalletRepositoryFake{
 		wallets: make(map[string]*wallet.Wallet),
+		ledger:  NewLedgerFake(),
 	}
 }
 
@@ -28,29 +35,125 @@ func (f *WalletRepositoryFake) GetByUserID(ctx context.Context, userID string) (
 
 	wallet, exists := f.wallets[userID]
 	if !exists {
-		return nil, errors.New("wallet not found")
+		return nil, domerrors.WalletNotFoundError(userID)
 	}
 
-	return wallet, nil
+	return copyWallet(wallet), nil
 }
 
-// Save stores a wallet
+// Save stores a new wallet, refusing to overwrite an existing one like the conditional put does
 func (f *WalletRepositoryFake) Save(ctx context.Context, wallet *wallet.Wallet) error {
 	f.mu.Lock()
 	defer f.mu.Unlock()
 
-	f.wallets[wallet.UserID().String()] = wallet
+	if _, exists := f.wallets[wallet.UserID().String()]; exists {
+		return domerrors.WalletAlreadyExistsError(wallet.UserID().String())
+	}
+
+	if err := f.ledger.post(wallet.PendingEntries()); err != nil {
+		return err
+	}
+	wallet.ClearPendingEntries()
+
+	f.wallets[wallet.UserID().String()] = copyWallet(wallet)
 	return nil
 }
 
-// Update updates a wallet (same as Save in this fake)
-func (f *WalletRepositoryFake) Update(ctx context.Context, wallet *wallet.Wallet) error {
-	return f.Save(ctx, wallet)
+// Update stores a wallet if its version matches the stored one
+func (f *WalletRepositoryFake) Update(ctx context.Context, wlt *wallet.Wallet) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if err := f.checkUpdateLocked(wlt); err != nil {
+		return err
+	}
+	return f.applyUpdateLocked(wlt)
+}
+
+// checkUpdateLocked fails the way the conditional write would; callers hold f.mu
+func (f *WalletRepositoryFake) checkUpdateLocked(wlt *wallet.Wallet) error {
+	f.updateCalls++
+	userID := wlt.UserID().String()
+
+	if f.conflictsToFail > 0 {
+		f.conflictsToFail--
+		return domerrors.WalletVersionConflictError(userID, wlt.Version())
+	}
+
+	// Like the transaction, an entry already posted wins over a stale version
+	if err := f.ledger.check(wlt.PendingEntries()); err != nil {
+		return err
+	}
+
+	// A missing row fails the update condition just like a stale version does
+	if stored, exists := f.wallets[userID]; !exists || stored.Version() != wlt.Version() {
+		return domerrors.WalletVersionConflictError(userID, wlt.Version())
+	}
+	return nil
+}
+
+// applyUpdateLocked posts the recorded entries and stores the wallet; callers hold f.mu
+func (f *WalletRepositoryFake) applyUpdateLocked(wlt *wallet.Wallet) error {
+	if err := f.ledger.post(wlt.PendingEntries()); err != nil {
+		return err
+	}
+	wlt.ClearPendingEntries()
+
+	wlt.IncrementVersion()
+	f.wallets[wlt.UserID().String()] = copyWallet(wlt)
+	return nil
+}
+
+// ForEachWallet calls fn with a copy of every stored wallet, in user ID order
+func (f *WalletRepositoryFake) ForEachWallet(ctx context.Context, fn func(*wallet.Wallet) error) error {
+	f.mu.RLock()
+	userIDs := make([]string, 0, len(f.wallets))
+	for userID := range f.wallets {
+		userIDs = append(userIDs, userID)
+	}
+	f.mu.RUnlock()
+	sort.Strings(userIDs)
+
+	for _, userID := range userIDs {
+		wlt, err := f.GetByUserID(ctx, userID)
+		if err != nil {
+			return err
+		}
+		if err := fn(wlt); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Ledger returns the ledger the repository posts journal entries to
+func (f *WalletRepositoryFake) Ledger() *LedgerFake {
+	return f.ledger
 }
 
 // SetWallet is a helper method for tests to pre-populate wallets
+// Like the seed script, it writes the balance without ledger history
 func (f *WalletRepositoryFake) SetWallet(wallet *wallet.Wallet) {
 	f.mu.Lock()
 	defer f.mu.Unlock()
-	f.wallets[wallet.UserID().String()] = wallet
+	f.wallets[wallet.UserID().String()] = copyWallet(wallet)
+}
+
+// FailNextUpdates makes the next n updates fail with a version conflict,
+// simulating another writer getting in between the read and the write
+func (f *WalletRepositoryFake) FailNextUpdates(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.conflictsToFail = n
+}
+
+// UpdateCalls returns how many times Update was called
+func (f *WalletRepositoryFake) UpdateCalls() int {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+	return f.updateCalls
+}
+
+func copyWallet(wlt *wallet.Wallet) *wallet.Wallet {
+	return wallet.ReconstructWallet(wlt.UserID(), wlt.DefaultCurrency(), wlt.Balances(), wlt.Holds(), wlt.UpdatedAt(), wlt.Version())
 }
@@ -0,0 +1,212 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestFeeSchedule charges a service fee that depends on service, client and amount band,
+// and a 1% processing fee that is never refunded
+func newTestFeeSchedule(t *testing.T) *payment.FeeSchedule {
+	t.Helper()
+	d := decimal.RequireFromString
+
+	schedule, err := payment.NewFeeSchedule([]payment.FeeRule{
+		{Code: "service_fee", ServiceID: "*", Currency: "ARS", Percentage: d("0.02"), MinFee: d("5")},
+		{Code: "service_fee", ServiceID: "utility-*", Currency: "ARS", Fixed: d("10"), Percentage: d("0.01"), MaxFee: d("30")},
+		{Code: "service_fee", ServiceID: "utility-*", ClientID: "client-vip", Currency: "ARS"},
+		{Code: "service_fee", ServiceID: "telecom-*", Currency: "ARS", MaxAmount: d("500"), Fixed: d("15")},
+		{Code: "service_fee", ServiceID: "telecom-*", Currency: "ARS", MinAmount: d("500"), Percentage: d("0.03")},
+		{Code: "processing_fee", ServiceID: "*", Percentage: d("0.01"), RefundPolicy: vo.FeeRefundNever},
+	}, vo.FeeRefundProportional)
+	require.NoError(t, err)
+	return schedule
+}
+
+func TestFeeSchedule_PricesEachFeeWithTheMostSpecificRule(t *testing.T) {
+	tests := []struct {
+		name      string
+		serviceID string
+		clientID  string
+		amount    vo.Money
+		want      []string
+	}{
+		{name: "any service, floor applies", serviceID: "service-123", amount: vo.MustNewMoney("100.00", "ARS"), want: []string{"service_fee 5.00 ARS", "processing_fee 1.00 ARS"}},
+		{name: "prefix rule", serviceID: "utility-power", amount: vo.MustNewMoney("100.00", "ARS"), want: []string{"service_fee 11.00 ARS", "processing_fee 1.00 ARS"}},
+		{name: "cap applies", serviceID: "utility-power", amount: vo.MustNewMoney("5000.00", "ARS"), want: []string{"service_fee 30.00 ARS", "processing_fee 50.00 ARS"}},
+		{name: "client rule waives the fee", serviceID: "utility-power", clientID: "client-vip", amount: vo.MustNewMoney("100.00", "ARS"), want: []string{"processing_fee 1.00 ARS"}},
+		{name: "lower band", serviceID: "telecom-mobile", amount: vo.MustNewMoney("499.99", "ARS"), want: []string{"service_fee 15.00 ARS", "processing_fee 5.00 ARS"}},
+		{name: "upper band", serviceID: "telecom-mobile", amount: vo.MustNewMoney("1000.00", "ARS"), want: []string{"service_fee 30.00 ARS", "processing_fee 10.00 ARS"}},
+		{name: "rules of another currency", serviceID: "service-123", amount: vo.MustNewMoney("100.00", "USD"), want: []string{"processing_fee 1.00 USD"}},
+	}
+
+	schedule := newTestFeeSchedule(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			lines, err := schedule.Evaluate(tt.serviceID, tt.clientID, tt.amount)
+
+			// Assert
+			require.NoError(t, err)
+			got := make([]string, 0, len(lines))
+			for _, line := range lines {
+				got = append(got, line.Code()+" "+line.Amount().String())
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+
+	_, err := payment.NewFeeSchedule([]payment.FeeRule{
+		{Code: "service_fee", ServiceID: "*", Fixed: decimal.NewFromInt(10)},
+	}, vo.FeeRefundNever)
+	assert.Error(t, err, "a fixed fee needs a currency")
+}
+
+func TestFeeLine_RefundedAtFollowsItsPolicy(t *testing.T) {
+	tests := []struct {
+		policy     vo.FeeRefundPolicy
+		partial    string
+		fullRefund string
+	}{
+		{policy: vo.FeeRefundNever, partial: "0", fullRefund: "0"},
+		{policy: vo.FeeRefundProportional, partial: "3", fullRefund: "10"},
+		{policy: vo.FeeRefundOnFullRefund, partial: "0", fullRefund: "10"},
+	}
+
+	paid := vo.MustNewMoney("100.00", "ARS")
+	for _, tt := range tests {
+		t.Run(tt.policy.String(), func(t *testing.T) {
+			// Arrange
+			line, err := vo.NewFeeLine("service_fee", vo.MustNewMoney("10.00", "ARS"), tt.policy)
+			require.NoError(t, err)
+
+			// Act
+			partial, err := line.RefundedAt(vo.MustNewMoney("30.00", "ARS"), paid)
+			require.NoError(t, err)
+			full, err := line.RefundedAt(paid, paid)
+			require.NoError(t, err)
+
+			// Assert
+			assert.Equal(t, tt.partial, partial.Amount().String())
+			assert.Equal(t, tt.fullRefund, full.Amount().String())
+		})
+	}
+}
+
+func TestCreatePayment_ChargesFeesOnTopOfTheAmount(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "1000.00"))
+	createService := command.NewCreatePaymentService(
+		stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, newTestFeeSchedule(t), "test-topic-arn",
+	)
+	ctx := context.Background()
+
+	// Act: 990 + 19.90 service fee + 9.90 processing fee is more than the wallet has
+	_, tooMuchErr := createService.Execute(ctx, command.CreatePaymentRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("990.00"), Currency: "ARS",
+		ServiceID: "utility-power", IdempotencyKey: "fee-key-1",
+	})
+	result, err := createService.Execute(ctx, command.CreatePaymentRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("100.00"), Currency: "ARS",
+		ServiceID: "utility-power", IdempotencyKey: "fee-key-2",
+	})
+
+	// Assert
+	assert.True(t, domerrors.IsErrorCode(tooMuchErr, domerrors.ErrCodeInsufficientFunds), "got %v", tooMuchErr)
+	require.NoError(t, err)
+
+	pmt, err := stores.Payments.FindByID(ctx, result.PaymentID)
+	require.NoError(t, err)
+	assert.Equal(t, "100.00 ARS", pmt.Money().String())
+	assert.Equal(t, "12.00 ARS", pmt.FeeTotal().String())
+	assert.Equal(t, "112.00 ARS", pmt.FundingMoney().String())
+
+	model, err := mappers.NewPaymentMapper().ToDBModel(pmt)
+	require.NoError(t, err)
+	restored, err := mappers.NewPaymentMapper().ToDomain(model)
+	require.NoError(t, err)
+	require.Len(t, restored.Fees(), 2)
+	assert.True(t, restored.FeeTotal().Equals(pmt.FeeTotal()))
+	assert.Equal(t, vo.FeeRefundNever, restored.Fees()[1].RefundPolicy())
+
+	requested := queued(t, stores.Outbox, "PaymentRequested")
+	require.Len(t, requested, 1)
+	fees := requested[0].(*payment.PaymentRequestedEvent).Fees()
+	require.Len(t, fees, 2)
+	assert.Equal(t, "service_fee", fees[0].Code)
+	assert.Equal(t, "11", fees[0].Amount.String())
+	assert.Equal(t, "PROPORTIONAL", fees[0].RefundPolicy)
+	assert.Equal(t, "NEVER", fees[1].RefundPolicy)
+
+	replayed, err := payment.RehydratePayment(requested)
+	require.NoError(t, err)
+	assert.True(t, replayed.FundingMoney().Equals(pmt.FundingMoney()))
+}
+
+func TestPaymentOrchestrator_HoldsFeesAndRefundsThemByPolicy(t *testing.T) {
+	// Arrange: a 100.00 ARS payment with an 11.00 proportional fee and a 1.00 fee never refunded
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "1000.00"))
+	createService := command.NewCreatePaymentService(
+		stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, newTestFeeSchedule(t), "test-topic-arn",
+	)
+	refundService := command.NewRefundPaymentService(stores.Payments, stores.PaymentUnitOfWork, stores.Idempotency, "test-topic-arn")
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+	result, err := createService.Execute(ctx, command.CreatePaymentRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("100.00"), Currency: "ARS",
+		ServiceID: "utility-power", IdempotencyKey: "fee-key",
+	})
+	require.NoError(t, err)
+	require.NoError(t, orch.HandlePaymentRequested(ctx, queued(t, stores.Outbox, "PaymentRequested")[0]))
+	require.NoError(t, orch.HandleExternalPaymentSucceeded(ctx,
+		payment.NewExternalPaymentSucceededEvent(result.PaymentID, "external-tx-456", shared.Metadata{})))
+
+	// Act: refund 30 and then the rest
+	partial, err := refundService.Execute(ctx, command.RefundPaymentRequest{
+		PaymentID: result.PaymentID, Amount: decimal.RequireFromString("30"), IdempotencyKey: "refund-1",
+	})
+	require.NoError(t, err)
+	_, err = refundService.Execute(ctx, command.RefundPaymentRequest{
+		PaymentID: result.PaymentID, IdempotencyKey: "refund-2",
+	})
+	require.NoError(t, err)
+	for _, refunded := range queued(t, stores.Outbox, "PaymentRefunded") {
+		require.NoError(t, orch.HandlePaymentRefunded(ctx, refunded))
+	}
+
+	// Assert: the wallet only keeps the processing fee
+	held := queued(t, stores.Outbox, "WalletFundsHeld")
+	require.Len(t, held, 1)
+	assert.Equal(t, "112", held[0].(*wallet.WalletFundsHeldEvent).Amount().String())
+	assert.Len(t, held[0].(*wallet.WalletFundsHeldEvent).Fees(), 2)
+
+	require.Len(t, partial.Fees, 1)
+	assert.Equal(t, "3.3", partial.Fees[0].Amount.String())
+
+	credited := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, credited, 2)
+	assert.Equal(t, "33.3", credited[0].(*wallet.WalletCreditedEvent).Amount().String())
+	assert.Equal(t, "77.7", credited[1].(*wallet.WalletCreditedEvent).Amount().String())
+
+	wlt, _ := stores.Wallets.GetByUserID(ctx, "user-123")
+	assert.Equal(t, "999", wlt.Balance(vo.ARS).Amount().String())
+}
@@ -0,0 +1,314 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/internal/infrastructure/fx"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConversion_AppliesSpreadAndSourceRounding(t *testing.T) {
+	tests := []struct {
+		name       string
+		target     vo.Money
+		source     string
+		marketRate string
+		spread     string
+		wantRate   string
+		wantSource string
+	}{
+		{name: "USD from ARS", target: vo.MustNewMoney("10.00", "USD"), source: "ARS", marketRate: "1000", spread: "0.01", wantRate: "1010", wantSource: "10100"},
+		{name: "rounded to cents", target: vo.MustNewMoney("0.07", "EUR"), source: "USD", marketRate: "1.0833", spread: "0", wantRate: "1.0833", wantSource: "0.08"},
+		{name: "CLP has no decimals", target: vo.MustNewMoney("1.00", "USD"), source: "CLP", marketRate: "950.55", spread: "0.02", wantRate: "969.561", wantSource: "970"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			conversion, err := vo.NewConversion("quote-1", tt.target, vo.MustNewCurrency(tt.source),
+				decimal.RequireFromString(tt.marketRate), decimal.RequireFromString(tt.spread))
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRate, conversion.Rate().String())
+			assert.Equal(t, tt.wantSource, conversion.Source().Amount().String())
+			assert.Equal(t, tt.source, conversion.Source().Currency().Code())
+			assert.True(t, conversion.Target().Equals(tt.target))
+		})
+	}
+
+	_, err := vo.NewConversion("quote-1", vo.MustNewMoney("1.00", "USD"), vo.USD, decimal.NewFromInt(1), decimal.Zero)
+	assert.Error(t, err)
+}
+
+func TestStaticRateProvider_InvertsTheOppositePair(t *testing.T) {
+	rates := fx.NewStaticRateProvider(map[string]map[string]decimal.Decimal{
+		"USD": {"ARS": decimal.RequireFromString("1000")},
+	})
+
+	direct, err := rates.Rate(context.Background(), "USD", "ARS")
+	require.NoError(t, err)
+	inverse, err := rates.Rate(context.Background(), "ARS", "USD")
+	require.NoError(t, err)
+	_, err = rates.Rate(context.Background(), "USD", "BRL")
+
+	assert.Equal(t, "1000", direct.String())
+	assert.Equal(t, "0.001", inverse.String())
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeFXRateUnavailable), "got %v", err)
+}
+
+func TestCreatePayment_WithFXQuoteRecordsTheConversion(t *testing.T) {
+	// Arrange: an ARS-only wallet and rates of 1000 ARS per USD with a 1% spread
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "20000.00"))
+	rates := fx.NewStaticRateProvider(map[string]map[string]decimal.Decimal{
+		"USD": {"ARS": decimal.RequireFromString("1000")},
+	})
+	quoteService := command.NewQuoteFXService(stores.Wallets, rates, stores.Quotes, decimal.RequireFromString("0.01"), time.Minute)
+	createService := command.NewCreatePaymentService(
+		stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, payment.NewEmptyFeeSchedule(), "test-topic-arn",
+	)
+	ctx := context.Background()
+	quote, err := quoteService.Execute(ctx, command.QuoteFXRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("10.00"), Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	// Act
+	result, err := createService.Execute(ctx, command.CreatePaymentRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("10.00"), Currency: "USD",
+		ServiceID: "service-123", IdempotencyKey: "fx-key", FXQuoteID: quote.QuoteID,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "ARS", quote.SourceCurrency)
+	assert.Equal(t, "10100", quote.SourceAmount.String())
+
+	pmt, err := stores.Payments.FindByID(ctx, result.PaymentID)
+	require.NoError(t, err)
+	assert.True(t, pmt.Money().Equals(vo.MustNewMoney("10.00", "USD")))
+	assert.Equal(t, "10100.00 ARS", pmt.FundingMoney().String())
+
+	model, err := mappers.NewPaymentMapper().ToDBModel(pmt)
+	require.NoError(t, err)
+	restored, err := mappers.NewPaymentMapper().ToDomain(model)
+	require.NoError(t, err)
+	assert.Equal(t, "1010", model.FXRate)
+	assert.Equal(t, "10100", model.FundingAmount)
+	assert.True(t, restored.FundingMoney().Equals(pmt.FundingMoney()))
+	assert.Equal(t, quote.QuoteID, restored.Conversion().QuoteID())
+
+	queuedRequests := queued(t, stores.Outbox, "PaymentRequested")
+	require.Len(t, queuedRequests, 1)
+	requested := queuedRequests[0].(*payment.PaymentRequestedEvent)
+	require.NotNil(t, requested.FX())
+	assert.Equal(t, quote.QuoteID, requested.FX().QuoteID)
+	assert.Equal(t, "1010", requested.FX().Rate.String())
+	assert.Equal(t, "0.01", requested.FX().Spread.String())
+}
+
+func TestCreatePayment_RejectsUnusableFXQuotes(t *testing.T) {
+	tests := []struct {
+		name     string
+		quote    func(conversion vo.Conversion) port.FXQuote
+		amount   string
+		wantCode domerrors.ErrorCode
+	}{
+		{
+			name: "expired",
+			quote: func(c vo.Conversion) port.FXQuote {
+				return port.FXQuote{ID: c.QuoteID(), UserID: "user-123", Conversion: c, ExpiresAt: time.Now().Add(-time.Second)}
+			},
+			amount:   "10.00",
+			wantCode: domerrors.ErrCodeFXQuoteExpired,
+		},
+		{
+			name: "someone else's",
+			quote: func(c vo.Conversion) port.FXQuote {
+				return port.FXQuote{ID: c.QuoteID(), UserID: "user-456", Conversion: c, ExpiresAt: time.Now().Add(time.Minute)}
+			},
+			amount:   "10.00",
+			wantCode: domerrors.ErrCodeFXQuoteNotFound,
+		},
+		{
+			name: "for another amount",
+			quote: func(c vo.Conversion) port.FXQuote {
+				return port.FXQuote{ID: c.QuoteID(), UserID: "user-123", Conversion: c, ExpiresAt: time.Now().Add(time.Minute)}
+			},
+			amount:   "12.00",
+			wantCode: domerrors.ErrCodeValidationFailed,
+		},
+		{
+			name: "more than the wallet holds",
+			quote: func(c vo.Conversion) port.FXQuote {
+				big, _ := vo.NewConversion(c.QuoteID(), vo.MustNewMoney("25.00", "USD"), vo.ARS, decimal.NewFromInt(1000), decimal.Zero)
+				return port.FXQuote{ID: c.QuoteID(), UserID: "user-123", Conversion: big, ExpiresAt: time.Now().Add(time.Minute)}
+			},
+			amount:   "25.00",
+			wantCode: domerrors.ErrCodeInsufficientFunds,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stores := fakes.NewStores()
+			stores.Wallets.SetWallet(newHoldWallet(t, "20000.00"))
+			createService := command.NewCreatePaymentService(
+				stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, payment.NewEmptyFeeSchedule(), "test-topic-arn",
+			)
+			conversion, err := vo.NewConversion("quote-1", vo.MustNewMoney("10.00", "USD"), vo.ARS, decimal.NewFromInt(1000), decimal.Zero)
+			require.NoError(t, err)
+			require.NoError(t, stores.Quotes.Save(context.Background(), tt.quote(conversion)))
+
+			// Act
+			_, err = createService.Execute(context.Background(), command.CreatePaymentRequest{
+				UserID: "user-123", Amount: decimal.RequireFromString(tt.amount), Currency: "USD",
+				ServiceID: "service-123", IdempotencyKey: "fx-key", FXQuoteID: "quote-1",
+			})
+
+			// Assert
+			require.Error(t, err)
+			assert.True(t, domerrors.IsErrorCode(err, tt.wantCode), "got %v", err)
+			assert.Empty(t, stores.Payments.GetAll())
+		})
+	}
+}
+
+func TestCreatePayment_FXQuoteFundsOnePayment(t *testing.T) {
+	// Arrange: one quote, several payments with their own idempotency keys racing for it
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "20000.00"))
+	rates := fx.NewStaticRateProvider(map[string]map[string]decimal.Decimal{
+		"USD": {"ARS": decimal.RequireFromString("1000")},
+	})
+	quoteService := command.NewQuoteFXService(stores.Wallets, rates, stores.Quotes, decimal.RequireFromString("0.01"), time.Minute)
+	createService := command.NewCreatePaymentService(
+		stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, payment.NewEmptyFeeSchedule(), "test-topic-arn",
+	)
+	ctx := context.Background()
+	quote, err := quoteService.Execute(ctx, command.QuoteFXRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("5.00"), Currency: "USD",
+	})
+	require.NoError(t, err)
+
+	request := func(key string) command.CreatePaymentRequest {
+		return command.CreatePaymentRequest{
+			UserID: "user-123", Amount: decimal.RequireFromString("5.00"), Currency: "USD",
+			ServiceID: "service-123", IdempotencyKey: key, FXQuoteID: quote.QuoteID,
+		}
+	}
+
+	// Act
+	const attempts = 4
+	results := make([]*command.CreatePaymentResponse, attempts)
+	errs := make([]error, attempts)
+	var wg sync.WaitGroup
+	for i := 0; i < attempts; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = createService.Execute(ctx, request(fmt.Sprintf("fx-key-%d", i)))
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert: one payment got the rate, the rest were told the quote is spent
+	var winner int
+	created := 0
+	for i := 0; i < attempts; i++ {
+		if errs[i] == nil {
+			winner = i
+			created++
+			continue
+		}
+		assert.True(t, domerrors.IsErrorCode(errs[i], domerrors.ErrCodeFXQuoteUsed), "got %v", errs[i])
+	}
+	require.Equal(t, 1, created)
+	assert.Len(t, stores.Payments.GetAll(), 1)
+
+	stored, err := stores.Quotes.Get(ctx, quote.QuoteID)
+	require.NoError(t, err)
+	assert.Equal(t, results[winner].PaymentID, stored.UsedBy)
+
+	// Assert: retrying the winning request still answers with its payment
+	retry, err := createService.Execute(ctx, request(fmt.Sprintf("fx-key-%d", winner)))
+	require.NoError(t, err)
+	assert.Equal(t, "ALREADY_PROCESSED", retry.Status)
+	assert.Equal(t, results[winner].PaymentID, retry.PaymentID)
+}
+
+func TestPaymentOrchestrator_FXPaymentMovesTheFundingCurrency(t *testing.T) {
+	// Arrange: a 10 USD payment paid from ARS at 1010
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "20000.00"))
+	rates := fx.NewStaticRateProvider(map[string]map[string]decimal.Decimal{
+		"USD": {"ARS": decimal.RequireFromString("1000")},
+	})
+	quoteService := command.NewQuoteFXService(stores.Wallets, rates, stores.Quotes, decimal.RequireFromString("0.01"), time.Minute)
+	createService := command.NewCreatePaymentService(
+		stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, payment.NewEmptyFeeSchedule(), "test-topic-arn",
+	)
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+	quote, err := quoteService.Execute(ctx, command.QuoteFXRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("10.00"), Currency: "USD",
+	})
+	require.NoError(t, err)
+	result, err := createService.Execute(ctx, command.CreatePaymentRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("10.00"), Currency: "USD",
+		ServiceID: "service-123", IdempotencyKey: "fx-key", FXQuoteID: quote.QuoteID,
+	})
+	require.NoError(t, err)
+
+	// Act
+	require.NoError(t, orch.HandlePaymentRequested(ctx, queued(t, stores.Outbox, "PaymentRequested")[0]))
+	require.NoError(t, orch.HandleExternalPaymentSucceeded(ctx,
+		payment.NewExternalPaymentSucceededEvent(result.PaymentID, "external-tx-456", shared.Metadata{})))
+	require.NoError(t, orch.HandlePaymentRefunded(ctx, payment.NewPaymentRefundedEvent(
+		result.PaymentID, "user-123", "refund-1", decimal.RequireFromString("2.50"), decimal.RequireFromString("2.50"), "USD", "", shared.Metadata{})))
+
+	// Assert: only ARS moved, never a USD balance
+	wlt, _ := stores.Wallets.GetByUserID(ctx, "user-123")
+	assert.Equal(t, "12425", wlt.Balance(vo.ARS).Amount().String())
+	assert.True(t, wlt.Balance(vo.USD).IsZero())
+
+	held := queued(t, stores.Outbox, "WalletFundsHeld")
+	require.Len(t, held, 1)
+	heldEvent := held[0].(*wallet.WalletFundsHeldEvent)
+	assert.Equal(t, "10100", heldEvent.Amount().String())
+	assert.Equal(t, "ARS", heldEvent.Currency())
+
+	captured := queued(t, stores.Outbox, "WalletHoldCaptured")
+	require.Len(t, captured, 1)
+	capturedEvent := captured[0].(*wallet.WalletHoldCapturedEvent)
+	assert.Equal(t, "10100", capturedEvent.Amount().String())
+	require.NotNil(t, capturedEvent.FX())
+	assert.Equal(t, "10", capturedEvent.FX().TargetAmount.String())
+	assert.Equal(t, "USD", capturedEvent.FX().TargetCurrency)
+
+	credited := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, credited, 1)
+	assert.Equal(t, "2525", credited[0].(*wallet.WalletCreditedEvent).Amount().String())
+	assert.Equal(t, "ARS", credited[0].(*wallet.WalletCreditedEvent).Currency())
+}
@@ -0,0 +1,180 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/domain/payment"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/infrastructure/gateway"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var errGatewayDown = domerrors.ExternalGatewayError("charge", errors.New("500"))
+
+func testBreakerConfig() gateway.CircuitBreakerConfig {
+	return gateway.CircuitBreakerConfig{
+		FailureThreshold: 2,
+		OpenTimeout:      20 * time.Millisecond,
+		HalfOpenProbes:   1,
+	}
+}
+
+func TestCircuitBreaker_OpensAfterConsecutiveFailures(t *testing.T) {
+	// Arrange
+	next := fakes.NewPaymentGatewayFake()
+	next.FailWith(errGatewayDown)
+	breaker := gateway.NewCircuitBreakerGateway("primary", next, testBreakerConfig())
+	ctx := context.Background()
+
+	// Act
+	breaker.Charge(ctx, chargeFor("service-123"))
+	breaker.Charge(ctx, chargeFor("service-123"))
+	_, err := breaker.Charge(ctx, chargeFor("service-123"))
+
+	// Assert: the third call never reaches the gateway
+	assert.Equal(t, gateway.CircuitOpen, breaker.State())
+	assert.False(t, breaker.Available())
+	assert.Equal(t, 2, next.Calls())
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeGatewayUnavailable))
+	assert.ErrorIs(t, err, gateway.ErrCircuitOpen)
+}
+
+func TestCircuitBreaker_DeclinesAreNotFailures(t *testing.T) {
+	next := fakes.NewPaymentGatewayFake()
+	next.DeclineWith("do_not_honor")
+	breaker := gateway.NewCircuitBreakerGateway("primary", next, testBreakerConfig())
+
+	for i := 0; i < 5; i++ {
+		_, err := breaker.Charge(context.Background(), chargeFor("service-123"))
+		require.NoError(t, err)
+	}
+
+	assert.Equal(t, gateway.CircuitClosed, breaker.State())
+}
+
+func TestCircuitBreaker_HalfOpenProbe(t *testing.T) {
+	tests := []struct {
+		name          string
+		probeErr      error
+		wantState     gateway.CircuitState
+		wantAvailable bool
+	}{
+		{name: "successful probe closes", wantState: gateway.CircuitClosed, wantAvailable: true},
+		{name: "failed probe reopens", probeErr: errGatewayDown, wantState: gateway.CircuitOpen},
+		{name: "cancelled probe frees the slot", probeErr: context.Canceled, wantState: gateway.CircuitHalfOpen, wantAvailable: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange: open the circuit and wait out the open timeout
+			next := fakes.NewPaymentGatewayFake()
+			next.FailTimes(2, errGatewayDown)
+			breaker := gateway.NewCircuitBreakerGateway("primary", next, testBreakerConfig())
+			breaker.Charge(context.Background(), chargeFor("service-123"))
+			breaker.Charge(context.Background(), chargeFor("service-123"))
+			require.Equal(t, gateway.CircuitOpen, breaker.State())
+			time.Sleep(30 * time.Millisecond)
+			require.True(t, breaker.Available())
+
+			if tt.probeErr != nil {
+				next.FailWith(tt.probeErr)
+			}
+
+			// Act
+			breaker.Charge(context.Background(), chargeFor("service-123"))
+
+			// Assert
+			assert.Equal(t, tt.wantState, breaker.State())
+			assert.Equal(t, tt.wantAvailable, breaker.Available())
+			assert.Equal(t, 3, next.Calls())
+		})
+	}
+}
+
+func TestRetryingGateway_RetriesUntilAnswer(t *testing.T) {
+	// Arrange
+	next := fakes.NewPaymentGatewayFake()
+	next.FailTimes(2, errGatewayDown)
+	gw := gateway.NewRetryingGateway(next, gateway.RetryConfig{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond})
+
+	// Act
+	result, err := gw.Charge(context.Background(), chargeFor("service-123"))
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEmpty(t, result.TransactionID)
+	assert.Equal(t, 3, next.Calls())
+}
+
+func TestRetryingGateway_DoesNotRetryOpenCircuit(t *testing.T) {
+	// Arrange
+	next := fakes.NewPaymentGatewayFake()
+	next.FailWith(errGatewayDown)
+	config := gateway.ResilienceConfig{
+		Breaker: gateway.CircuitBreakerConfig{FailureThreshold: 1, OpenTimeout: time.Minute, HalfOpenProbes: 1},
+		Retry:   gateway.RetryConfig{MaxAttempts: 5, BaseDelay: time.Millisecond, MaxDelay: 5 * time.Millisecond},
+	}
+	gw, breaker := gateway.WrapResilient("primary", next, config)
+
+	// Act
+	_, err := gw.Charge(context.Background(), chargeFor("service-123"))
+
+	// Assert: the first failure opens the circuit and the retry stops there
+	require.Error(t, err)
+	assert.Equal(t, gateway.CircuitOpen, breaker.State())
+	assert.Equal(t, 1, next.Calls())
+}
+
+func TestBackoff_StaysUnderExponentialCeiling(t *testing.T) {
+	base, maxDelay := 100*time.Millisecond, time.Second
+
+	for attempt := 1; attempt <= 6; attempt++ {
+		ceiling := base << (attempt - 1)
+		if ceiling > maxDelay {
+			ceiling = maxDelay
+		}
+		for i := 0; i < 50; i++ {
+			delay := gateway.Backoff(attempt, base, maxDelay)
+			assert.GreaterOrEqual(t, delay, time.Duration(0))
+			assert.LessOrEqual(t, delay, ceiling)
+		}
+	}
+}
+
+// unhealthyGateways marks the listed gateways as unavailable
+type unhealthyGateways map[string]bool
+
+func (u unhealthyGateways) Available(gateway string) bool { return !u[gateway] }
+
+func TestPaymentOrchestrator_FailsFastWhenGatewaysUnavailable(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	router := orchestrator.NewSingleGatewayRouter("primary").WithHealth(unhealthyGateways{"primary": true})
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, router, stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	// Act
+	require.NoError(t, orch.HandlePaymentRequested(context.Background(), requestedEventFor(pmt)))
+
+	// Assert: rejected with the new reason and the wallet untouched
+	updated, _ := stores.Payments.FindByID(context.Background(), pmt.ID().String())
+	assert.Equal(t, vo.PaymentStatusRejected, updated.Status())
+	failed := queued(t, stores.Outbox, "PaymentFailed")
+	require.Len(t, failed, 1)
+	assert.Equal(t, string(domerrors.ErrCodeGatewayUnavailable), failed[0].(*payment.PaymentFailedEvent).Reason())
+
+	wlt, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.Empty(t, queued(t, stores.Outbox, "WalletFundsHeld"))
+}
@@ -0,0 +1,190 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestRouter(t *testing.T) *orchestrator.GatewayRouter {
+	t.Helper()
+
+	router, err := orchestrator.NewGatewayRouter([]orchestrator.GatewayRoute{
+		{ServiceID: "*", Gateways: []string{"fallback"}},
+		{ServiceID: "telecom-*", Gateways: []string{"telco"}},
+		{ServiceID: "telecom-*", Currency: "USD", Gateways: []string{"telco-usd"}},
+		{ServiceID: "telecom-mobile-*", Gateways: []string{"mobile", "telco"}},
+		{ServiceID: "telecom-mobile-claro", Gateways: []string{"claro"}},
+	})
+	require.NoError(t, err)
+	return router
+}
+
+func TestGatewayRouter_PicksMostSpecificRoute(t *testing.T) {
+	tests := []struct {
+		name      string
+		serviceID string
+		currency  string
+		want      []string
+	}{
+		{name: "exact id wins", serviceID: "telecom-mobile-claro", currency: "ARS", want: []string{"claro"}},
+		{name: "longest prefix wins", serviceID: "telecom-mobile-movistar", currency: "ARS", want: []string{"mobile", "telco"}},
+		{name: "currency wins at same prefix", serviceID: "telecom-fiber", currency: "USD", want: []string{"telco-usd"}},
+		{name: "any currency", serviceID: "telecom-fiber", currency: "ARS", want: []string{"telco"}},
+		{name: "catch all", serviceID: "utility-gas", currency: "ARS", want: []string{"fallback"}},
+	}
+
+	router := newTestRouter(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			route, err := router.Route(tt.serviceID, tt.currency)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, route)
+		})
+	}
+}
+
+func TestGatewayRouter_NoRoute(t *testing.T) {
+	router, err := orchestrator.NewGatewayRouter([]orchestrator.GatewayRoute{
+		{ServiceID: "telecom-*", Currency: "ARS", Gateways: []string{"telco"}},
+	})
+	require.NoError(t, err)
+
+	_, err = router.Route("telecom-fiber", "USD")
+
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeNoGatewayRoute))
+}
+
+func TestPaymentOrchestrator_RecordsGatewayRouteOnRequest(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	router, err := orchestrator.NewGatewayRouter([]orchestrator.GatewayRoute{
+		{ServiceID: "service-*", Currency: "ARS", Gateways: []string{"primary", "secondary"}},
+	})
+	require.NoError(t, err)
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, router, stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	// Act
+	require.NoError(t, orch.HandlePaymentRequested(context.Background(), requestedEventFor(pmt)))
+
+	// Assert
+	requested := queued(t, stores.Outbox, "ExternalPaymentRequested")
+	require.Len(t, requested, 1)
+	extra := requested[0].Metadata().Extra
+	assert.Equal(t, "primary", extra[orchestrator.MetadataGateway])
+	assert.Equal(t, "primary,secondary", extra[orchestrator.MetadataGatewayRoute])
+}
+
+func TestPaymentOrchestrator_FailsUnroutablePaymentBeforeDebit(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	router, err := orchestrator.NewGatewayRouter([]orchestrator.GatewayRoute{
+		{ServiceID: "telecom-*", Gateways: []string{"telco"}},
+	})
+	require.NoError(t, err)
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, router, stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	// Act
+	require.NoError(t, orch.HandlePaymentRequested(context.Background(), requestedEventFor(pmt)))
+
+	// Assert
+	updated, _ := stores.Payments.FindByID(context.Background(), pmt.ID().String())
+	assert.Equal(t, vo.PaymentStatusRejected, updated.Status())
+	wlt, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.Empty(t, queued(t, stores.Outbox, "ExternalPaymentRequested"))
+}
+
+// routedRequest builds an ExternalPaymentRequested carrying the route primary → secondary
+func routedRequest() *payment.ExternalPaymentRequestedEvent {
+	metadata := shared.Metadata{}.
+		WithExtra(orchestrator.MetadataGateway, "primary").
+		WithExtra(orchestrator.MetadataGatewayRoute, "primary,secondary")
+
+	return payment.NewExternalPaymentRequestedEvent("payment-1", "user-123", decimal.RequireFromString("100.50"), "ARS", "service-123", metadata)
+}
+
+func newFailoverHandler(primary, secondary port.PaymentGateway, stores *fakes.Stores) *orchestrator.ExternalPaymentHandler {
+	return orchestrator.NewExternalPaymentHandler(
+		map[string]port.PaymentGateway{"primary": primary, "secondary": secondary},
+		orchestrator.NewSingleGatewayRouter("primary"),
+		stores.UnitOfWork,
+		"test-topic-arn",
+	)
+}
+
+func TestExternalPaymentHandler_FailsOverWhenPrimaryUnavailable(t *testing.T) {
+	// Arrange
+	primary, secondary := fakes.NewPaymentGatewayFake(), fakes.NewPaymentGatewayFake()
+	primary.FailWith(domerrors.GatewayUnavailableError("charge", errors.New("503")))
+	stores := fakes.NewStores()
+	handler := newFailoverHandler(primary, secondary, stores)
+
+	// Act
+	err := handler.HandleExternalPaymentRequested(context.Background(), routedRequest())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, secondary.Calls())
+	succeeded := queued(t, stores.Outbox, "ExternalPaymentSucceeded")
+	require.Len(t, succeeded, 1)
+	assert.Equal(t, "secondary", succeeded[0].Metadata().Extra[orchestrator.MetadataGateway])
+}
+
+func TestExternalPaymentHandler_DoesNotFailOver(t *testing.T) {
+	tests := []struct {
+		name    string
+		arrange func(primary *fakes.PaymentGatewayFake)
+		wantErr bool
+	}{
+		{
+			name: "unknown outcome",
+			arrange: func(p *fakes.PaymentGatewayFake) {
+				p.FailWith(domerrors.ExternalGatewayError("charge", errors.New("500")))
+			},
+			wantErr: true,
+		},
+		{
+			name:    "decline",
+			arrange: func(p *fakes.PaymentGatewayFake) { p.DeclineWith("do_not_honor") },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			primary, secondary := fakes.NewPaymentGatewayFake(), fakes.NewPaymentGatewayFake()
+			tt.arrange(primary)
+			handler := newFailoverHandler(primary, secondary, fakes.NewStores())
+
+			// Act
+			err := handler.HandleExternalPaymentRequested(context.Background(), routedRequest())
+
+			// Assert: the primary may have taken the charge, the secondary must not be asked
+			assert.Equal(t, tt.wantErr, err != nil)
+			assert.Equal(t, 0, secondary.Calls())
+		})
+	}
+}
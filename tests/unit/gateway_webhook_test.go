@@ -0,0 +1,195 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/infrastructure/gateway"
+	httpHandler "github.com/franco/payment-api/internal/infrastructure/http"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testWebhookSecret = "test-webhook-secret"
+
+// webhookBody is a provider callback about the charge of paymentID
+func webhookBody(paymentID, eventID, eventType string) string {
+	return `{"id": "` + eventID + `", "type": "` + eventType + `", "data": {"chargeId": "ch_1", "reference": "` + paymentID + `", "declineCode": "do_not_honor"}}`
+}
+
+// seedRoutedPayment stores a payment whose charge the orchestrator sent to gatewayName
+func seedRoutedPayment(t *testing.T, stores *fakes.Stores, gatewayName string) string {
+	t.Helper()
+
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter(gatewayName),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	require.NoError(t, orch.HandlePaymentRequested(context.Background(), requestedEventFor(pmt)))
+	return pmt.ID().String()
+}
+
+// sendWebhook posts a callback signed with secret at signedAt
+func sendWebhook(handler *httpHandler.WebhookHandler, provider, body, secret string, signedAt time.Time) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/gateway/"+provider, strings.NewReader(body))
+	req.Header.Set(gateway.WebhookTimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+	req.Header.Set(gateway.WebhookSignatureHeader, gateway.SignWebhook(secret, signedAt, []byte(body)))
+
+	rec := httptest.NewRecorder()
+	handler.HandleGatewayWebhook(rec, req)
+	return rec
+}
+
+func TestGatewayWebhook_PublishesChargeResult(t *testing.T) {
+	tests := []struct {
+		name      string
+		eventType string
+		wantEvent string
+	}{
+		{name: "succeeded", eventType: gateway.WebhookChargeSucceeded, wantEvent: "ExternalPaymentSucceeded"},
+		{name: "failed", eventType: gateway.WebhookChargeFailed, wantEvent: "ExternalPaymentFailed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stores := fakes.NewStores()
+			paymentID := seedRoutedPayment(t, stores, "stub")
+			service := command.NewProcessGatewayWebhookService(stores.Payments, stores.Events, stores.Inbox, stores.UnitOfWork, "test-topic-arn")
+			handler := httpHandler.NewWebhookHandler(service, map[string]string{"stub": testWebhookSecret}, time.Minute)
+
+			// Act
+			rec := sendWebhook(handler, "stub", webhookBody(paymentID, "evt_1", tt.eventType), testWebhookSecret, time.Now())
+
+			// Assert
+			require.Equal(t, http.StatusOK, rec.Code, rec.Body.String())
+			var response httpHandler.WebhookResponse
+			require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+			assert.Equal(t, command.WebhookAccepted, response.Status)
+
+			published := queued(t, stores.Outbox, tt.wantEvent)
+			require.Len(t, published, 1)
+			assert.Equal(t, "stub", published[0].Metadata().Extra["gateway"])
+		})
+	}
+}
+
+func TestGatewayWebhook_DeduplicatesProviderEventID(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	paymentID := seedRoutedPayment(t, stores, "stub")
+	service := command.NewProcessGatewayWebhookService(stores.Payments, stores.Events, stores.Inbox, stores.UnitOfWork, "test-topic-arn")
+	handler := httpHandler.NewWebhookHandler(service, map[string]string{"stub": testWebhookSecret}, time.Minute)
+	body := webhookBody(paymentID, "evt_1", gateway.WebhookChargeSucceeded)
+	require.Equal(t, http.StatusOK, sendWebhook(handler, "stub", body, testWebhookSecret, time.Now()).Code)
+
+	// Act
+	rec := sendWebhook(handler, "stub", body, testWebhookSecret, time.Now())
+
+	// Assert
+	require.Equal(t, http.StatusOK, rec.Code)
+	var response httpHandler.WebhookResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &response))
+	assert.Equal(t, command.WebhookAlreadyProcessed, response.Status)
+	assert.Len(t, queued(t, stores.Outbox, "ExternalPaymentSucceeded"), 1)
+}
+
+func TestGatewayWebhook_Rejections(t *testing.T) {
+	tests := []struct {
+		name       string
+		provider   string
+		secret     string
+		signedAt   time.Time
+		wantStatus int
+	}{
+		{name: "wrong secret", provider: "stub", secret: "other-secret", signedAt: time.Now(), wantStatus: http.StatusUnauthorized},
+		{name: "timestamp too old", provider: "stub", secret: testWebhookSecret, signedAt: time.Now().Add(-time.Hour), wantStatus: http.StatusUnauthorized},
+		{name: "unknown provider", provider: "other", secret: testWebhookSecret, signedAt: time.Now(), wantStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stores := fakes.NewStores()
+			paymentID := seedRoutedPayment(t, stores, "stub")
+			service := command.NewProcessGatewayWebhookService(stores.Payments, stores.Events, stores.Inbox, stores.UnitOfWork, "test-topic-arn")
+			handler := httpHandler.NewWebhookHandler(service, map[string]string{"stub": testWebhookSecret}, time.Minute)
+
+			rec := sendWebhook(handler, tt.provider, webhookBody(paymentID, "evt_1", gateway.WebhookChargeSucceeded), tt.secret, tt.signedAt)
+
+			assert.Equal(t, tt.wantStatus, rec.Code)
+			assert.Empty(t, queued(t, stores.Outbox, "ExternalPaymentSucceeded"))
+		})
+	}
+}
+
+func TestGatewayWebhook_TamperedBodyIsRejected(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	paymentID := seedRoutedPayment(t, stores, "stub")
+	service := command.NewProcessGatewayWebhookService(stores.Payments, stores.Events, stores.Inbox, stores.UnitOfWork, "test-topic-arn")
+	handler := httpHandler.NewWebhookHandler(service, map[string]string{"stub": testWebhookSecret}, time.Minute)
+	signedAt := time.Now()
+	signature := gateway.SignWebhook(testWebhookSecret, signedAt, []byte(webhookBody(paymentID, "evt_1", gateway.WebhookChargeFailed)))
+
+	req := httptest.NewRequest(http.MethodPost, "/webhooks/gateway/stub",
+		strings.NewReader(webhookBody(paymentID, "evt_1", gateway.WebhookChargeSucceeded)))
+	req.Header.Set(gateway.WebhookTimestampHeader, strconv.FormatInt(signedAt.Unix(), 10))
+	req.Header.Set(gateway.WebhookSignatureHeader, signature)
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.HandleGatewayWebhook(rec, req)
+
+	// Assert
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Empty(t, queued(t, stores.Outbox, "ExternalPaymentSucceeded"))
+}
+
+func TestGatewayWebhook_RejectsProviderThePaymentWasNotSentTo(t *testing.T) {
+	tests := []struct {
+		name    string
+		arrange func(t *testing.T, stores *fakes.Stores) string
+	}{
+		{
+			name: "sent to another gateway",
+			arrange: func(t *testing.T, stores *fakes.Stores) string {
+				return seedRoutedPayment(t, stores, "stub")
+			},
+		},
+		{
+			name: "not sent to any gateway yet",
+			arrange: func(t *testing.T, stores *fakes.Stores) string {
+				return seedPendingPayment(t, stores.Payments).ID().String()
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stores := fakes.NewStores()
+			paymentID := tt.arrange(t, stores)
+			service := command.NewProcessGatewayWebhookService(stores.Payments, stores.Events, stores.Inbox, stores.UnitOfWork, "test-topic-arn")
+			handler := httpHandler.NewWebhookHandler(service, map[string]string{"stub": testWebhookSecret, "other": testWebhookSecret}, time.Minute)
+
+			// Act: a provider with valid credentials reports a result for a charge it was not given
+			rec := sendWebhook(handler, "other", webhookBody(paymentID, "evt_1", gateway.WebhookChargeSucceeded), testWebhookSecret, time.Now())
+
+			// Assert
+			assert.Equal(t, http.StatusForbidden, rec.Code, rec.Body.String())
+			assert.Contains(t, rec.Body.String(), "GATEWAY_MISMATCH")
+			assert.Empty(t, queued(t, stores.Outbox, "ExternalPaymentSucceeded"))
+		})
+	}
+}
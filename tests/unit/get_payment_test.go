@@ -0,0 +1,123 @@
+package unit
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	httpHandler "github.com/franco/payment-api/internal/infrastructure/http"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetPayment_WithEvents(t *testing.T) {
+	// Arrange
+	paymentRepo := fakes.NewPaymentRepositoryFake()
+	eventStore := fakes.NewEventStoreFake()
+
+	paymentID := vo.GeneratePaymentID()
+	userID, _ := vo.NewUserID("user-123")
+	serviceID, _ := vo.NewServiceID("service-123")
+	amount := vo.MustNewMoney("100.00", "ARS")
+	idempKey, _ := vo.NewIdempotencyKey("key-123")
+	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, amount, idempKey)
+	require.NoError(t, pmt.MarkProcessing())
+	require.NoError(t, pmt.MarkCompleted("external-tx-456"))
+	paymentRepo.Save(context.Background(), pmt)
+
+	metadata := shared.Metadata{ClientID: "test-client", RequestID: "req-123", Source: "test"}
+	eventStore.Append(context.Background(), payment.NewPaymentRequestedEvent(
+		paymentID.String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", "key-123", metadata,
+	), paymentID.String())
+	eventStore.Append(context.Background(), payment.NewPaymentCompletedEvent(
+		paymentID.String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "external-tx-456", metadata,
+	), paymentID.String())
+
+	service := query.NewGetPaymentService(paymentRepo, eventStore)
+
+	// Act
+	result, err := service.Execute(context.Background(), query.GetPaymentRequest{
+		PaymentID:     paymentID.String(),
+		IncludeEvents: true,
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "COMPLETED", result.Status)
+	assert.Equal(t, "external-tx-456", result.ExternalTxID)
+	require.Len(t, result.Events, 2)
+	assert.Equal(t, "PaymentRequested", result.Events[0].EventType)
+	assert.Equal(t, "PaymentCompleted", result.Events[1].EventType)
+}
+
+func TestGetPayment_NotFound(t *testing.T) {
+	// Arrange
+	service := query.NewGetPaymentService(fakes.NewPaymentRepositoryFake(), fakes.NewEventStoreFake())
+
+	// Act
+	_, err := service.Execute(context.Background(), query.GetPaymentRequest{
+		PaymentID: vo.GeneratePaymentID().String(),
+	})
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodePaymentNotFound))
+}
+
+func TestGetPaymentHandler_StatusCodes(t *testing.T) {
+	// Arrange
+	paymentRepo := fakes.NewPaymentRepositoryFake()
+	paymentID := vo.GeneratePaymentID()
+	userID, _ := vo.NewUserID("user-123")
+	serviceID, _ := vo.NewServiceID("service-123")
+	idempKey, _ := vo.NewIdempotencyKey("key-123")
+	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, vo.MustNewMoney("100.00", "ARS"), idempKey)
+	paymentRepo.Save(context.Background(), pmt)
+
+	handler := httpHandler.NewPaymentHandler(
+		nil,
+		nil,
+		nil,
+		query.NewGetPaymentService(paymentRepo, fakes.NewEventStoreFake()),
+		nil,
+	)
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedStatus int
+	}{
+		{name: "Existing payment", path: "/payments/" + paymentID.String(), expectedStatus: http.StatusOK},
+		{name: "Unknown payment", path: "/payments/" + vo.GeneratePaymentID().String(), expectedStatus: http.StatusNotFound},
+		{name: "Malformed ID", path: "/payments/not-a-uuid", expectedStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			rec := httptest.NewRecorder()
+			handler.HandlePaymentResource(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+
+	// Verify response body for the existing payment
+	rec := httptest.NewRecorder()
+	handler.HandlePaymentResource(rec, httptest.NewRequest(http.MethodGet, "/payments/"+paymentID.String(), nil))
+
+	var body map[string]interface{}
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+	assert.Equal(t, "PENDING", body["status"])
+	assert.Equal(t, "ARS", body["currency"])
+}
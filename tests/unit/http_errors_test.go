@@ -0,0 +1,183 @@
+package unit
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/domain/payment"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	httpHandler "github.com/franco/payment-api/internal/infrastructure/http"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreatePaymentHandler_ErrorTranslation(t *testing.T) {
+	// Arrange
+	walletRepo := fakes.NewWalletRepositoryFake()
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("50.00", "ARS"))
+	walletRepo.SetWallet(wlt)
+
+	idempotencyStore := fakes.NewIdempotencyStoreFake()
+	service := command.NewCreatePaymentService(
+		fakes.NewPaymentUnitOfWorkFake(fakes.NewPaymentRepositoryFake(), idempotencyStore, fakes.NewEventStoreFake(), fakes.NewOutboxStoreFake(), fakes.NewFXQuoteStoreFake()),
+		walletRepo,
+		idempotencyStore,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
+		"test-topic-arn",
+	)
+	handler := httpHandler.NewPaymentHandler(service, nil, nil, nil, nil)
+
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		expectedCode   string
+	}{
+		{
+			name:           "Malformed body",
+			body:           `{"amount": "abc"`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_FAILED",
+		},
+		{
+			name:           "Amount is not a decimal",
+			body:           `{"userId": "user-123", "amount": "ten", "currency": "ARS", "serviceId": "svc", "idempotencyKey": "k0"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_FAILED",
+		},
+		{
+			name:           "More decimals than the currency allows",
+			body:           `{"userId": "user-123", "amount": "10.005", "currency": "ARS", "serviceId": "svc", "idempotencyKey": "k6"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_AMOUNT",
+		},
+		{
+			name:           "Missing user",
+			body:           `{"amount": 10, "currency": "ARS", "serviceId": "svc", "idempotencyKey": "k1"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "VALIDATION_FAILED",
+		},
+		{
+			name:           "Unsupported currency",
+			body:           `{"userId": "user-123", "amount": 10, "currency": "XYZ", "serviceId": "svc", "idempotencyKey": "k2"}`,
+			expectedStatus: http.StatusBadRequest,
+			expectedCode:   "INVALID_CURRENCY",
+		},
+		{
+			name:           "Unknown wallet",
+			body:           `{"userId": "user-999", "amount": 10, "currency": "ARS", "serviceId": "svc", "idempotencyKey": "k3"}`,
+			expectedStatus: http.StatusNotFound,
+			expectedCode:   "WALLET_NOT_FOUND",
+		},
+		{
+			name:           "Insufficient funds",
+			body:           `{"userId": "user-123", "amount": 100, "currency": "ARS", "serviceId": "svc", "idempotencyKey": "k4"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "INSUFFICIENT_FUNDS",
+		},
+		{
+			name:           "No balance in the payment currency",
+			body:           `{"userId": "user-123", "amount": 10, "currency": "USD", "serviceId": "svc", "idempotencyKey": "k5"}`,
+			expectedStatus: http.StatusUnprocessableEntity,
+			expectedCode:   "INSUFFICIENT_FUNDS",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPost, "/payments", bytes.NewBufferString(tt.body))
+			req.Header.Set(httpHandler.RequestIDHeader, "req-abc")
+			rec := httptest.NewRecorder()
+
+			// Act
+			handler.HandleCreatePayment(rec, req)
+
+			// Assert
+			assert.Equal(t, tt.expectedStatus, rec.Code)
+
+			var body httpHandler.ErrorResponse
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			assert.Equal(t, tt.expectedCode, body.Code)
+			assert.NotEmpty(t, body.Message)
+			assert.Equal(t, "req-abc", body.RequestID)
+		})
+	}
+}
+
+func TestCreatePaymentHandler_InsufficientFundsDetails(t *testing.T) {
+	// Arrange
+	walletRepo := fakes.NewWalletRepositoryFake()
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("50.00", "ARS"))
+	walletRepo.SetWallet(wlt)
+
+	idempotencyStore := fakes.NewIdempotencyStoreFake()
+	service := command.NewCreatePaymentService(
+		fakes.NewPaymentUnitOfWorkFake(fakes.NewPaymentRepositoryFake(), idempotencyStore, fakes.NewEventStoreFake(), fakes.NewOutboxStoreFake(), fakes.NewFXQuoteStoreFake()),
+		walletRepo,
+		idempotencyStore,
+		fakes.NewFXQuoteStoreFake(),
+		payment.NewEmptyFeeSchedule(),
+		"test-topic-arn",
+	)
+	handler := httpHandler.NewPaymentHandler(service, nil, nil, nil, nil)
+
+	body := `{"userId": "user-123", "amount": "100", "currency": "ARS", "serviceId": "svc", "idempotencyKey": "k1"}`
+	rec := httptest.NewRecorder()
+
+	// Act
+	handler.HandleCreatePayment(rec, httptest.NewRequest(http.MethodPost, "/payments", bytes.NewBufferString(body)))
+
+	// Assert
+	var response httpHandler.ErrorResponse
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&response))
+	assert.Equal(t, "100.00 ARS", response.Details["required"])
+	assert.Equal(t, "50.00 ARS", response.Details["available"])
+	assert.NotEmpty(t, rec.Header().Get(httpHandler.RequestIDHeader))
+}
+
+func TestStatusForCode_EveryDomainCodeIsMapped(t *testing.T) {
+	// Arrange: read the codes from the source so a new one cannot be forgotten here too
+	file, err := parser.ParseFile(token.NewFileSet(), "../../internal/domain/shared/errors/errors.go", nil, 0)
+	require.NoError(t, err)
+
+	codes := make([]domerrors.ErrorCode, 0)
+	for _, decl := range file.Decls {
+		gen, ok := decl.(*ast.GenDecl)
+		if !ok || gen.Tok != token.CONST {
+			continue
+		}
+		for _, spec := range gen.Specs {
+			value := spec.(*ast.ValueSpec)
+			for i, name := range value.Names {
+				if !strings.HasPrefix(name.Name, "ErrCode") {
+					continue
+				}
+				literal := value.Values[i].(*ast.BasicLit)
+				codes = append(codes, domerrors.ErrorCode(strings.Trim(literal.Value, `"`)))
+			}
+		}
+	}
+	require.NotEmpty(t, codes)
+
+	for _, code := range codes {
+		// Act
+		_, ok := httpHandler.LookupStatus(code)
+
+		// Assert
+		assert.True(t, ok, "%s has no HTTP status", code)
+	}
+}
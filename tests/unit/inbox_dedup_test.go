@@ -0,0 +1,110 @@
+package unit
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/saga"
+	"github.com/franco/payment-api/internal/domain/shared"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/codec"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/inbox"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventID_SurvivesSerialization(t *testing.T) {
+	// Arrange
+	event := payment.NewPaymentRefundRequestedEvent("payment-1", "user-123", decimal.RequireFromString("100.00"), "ARS", "TIMEOUT", shared.Metadata{})
+
+	// Act
+	payload, err := codec.SerializeEvent(event)
+	require.NoError(t, err)
+	parsed, err := codec.ParseEvent(event.EventType(), payload)
+
+	// Assert
+	require.NoError(t, err)
+	assert.NotEmpty(t, event.EventID())
+	assert.Equal(t, event.EventID(), parsed.EventID())
+}
+
+func TestInboxDeduplicate_RedeliveredCompensationAppliesOnce(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+
+	paymentID := vo.GeneratePaymentID()
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+	wlt.PlaceHold(paymentID.String(), vo.MustNewMoney("100.00", "ARS"))
+	stores.Wallets.SetWallet(wlt)
+
+	serviceID, _ := vo.NewServiceID("service-123")
+	idempKey, _ := vo.NewIdempotencyKey("key-123")
+	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, vo.MustNewMoney("100.00", "ARS"), idempKey)
+	pmt.MarkProcessing()
+	pmt.MarkFailed("EXTERNAL_FAILURE")
+	stores.Payments.Save(context.Background(), pmt)
+
+	stores.Sagas.SeedSaga(paymentID.String(), time.Now().Add(time.Minute),
+		fakes.SagaMove{EventType: "PaymentRequested", To: saga.StepAwaitingGateway},
+		fakes.SagaMove{EventType: "ExternalPaymentFailed", To: saga.StepCompensating},
+	)
+
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"), stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	handler := inbox.Deduplicate(stores.Inbox, "wallet-service", orch.HandlePaymentRefundRequested)
+
+	refundEvent := payment.NewPaymentRefundRequestedEvent(
+		paymentID.String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "EXTERNAL_FAILURE", shared.Metadata{},
+	)
+	payload, _ := codec.SerializeEvent(refundEvent)
+
+	// Act - SQS delivers the same message twice; each delivery is parsed independently
+	for i := 0; i < 2; i++ {
+		delivered, err := codec.ParseEvent(refundEvent.EventType(), payload)
+		require.NoError(t, err)
+		require.NoError(t, handler(context.Background(), delivered))
+	}
+
+	// Assert
+	updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, updatedWallet.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.Len(t, queued(t, stores.Outbox, "WalletHoldReleased"), 1)
+}
+
+func TestInboxDeduplicate_RetriesAfterHandlerFailure(t *testing.T) {
+	// Arrange
+	inboxStore := fakes.NewInboxStoreFake()
+	calls := 0
+	handler := inbox.Deduplicate(inboxStore, "payment-service", func(ctx context.Context, event shared.Event) error {
+		calls++
+		if calls == 1 {
+			return errors.New("transient failure")
+		}
+		return nil
+	})
+	event := payment.NewExternalPaymentSucceededEvent("payment-1", "external-tx-456", shared.Metadata{})
+
+	// Act
+	firstErr := handler(context.Background(), event)
+	secondErr := handler(context.Background(), event)
+	thirdErr := handler(context.Background(), event)
+
+	// Assert
+	require.Error(t, firstErr)
+	require.NoError(t, secondErr)
+	require.NoError(t, thirdErr)
+	assert.Equal(t, 2, calls)
+
+	// Other consumers of the same fan-out are tracked separately
+	processed, _ := inboxStore.IsProcessed(context.Background(), "wallet-service", event.EventID())
+	assert.False(t, processed)
+}
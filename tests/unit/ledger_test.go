@@ -0,0 +1,208 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/domain/ledger"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJournalEntry_BalancesInEveryCurrency(t *testing.T) {
+	money := vo.MustNewMoney
+	fee, err := vo.NewFeeLine("service_fee", money("2.00", "USD"), vo.FeeRefundProportional)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		build   func() (*ledger.JournalEntry, error)
+		wantErr bool
+	}{
+		{
+			name: "capture in the wallet currency",
+			build: func() (*ledger.JournalEntry, error) {
+				return ledger.NewCaptureEntry("pay-1", "user-123", money("102.00", "USD"), money("100.00", "USD"), []vo.FeeLine{fee})
+			},
+		},
+		{
+			name: "capture funded in another currency goes through fx",
+			build: func() (*ledger.JournalEntry, error) {
+				return ledger.NewCaptureEntry("pay-1", "user-123", money("102000.00", "ARS"), money("100.00", "USD"), []vo.FeeLine{fee})
+			},
+		},
+		{
+			name: "capture that does not add up",
+			build: func() (*ledger.JournalEntry, error) {
+				return ledger.NewCaptureEntry("pay-1", "user-123", money("101.00", "USD"), money("100.00", "USD"), []vo.FeeLine{fee})
+			},
+			wantErr: true,
+		},
+		{
+			name: "single posting",
+			build: func() (*ledger.JournalEntry, error) {
+				return ledger.NewJournalEntry("manual-1", ledger.EntryTopUp, "", []ledger.Posting{
+					{Account: ledger.WalletAccount("user-123"), Side: ledger.Credit, Amount: money("10.00", "ARS")},
+				})
+			},
+			wantErr: true,
+		},
+		{
+			name: "zero posting",
+			build: func() (*ledger.JournalEntry, error) {
+				return ledger.NewJournalEntry("manual-1", ledger.EntryTopUp, "", []ledger.Posting{
+					{Account: ledger.FundingAccount, Side: ledger.Debit, Amount: vo.Zero(vo.ARS)},
+					{Account: ledger.WalletAccount("user-123"), Side: ledger.Credit, Amount: vo.Zero(vo.ARS)},
+				})
+			},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			entry, err := tt.build()
+
+			// Assert
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			for code, balance := range ledger.Balances(entry.Postings()) {
+				assert.True(t, balance.IsZero(), "%s does not balance: %s", code, balance)
+			}
+		})
+	}
+}
+
+func TestLedgerMapper_SpreadsSharedAccountsOverBuckets(t *testing.T) {
+	// Arrange
+	mapper := mappers.NewLedgerMapper()
+	wallet := ledger.WalletAccount("user-123")
+
+	// Act
+	partitions := make(map[string]bool)
+	for _, paymentID := range []string{"pay-1", "pay-2", "pay-3", "pay-4", "pay-5", "pay-6"} {
+		entry, err := ledger.NewCaptureEntry(paymentID, "user-123", vo.MustNewMoney("10.00", "ARS"), vo.MustNewMoney("10.00", "ARS"), nil)
+		require.NoError(t, err)
+
+		for _, row := range mapper.ToPostingRows(entry) {
+			if row.AccountID == wallet.String() {
+				continue
+			}
+			partitions[row.AccountID] = true
+		}
+	}
+
+	// Assert: settlement is written to more than one partition, all of which are read back
+	assert.Greater(t, len(partitions), 1)
+	readable := make(map[string]bool)
+	for _, partition := range mapper.PostingPartitions(ledger.SettlementAccount) {
+		readable[partition] = true
+	}
+	for partition := range partitions {
+		assert.True(t, readable[partition], "partition %s is never read", partition)
+	}
+	assert.Equal(t, []string{wallet.String()}, mapper.PostingPartitions(wallet))
+}
+
+func TestPaymentOrchestrator_PostsEveryWalletMovementToTheLedger(t *testing.T) {
+	// Arrange: the 1000.00 ARS seeded wallet has no ledger history until it is opened
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "1000.00"))
+	createService := command.NewCreatePaymentService(
+		stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, newTestFeeSchedule(t), "test-topic-arn",
+	)
+	refundService := command.NewRefundPaymentService(stores.Payments, stores.PaymentUnitOfWork, stores.Idempotency, "test-topic-arn")
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+	checker := query.NewCheckLedgerService(stores.Wallets, stores.Wallets.Ledger())
+
+	report, err := checker.Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Drifts, 1)
+	assert.Equal(t, "1000", report.Drifts[0].Difference.String())
+
+	wlt, err := stores.Wallets.GetByUserID(ctx, "user-123")
+	require.NoError(t, err)
+	opening, err := ledger.NewOpeningEntry("user-123", wlt.Balance(vo.ARS))
+	require.NoError(t, err)
+	require.NoError(t, wlt.RecordEntry(opening))
+	require.NoError(t, stores.Wallets.Update(ctx, wlt))
+
+	// Act: pay 100.00 with 12.00 of fees, refund 30, then top up 50
+	result, err := createService.Execute(ctx, command.CreatePaymentRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("100.00"), Currency: "ARS",
+		ServiceID: "utility-power", IdempotencyKey: "ledger-key",
+	})
+	require.NoError(t, err)
+	require.NoError(t, orch.HandlePaymentRequested(ctx, queued(t, stores.Outbox, "PaymentRequested")[0]))
+	require.NoError(t, orch.HandleExternalPaymentSucceeded(ctx,
+		payment.NewExternalPaymentSucceededEvent(result.PaymentID, "external-tx-456", shared.Metadata{})))
+
+	refund, err := refundService.Execute(ctx, command.RefundPaymentRequest{
+		PaymentID: result.PaymentID, Amount: decimal.RequireFromString("30"), IdempotencyKey: "refund-1",
+	})
+	require.NoError(t, err)
+	require.NoError(t, orch.HandlePaymentRefunded(ctx, queued(t, stores.Outbox, "PaymentRefunded")[0]))
+
+	topUp, err := command.NewTopUpWalletService(
+		stores.Wallets, stores.Wallets.Ledger(), stores.Events, stores.UnitOfWork, "test-topic-arn",
+	).Execute(ctx, command.TopUpWalletRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("50"), Currency: "ARS", IdempotencyKey: "topup-1",
+	})
+	require.NoError(t, err)
+
+	// Assert: one entry per movement, the hold itself posts nothing
+	ledgerFake := stores.Wallets.Ledger()
+	assert.Len(t, ledgerFake.Entries(), 4)
+	capture := ledgerFake.Entry(ledger.CaptureEntryID(result.PaymentID))
+	require.NotNil(t, capture)
+	assert.Len(t, capture.Postings(), 4)
+	assert.NotNil(t, ledgerFake.Entry(ledger.RefundEntryID(refund.RefundID)))
+	assert.NotNil(t, ledgerFake.Entry(ledger.TopUpEntryID(topUp.TopUpID)))
+
+	accountBalance := func(account ledger.AccountID) string {
+		postings, err := ledgerFake.AccountPostings(ctx, account)
+		require.NoError(t, err)
+		return ledger.Balances(postings)["ARS"].String()
+	}
+	assert.Equal(t, "70", accountBalance(ledger.SettlementAccount))
+	assert.Equal(t, "7.7", accountBalance(ledger.FeeAccount("service_fee")))
+	assert.Equal(t, "1", accountBalance(ledger.FeeAccount("processing_fee")))
+	assert.Equal(t, "971.3", accountBalance(ledger.WalletAccount("user-123")))
+
+	report, err = checker.Execute(ctx)
+	require.NoError(t, err)
+	assert.True(t, report.Consistent)
+	assert.Equal(t, 1, report.WalletsChecked)
+
+	// Assert: a balance overwritten outside the ledger is reported
+	tampered, err := stores.Wallets.GetByUserID(ctx, "user-123")
+	require.NoError(t, err)
+	_, _, err = tampered.Credit(vo.MustNewMoney("5.00", "ARS"))
+	require.NoError(t, err)
+	stores.Wallets.SetWallet(tampered)
+
+	report, err = checker.Execute(ctx)
+	require.NoError(t, err)
+	require.Len(t, report.Drifts, 1)
+	assert.Equal(t, "976.3", report.Drifts[0].Balance.String())
+	assert.Equal(t, "971.3", report.Drifts[0].Ledger.String())
+	assert.Equal(t, "5", report.Drifts[0].Difference.String())
+}
@@ -0,0 +1,156 @@
+package unit
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/infrastructure/gateway"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// outcomeOf summarizes a charge answer so runs can be compared
+func outcomeOf(result *port.ChargeResult, err error) string {
+	if err != nil {
+		return string(domerrors.GetErrorCode(err))
+	}
+	return string(result.Status) + ":" + result.DeclineCode
+}
+
+func TestMockGateway_SameSeedSameOutcomes(t *testing.T) {
+	scenario := gateway.MockScenario{
+		Seed:         42,
+		DeclineRate:  0.3,
+		ErrorRate:    0.2,
+		DeclineCodes: []gateway.MockDecline{{Code: "insufficient_funds"}, {Code: "expired_card"}},
+	}
+
+	run := func() []string {
+		gw, err := gateway.NewMockGateway(scenario)
+		require.NoError(t, err)
+
+		outcomes := make([]string, 0, 50)
+		for i := 0; i < 50; i++ {
+			req := chargeFor("service-123")
+			req.IdempotencyKey = fmt.Sprintf("payment-%d", i)
+			outcomes = append(outcomes, outcomeOf(gw.Charge(context.Background(), req)))
+		}
+		return outcomes
+	}
+
+	first, second := run(), run()
+
+	assert.Equal(t, first, second)
+	assert.Contains(t, first, "APPROVED:")
+	assert.Contains(t, first, string(domerrors.ErrCodeExternalGatewayError))
+}
+
+func TestMockGateway_RulesForceOutcome(t *testing.T) {
+	gw, err := gateway.NewMockGateway(gateway.MockScenario{
+		Seed:        1,
+		DeclineRate: 1, // anything a rule does not catch is declined
+		Rules: []gateway.MockRule{
+			{Amount: "13.13", Outcome: gateway.OutcomeDecline, Decline: gateway.MockDecline{Code: "insufficient_funds", Reason: "INSUFFICIENT_FUNDS"}},
+			{ServiceID: "down-*", Outcome: gateway.OutcomeUnavailable},
+			{Amount: "100.50", ServiceID: "vip", Outcome: gateway.OutcomeApprove},
+		},
+	})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		amount    float64
+		serviceID string
+		want      string
+	}{
+		{name: "test amount", amount: 13.13, serviceID: "service-123", want: "DECLINED:insufficient_funds"},
+		{name: "service prefix", amount: 10, serviceID: "down-telco", want: string(domerrors.ErrCodeGatewayUnavailable)},
+		{name: "amount and service", amount: 100.50, serviceID: "vip", want: "APPROVED:"},
+		{name: "amount without service", amount: 100.50, serviceID: "other", want: "DECLINED:do_not_honor"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := chargeFor(tt.serviceID)
+			req.IdempotencyKey = tt.name
+			req.Amount = decimal.NewFromFloat(tt.amount)
+
+			assert.Equal(t, tt.want, outcomeOf(gw.Charge(context.Background(), req)))
+		})
+	}
+}
+
+func TestMockGateway_LatencyAndTimeout(t *testing.T) {
+	// Arrange
+	gw, err := gateway.NewMockGateway(gateway.MockScenario{
+		Seed:         1,
+		TimeoutAfter: gateway.Duration(30 * time.Millisecond),
+		Latency:      gateway.MockLatency{Distribution: gateway.LatencyFixed, Mean: gateway.Duration(20 * time.Millisecond)},
+		Rules:        []gateway.MockRule{{ServiceID: "slow", Outcome: gateway.OutcomeTimeout}},
+	})
+	require.NoError(t, err)
+
+	// Act
+	start := time.Now()
+	_, err = gw.Charge(context.Background(), chargeFor("service-123"))
+	approvedAfter := time.Since(start)
+
+	slow := chargeFor("slow")
+	slow.IdempotencyKey = "payment-2"
+	start = time.Now()
+	_, timeoutErr := gw.Charge(context.Background(), slow)
+	timedOutAfter := time.Since(start)
+
+	// Assert
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, approvedAfter, 20*time.Millisecond)
+	assert.True(t, domerrors.IsErrorCode(timeoutErr, domerrors.ErrCodeExternalTimeout))
+	assert.GreaterOrEqual(t, timedOutAfter, 30*time.Millisecond)
+}
+
+func TestMockGateway_ReplaysAnswerForSameIdempotencyKey(t *testing.T) {
+	gw, err := gateway.NewMockGateway(gateway.MockScenario{Seed: 7, DeclineRate: 0.5})
+	require.NoError(t, err)
+
+	first, err := gw.Charge(context.Background(), chargeFor("service-123"))
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		again, err := gw.Charge(context.Background(), chargeFor("service-123"))
+		require.NoError(t, err)
+		assert.Equal(t, first, again)
+	}
+}
+
+func TestMockGateway_InvalidScenario(t *testing.T) {
+	tests := []struct {
+		name     string
+		scenario gateway.MockScenario
+	}{
+		{name: "rates above one", scenario: gateway.MockScenario{DeclineRate: 0.6, ErrorRate: 0.6}},
+		{name: "unknown outcome", scenario: gateway.MockScenario{Rules: []gateway.MockRule{{Amount: "1", Outcome: "explode"}}}},
+		{name: "bad amount", scenario: gateway.MockScenario{Rules: []gateway.MockRule{{Amount: "abc", Outcome: gateway.OutcomeApprove}}}},
+		{name: "unknown latency", scenario: gateway.MockScenario{Latency: gateway.MockLatency{Distribution: "poisson"}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := gateway.NewMockGateway(tt.scenario)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func TestMockGateway_PresetsAreValid(t *testing.T) {
+	for _, name := range gateway.MockPresets() {
+		scenario, err := gateway.LoadMockScenario(name)
+		require.NoError(t, err, name)
+
+		_, err = gateway.NewMockGateway(scenario)
+		assert.NoError(t, err, name)
+	}
+}
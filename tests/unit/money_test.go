@@ -0,0 +1,109 @@
+package unit
+
+import (
+	"testing"
+
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewMoney_RespectsCurrencyPrecision(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		wantErr  bool
+	}{
+		{amount: "10", currency: "CLP"},
+		{amount: "10.00", currency: "CLP"},
+		{amount: "10.5", currency: "CLP", wantErr: true},
+		{amount: "1.00", currency: "USD"},
+		{amount: "1.00001", currency: "USD", wantErr: true},
+		{amount: "99.99", currency: "ARS"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+tt.currency, func(t *testing.T) {
+			_, err := vo.NewMoney(decimal.RequireFromString(tt.amount), vo.MustNewCurrency(tt.currency))
+
+			assert.Equal(t, tt.wantErr, err != nil, "got %v", err)
+		})
+	}
+}
+
+func TestNewMoneyRounded_UsesCurrencyRoundingMode(t *testing.T) {
+	tests := []struct {
+		amount   string
+		currency string
+		want     string
+	}{
+		{amount: "1.005", currency: "ARS", want: "1.01"},
+		{amount: "1.005", currency: "USD", want: "1"},
+		{amount: "1.015", currency: "USD", want: "1.02"},
+		{amount: "10.5", currency: "CLP", want: "11"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.amount+" "+tt.currency, func(t *testing.T) {
+			money, err := vo.NewMoneyRounded(decimal.RequireFromString(tt.amount), vo.MustNewCurrency(tt.currency))
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, money.Amount().String())
+		})
+	}
+}
+
+func TestMoney_MinorUnitsRoundTrip(t *testing.T) {
+	usd := vo.MustNewMoney("123.45", "USD")
+	clp := vo.MustNewMoney("5000", "CLP")
+
+	assert.Equal(t, int64(12345), usd.ToMinorUnits())
+	assert.Equal(t, int64(5000), clp.ToMinorUnits())
+
+	restored, err := vo.FromMinorUnits(12345, vo.USD)
+	require.NoError(t, err)
+	assert.True(t, restored.Equals(usd))
+	assert.Equal(t, "5000 CLP", clp.String())
+}
+
+func TestMoney_AllocateKeepsEveryMinorUnit(t *testing.T) {
+	tests := []struct {
+		name   string
+		money  vo.Money
+		ratios []int
+		want   []string
+	}{
+		{name: "thirds", money: vo.MustNewMoney("100.00", "ARS"), ratios: []int{1, 1, 1}, want: []string{"33.34", "33.33", "33.33"}},
+		{name: "fee split", money: vo.MustNewMoney("0.05", "USD"), ratios: []int{70, 30}, want: []string{"0.04", "0.01"}},
+		{name: "zero ratio gets nothing", money: vo.MustNewMoney("10", "CLP"), ratios: []int{0, 1, 2}, want: []string{"0", "4", "6"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Act
+			parts, err := tt.money.Allocate(tt.ratios...)
+
+			// Assert
+			require.NoError(t, err)
+			total := vo.Zero(tt.money.Currency())
+			for i, part := range parts {
+				assert.Equal(t, tt.want[i], part.Amount().String())
+				total, _ = total.Add(part)
+			}
+			assert.True(t, total.Equals(tt.money))
+		})
+	}
+
+	_, err := vo.MustNewMoney("1.00", "USD").Allocate(0, 0)
+	assert.Error(t, err)
+}
+
+func TestMoney_SplitDiffersByOneMinorUnitAtMost(t *testing.T) {
+	parts, err := vo.MustNewMoney("10.00", "USD").Split(3)
+
+	require.NoError(t, err)
+	assert.Equal(t, "3.34", parts[0].Amount().String())
+	assert.Equal(t, "3.33", parts[1].Amount().String())
+	assert.Equal(t, "3.33", parts[2].Amount().String())
+}
@@ -0,0 +1,130 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/outbox"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testRelayConfig() outbox.RelayConfig {
+	return outbox.RelayConfig{
+		PollInterval: 10 * time.Millisecond,
+		BatchSize:    10,
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+	}
+}
+
+func TestOutboxRelay_DispatchesPendingInOrder(t *testing.T) {
+	// Arrange
+	outboxStore := fakes.NewOutboxStoreFake()
+	eventPublisher := fakes.NewEventPublisherFake()
+	publisher := outbox.NewPublisher(outboxStore)
+
+	require.NoError(t, publisher.Publish(context.Background(), payment.NewPaymentRequestedEvent(
+		"payment-1", "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", "key-123", shared.Metadata{},
+	), "test-topic-arn"))
+	time.Sleep(time.Millisecond)
+	require.NoError(t, publisher.Publish(context.Background(),
+		payment.NewExternalPaymentSucceededEvent("payment-1", "external-tx-456", shared.Metadata{}),
+		"test-topic-arn",
+	))
+
+	relay := outbox.NewRelay(outboxStore, eventPublisher, testRelayConfig())
+
+	// Act
+	dispatched, err := relay.DispatchPending(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 2, dispatched)
+	assert.Empty(t, outboxStore.GetPending())
+
+	published := eventPublisher.GetPublishedEvents()
+	require.Len(t, published, 2)
+	assert.Equal(t, "PaymentRequested", published[0].EventType())
+	assert.Equal(t, "ExternalPaymentSucceeded", published[1].EventType())
+
+	stats := relay.Stats()
+	assert.Equal(t, int64(2), stats.Dispatched)
+	assert.Equal(t, time.Duration(0), stats.Lag)
+}
+
+func TestOutboxRelay_KeepsEntriesPendingDuringOutage(t *testing.T) {
+	// Arrange
+	outboxStore := fakes.NewOutboxStoreFake()
+	eventPublisher := fakes.NewEventPublisherFake()
+	require.NoError(t, outbox.NewPublisher(outboxStore).Publish(context.Background(), payment.NewPaymentRequestedEvent(
+		"payment-1", "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", "key-123", shared.Metadata{},
+	), "test-topic-arn"))
+
+	relay := outbox.NewRelay(outboxStore, eventPublisher, testRelayConfig())
+	eventPublisher.FailNextPublishes(3)
+
+	// Act - every in-pass retry fails
+	dispatched, err := relay.DispatchPending(context.Background())
+
+	// Assert
+	require.Error(t, err)
+	assert.Equal(t, 0, dispatched)
+
+	pending := outboxStore.GetPending()
+	require.Len(t, pending, 1)
+	assert.Equal(t, 1, pending[0].Attempts)
+	assert.Equal(t, "publisher unavailable", pending[0].LastError)
+	assert.Equal(t, int64(1), relay.Stats().Failed)
+	assert.Greater(t, relay.Stats().Lag, time.Duration(0))
+
+	// Act - the bus is back
+	dispatched, err = relay.DispatchPending(context.Background())
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Empty(t, outboxStore.GetPending())
+	assert.Len(t, eventPublisher.GetEventsByType("PaymentRequested"), 1)
+}
+
+func TestOutboxRelay_MarksUnparseableEntriesDead(t *testing.T) {
+	// Arrange: an entry no version of the parser can read, queued before a good one
+	outboxStore := fakes.NewOutboxStoreFake()
+	eventPublisher := fakes.NewEventPublisherFake()
+	outboxStore.AddEntry(port.OutboxEntry{
+		ID:        "poison-1",
+		EventType: "PaymentTeleported",
+		TopicArn:  "test-topic-arn",
+		Payload:   `{}`,
+		CreatedAt: time.Now().UTC().Add(-time.Minute),
+	})
+	require.NoError(t, outbox.NewPublisher(outboxStore).Publish(context.Background(),
+		payment.NewExternalPaymentSucceededEvent("payment-1", "external-tx-456", shared.Metadata{}),
+		"test-topic-arn",
+	))
+
+	relay := outbox.NewRelay(outboxStore, eventPublisher, testRelayConfig())
+
+	// Act
+	dispatched, err := relay.DispatchPending(context.Background())
+
+	// Assert: the poison entry leaves the pending list instead of blocking the ones behind it
+	require.NoError(t, err)
+	assert.Equal(t, 1, dispatched)
+	assert.Empty(t, outboxStore.GetPending())
+	assert.Len(t, eventPublisher.GetEventsByType("ExternalPaymentSucceeded"), 1)
+
+	dead := outboxStore.GetDead()
+	require.Len(t, dead, 1)
+	assert.Equal(t, "poison-1", dead[0].ID)
+	assert.Contains(t, dead[0].LastError, "unknown event type")
+	assert.Equal(t, int64(1), relay.Stats().Dead)
+	assert.Equal(t, time.Duration(0), relay.Stats().Lag)
+}
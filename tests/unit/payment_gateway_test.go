@@ -0,0 +1,191 @@
+package unit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	"github.com/franco/payment-api/internal/infrastructure/gateway"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testGatewayKey = "test-gateway-key"
+
+func newStubGateway(t *testing.T) (*gateway.StubServer, *gateway.HTTPGateway) {
+	t.Helper()
+
+	stub := gateway.NewStubServer(testGatewayKey)
+	server := httptest.NewServer(stub)
+	t.Cleanup(server.Close)
+
+	return stub, gateway.NewHTTPGateway(server.URL, testGatewayKey, time.Second)
+}
+
+func newGatewayHandler(gw port.PaymentGateway, stores *fakes.Stores) *orchestrator.ExternalPaymentHandler {
+	return orchestrator.NewExternalPaymentHandler(
+		map[string]port.PaymentGateway{"stub": gw},
+		orchestrator.NewSingleGatewayRouter("stub"),
+		stores.UnitOfWork,
+		"test-topic-arn",
+	)
+}
+
+func chargeFor(serviceID string) port.ChargeRequest {
+	return port.ChargeRequest{
+		PaymentID:      "payment-1",
+		IdempotencyKey: "payment-1",
+		UserID:         "user-123",
+		ServiceID:      serviceID,
+		Amount:         decimal.RequireFromString("100.50"),
+		Currency:       "ARS",
+	}
+}
+
+func TestHTTPGateway_ChargeOutcomes(t *testing.T) {
+	tests := []struct {
+		name          string
+		serviceID     string
+		wantStatus    port.ChargeStatus
+		wantErrorCode domerrors.ErrorCode
+	}{
+		{name: "approved", serviceID: "service-123", wantStatus: port.ChargeApproved},
+		{name: "declined", serviceID: gateway.StubDeclinePrefix + "service", wantStatus: port.ChargeDeclined},
+		{name: "pending", serviceID: gateway.StubPendingPrefix + "service", wantStatus: port.ChargePending},
+		{name: "unavailable", serviceID: gateway.StubUnavailablePrefix + "service", wantErrorCode: domerrors.ErrCodeGatewayUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, gw := newStubGateway(t)
+
+			result, err := gw.Charge(context.Background(), chargeFor(tt.serviceID))
+
+			if tt.wantErrorCode != "" {
+				require.Error(t, err)
+				assert.True(t, domerrors.IsErrorCode(err, tt.wantErrorCode))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantStatus, result.Status)
+		})
+	}
+}
+
+func TestHTTPGateway_ReplaysChargeForSameIdempotencyKey(t *testing.T) {
+	// Arrange
+	stub, gw := newStubGateway(t)
+
+	// Act
+	first, err := gw.Charge(context.Background(), chargeFor("service-123"))
+	require.NoError(t, err)
+	second, err := gw.Charge(context.Background(), chargeFor("service-123"))
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, first.TransactionID, second.TransactionID)
+	assert.Equal(t, 1, stub.ChargeCount())
+}
+
+func TestHTTPGateway_WrongAPIKeyIsNotADecline(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(gateway.NewStubServer(testGatewayKey))
+	defer server.Close()
+	gw := gateway.NewHTTPGateway(server.URL, "wrong-key", time.Second)
+
+	// Act
+	_, err := gw.Charge(context.Background(), chargeFor("service-123"))
+
+	// Assert: misconfiguration must be retried, never turned into a failed payment
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeExternalGatewayError))
+}
+
+func TestHTTPGateway_ConnectionRefusedIsUnavailable(t *testing.T) {
+	// Arrange: a server that is already gone
+	server := httptest.NewServer(gateway.NewStubServer(testGatewayKey))
+	server.Close()
+	gw := gateway.NewHTTPGateway(server.URL, testGatewayKey, time.Second)
+
+	// Act
+	_, err := gw.Charge(context.Background(), chargeFor("service-123"))
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeGatewayUnavailable))
+}
+
+func TestHTTPGateway_Timeout(t *testing.T) {
+	// Arrange
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer server.Close()
+	gw := gateway.NewHTTPGateway(server.URL, testGatewayKey, 50*time.Millisecond)
+
+	// Act
+	_, err := gw.Charge(context.Background(), chargeFor("service-123"))
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeExternalTimeout))
+}
+
+func TestExternalPaymentHandler_MapsGatewayAnswerToEvents(t *testing.T) {
+	tests := []struct {
+		name      string
+		serviceID string
+		wantEvent string
+	}{
+		{name: "approved", serviceID: "service-123", wantEvent: "ExternalPaymentSucceeded"},
+		{name: "declined", serviceID: gateway.StubDeclinePrefix + "service", wantEvent: "ExternalPaymentFailed"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			_, gw := newStubGateway(t)
+			stores := fakes.NewStores()
+			handler := newGatewayHandler(gw, stores)
+
+			requested := payment.NewExternalPaymentRequestedEvent(
+				"payment-1", "user-123", decimal.RequireFromString("100.50"), "ARS", tt.serviceID, shared.Metadata{},
+			)
+
+			// Act
+			err := handler.HandleExternalPaymentRequested(context.Background(), requested)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Len(t, queued(t, stores.Outbox, tt.wantEvent), 1)
+		})
+	}
+}
+
+func TestExternalPaymentHandler_UnknownOutcomeIsRetried(t *testing.T) {
+	// Arrange
+	_, gw := newStubGateway(t)
+	stores := fakes.NewStores()
+	handler := newGatewayHandler(gw, stores)
+
+	requested := payment.NewExternalPaymentRequestedEvent(
+		"payment-1", "user-123", decimal.RequireFromString("100.50"), "ARS", gateway.StubUnavailablePrefix+"service", shared.Metadata{},
+	)
+
+	// Act
+	err := handler.HandleExternalPaymentRequested(context.Background(), requested)
+
+	// Assert: no result event, the SQS message stays for redelivery
+	require.Error(t, err)
+	assert.Empty(t, queued(t, stores.Outbox, "ExternalPaymentFailed"))
+	assert.Empty(t, queued(t, stores.Outbox, "ExternalPaymentSucceeded"))
+}
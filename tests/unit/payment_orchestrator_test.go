@@ -3,9 +3,11 @@ package unit
 import (
 	"context"
 	"testing"
+	"time"
 
 	"github.com/franco/payment-api/internal/application/orchestrator"
 	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/saga"
 	"github.com/franco/payment-api/internal/domain/shared"
 	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
 	"github.com/franco/payment-api/internal/domain/wallet"
@@ -17,23 +19,23 @@ import (
 
 func TestPaymentOrchestrator_InsufficientFunds(t *testing.T) {
 	// Arrange
-	paymentRepo := fakes.NewPaymentRepositoryFake()
-	walletRepo := fakes.NewWalletRepositoryFake()
-	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
+	stores := fakes.NewStores()
 
 	// Seed wallet with low balance
 	userID, _ := vo.NewUserID("user-123")
 	balance := vo.MustNewMoney("50.00", "ARS")
 	wallet, _ := wallet.NewWallet(userID, balance)
-	walletRepo.SetWallet(wallet)
+	stores.Wallets.SetWallet(wallet)
 
 	orch := orchestrator.NewPaymentOrchestrator(
-		paymentRepo,
-		walletRepo,
-		eventStore,
-		eventPublisher,
+		stores.Payments,
+		stores.Wallets,
+		stores.Sagas,
+		orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events,
+		stores.UnitOfWork,
 		"test-topic-arn",
+		time.Minute,
 	)
 
 	// Create payment that requires more than available balance
@@ -42,7 +44,7 @@ func TestPaymentOrchestrator_InsufficientFunds(t *testing.T) {
 	amount := vo.MustNewMoney("100.00", "ARS")
 	idempKey, _ := vo.NewIdempotencyKey("key-123")
 	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, amount, idempKey)
-	paymentRepo.Save(context.Background(), pmt)
+	stores.Payments.Save(context.Background(), pmt)
 
 	metadata := shared.Metadata{
 		ClientID:  "test-client",
@@ -53,7 +55,7 @@ func TestPaymentOrchestrator_InsufficientFunds(t *testing.T) {
 	event := payment.NewPaymentRequestedEvent(
 		paymentID.String(),
 		"user-123",
-		100.00,
+		decimal.RequireFromString("100.00"),
 		"ARS",
 		"service-123",
 		"key-123",
@@ -66,35 +68,35 @@ func TestPaymentOrchestrator_InsufficientFunds(t *testing.T) {
 	// Assert
 	require.NoError(t, err)
 
-	// Verify payment was marked as failed
-	updatedPayment, _ := paymentRepo.FindByID(context.Background(), paymentID.String())
-	assert.True(t, updatedPayment.Status().IsFailed())
+	// Verify payment was rejected before holding any funds
+	updatedPayment, _ := stores.Payments.FindByID(context.Background(), paymentID.String())
+	assert.True(t, updatedPayment.Status().IsRejected())
 	assert.Equal(t, "INSUFFICIENT_FUNDS", updatedPayment.FailureReason())
 
 	// Verify PaymentFailed event was published
-	events := eventPublisher.GetEventsByType("PaymentFailed")
+	events := queued(t, stores.Outbox, "PaymentFailed")
 	assert.Len(t, events, 1)
 }
 
-func TestPaymentOrchestrator_SuccessfulDebit(t *testing.T) {
+func TestPaymentOrchestrator_SuccessfulHold(t *testing.T) {
 	// Arrange
-	paymentRepo := fakes.NewPaymentRepositoryFake()
-	walletRepo := fakes.NewWalletRepositoryFake()
-	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
+	stores := fakes.NewStores()
 
 	// Seed wallet with sufficient balance
 	userID, _ := vo.NewUserID("user-123")
 	balance := vo.MustNewMoney("500.00", "ARS")
-	wallet, _ := wallet.NewWallet(userID, balance)
-	walletRepo.SetWallet(wallet)
+	wlt, _ := wallet.NewWallet(userID, balance)
+	stores.Wallets.SetWallet(wlt)
 
 	orch := orchestrator.NewPaymentOrchestrator(
-		paymentRepo,
-		walletRepo,
-		eventStore,
-		eventPublisher,
+		stores.Payments,
+		stores.Wallets,
+		stores.Sagas,
+		orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events,
+		stores.UnitOfWork,
 		"test-topic-arn",
+		time.Minute,
 	)
 
 	paymentID := vo.GeneratePaymentID()
@@ -102,7 +104,7 @@ func TestPaymentOrchestrator_SuccessfulDebit(t *testing.T) {
 	amount := vo.MustNewMoney("100.00", "ARS")
 	idempKey, _ := vo.NewIdempotencyKey("key-123")
 	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, amount, idempKey)
-	paymentRepo.Save(context.Background(), pmt)
+	stores.Payments.Save(context.Background(), pmt)
 
 	metadata := shared.Metadata{
 		ClientID:  "test-client",
@@ -113,7 +115,7 @@ func TestPaymentOrchestrator_SuccessfulDebit(t *testing.T) {
 	event := payment.NewPaymentRequestedEvent(
 		paymentID.String(),
 		"user-123",
-		100.00,
+		decimal.RequireFromString("100.00"),
 		"ARS",
 		"service-123",
 		"key-123",
@@ -126,32 +128,35 @@ func TestPaymentOrchestrator_SuccessfulDebit(t *testing.T) {
 	// Assert
 	require.NoError(t, err)
 
-	// Verify wallet was debited
-	updatedWallet, _ := walletRepo.GetByUserID(context.Background(), "user-123")
-	expectedBalance := decimal.NewFromFloat(400.00)
-	assert.True(t, updatedWallet.Balance().Amount().Equal(expectedBalance))
+	// Verify the funds were held, not debited
+	updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, updatedWallet.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.True(t, updatedWallet.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.True(t, updatedWallet.HasHold(paymentID.String()))
 
 	// Verify events were published
-	debitedEvents := eventPublisher.GetEventsByType("WalletDebited")
-	assert.Len(t, debitedEvents, 1)
+	heldEvents := queued(t, stores.Outbox, "WalletFundsHeld")
+	require.Len(t, heldEvents, 1)
+	assert.Equal(t, "400", heldEvents[0].(*wallet.WalletFundsHeldEvent).AvailableBalance().String())
+	assert.Empty(t, queued(t, stores.Outbox, "WalletDebited"))
 
-	externalEvents := eventPublisher.GetEventsByType("ExternalPaymentRequested")
+	externalEvents := queued(t, stores.Outbox, "ExternalPaymentRequested")
 	assert.Len(t, externalEvents, 1)
 }
 
 func TestPaymentOrchestrator_ExternalPaymentSuccess(t *testing.T) {
 	// Arrange
-	paymentRepo := fakes.NewPaymentRepositoryFake()
-	walletRepo := fakes.NewWalletRepositoryFake()
-	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
+	stores := fakes.NewStores()
 
 	orch := orchestrator.NewPaymentOrchestrator(
-		paymentRepo,
-		walletRepo,
-		eventStore,
-		eventPublisher,
+		stores.Payments,
+		stores.Wallets,
+		stores.Sagas,
+		orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events,
+		stores.UnitOfWork,
 		"test-topic-arn",
+		time.Minute,
 	)
 
 	paymentID := vo.GeneratePaymentID()
@@ -160,7 +165,16 @@ func TestPaymentOrchestrator_ExternalPaymentSuccess(t *testing.T) {
 	amount := vo.MustNewMoney("100.00", "ARS")
 	idempKey, _ := vo.NewIdempotencyKey("key-123")
 	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, amount, idempKey)
-	paymentRepo.Save(context.Background(), pmt)
+	pmt.MarkProcessing()
+	stores.Payments.Save(context.Background(), pmt)
+	stores.Sagas.SeedSaga(paymentID.String(), time.Now().Add(time.Minute),
+		fakes.SagaMove{EventType: "PaymentRequested", To: saga.StepAwaitingGateway},
+	)
+
+	// Seed wallet holding the payment's funds
+	wallet, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+	wallet.PlaceHold(paymentID.String(), amount)
+	stores.Wallets.SetWallet(wallet)
 
 	metadata := shared.Metadata{
 		ClientID:  "test-client",
@@ -181,71 +195,106 @@ func TestPaymentOrchestrator_ExternalPaymentSuccess(t *testing.T) {
 	require.NoError(t, err)
 
 	// Verify payment was marked as completed
-	updatedPayment, _ := paymentRepo.FindByID(context.Background(), paymentID.String())
+	updatedPayment, _ := stores.Payments.FindByID(context.Background(), paymentID.String())
 	assert.True(t, updatedPayment.Status().IsCompleted())
 	assert.Equal(t, "external-tx-456", updatedPayment.ExternalTxID())
 
+	// Verify the hold was captured
+	updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, updatedWallet.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.True(t, updatedWallet.HeldBalance(vo.ARS).IsZero())
+	assert.Len(t, queued(t, stores.Outbox, "WalletHoldCaptured"), 1)
+
 	// Verify PaymentCompleted event was published
-	events := eventPublisher.GetEventsByType("PaymentCompleted")
+	events := queued(t, stores.Outbox, "PaymentCompleted")
 	assert.Len(t, events, 1)
 }
 
-func TestPaymentOrchestrator_RefundFlow(t *testing.T) {
-	// Arrange
-	paymentRepo := fakes.NewPaymentRepositoryFake()
-	walletRepo := fakes.NewWalletRepositoryFake()
-	eventStore := fakes.NewEventStoreFake()
-	eventPublisher := fakes.NewEventPublisherFake()
-
-	userID, _ := vo.NewUserID("user-123")
-	balance := vo.MustNewMoney("400.00", "ARS")
-	wallet, _ := wallet.NewWallet(userID, balance)
-	walletRepo.SetWallet(wallet)
-
-	orch := orchestrator.NewPaymentOrchestrator(
-		paymentRepo,
-		walletRepo,
-		eventStore,
-		eventPublisher,
-		"test-topic-arn",
-	)
-
-	paymentID := vo.GeneratePaymentID()
-	serviceID, _ := vo.NewServiceID("service-123")
-	amount := vo.MustNewMoney("100.00", "ARS")
-	idempKey, _ := vo.NewIdempotencyKey("key-123")
-	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, amount, idempKey)
-
-	// Mark payment as failed so it can be refunded
-	pmt.MarkFailed("EXTERNAL_FAILURE")
-	paymentRepo.Save(context.Background(), pmt)
-
-	metadata := shared.Metadata{
-		ClientID:  "test-client",
-		RequestID: "req-123",
-		Source:    "test",
+func TestPaymentOrchestrator_CompensationFlow(t *testing.T) {
+	tests := []struct {
+		name          string
+		held          bool // payment authorized with a hold
+		legacyDebit   bool // payment debited outright before holds existed
+		wantBalance   float64
+		wantEvent     string
+		wantAction    string
+		wantNoEventOf string
+	}{
+		{name: "hold is released", held: true, wantBalance: 500.00, wantEvent: "WalletHoldReleased", wantAction: saga.CompensationHoldRelease, wantNoEventOf: "WalletCredited"},
+		{name: "legacy debit is refunded", legacyDebit: true, wantBalance: 500.00, wantEvent: "WalletCredited", wantAction: saga.CompensationWalletRefund, wantNoEventOf: "WalletHoldReleased"},
 	}
 
-	refundEvent := payment.NewPaymentRefundRequestedEvent(
-		paymentID.String(),
-		"user-123",
-		100.00,
-		"EXTERNAL_FAILURE",
-		metadata,
-	)
-
-	// Act
-	err := orch.HandlePaymentRefundRequested(context.Background(), refundEvent)
-
-	// Assert
-	require.NoError(t, err)
-
-	// Verify wallet was credited
-	updatedWallet, _ := walletRepo.GetByUserID(context.Background(), "user-123")
-	expectedBalance := decimal.NewFromFloat(500.00)
-	assert.True(t, updatedWallet.Balance().Amount().Equal(expectedBalance))
-
-	// Verify WalletCredited event was published
-	events := eventPublisher.GetEventsByType("WalletCredited")
-	assert.Len(t, events, 1)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stores := fakes.NewStores()
+
+			orch := orchestrator.NewPaymentOrchestrator(
+				stores.Payments,
+				stores.Wallets,
+				stores.Sagas,
+				orchestrator.NewSingleGatewayRouter("mock"),
+				stores.Events,
+				stores.UnitOfWork,
+				"test-topic-arn",
+				time.Minute,
+			)
+
+			userID, _ := vo.NewUserID("user-123")
+			paymentID := vo.GeneratePaymentID()
+			serviceID, _ := vo.NewServiceID("service-123")
+			amount := vo.MustNewMoney("100.00", "ARS")
+			idempKey, _ := vo.NewIdempotencyKey("key-123")
+			pmt, _ := payment.NewPayment(paymentID, userID, serviceID, amount, idempKey)
+
+			wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+			if tt.held {
+				wlt.PlaceHold(paymentID.String(), amount)
+			}
+			if tt.legacyDebit {
+				wlt.Debit(amount)
+				stores.Events.Append(context.Background(), wallet.NewWalletDebitedEvent(
+					paymentID.String(), "user-123",
+					decimal.RequireFromString("100.00"), decimal.RequireFromString("500.00"), decimal.RequireFromString("400.00"), "ARS", shared.Metadata{},
+				), paymentID.String())
+			}
+			stores.Wallets.SetWallet(wlt)
+
+			// Mark payment as failed so it can be compensated
+			pmt.MarkProcessing()
+			pmt.MarkFailed("EXTERNAL_FAILURE")
+			stores.Payments.Save(context.Background(), pmt)
+			stores.Sagas.SeedSaga(paymentID.String(), time.Now().Add(time.Minute),
+				fakes.SagaMove{EventType: "PaymentRequested", To: saga.StepAwaitingGateway},
+				fakes.SagaMove{EventType: "ExternalPaymentFailed", To: saga.StepCompensating},
+			)
+
+			refundEvent := payment.NewPaymentRefundRequestedEvent(
+				paymentID.String(),
+				"user-123",
+				decimal.RequireFromString("100.00"),
+				"ARS",
+				"EXTERNAL_FAILURE",
+				shared.Metadata{ClientID: "test-client", RequestID: "req-123", Source: "test"},
+			)
+
+			// Act
+			err := orch.HandlePaymentRefundRequested(context.Background(), refundEvent)
+
+			// Assert
+			require.NoError(t, err)
+
+			updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+			assert.True(t, updatedWallet.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(tt.wantBalance)))
+			assert.True(t, updatedWallet.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(tt.wantBalance)))
+
+			assert.Len(t, queued(t, stores.Outbox, tt.wantEvent), 1)
+			assert.Empty(t, queued(t, stores.Outbox, tt.wantNoEventOf))
+
+			sg, _ := stores.Sagas.FindByPaymentID(context.Background(), paymentID.String())
+			assert.Equal(t, saga.StepCompensated, sg.Step())
+			require.Len(t, sg.Compensations(), 1)
+			assert.Equal(t, tt.wantAction, sg.Compensations()[0].Action)
+		})
+	}
 }
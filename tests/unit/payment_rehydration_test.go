@@ -0,0 +1,128 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/internal/infrastructure/messaging/outbox"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/eventsourcing"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// runPaymentFlow creates a payment and drives it through the orchestrator up to the gateway result
+func runPaymentFlow(t *testing.T, gatewayResult func(paymentID string) shared.Event) (string, *fakes.PaymentRepositoryFake, *fakes.EventStoreFake) {
+	t.Helper()
+
+	stores := fakes.NewStores()
+
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+	stores.Wallets.SetWallet(wlt)
+
+	service := command.NewCreatePaymentService(
+		stores.PaymentUnitOfWork, stores.Wallets, stores.Idempotency, stores.Quotes, payment.NewEmptyFeeSchedule(), "test-topic-arn",
+	)
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"), stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	result, err := service.Execute(context.Background(), command.CreatePaymentRequest{
+		UserID:         "user-123",
+		Amount:         decimal.RequireFromString("100.50"),
+		Currency:       "ARS",
+		ServiceID:      "service-123",
+		IdempotencyKey: "key-123",
+		ClientID:       "web-app",
+	})
+	require.NoError(t, err)
+
+	// Deliver PaymentRequested from the outbox
+	_, err = outbox.NewRelay(stores.Outbox, stores.Publisher, outbox.DefaultRelayConfig()).DispatchPending(context.Background())
+	require.NoError(t, err)
+
+	requested := stores.Publisher.GetEventsByType("PaymentRequested")
+	require.Len(t, requested, 1)
+	require.NoError(t, orch.HandlePaymentRequested(context.Background(), requested[0]))
+
+	switch event := gatewayResult(result.PaymentID).(type) {
+	case *payment.ExternalPaymentSucceededEvent:
+		require.NoError(t, orch.HandleExternalPaymentSucceeded(context.Background(), event))
+	case *payment.ExternalPaymentFailedEvent:
+		require.NoError(t, orch.HandleExternalPaymentFailed(context.Background(), event))
+	}
+
+	return result.PaymentID, stores.Payments, stores.Events
+}
+
+func TestRehydratePayment_CompletedFlow(t *testing.T) {
+	// Arrange
+	paymentID, paymentRepo, eventStore := runPaymentFlow(t, func(paymentID string) shared.Event {
+		return payment.NewExternalPaymentSucceededEvent(paymentID, "external-tx-456", shared.Metadata{})
+	})
+	repo := eventsourcing.NewEventSourcedPaymentRepository(eventStore)
+
+	// Act
+	replayed, err := repo.FindByID(context.Background(), paymentID)
+
+	// Assert
+	require.NoError(t, err)
+	snapshot, _ := paymentRepo.FindByID(context.Background(), paymentID)
+	assert.True(t, replayed.Status().IsCompleted())
+	assert.Equal(t, "external-tx-456", replayed.ExternalTxID())
+	assert.True(t, replayed.Money().Equals(snapshot.Money()))
+	assert.Equal(t, snapshot.IdempotencyKey(), replayed.IdempotencyKey())
+	assert.False(t, replayed.CreatedAt().IsZero())
+}
+
+func TestRehydratePayment_FailedFlowMatchesSnapshot(t *testing.T) {
+	// Arrange
+	paymentID, paymentRepo, eventStore := runPaymentFlow(t, func(paymentID string) shared.Event {
+		return payment.NewExternalPaymentFailedEvent(paymentID, "GATEWAY_REJECTED", "ERR_DECLINED", shared.Metadata{})
+	})
+	audit := query.NewAuditPaymentService(paymentRepo, eventsourcing.NewEventSourcedPaymentRepository(eventStore))
+
+	// Act
+	result, err := audit.Execute(context.Background(), paymentID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.True(t, result.Consistent, "mismatches: %v", result.Mismatches)
+	assert.Equal(t, "FAILED", result.ReplayedStatus)
+}
+
+func TestAuditPayment_DetectsDrift(t *testing.T) {
+	// Arrange
+	paymentID, paymentRepo, eventStore := runPaymentFlow(t, func(paymentID string) shared.Event {
+		return payment.NewExternalPaymentSucceededEvent(paymentID, "external-tx-456", shared.Metadata{})
+	})
+
+	// Tamper with the snapshot row so it no longer matches the stream
+	snapshot, _ := paymentRepo.FindByID(context.Background(), paymentID)
+	paymentRepo.Save(context.Background(), payment.ReconstructPayment(
+		snapshot.ID(), snapshot.UserID(), snapshot.ServiceID(), snapshot.Money(), snapshot.IdempotencyKey(),
+		vo.PaymentStatusFailed, snapshot.RefundedAmount(), snapshot.Fees(), nil, "MANUAL_EDIT", "", snapshot.CreatedAt(), snapshot.UpdatedAt(),
+	))
+
+	audit := query.NewAuditPaymentService(paymentRepo, eventsourcing.NewEventSourcedPaymentRepository(eventStore))
+
+	// Act
+	result, err := audit.Execute(context.Background(), paymentID)
+
+	// Assert
+	require.NoError(t, err)
+	assert.False(t, result.Consistent)
+	assert.Equal(t, "FAILED", result.SnapshotStatus)
+	assert.Equal(t, "COMPLETED", result.ReplayedStatus)
+	assert.Len(t, result.Mismatches, 3)
+}
@@ -0,0 +1,247 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/saga"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedPendingPayment stores a pending 100.00 ARS payment of user-123
+func seedPendingPayment(t *testing.T, paymentRepo *fakes.PaymentRepositoryFake) *payment.Payment {
+	t.Helper()
+
+	userID, _ := vo.NewUserID("user-123")
+	serviceID, _ := vo.NewServiceID("service-123")
+	idempKey, _ := vo.NewIdempotencyKey("key-123")
+	pmt, err := payment.NewPayment(vo.GeneratePaymentID(), userID, serviceID, vo.MustNewMoney("100.00", "ARS"), idempKey)
+	require.NoError(t, err)
+	paymentRepo.Save(context.Background(), pmt)
+	return pmt
+}
+
+// requestedEventFor builds the PaymentRequested the create service would have queued for pmt
+func requestedEventFor(pmt *payment.Payment) *payment.PaymentRequestedEvent {
+	return payment.NewPaymentRequestedEvent(
+		pmt.ID().String(), pmt.UserID().String(), pmt.Money().Amount(), pmt.Money().Currency().Code(),
+		pmt.ServiceID().String(), pmt.IdempotencyKey().String(), shared.Metadata{},
+	)
+}
+
+// queued reads back the events of a type the services queued in the outbox, oldest first
+func queued(t *testing.T, outbox *fakes.OutboxStoreFake, eventType string) []shared.Event {
+	t.Helper()
+	events, err := outbox.PendingEvents(eventType)
+	require.NoError(t, err)
+	return events
+}
+
+func TestPaymentSaga_TracksSuccessfulFlow(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+
+	// Act
+	require.NoError(t, orch.HandlePaymentRequested(ctx, requestedEventFor(pmt)))
+	afterHold := stores.Sagas.GetSaga(paymentID)
+	require.NoError(t, orch.HandleExternalPaymentSucceeded(ctx,
+		payment.NewExternalPaymentSucceededEvent(paymentID, "external-tx-456", shared.Metadata{})))
+
+	// Assert
+	assert.Equal(t, saga.StepAwaitingGateway, afterHold.Step())
+	assert.False(t, afterHold.Deadline().IsZero())
+
+	sg := stores.Sagas.GetSaga(paymentID)
+	assert.Equal(t, saga.StepCompleted, sg.Step())
+	assert.True(t, sg.Deadline().IsZero())
+	require.Len(t, sg.History(), 2)
+	assert.Equal(t, saga.StepAwaitingDebit, sg.History()[0].From)
+	assert.Equal(t, "ExternalPaymentSucceeded", sg.History()[1].EventType)
+	assert.Empty(t, sg.Compensations())
+}
+
+func TestPaymentSaga_IgnoresDuplicatePaymentRequested(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+	require.NoError(t, orch.HandlePaymentRequested(ctx, requestedEventFor(pmt)))
+
+	// Act - a second copy of the event arrives outside the inbox (e.g. republished by hand)
+	err := orch.HandlePaymentRequested(ctx, requestedEventFor(pmt))
+
+	// Assert
+	require.NoError(t, err)
+	wlt, _ := stores.Wallets.GetByUserID(ctx, "user-123")
+	assert.True(t, wlt.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.Len(t, queued(t, stores.Outbox, "WalletFundsHeld"), 1)
+}
+
+func TestPaymentSaga_RedeliveryRunsStepWhoseCommitFailed(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+	stores.UnitOfWork.FailNextCommits(1)
+	require.Error(t, orch.HandlePaymentRequested(ctx, requestedEventFor(pmt)))
+	afterFailure := stores.Sagas.GetSaga(paymentID)
+
+	// Act - the queue redelivers the event
+	err := orch.HandlePaymentRequested(ctx, requestedEventFor(pmt))
+
+	// Assert - the failed commit left the saga where it was, so the step ran again
+	require.NoError(t, err)
+	assert.Equal(t, saga.StepAwaitingDebit, afterFailure.Step())
+
+	assert.Equal(t, saga.StepAwaitingGateway, stores.Sagas.GetSaga(paymentID).Step())
+	wlt, _ := stores.Wallets.GetByUserID(ctx, "user-123")
+	assert.True(t, wlt.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.Len(t, queued(t, stores.Outbox, "WalletFundsHeld"), 1)
+	assert.Len(t, queued(t, stores.Outbox, "ExternalPaymentRequested"), 1)
+}
+
+func TestPaymentSaga_TimeoutAfterCompletionIsStale(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+	require.NoError(t, orch.HandlePaymentRequested(ctx, requestedEventFor(pmt)))
+	require.NoError(t, orch.HandleExternalPaymentSucceeded(ctx,
+		payment.NewExternalPaymentSucceededEvent(paymentID, "external-tx-456", shared.Metadata{})))
+
+	// Act
+	err := orch.HandleExternalPaymentTimeout(ctx,
+		payment.NewExternalPaymentTimeoutEvent(paymentID, time.Minute, shared.Metadata{}))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Empty(t, queued(t, stores.Outbox, "PaymentRefundRequested"))
+
+	updated, _ := stores.Payments.FindByID(ctx, paymentID)
+	assert.True(t, updated.Status().IsCompleted())
+	assert.Equal(t, saga.StepCompleted, stores.Sagas.GetSaga(paymentID).Step())
+}
+
+func TestPaymentSaga_RejectsOutOfOrderEvent(t *testing.T) {
+	// Arrange - the gateway answer arrives before the funds were held
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	stores.Sagas.SeedSaga(paymentID, time.Now().Add(time.Minute))
+
+	// Act
+	err := orch.HandleExternalPaymentSucceeded(context.Background(),
+		payment.NewExternalPaymentSucceededEvent(paymentID, "external-tx-456", shared.Metadata{}))
+
+	// Assert
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeSagaOutOfOrder))
+
+	updated, _ := stores.Payments.FindByID(context.Background(), paymentID)
+	assert.True(t, updated.Status().IsPending())
+	assert.Empty(t, queued(t, stores.Outbox, "PaymentCompleted"))
+}
+
+func TestPaymentSaga_RecordsCompensation(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	pmt := seedPendingPayment(t, stores.Payments)
+	paymentID := pmt.ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+	ctx := context.Background()
+	require.NoError(t, orch.HandlePaymentRequested(ctx, requestedEventFor(pmt)))
+	require.NoError(t, orch.HandleExternalPaymentFailed(ctx,
+		payment.NewExternalPaymentFailedEvent(paymentID, "GATEWAY_REJECTED", "GW_500", shared.Metadata{})))
+
+	refunds := queued(t, stores.Outbox, "PaymentRefundRequested")
+	require.Len(t, refunds, 1)
+
+	// Act
+	err := orch.HandlePaymentRefundRequested(ctx, refunds[0])
+
+	// Assert
+	require.NoError(t, err)
+
+	sg := stores.Sagas.GetSaga(paymentID)
+	assert.Equal(t, saga.StepCompensated, sg.Step())
+	require.Len(t, sg.Compensations(), 1)
+	assert.Equal(t, saga.CompensationHoldRelease, sg.Compensations()[0].Action)
+	assert.Equal(t, "GATEWAY_REJECTED", sg.Compensations()[0].Reason)
+
+	wlt, _ := stores.Wallets.GetByUserID(ctx, "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.True(t, wlt.HeldBalance(vo.ARS).IsZero())
+}
+
+func TestGetSagaService_ListStuck(t *testing.T) {
+	// Arrange
+	sagaRepo := fakes.NewSagaRepositoryFake()
+	past := time.Now().Add(-time.Minute)
+	stuckGateway := vo.GeneratePaymentID().String()
+	stuckDebit := vo.GeneratePaymentID().String()
+
+	sagaRepo.SeedSaga(stuckGateway, past, fakes.SagaMove{EventType: "PaymentRequested", To: saga.StepAwaitingGateway})
+	sagaRepo.SeedSaga(stuckDebit, past)
+	sagaRepo.SeedSaga(vo.GeneratePaymentID().String(), time.Now().Add(time.Hour))
+	sagaRepo.SeedSaga(vo.GeneratePaymentID().String(), past,
+		fakes.SagaMove{EventType: "PaymentRequested", To: saga.StepFailed},
+	)
+
+	service := query.NewGetSagaService(sagaRepo)
+
+	// Act
+	all, err := service.ListStuck(context.Background(), query.ListStuckSagasRequest{})
+	require.NoError(t, err)
+	awaitingGateway, err := service.ListStuck(context.Background(), query.ListStuckSagasRequest{Step: "AWAITING_GATEWAY"})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Len(t, all, 2)
+	require.Len(t, awaitingGateway, 1)
+	assert.Equal(t, stuckGateway, awaitingGateway[0].PaymentID)
+	assert.True(t, awaitingGateway[0].Stuck)
+}
@@ -0,0 +1,106 @@
+package unit
+
+import (
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/domain/payment"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPaymentStatus_TransitionTable(t *testing.T) {
+	// Every allowed move; any pair not listed must be rejected
+	allowed := map[vo.PaymentStatus][]vo.PaymentStatus{
+		vo.PaymentStatusPending:           {vo.PaymentStatusProcessing, vo.PaymentStatusRejected, vo.PaymentStatusCancelled},
+		vo.PaymentStatusProcessing:        {vo.PaymentStatusCompleted, vo.PaymentStatusFailed, vo.PaymentStatusExpired},
+		vo.PaymentStatusCompleted:         {vo.PaymentStatusPartiallyRefunded, vo.PaymentStatusRefunded},
+		vo.PaymentStatusPartiallyRefunded: {vo.PaymentStatusPartiallyRefunded, vo.PaymentStatusRefunded},
+	}
+	terminal := []vo.PaymentStatus{
+		vo.PaymentStatusRejected, vo.PaymentStatusFailed, vo.PaymentStatusExpired,
+		vo.PaymentStatusCancelled, vo.PaymentStatusRefunded,
+	}
+
+	for _, from := range vo.PaymentStatuses() {
+		for _, to := range vo.PaymentStatuses() {
+			want := false
+			for _, s := range allowed[from] {
+				want = want || s == to
+			}
+
+			assert.Equal(t, want, from.CanTransitionTo(to), "%s -> %s", from, to)
+			assert.Equal(t, want, from.ValidateTransition(to) == nil, "%s -> %s", from, to)
+		}
+		assert.Equal(t, len(allowed[from]) == 0, from.IsTerminal(), "%s terminal", from)
+	}
+	for _, s := range terminal {
+		assert.True(t, s.IsTerminal(), s.String())
+	}
+}
+
+func TestPaymentStatus_ParseAndMapperRoundTrip(t *testing.T) {
+	mapper := mappers.NewPaymentMapper()
+	userID, _ := vo.NewUserID("user-123")
+	serviceID, _ := vo.NewServiceID("service-123")
+	idempKey, _ := vo.NewIdempotencyKey("key-123")
+	now := time.Now().UTC().Truncate(time.Second)
+
+	for _, status := range vo.PaymentStatuses() {
+		t.Run(status.String(), func(t *testing.T) {
+			parsed, err := vo.ParsePaymentStatus(status.String())
+			require.NoError(t, err)
+			assert.Equal(t, status, parsed)
+
+			pmt := payment.ReconstructPayment(
+				vo.GeneratePaymentID(), userID, serviceID, vo.MustNewMoney("100.00", "ARS"), idempKey,
+				status, vo.Zero(vo.MustNewCurrency("ARS")), nil, nil, "", "", now, now,
+			)
+			model, err := mapper.ToDBModel(pmt)
+			require.NoError(t, err)
+			restored, err := mapper.ToDomain(model)
+			require.NoError(t, err)
+			assert.Equal(t, status, restored.Status())
+		})
+	}
+
+	_, err := vo.ParsePaymentStatus("SETTLED")
+	assert.Error(t, err)
+}
+
+func TestPayment_FailureStatesSeparateRejectionFromFailure(t *testing.T) {
+	tests := []struct {
+		name       string
+		processing bool
+		fail       func(p *payment.Payment) error
+		want       vo.PaymentStatus
+	}{
+		{name: "rejected before hold", fail: func(p *payment.Payment) error { return p.Reject("INSUFFICIENT_FUNDS") }, want: vo.PaymentStatusRejected},
+		{name: "declined after hold", processing: true, fail: func(p *payment.Payment) error { return p.MarkFailed("CARD_DECLINED") }, want: vo.PaymentStatusFailed},
+		{name: "gateway timeout", processing: true, fail: func(p *payment.Payment) error { return p.MarkFailed(payment.FailureReasonTimeout) }, want: vo.PaymentStatusExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			userID, _ := vo.NewUserID("user-123")
+			serviceID, _ := vo.NewServiceID("service-123")
+			idempKey, _ := vo.NewIdempotencyKey("key-123")
+			pmt, err := payment.NewPayment(vo.GeneratePaymentID(), userID, serviceID, vo.MustNewMoney("100.00", "ARS"), idempKey)
+			require.NoError(t, err)
+			if tt.processing {
+				require.NoError(t, pmt.MarkProcessing())
+			}
+
+			// Act
+			err = tt.fail(pmt)
+
+			// Assert
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, pmt.Status())
+			assert.Equal(t, tt.processing, pmt.Status().IsCompensable())
+		})
+	}
+}
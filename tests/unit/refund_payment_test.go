@@ -0,0 +1,258 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/domain/ledger"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// seedCompletedPayment stores a completed 100.00 ARS payment of user-123
+func seedCompletedPayment(t *testing.T, paymentRepo *fakes.PaymentRepositoryFake) *payment.Payment {
+	t.Helper()
+
+	pmt := seedPendingPayment(t, paymentRepo)
+	require.NoError(t, pmt.MarkProcessing())
+	require.NoError(t, pmt.MarkCompleted("external-tx-456"))
+	paymentRepo.Save(context.Background(), pmt)
+	return pmt
+}
+
+func TestRefundPayment_PartialThenRemainder(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	paymentID := seedCompletedPayment(t, stores.Payments).ID().String()
+	service := command.NewRefundPaymentService(stores.Payments, stores.PaymentUnitOfWork, stores.Idempotency, "test-topic-arn")
+	ctx := context.Background()
+
+	// Act
+	partial, err := service.Execute(ctx, command.RefundPaymentRequest{
+		PaymentID: paymentID, Amount: decimal.RequireFromString("30"), Reason: "SERVICE_NOT_DELIVERED", IdempotencyKey: "refund-1",
+	})
+	require.NoError(t, err)
+	rest, err := service.Execute(ctx, command.RefundPaymentRequest{
+		PaymentID: paymentID, IdempotencyKey: "refund-2",
+	})
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "PARTIALLY_REFUNDED", partial.Status)
+	assert.Equal(t, "30", partial.RefundedAmount.String())
+	assert.Equal(t, "70", partial.RefundableAmount.String())
+
+	assert.Equal(t, "REFUNDED", rest.Status)
+	assert.Equal(t, "70", rest.Amount.String())
+	assert.Equal(t, "100", rest.RefundedAmount.String())
+	assert.True(t, rest.RefundableAmount.IsZero())
+
+	pmt, _ := stores.Payments.FindByID(ctx, paymentID)
+	assert.True(t, pmt.Status().IsRefunded())
+	assert.True(t, pmt.Status().IsTerminal())
+
+	refunds, err := stores.Outbox.PendingEvents("PaymentRefunded")
+	require.NoError(t, err)
+	require.Len(t, refunds, 2)
+	first := refunds[0].(*payment.PaymentRefundedEvent)
+	assert.Equal(t, "SERVICE_NOT_DELIVERED", first.Reason())
+	assert.Equal(t, partial.RefundID, first.RefundID())
+	assert.Equal(t, "30", first.RefundedTotal().String())
+}
+
+func TestRefundPayment_Rejected(t *testing.T) {
+	tests := []struct {
+		name     string
+		pending  bool
+		previous float64 // refunded before the request under test
+		amount   float64
+		wantCode domerrors.ErrorCode
+	}{
+		{name: "pending payment", pending: true, amount: 10, wantCode: domerrors.ErrCodePaymentNotRefundable},
+		{name: "more than paid", amount: 100.01, wantCode: domerrors.ErrCodeRefundExceedsAmount},
+		{name: "more than left", previous: 80, amount: 30, wantCode: domerrors.ErrCodeRefundExceedsAmount},
+		{name: "already refunded", previous: 100, wantCode: domerrors.ErrCodePaymentNotRefundable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stores := fakes.NewStores()
+			seed := seedCompletedPayment
+			if tt.pending {
+				seed = seedPendingPayment
+			}
+			paymentID := seed(t, stores.Payments).ID().String()
+			service := command.NewRefundPaymentService(stores.Payments, stores.PaymentUnitOfWork, stores.Idempotency, "test-topic-arn")
+			if tt.previous > 0 {
+				_, err := service.Execute(context.Background(), command.RefundPaymentRequest{
+					PaymentID: paymentID, Amount: decimal.NewFromFloat(tt.previous), IdempotencyKey: "previous",
+				})
+				require.NoError(t, err)
+			}
+
+			// Act
+			_, err := service.Execute(context.Background(), command.RefundPaymentRequest{
+				PaymentID: paymentID, Amount: decimal.NewFromFloat(tt.amount), IdempotencyKey: "refund-1",
+			})
+
+			// Assert
+			require.Error(t, err)
+			assert.True(t, domerrors.IsErrorCode(err, tt.wantCode), "got %v", err)
+		})
+	}
+}
+
+func TestRefundPayment_SameKeyRefundsOnce(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	paymentID := seedCompletedPayment(t, stores.Payments).ID().String()
+	service := command.NewRefundPaymentService(stores.Payments, stores.PaymentUnitOfWork, stores.Idempotency, "test-topic-arn")
+	req := command.RefundPaymentRequest{PaymentID: paymentID, Amount: decimal.RequireFromString("40"), IdempotencyKey: "refund-1"}
+
+	// Act
+	first, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	second, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "PARTIALLY_REFUNDED", first.Status)
+	assert.Equal(t, "ALREADY_PROCESSED", second.Status)
+	assert.Len(t, stores.Outbox.GetPendingByType("PaymentRefunded"), 1)
+
+	pmt, _ := stores.Payments.FindByID(context.Background(), paymentID)
+	assert.True(t, pmt.RefundedAmount().Amount().Equal(decimal.NewFromFloat(40)))
+}
+
+func TestPaymentOrchestrator_CreditsRefundOnce(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "400.00"))
+	paymentID := seedCompletedPayment(t, stores.Payments).ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	refunded := payment.NewPaymentRefundedEvent(paymentID, "user-123", "refund-1", decimal.RequireFromString("30"), decimal.RequireFromString("30"), "ARS", "SERVICE_NOT_DELIVERED", shared.Metadata{})
+
+	// Act: the second delivery finds the credit on the payment stream
+	require.NoError(t, orch.HandlePaymentRefunded(context.Background(), refunded))
+	require.NoError(t, orch.HandlePaymentRefunded(context.Background(), refunded))
+
+	// Assert
+	wlt, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(430.00)))
+
+	credited := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, credited, 1)
+	assert.Equal(t, "refund-1", credited[0].Metadata().Extra["refundId"])
+}
+
+func TestPaymentOrchestrator_RefundCreditIsAllOrNothing(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "400.00"))
+	paymentID := seedCompletedPayment(t, stores.Payments).ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	refunded := payment.NewPaymentRefundedEvent(paymentID, "user-123", "refund-1", decimal.RequireFromString("30"), decimal.RequireFromString("30"), "ARS", "SERVICE_NOT_DELIVERED", shared.Metadata{})
+
+	// Act: the first delivery fails to commit
+	stores.UnitOfWork.FailNextCommits(1)
+	require.Error(t, orch.HandlePaymentRefunded(context.Background(), refunded))
+
+	// Assert: neither the credit nor its event were written
+	wlt, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.Empty(t, stores.Wallets.Ledger().Entries())
+	assert.Empty(t, queued(t, stores.Outbox, "WalletCredited"))
+
+	// Act: the redelivery credits the refund
+	require.NoError(t, orch.HandlePaymentRefunded(context.Background(), refunded))
+
+	// Assert
+	wlt, _ = stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(430.00)))
+
+	credited := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, credited, 1)
+	creditedEvent := credited[0].(*wallet.WalletCreditedEvent)
+	assert.Equal(t, "400", creditedEvent.PrevBalance().String())
+	assert.Equal(t, "430", creditedEvent.NewBalance().String())
+}
+
+func TestPaymentOrchestrator_RecordsRefundCreditedWithoutItsEvent(t *testing.T) {
+	// Arrange: the credit was posted by a build that wrote its event separately and stopped before it
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "400.00"))
+	paymentID := seedCompletedPayment(t, stores.Payments).ID().String()
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"),
+		stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	credit := vo.MustNewMoney("30.00", "ARS")
+	entry, err := ledger.NewRefundEntry("refund-1", paymentID, "user-123", credit, credit, nil)
+	require.NoError(t, err)
+	wlt, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	_, _, err = wlt.Credit(credit)
+	require.NoError(t, err)
+	require.NoError(t, wlt.RecordEntry(entry))
+	require.NoError(t, stores.Wallets.Update(context.Background(), wlt))
+
+	refunded := payment.NewPaymentRefundedEvent(paymentID, "user-123", "refund-1", decimal.RequireFromString("30"), decimal.RequireFromString("30"), "ARS", "SERVICE_NOT_DELIVERED", shared.Metadata{})
+
+	// Act
+	err = orch.HandlePaymentRefunded(context.Background(), refunded)
+
+	// Assert: the event is recorded instead of failing on the posted entry, without crediting again
+	require.NoError(t, err)
+	wlt, _ = stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(430.00)))
+
+	credited := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, credited, 1)
+	creditedEvent := credited[0].(*wallet.WalletCreditedEvent)
+	assert.Equal(t, "400", creditedEvent.PrevBalance().String())
+	assert.Equal(t, "430", creditedEvent.NewBalance().String())
+
+	// Assert: a later delivery finds the event and does nothing
+	require.NoError(t, orch.HandlePaymentRefunded(context.Background(), refunded))
+	assert.Len(t, queued(t, stores.Outbox, "WalletCredited"), 1)
+}
+
+func TestRehydratePayment_ReplaysRefunds(t *testing.T) {
+	// Arrange
+	paymentID := vo.GeneratePaymentID().String()
+	events := []shared.Event{
+		payment.NewPaymentRequestedEvent(paymentID, "user-123", decimal.RequireFromString("100"), "ARS", "service-123", "key-123", shared.Metadata{}),
+		payment.NewExternalPaymentRequestedEvent(paymentID, "user-123", decimal.RequireFromString("100"), "ARS", "service-123", shared.Metadata{}),
+		payment.NewPaymentCompletedEvent(paymentID, "user-123", decimal.RequireFromString("100"), "ARS", "external-tx-456", shared.Metadata{}),
+		payment.NewPaymentRefundedEvent(paymentID, "user-123", "refund-1", decimal.RequireFromString("25"), decimal.RequireFromString("25"), "ARS", "", shared.Metadata{}),
+		payment.NewPaymentRefundedEvent(paymentID, "user-123", "refund-2", decimal.RequireFromString("75"), decimal.RequireFromString("100"), "ARS", "", shared.Metadata{}),
+	}
+
+	// Act
+	pmt, err := payment.RehydratePayment(events)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, vo.PaymentStatusRefunded, pmt.Status())
+	assert.True(t, pmt.RefundedAmount().Amount().Equal(decimal.NewFromFloat(100)))
+	assert.False(t, pmt.CanBeRefunded())
+}
@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/application/port"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func seedProcessingPayment(t *testing.T, paymentRepo *fakes.PaymentRepositoryFake) *payment.Payment {
+	t.Helper()
+
+	pmt := seedPendingPayment(t, paymentRepo)
+	require.NoError(t, pmt.MarkProcessing())
+	paymentRepo.Save(context.Background(), pmt)
+	return pmt
+}
+
+func TestTimeoutScheduler_FiresAfterDeadlineAcrossRestart(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	pmt := seedProcessingPayment(t, stores.Payments)
+
+	requested := payment.NewExternalPaymentRequestedEvent(
+		pmt.ID().String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", shared.Metadata{},
+	)
+
+	scheduler := orchestrator.NewTimeoutScheduler(
+		stores.Payments, stores.Timeouts, stores.UnitOfWork, "test-topic-arn", 30*time.Second,
+	)
+	require.NoError(t, scheduler.HandleExternalPaymentRequested(context.Background(), requested))
+
+	// Act - before the deadline nothing happens
+	fired, err := scheduler.FireDue(context.Background(), requested.OccurredAt().Add(10*time.Second))
+	require.NoError(t, err)
+	assert.Equal(t, 0, fired)
+
+	// Act - a new instance (after a restart) picks the deadline up from the store
+	restarted := orchestrator.NewTimeoutScheduler(
+		stores.Payments, stores.Timeouts, stores.UnitOfWork, "test-topic-arn", 30*time.Second,
+	)
+	fired, err = restarted.FireDue(context.Background(), requested.OccurredAt().Add(31*time.Second))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	assert.Equal(t, port.TimeoutOutcomeFired, stores.Timeouts.GetOutcome(pmt.ID().String()))
+
+	events := queued(t, stores.Outbox, "ExternalPaymentTimeout")
+	require.Len(t, events, 1)
+	timeoutEvent := events[0].(*payment.ExternalPaymentTimeoutEvent)
+	assert.Equal(t, pmt.ID().String(), timeoutEvent.PaymentID())
+	assert.Equal(t, 30*time.Second, timeoutEvent.TimeoutDuration())
+
+	// Firing again is a no-op
+	fired, err = restarted.FireDue(context.Background(), requested.OccurredAt().Add(time.Minute))
+	require.NoError(t, err)
+	assert.Equal(t, 0, fired)
+}
+
+func TestTimeoutScheduler_SkipsTerminalPayments(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	pmt := seedProcessingPayment(t, stores.Payments)
+
+	scheduler := orchestrator.NewTimeoutScheduler(
+		stores.Payments, stores.Timeouts, stores.UnitOfWork, "test-topic-arn", 30*time.Second,
+	)
+	requested := payment.NewExternalPaymentRequestedEvent(
+		pmt.ID().String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", shared.Metadata{},
+	)
+	require.NoError(t, scheduler.HandleExternalPaymentRequested(context.Background(), requested))
+
+	// The gateway answered before the deadline
+	require.NoError(t, pmt.MarkCompleted("external-tx-456"))
+	stores.Payments.Update(context.Background(), pmt)
+
+	// Act
+	fired, err := scheduler.FireDue(context.Background(), requested.OccurredAt().Add(time.Minute))
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 0, fired)
+	assert.Equal(t, port.TimeoutOutcomeResolved, stores.Timeouts.GetOutcome(pmt.ID().String()))
+	assert.Empty(t, queued(t, stores.Outbox, "ExternalPaymentTimeout"))
+}
+
+func TestTimeoutScheduler_RedeliveryDoesNotReopenAndFailuresDoNotBlock(t *testing.T) {
+	// Arrange: two payments past their deadline, the first one due earlier
+	stores := fakes.NewStores()
+	first := seedProcessingPayment(t, stores.Payments)
+	second := seedProcessingPayment(t, stores.Payments)
+
+	scheduler := orchestrator.NewTimeoutScheduler(
+		stores.Payments, stores.Timeouts, stores.UnitOfWork, "test-topic-arn", 30*time.Second,
+	)
+	firstRequested := payment.NewExternalPaymentRequestedEvent(
+		first.ID().String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", shared.Metadata{},
+	)
+	secondRequested := payment.NewExternalPaymentRequestedEvent(
+		second.ID().String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", shared.Metadata{},
+	)
+	secondRequested.RestoreOccurredAt(firstRequested.OccurredAt().Add(time.Second))
+	require.NoError(t, scheduler.HandleExternalPaymentRequested(context.Background(), firstRequested))
+	require.NoError(t, scheduler.HandleExternalPaymentRequested(context.Background(), secondRequested))
+	now := firstRequested.OccurredAt().Add(time.Minute)
+
+	// Act - committing the first timeout fails
+	stores.UnitOfWork.FailNextCommits(1)
+	fired, err := scheduler.FireDue(context.Background(), now)
+
+	// Assert: the second one still fires and the first is due again
+	require.Error(t, err)
+	assert.Equal(t, 1, fired)
+	assert.Equal(t, "", stores.Timeouts.GetOutcome(first.ID().String()))
+	assert.Equal(t, port.TimeoutOutcomeFired, stores.Timeouts.GetOutcome(second.ID().String()))
+
+	// Act - the second request is redelivered after its timeout fired
+	require.NoError(t, scheduler.HandleExternalPaymentRequested(context.Background(), secondRequested))
+	fired, err = scheduler.FireDue(context.Background(), now)
+
+	// Assert: only the first one fires now, the second stays closed
+	require.NoError(t, err)
+	assert.Equal(t, 1, fired)
+	assert.Equal(t, port.TimeoutOutcomeFired, stores.Timeouts.GetOutcome(second.ID().String()))
+	assert.Len(t, queued(t, stores.Outbox, "ExternalPaymentTimeout"), 2)
+}
@@ -0,0 +1,120 @@
+package unit
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/franco/payment-api/internal/application/orchestrator"
+	"github.com/franco/payment-api/internal/domain/payment"
+	"github.com/franco/payment-api/internal/domain/shared"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWalletRepository_RejectsStaleUpdate(t *testing.T) {
+	// Arrange
+	walletRepo := fakes.NewWalletRepositoryFake()
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+	walletRepo.SetWallet(wlt)
+
+	// Two writers read the same version
+	first, _ := walletRepo.GetByUserID(context.Background(), "user-123")
+	second, _ := walletRepo.GetByUserID(context.Background(), "user-123")
+
+	_, _, err := first.Debit(vo.MustNewMoney("100.00", "ARS"))
+	require.NoError(t, err)
+	_, _, err = second.Debit(vo.MustNewMoney("100.00", "ARS"))
+	require.NoError(t, err)
+
+	// Act
+	firstErr := walletRepo.Update(context.Background(), first)
+	secondErr := walletRepo.Update(context.Background(), second)
+
+	// Assert
+	require.NoError(t, firstErr)
+	assert.Equal(t, int64(1), first.Version())
+	assert.True(t, domerrors.IsErrorCode(secondErr, domerrors.ErrCodeConcurrentModification))
+
+	stored, _ := walletRepo.GetByUserID(context.Background(), "user-123")
+	assert.True(t, stored.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+}
+
+func TestPaymentOrchestrator_RetriesHoldOnVersionConflict(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+	stores.Wallets.SetWallet(wlt)
+	stores.Wallets.FailNextUpdates(2)
+
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"), stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	paymentID := vo.GeneratePaymentID()
+	serviceID, _ := vo.NewServiceID("service-123")
+	idempKey, _ := vo.NewIdempotencyKey("key-123")
+	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, vo.MustNewMoney("100.00", "ARS"), idempKey)
+	stores.Payments.Save(context.Background(), pmt)
+
+	event := payment.NewPaymentRequestedEvent(
+		paymentID.String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", "key-123", shared.Metadata{},
+	)
+
+	// Act
+	err := orch.HandlePaymentRequested(context.Background(), event)
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, 3, stores.Wallets.UpdateCalls())
+
+	// Held exactly once despite the retries
+	updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, updatedWallet.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+	assert.Len(t, updatedWallet.Holds(), 1)
+	assert.Len(t, queued(t, stores.Outbox, "WalletFundsHeld"), 1)
+}
+
+func TestPaymentOrchestrator_GivesUpAfterRepeatedConflicts(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+
+	userID, _ := vo.NewUserID("user-123")
+	wlt, _ := wallet.NewWallet(userID, vo.MustNewMoney("500.00", "ARS"))
+	stores.Wallets.SetWallet(wlt)
+	stores.Wallets.FailNextUpdates(10)
+
+	orch := orchestrator.NewPaymentOrchestrator(
+		stores.Payments, stores.Wallets, stores.Sagas, orchestrator.NewSingleGatewayRouter("mock"), stores.Events, stores.UnitOfWork, "test-topic-arn", time.Minute,
+	)
+
+	paymentID := vo.GeneratePaymentID()
+	serviceID, _ := vo.NewServiceID("service-123")
+	idempKey, _ := vo.NewIdempotencyKey("key-123")
+	pmt, _ := payment.NewPayment(paymentID, userID, serviceID, vo.MustNewMoney("100.00", "ARS"), idempKey)
+	stores.Payments.Save(context.Background(), pmt)
+
+	event := payment.NewPaymentRequestedEvent(
+		paymentID.String(), "user-123", decimal.RequireFromString("100.00"), "ARS", "service-123", "key-123", shared.Metadata{},
+	)
+
+	// Act
+	err := orch.HandlePaymentRequested(context.Background(), event)
+
+	// Assert: the message is left for redelivery and nothing was held or published
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeConcurrentModification))
+	assert.Equal(t, 3, stores.Wallets.UpdateCalls())
+
+	updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, updatedWallet.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.Empty(t, queued(t, stores.Outbox, "WalletFundsHeld"))
+}
@@ -0,0 +1,122 @@
+package unit
+
+import (
+	"testing"
+
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newHoldWallet(t *testing.T, balance string) *wallet.Wallet {
+	t.Helper()
+	userID, _ := vo.NewUserID("user-123")
+	wlt, err := wallet.NewWallet(userID, vo.MustNewMoney(balance, "ARS"))
+	require.NoError(t, err)
+	return wlt
+}
+
+func TestWallet_HoldReservesAvailableBalance(t *testing.T) {
+	// Arrange
+	wlt := newHoldWallet(t, "500.00")
+
+	// Act
+	prev, next, err := wlt.PlaceHold("payment-1", vo.MustNewMoney("300.00", "ARS"))
+
+	// Assert: the balance is untouched, only the available part shrinks
+	require.NoError(t, err)
+	assert.True(t, prev.Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.True(t, next.Amount().Equal(decimal.NewFromFloat(200.00)))
+	assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(500.00)))
+	assert.True(t, wlt.HeldBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(300.00)))
+
+	// Held funds cannot be spent twice
+	assert.False(t, wlt.CanDebit(vo.MustNewMoney("250.00", "ARS")))
+	_, _, err = wlt.PlaceHold("payment-2", vo.MustNewMoney("250.00", "ARS"))
+	assert.Error(t, err)
+	_, _, err = wlt.Debit(vo.MustNewMoney("250.00", "ARS"))
+	assert.Error(t, err)
+}
+
+func TestWallet_PlacingSameHoldTwiceReservesOnce(t *testing.T) {
+	wlt := newHoldWallet(t, "500.00")
+
+	_, _, err := wlt.PlaceHold("payment-1", vo.MustNewMoney("100.00", "ARS"))
+	require.NoError(t, err)
+	_, _, err = wlt.PlaceHold("payment-1", vo.MustNewMoney("100.00", "ARS"))
+	require.NoError(t, err)
+
+	assert.Len(t, wlt.Holds(), 1)
+	assert.True(t, wlt.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(400.00)))
+}
+
+func TestWallet_SettleHold(t *testing.T) {
+	tests := []struct {
+		name          string
+		settle        func(w *wallet.Wallet) error
+		wantBalance   float64
+		wantAvailable float64
+	}{
+		{
+			name: "capture debits the balance",
+			settle: func(w *wallet.Wallet) error {
+				_, _, _, err := w.CaptureHold("payment-1")
+				return err
+			},
+			wantBalance:   400.00,
+			wantAvailable: 400.00,
+		},
+		{
+			name: "release gives the funds back",
+			settle: func(w *wallet.Wallet) error {
+				_, err := w.ReleaseHold("payment-1")
+				return err
+			},
+			wantBalance:   500.00,
+			wantAvailable: 500.00,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			wlt := newHoldWallet(t, "500.00")
+			_, _, err := wlt.PlaceHold("payment-1", vo.MustNewMoney("100.00", "ARS"))
+			require.NoError(t, err)
+
+			// Act
+			err = tt.settle(wlt)
+
+			// Assert
+			require.NoError(t, err)
+			assert.False(t, wlt.HasHold("payment-1"))
+			assert.True(t, wlt.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(tt.wantBalance)))
+			assert.True(t, wlt.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(tt.wantAvailable)))
+
+			// A settled hold cannot be settled again
+			assert.Error(t, tt.settle(wlt))
+		})
+	}
+}
+
+func TestWalletMapper_RoundTripsHolds(t *testing.T) {
+	// Arrange
+	wlt := newHoldWallet(t, "500.00")
+	_, _, err := wlt.PlaceHold("payment-1", vo.MustNewMoney("120.50", "ARS"))
+	require.NoError(t, err)
+	mapper := mappers.NewWalletMapper()
+
+	// Act
+	model, err := mapper.ToDBModel(wlt)
+	require.NoError(t, err)
+	restored, err := mapper.ToDomain(model)
+
+	// Assert
+	require.NoError(t, err)
+	require.Len(t, restored.Holds(), 1)
+	assert.Equal(t, "payment-1", restored.Holds()[0].PaymentID)
+	assert.True(t, restored.AvailableBalance(vo.ARS).Amount().Equal(decimal.NewFromFloat(379.50)))
+}
@@ -0,0 +1,291 @@
+package unit
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/query"
+	"github.com/franco/payment-api/internal/domain/ledger"
+	domerrors "github.com/franco/payment-api/internal/domain/shared/errors"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCreateWallet_RejectsDuplicate(t *testing.T) {
+	// Arrange
+	walletRepo := fakes.NewWalletRepositoryFake()
+	service := command.NewCreateWalletService(walletRepo)
+
+	req := command.CreateWalletRequest{UserID: "user-123", Currency: "ARS"}
+
+	// Act
+	result, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	_, err = service.Execute(context.Background(), req)
+
+	// Assert
+	assert.True(t, result.Balance.IsZero())
+	assert.Equal(t, "ARS", result.Currency)
+	require.Error(t, err)
+	assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeWalletAlreadyExists))
+}
+
+func TestCreateWallet_ConcurrentRequestsCreateOneWallet(t *testing.T) {
+	// Arrange: both requests pass the existence check before either saves
+	walletRepo := fakes.NewWalletRepositoryFake()
+	service := command.NewCreateWalletService(walletRepo)
+	req := command.CreateWalletRequest{UserID: "user-123", Currency: "ARS"}
+
+	// Act
+	var wg sync.WaitGroup
+	errs := make([]error, 5)
+	for i := range errs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, errs[i] = service.Execute(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert: the losers get WALLET_ALREADY_EXISTS instead of overwriting the winner
+	created := 0
+	for _, err := range errs {
+		if err == nil {
+			created++
+			continue
+		}
+		assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeWalletAlreadyExists))
+	}
+	assert.Equal(t, 1, created)
+}
+
+func TestTopUpWallet_IdempotentCredit(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+
+	_, err := command.NewCreateWalletService(stores.Wallets).Execute(context.Background(), command.CreateWalletRequest{
+		UserID:   "user-123",
+		Currency: "ARS",
+	})
+	require.NoError(t, err)
+
+	service := command.NewTopUpWalletService(
+		stores.Wallets,
+		stores.Wallets.Ledger(),
+		stores.Events,
+		stores.UnitOfWork,
+		"test-topic-arn",
+	)
+
+	req := command.TopUpWalletRequest{
+		UserID:         "user-123",
+		Amount:         decimal.RequireFromString("250.00"),
+		Currency:       "ARS",
+		IdempotencyKey: "topup-key-1",
+		ClientID:       "back-office",
+	}
+
+	// Act
+	result1, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	result2, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, result1.TopUpID, result2.TopUpID)
+	assert.Equal(t, "ALREADY_PROCESSED", result2.Status)
+
+	updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, updatedWallet.Balance(vo.ARS).Amount().Equal(decimal.NewFromFloat(250.00)))
+
+	events := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, events, 1)
+	assert.Equal(t, command.TopUpReason, events[0].(*wallet.WalletCreditedEvent).Reason())
+
+	// Verify the top-up shows up in the wallet history
+	history, err := query.NewGetWalletService(stores.Wallets, stores.Events).History(context.Background(), "user-123")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	assert.Equal(t, "WalletCredited", history[0].EventType)
+}
+
+func TestTopUpWallet_ConcurrentRetriesCreditOnce(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+
+	_, err := command.NewCreateWalletService(stores.Wallets).Execute(context.Background(), command.CreateWalletRequest{
+		UserID:   "user-123",
+		Currency: "ARS",
+	})
+	require.NoError(t, err)
+
+	service := command.NewTopUpWalletService(stores.Wallets, stores.Wallets.Ledger(), stores.Events, stores.UnitOfWork, "test-topic-arn")
+	req := command.TopUpWalletRequest{
+		UserID:         "user-123",
+		Amount:         decimal.RequireFromString("100.00"),
+		Currency:       "ARS",
+		IdempotencyKey: "topup-key-1",
+	}
+
+	// Act: the same request arrives several times at once
+	const retries = 5
+	results := make([]*command.TopUpWalletResponse, retries)
+	errs := make([]error, retries)
+	var wg sync.WaitGroup
+	for i := 0; i < retries; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = service.Execute(context.Background(), req)
+		}(i)
+	}
+	wg.Wait()
+
+	// Assert: whichever retry lost the race reports the top-up as done, none credits again
+	completed := 0
+	for i := 0; i < retries; i++ {
+		require.NoError(t, errs[i])
+		assert.Equal(t, results[0].TopUpID, results[i].TopUpID)
+		if results[i].Status == "COMPLETED" {
+			completed++
+		}
+	}
+	assert.Equal(t, 1, completed)
+
+	updatedWallet, err := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	require.NoError(t, err)
+	assert.True(t, updatedWallet.Balance(vo.ARS).Amount().Equal(decimal.RequireFromString("100.00")))
+	assert.Len(t, queued(t, stores.Outbox, "WalletCredited"), 1)
+}
+
+func TestTopUpWallet_KeyReusedForAnotherTopUpIsRefused(t *testing.T) {
+	tests := []struct {
+		name     string
+		amount   string
+		currency string
+	}{
+		{name: "different amount", amount: "300.00", currency: "ARS"},
+		{name: "different currency", amount: "250.00", currency: "USD"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Arrange
+			stores := fakes.NewStores()
+			_, err := command.NewCreateWalletService(stores.Wallets).Execute(context.Background(), command.CreateWalletRequest{
+				UserID:   "user-123",
+				Currency: "ARS",
+			})
+			require.NoError(t, err)
+
+			service := command.NewTopUpWalletService(stores.Wallets, stores.Wallets.Ledger(), stores.Events, stores.UnitOfWork, "test-topic-arn")
+			_, err = service.Execute(context.Background(), command.TopUpWalletRequest{
+				UserID:         "user-123",
+				Amount:         decimal.RequireFromString("250.00"),
+				Currency:       "ARS",
+				IdempotencyKey: "topup-key-1",
+			})
+			require.NoError(t, err)
+
+			// Act
+			_, err = service.Execute(context.Background(), command.TopUpWalletRequest{
+				UserID:         "user-123",
+				Amount:         decimal.RequireFromString(tt.amount),
+				Currency:       tt.currency,
+				IdempotencyKey: "topup-key-1",
+			})
+
+			// Assert
+			require.Error(t, err)
+			assert.True(t, domerrors.IsErrorCode(err, domerrors.ErrCodeDuplicateRequest), "got %v", err)
+
+			updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+			assert.True(t, updatedWallet.Balance(vo.ARS).Amount().Equal(decimal.RequireFromString("250.00")))
+			assert.Len(t, queued(t, stores.Outbox, "WalletCredited"), 1)
+		})
+	}
+}
+
+func TestTopUpWallet_RetryRecordsCreditedEventMissingFromTheStream(t *testing.T) {
+	// Arrange: learn the top-up ID the key stands for on the wallet
+	req := command.TopUpWalletRequest{
+		UserID:         "user-123",
+		Amount:         decimal.RequireFromString("250.00"),
+		Currency:       "ARS",
+		IdempotencyKey: "topup-key-1",
+	}
+	probe := fakes.NewStores()
+	_, err := command.NewCreateWalletService(probe.Wallets).Execute(context.Background(), command.CreateWalletRequest{UserID: "user-123", Currency: "ARS"})
+	require.NoError(t, err)
+	first, err := command.NewTopUpWalletService(probe.Wallets, probe.Wallets.Ledger(), probe.Events, probe.UnitOfWork, "test-topic-arn").
+		Execute(context.Background(), req)
+	require.NoError(t, err)
+
+	// The wallet was credited for that top-up, but its WalletCredited was never written
+	stores := fakes.NewStores()
+	_, err = command.NewCreateWalletService(stores.Wallets).Execute(context.Background(), command.CreateWalletRequest{UserID: "user-123", Currency: "ARS"})
+	require.NoError(t, err)
+	wlt, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	credit := vo.MustNewMoney("250.00", "ARS")
+	_, _, err = wlt.Credit(credit)
+	require.NoError(t, err)
+	entry, err := ledger.NewTopUpEntry(first.TopUpID, "user-123", credit)
+	require.NoError(t, err)
+	require.NoError(t, wlt.RecordEntry(entry))
+	require.NoError(t, stores.Wallets.Update(context.Background(), wlt))
+
+	service := command.NewTopUpWalletService(stores.Wallets, stores.Wallets.Ledger(), stores.Events, stores.UnitOfWork, "test-topic-arn")
+
+	// Act
+	result, err := service.Execute(context.Background(), req)
+	require.NoError(t, err)
+	_, err = service.Execute(context.Background(), req)
+	require.NoError(t, err)
+
+	// Assert: the retry records the event once and does not credit again
+	assert.Equal(t, "ALREADY_PROCESSED", result.Status)
+	assert.True(t, result.Balance.Equal(decimal.RequireFromString("250.00")))
+
+	events := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, events, 1)
+	credited := events[0].(*wallet.WalletCreditedEvent)
+	assert.Equal(t, command.TopUpReason, credited.Reason())
+	assert.Equal(t, "0", credited.PrevBalance().String())
+	assert.Equal(t, "250", credited.NewBalance().String())
+}
+
+func TestTopUpWallet_RetriesOnVersionConflict(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	_, err := command.NewCreateWalletService(stores.Wallets).Execute(context.Background(), command.CreateWalletRequest{
+		UserID:   "user-123",
+		Currency: "ARS",
+	})
+	require.NoError(t, err)
+	stores.Wallets.FailNextUpdates(2)
+
+	service := command.NewTopUpWalletService(stores.Wallets, stores.Wallets.Ledger(), stores.Events, stores.UnitOfWork, "test-topic-arn")
+
+	// Act
+	result, err := service.Execute(context.Background(), command.TopUpWalletRequest{
+		UserID:         "user-123",
+		Amount:         decimal.RequireFromString("100.00"),
+		Currency:       "ARS",
+		IdempotencyKey: "topup-key-1",
+	})
+
+	// Assert
+	require.NoError(t, err)
+	assert.Equal(t, "COMPLETED", result.Status)
+
+	updatedWallet, _ := stores.Wallets.GetByUserID(context.Background(), "user-123")
+	assert.True(t, updatedWallet.Balance(vo.ARS).Amount().Equal(decimal.RequireFromString("100.00")))
+	assert.Len(t, queued(t, stores.Outbox, "WalletCredited"), 1)
+}
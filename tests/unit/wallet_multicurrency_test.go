@@ -0,0 +1,135 @@
+package unit
+
+import (
+	"context"
+	"testing"
+
+	"github.com/franco/payment-api/internal/application/command"
+	"github.com/franco/payment-api/internal/application/query"
+	vo "github.com/franco/payment-api/internal/domain/shared/valueobjects"
+	"github.com/franco/payment-api/internal/domain/wallet"
+	"github.com/franco/payment-api/internal/infrastructure/persistence/dynamodb/mappers"
+	"github.com/franco/payment-api/tests/unit/fakes"
+	"github.com/shopspring/decimal"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newDualWallet opens an ARS wallet with 500.00 ARS and 20.00 USD
+func newDualWallet(t *testing.T) *wallet.Wallet {
+	t.Helper()
+	wlt := newHoldWallet(t, "500.00")
+	_, _, err := wlt.Credit(vo.MustNewMoney("20.00", "USD"))
+	require.NoError(t, err)
+	return wlt
+}
+
+func TestWallet_MovementsPickTheMatchingBalance(t *testing.T) {
+	// Arrange
+	wlt := newDualWallet(t)
+
+	// Act
+	prev, next, err := wlt.Debit(vo.MustNewMoney("5.00", "USD"))
+	require.NoError(t, err)
+	_, _, err = wlt.PlaceHold("payment-1", vo.MustNewMoney("15.00", "USD"))
+	require.NoError(t, err)
+
+	// Assert: ARS never moved
+	assert.Equal(t, vo.USD, prev.Currency())
+	assert.Equal(t, "20", prev.Amount().String())
+	assert.Equal(t, "15", next.Amount().String())
+	assert.Equal(t, "500", wlt.Balance(vo.ARS).Amount().String())
+	assert.Equal(t, "500", wlt.AvailableBalance(vo.ARS).Amount().String())
+	assert.True(t, wlt.AvailableBalance(vo.USD).IsZero())
+	assert.Equal(t, "15", wlt.HeldBalance(vo.USD).Amount().String())
+
+	assert.False(t, wlt.CanDebit(vo.MustNewMoney("0.01", "USD")))
+	assert.False(t, wlt.CanDebit(vo.MustNewMoney("1.00", "EUR")))
+	assert.True(t, wlt.CanDebit(vo.MustNewMoney("500.00", "ARS")))
+}
+
+func TestWallet_BalancesListDefaultCurrencyFirst(t *testing.T) {
+	wlt := newDualWallet(t)
+	_, _, err := wlt.Credit(vo.MustNewMoney("1.00", "BRL"))
+	require.NoError(t, err)
+
+	var codes []string
+	for _, balance := range wlt.Balances() {
+		codes = append(codes, balance.Currency().Code())
+	}
+
+	assert.Equal(t, []string{"ARS", "BRL", "USD"}, codes)
+	assert.Equal(t, vo.ARS, wlt.DefaultCurrency())
+}
+
+func TestWalletMapper_ReadsLegacySingleBalanceRows(t *testing.T) {
+	// Arrange: a row written before multi-currency wallets
+	legacy := &mappers.WalletDBModel{
+		UserID:    "user-123",
+		Balance:   "1000.00",
+		Currency:  "ARS",
+		Holds:     []mappers.HoldDBModel{{PaymentID: "payment-1", Amount: "100.00", CreatedAt: "2024-01-01T00:00:00Z"}},
+		UpdatedAt: "2024-01-01T00:00:00Z",
+	}
+	mapper := mappers.NewWalletMapper()
+
+	// Act
+	wlt, err := mapper.ToDomain(legacy)
+	require.NoError(t, err)
+	rewritten, err := mapper.ToDBModel(wlt)
+	require.NoError(t, err)
+
+	// Assert
+	assert.True(t, legacy.IsLegacy())
+	assert.Equal(t, "900", wlt.AvailableBalance(vo.ARS).Amount().String())
+	assert.False(t, rewritten.IsLegacy())
+	assert.Empty(t, rewritten.Balance)
+	assert.Equal(t, map[string]string{"ARS": "1000"}, rewritten.Balances)
+	assert.Equal(t, "ARS", rewritten.Holds[0].Currency)
+}
+
+func TestWalletMapper_RoundTripsEveryCurrency(t *testing.T) {
+	wlt := newDualWallet(t)
+	_, _, err := wlt.PlaceHold("payment-1", vo.MustNewMoney("10.00", "USD"))
+	require.NoError(t, err)
+	mapper := mappers.NewWalletMapper()
+
+	model, err := mapper.ToDBModel(wlt)
+	require.NoError(t, err)
+	restored, err := mapper.ToDomain(model)
+
+	require.NoError(t, err)
+	assert.Equal(t, vo.ARS, restored.DefaultCurrency())
+	assert.Equal(t, "500", restored.Balance(vo.ARS).Amount().String())
+	assert.Equal(t, "10", restored.AvailableBalance(vo.USD).Amount().String())
+}
+
+func TestTopUpAndGetWallet_SecondCurrency(t *testing.T) {
+	// Arrange
+	stores := fakes.NewStores()
+	stores.Wallets.SetWallet(newHoldWallet(t, "500.00"))
+	topUp := command.NewTopUpWalletService(stores.Wallets, stores.Wallets.Ledger(), stores.Events, stores.UnitOfWork, "test-topic-arn")
+	ctx := context.Background()
+
+	// Act
+	result, err := topUp.Execute(ctx, command.TopUpWalletRequest{
+		UserID: "user-123", Amount: decimal.RequireFromString("25.00"), Currency: "USD", IdempotencyKey: "topup-usd",
+	})
+	require.NoError(t, err)
+	view, err := query.NewGetWalletService(stores.Wallets, stores.Events).Execute(ctx, "user-123")
+	require.NoError(t, err)
+
+	// Assert
+	assert.Equal(t, "USD", result.Currency)
+	assert.Equal(t, "25", result.Balance.String())
+
+	assert.Equal(t, "ARS", view.Currency)
+	assert.Equal(t, "500", view.Balance.String())
+	require.Len(t, view.Balances, 2)
+	assert.Equal(t, "USD", view.Balances[1].Currency)
+	assert.Equal(t, "25", view.Balances[1].AvailableBalance.String())
+
+	credited := queued(t, stores.Outbox, "WalletCredited")
+	require.Len(t, credited, 1)
+	assert.Equal(t, "USD", credited[0].(*wallet.WalletCreditedEvent).Currency())
+}